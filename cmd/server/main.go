@@ -22,6 +22,7 @@ import (
 	"flowweave/internal/domain/memory"
 	"flowweave/internal/domain/rag"
 	"flowweave/internal/domain/workflow/engine"
+	types "flowweave/internal/domain/workflow/model"
 	"flowweave/internal/domain/workflow/port"
 	"flowweave/internal/platform/config"
 	applog "flowweave/internal/platform/log"
@@ -76,6 +77,46 @@ func main() {
 	} else {
 		applog.Info("✅ Node executions table ready")
 	}
+	if err := pgRepo.EnsureWorkflowVersionTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure workflow_versions table: %v", err)
+	} else {
+		applog.Info("✅ Workflow versions table ready")
+	}
+	if err := pgRepo.EnsureWorkflowPublishColumns(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure workflow publish columns: %v", err)
+	} else {
+		applog.Info("✅ Workflow publish columns ready")
+	}
+	if err := pgRepo.EnsureScheduleTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure workflow_schedules table: %v", err)
+	} else {
+		applog.Info("✅ Workflow schedules table ready")
+	}
+	if err := pgRepo.EnsureWebhookTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure workflow_webhooks table: %v", err)
+	} else {
+		applog.Info("✅ Workflow webhooks table ready")
+	}
+	if err := pgRepo.EnsureIdempotencyKeyTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure run_idempotency_keys table: %v", err)
+	} else {
+		applog.Info("✅ Run idempotency keys table ready")
+	}
+	if err := pgRepo.EnsureNotificationTables(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure run notification tables: %v", err)
+	} else {
+		applog.Info("✅ Run notification tables ready")
+	}
+	if err := pgRepo.EnsureRunCheckpointTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure run_checkpoints table: %v", err)
+	} else {
+		applog.Info("✅ Run checkpoints table ready")
+	}
+	if err := pgRepo.EnsurePendingInputTable(migrateCtx); err != nil {
+		applog.Warnf("⚠️  Failed to ensure run_pending_inputs table: %v", err)
+	} else {
+		applog.Info("✅ Pending inputs table ready")
+	}
 	if err := pgRepo.EnsureLLMTracesTable(migrateCtx); err != nil {
 		applog.Warnf("⚠️  Failed to ensure llm_call_traces table: %v", err)
 	} else {
@@ -98,9 +139,27 @@ func main() {
 	}
 
 	engineConfig := &engine.Config{
-		MaxWorkers:   cfg.Engine.MaxWorkers,
-		NodeTimeout:  time.Duration(cfg.Engine.NodeTimeoutSeconds) * time.Second,
-		MaxNodeSteps: cfg.Engine.MaxNodeSteps,
+		MaxWorkers:     cfg.Engine.MaxWorkers,
+		NodeTimeout:    time.Duration(cfg.Engine.NodeTimeoutSeconds) * time.Second,
+		MaxNodeSteps:   cfg.Engine.MaxNodeSteps,
+		MaxOutputBytes: cfg.Engine.NodeMaxOutputBytes,
+	}
+	if len(cfg.Engine.NodeConcurrency) > 0 {
+		limits := make([]engine.ConcurrencyLimit, 0, len(cfg.Engine.NodeConcurrency))
+		for _, l := range cfg.Engine.NodeConcurrency {
+			limits = append(limits, engine.ConcurrencyLimit{
+				NodeType:      types.NodeType(l.NodeType),
+				Provider:      l.Provider,
+				Model:         l.Model,
+				MaxConcurrent: l.MaxConcurrent,
+			})
+		}
+		limiter, err := engine.NewConcurrencyLimiter(limits)
+		if err != nil {
+			applog.Fatalf("❌ Invalid engine node_concurrency: %v", err)
+		}
+		engineConfig.Limiter = limiter
+		applog.Info("✅ Node concurrency limits enabled", "rules", len(limits))
 	}
 
 	initLLMProviders(
@@ -113,12 +172,42 @@ func main() {
 
 	memCoord := initMemory(db, cfg)
 	runner := workflow.NewWorkflowRunner(engineConfig, memCoord)
+	runner.SetRepository(repo)
+	runner.SetMaxSubWorkflowDepth(cfg.Engine.MaxSubWorkflowDepth)
+	notifier := workflow.NewRunNotifier(repo, workflow.RunNotifierConfig{
+		PollInterval:         time.Duration(cfg.Runtime.RunNotifyPollIntervalMs) * time.Millisecond,
+		Timeout:              time.Duration(cfg.Runtime.RunNotifyTimeoutSeconds) * time.Second,
+		MaxAttempts:          cfg.Runtime.RunNotifyMaxAttempts,
+		DefaultSecret:        cfg.Runtime.RunCallbackSecret,
+		AllowPrivateNetworks: cfg.Runtime.RunNotifyAllowPrivateNet,
+	})
+	runner.SetRunNotifier(notifier)
 	asyncManager := workflow.NewAsyncRunManager(repo, runner, workflow.AsyncRunManagerConfig{
-		Workers:      cfg.Runtime.AsyncRunWorkers,
-		PollInterval: time.Duration(cfg.Runtime.AsyncRunPollIntervalMs) * time.Millisecond,
-		RunTimeout:   time.Duration(cfg.Runtime.AsyncRunTimeoutSeconds) * time.Second,
+		Workers:              cfg.Runtime.AsyncRunWorkers,
+		PollInterval:         time.Duration(cfg.Runtime.AsyncRunPollIntervalMs) * time.Millisecond,
+		RunTimeout:           time.Duration(cfg.Runtime.AsyncRunTimeoutSeconds) * time.Second,
+		TenantMaxConcurrency: cfg.Runtime.AsyncRunTenantMaxConcurrency,
+		LeaseDuration:        time.Duration(cfg.Runtime.AsyncRunLeaseSeconds) * time.Second,
+		WorkerLostRetries:    cfg.Runtime.AsyncRunWorkerLostRetries,
 	})
+	if opt, err := goredis.ParseURL(cfg.Redis.URL); err == nil {
+		runRedis := goredis.NewClient(opt)
+		runner.StartCommandRelay(appCtx, redisdb.NewRunCommandBus(runRedis))
+		applog.Info("✅ Run command relay started (Redis pub/sub)")
+		runner.SetRunEventLog(redisdb.NewRunEventLog(runRedis,
+			time.Duration(cfg.Runtime.RunEventTTLHours)*time.Hour,
+			cfg.Runtime.RunEventMaxLen,
+		))
+		applog.Info("✅ Run event log enabled (Redis Streams)")
+	} else {
+		applog.Warnf("⚠️  Redis URL invalid, run commands limited to local instance and run event log disabled: %v", err)
+	}
 	asyncManager.Start(appCtx)
+	notifier.Start(appCtx)
+	workflow.NewScheduler(repo, workflow.SchedulerConfig{
+		PollInterval:     time.Duration(cfg.Runtime.SchedulerPollIntervalMs) * time.Millisecond,
+		MisfireThreshold: time.Duration(cfg.Runtime.SchedulerMisfireSeconds) * time.Second,
+	}).Start(appCtx)
 
 	serverConfig := api.DefaultServerConfig()
 	serverConfig.Host = cfg.Server.Host
@@ -126,6 +215,7 @@ func main() {
 	serverConfig.ReadTimeout = time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second
 	serverConfig.WriteTimeout = time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second
 	serverConfig.RunTimeout = time.Duration(cfg.Server.RunTimeoutSeconds) * time.Second
+	serverConfig.IdempotencyKeyTTL = time.Duration(cfg.Server.IdempotencyKeyTTLHours) * time.Hour
 	serverConfig.JWTSecret = cfg.Auth.JWTSecret
 	serverConfig.JWTIssuer = cfg.Auth.JWTIssuer
 	serverConfig.ASRTempDir = cfg.ASR.TempDir
@@ -220,6 +310,13 @@ func main() {
 	}
 	appCancel()
 
+	// 等待异步 worker 释放执行中运行的租约，其他实例可立即接手
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Runtime.ShutdownTimeoutSeconds)*time.Second)
+	if err := asyncManager.Wait(waitCtx); err != nil {
+		applog.Warnf("⚠️  Async workers did not stop in time: %v", err)
+	}
+	waitCancel()
+
 	applog.Info("👋 Server stopped")
 }
 
@@ -153,7 +153,7 @@ func (p *Provider) Complete(ctx context.Context, req *provider.CompletionRequest
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, &provider.APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	var apiResp apiResponse
@@ -228,7 +228,7 @@ func (p *Provider) StreamComplete(ctx context.Context, req *provider.CompletionR
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			errCh <- fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+			errCh <- &provider.APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 			return
 		}
 
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"fmt"
 )
 
 // Message LLM 对话消息
@@ -75,6 +76,21 @@ type ToolCallFunction struct {
 	Arguments string `json:"arguments"` // JSON string
 }
 
+// APIError 供应商 API 返回的非成功响应（状态码用于区分限流、服务端错误等）
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatusCode 返回响应状态码
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
 // LLMProvider LLM 供应商接口
 type LLMProvider interface {
 	// Name 返回供应商名称
@@ -25,17 +25,22 @@ func authMiddleware(cfg *JWTConfig, repo port.Repository) func(http.Handler) htt
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 从 Authorization 头中提取 token
 			authHeader := r.Header.Get("Authorization")
+			var tokenStr string
 			if authHeader == "" {
-				writeErrorCode(w, http.StatusUnauthorized, "unauthorized", "Missing Authorization header")
-				return
-			}
-
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-				writeErrorCode(w, http.StatusUnauthorized, "unauthorized", "Invalid Authorization header format")
-				return
+				// 浏览器 WebSocket 无法设置请求头，升级请求允许以 access_token 查询参数携带 token
+				tokenStr = websocketAccessToken(r)
+				if tokenStr == "" {
+					writeErrorCode(w, http.StatusUnauthorized, "unauthorized", "Missing Authorization header")
+					return
+				}
+			} else {
+				parts := strings.SplitN(authHeader, " ", 2)
+				if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+					writeErrorCode(w, http.StatusUnauthorized, "unauthorized", "Invalid Authorization header format")
+					return
+				}
+				tokenStr = parts[1]
 			}
-			tokenStr := parts[1]
 
 			// 解析并验证 JWT
 			parserOpts := []jwt.ParserOption{jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"})}
@@ -111,6 +116,14 @@ func authMiddleware(cfg *JWTConfig, repo port.Repository) func(http.Handler) htt
 	}
 }
 
+// websocketAccessToken 返回 WebSocket 升级请求的 access_token 查询参数；非升级请求返回空
+func websocketAccessToken(r *http.Request) string {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return ""
+	}
+	return strings.TrimSpace(r.URL.Query().Get("access_token"))
+}
+
 func validateScopeClaims(ctx context.Context, repo port.Repository, orgID, tenantID string) error {
 	if repo == nil {
 		return fmt.Errorf("scope repository is not configured")
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// --- 死信队列（async_retry 重试耗尽的异步运行） ---
+
+// deadLetterRequeueLimit 单次批量重新入队的运行数上限
+const deadLetterRequeueLimit = 500
+
+type deadLetterRequeueRequest struct {
+	RunIDs     []string `json:"run_ids,omitempty"`
+	WorkflowID string   `json:"workflow_id,omitempty"`
+	All        bool     `json:"all,omitempty"`
+}
+
+type deadLetterSkipped struct {
+	RunID  string `json:"run_id"`
+	Reason string `json:"reason"`
+}
+
+// ListDeadLetterRuns 列出死信运行（可按 workflow_id 过滤），每条运行附带历次失败尝试
+func (h *WorkflowHandler) ListDeadLetterRuns(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	result, err := h.repo.ListRuns(ctx, port.ListRunsParams{
+		WorkflowID: r.URL.Query().Get("workflow_id"),
+		Status:     port.RunStatusDeadLetter,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list dead-letter runs")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RequeueDeadLetterRuns 批量将死信运行重新入队：指定 run_ids，或按 workflow_id 取出死信队列
+// （all=true 时为当前租户的全部死信）。不在死信状态的运行跳过，不影响其余运行
+func (h *WorkflowHandler) RequeueDeadLetterRuns(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	var req deadLetterRequeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	req.WorkflowID = strings.TrimSpace(req.WorkflowID)
+	if len(req.RunIDs) == 0 && req.WorkflowID == "" && !req.All {
+		writeError(w, http.StatusBadRequest, "one of run_ids, workflow_id or all is required")
+		return
+	}
+	if len(req.RunIDs) > deadLetterRequeueLimit {
+		writeError(w, http.StatusBadRequest, "too many run_ids (max "+strconv.Itoa(deadLetterRequeueLimit)+")")
+		return
+	}
+
+	runIDs := req.RunIDs
+	if len(runIDs) == 0 {
+		// 先收集再入队，避免边翻页边改状态导致漏页
+		for page := 1; len(runIDs) < deadLetterRequeueLimit; page++ {
+			result, err := h.repo.ListRuns(ctx, port.ListRunsParams{
+				WorkflowID: req.WorkflowID,
+				Status:     port.RunStatusDeadLetter,
+				Page:       page,
+				PageSize:   100,
+			})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list dead-letter runs")
+				return
+			}
+			for _, run := range result.Runs {
+				runIDs = append(runIDs, run.ID)
+			}
+			if len(result.Runs) < result.PageSize || page*result.PageSize >= result.Total {
+				break
+			}
+		}
+		if len(runIDs) > deadLetterRequeueLimit {
+			runIDs = runIDs[:deadLetterRequeueLimit]
+		}
+	}
+
+	requeued := make([]string, 0, len(runIDs))
+	skipped := []deadLetterSkipped{}
+	for _, id := range runIDs {
+		ok, err := h.repo.RequeueRun(ctx, id, []port.RunStatus{port.RunStatusDeadLetter})
+		if err != nil {
+			applog.Error("[Workflow/DeadLetter] Failed to requeue run", "run_id", id, "error", err)
+			skipped = append(skipped, deadLetterSkipped{RunID: id, Reason: "requeue_failed"})
+			continue
+		}
+		if !ok {
+			skipped = append(skipped, deadLetterSkipped{RunID: id, Reason: "not_dead_letter"})
+			continue
+		}
+		requeued = append(requeued, id)
+	}
+
+	applog.Info("[Workflow/DeadLetter] Runs requeued", "requeued", len(requeued), "skipped", len(skipped))
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"requeued": requeued,
+		"skipped":  skipped,
+	})
+}
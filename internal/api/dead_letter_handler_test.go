@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockDeadLetterRepo 按插入顺序保存多条运行
+type mockDeadLetterRepo struct {
+	port.Repository
+	runs []*port.WorkflowRun
+}
+
+func (m *mockDeadLetterRepo) ListRuns(ctx context.Context, params port.ListRunsParams) (*port.ListRunsResult, error) {
+	var matched []*port.WorkflowRun
+	for _, run := range m.runs {
+		if (params.WorkflowID == "" || run.WorkflowID == params.WorkflowID) && (params.Status == "" || run.Status == params.Status) {
+			matched = append(matched, run)
+		}
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = 20
+	}
+	result := &port.ListRunsResult{Runs: []*port.WorkflowRun{}, Total: len(matched), Page: params.Page, PageSize: params.PageSize}
+	for i := (params.Page - 1) * params.PageSize; i < len(matched) && i < params.Page*params.PageSize; i++ {
+		result.Runs = append(result.Runs, matched[i])
+	}
+	return result, nil
+}
+
+func (m *mockDeadLetterRepo) RequeueRun(ctx context.Context, id string, from []port.RunStatus) (bool, error) {
+	for _, run := range m.runs {
+		if run.ID == id && containsRunStatus(from, run.Status) {
+			if run.Status == port.RunStatusDeadLetter {
+				run.RetryCount = 0
+			} else {
+				run.RetryCount++
+			}
+			run.Status = port.RunStatusQueued
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newDeadLetterRepo() *mockDeadLetterRepo {
+	attempts := []port.RunAttempt{{Attempt: 1, Error: "upstream 503", ErrorClass: "http_5xx"}, {Attempt: 2, Error: "upstream 503", ErrorClass: "http_5xx"}}
+	return &mockDeadLetterRepo{runs: []*port.WorkflowRun{
+		{ID: "run_1", WorkflowID: "wf_1", Status: port.RunStatusDeadLetter, RetryCount: 1, Attempts: attempts},
+		{ID: "run_2", WorkflowID: "wf_1", Status: port.RunStatusDeadLetter, RetryCount: 1, Attempts: attempts},
+		{ID: "run_3", WorkflowID: "wf_2", Status: port.RunStatusDeadLetter, RetryCount: 1, Attempts: attempts},
+		{ID: "run_4", WorkflowID: "wf_1", Status: port.RunStatusFailed},
+	}}
+}
+
+type deadLetterRequeueResponse struct {
+	Data struct {
+		Requeued []string            `json:"requeued"`
+		Skipped  []deadLetterSkipped `json:"skipped"`
+	} `json:"data"`
+}
+
+func TestListDeadLetterRuns(t *testing.T) {
+	h := NewWorkflowHandler(newDeadLetterRepo(), nil, 0, RunInputConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runs/dead-letter?workflow_id=wf_1", nil)
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data port.ListRunsResult `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Total != 2 || len(resp.Data.Runs[0].Attempts) != 2 || resp.Data.Runs[0].Attempts[1].Error != "upstream 503" {
+		t.Fatalf("expected 2 dead-letter runs of wf_1 with attempts, got %s", rr.Body.String())
+	}
+}
+
+func TestRequeueDeadLetterRuns(t *testing.T) {
+	repo := newDeadLetterRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	if rr := postDebug(h, "/api/v1/runs/dead-letter/requeue", `{}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without selector, got=%d", rr.Code)
+	}
+
+	// 指定 run_ids：不在死信状态的运行跳过
+	rr := postDebug(h, "/api/v1/runs/dead-letter/requeue", `{"run_ids": ["run_1", "run_4"]}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp deadLetterRequeueResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data.Requeued) != 1 || resp.Data.Requeued[0] != "run_1" || len(resp.Data.Skipped) != 1 || resp.Data.Skipped[0].Reason != "not_dead_letter" {
+		t.Fatalf("unexpected requeue result: %s", rr.Body.String())
+	}
+	if repo.runs[3].Status != port.RunStatusFailed {
+		t.Fatalf("expected failed run to be left alone, got %s", repo.runs[3].Status)
+	}
+
+	// 按工作流批量入队
+	rr = postDebug(h, "/api/v1/runs/dead-letter/requeue", `{"workflow_id": "wf_1"}`)
+	resp = deadLetterRequeueResponse{}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusAccepted || strings.Join(resp.Data.Requeued, ",") != "run_2" {
+		t.Fatalf("expected run_2 requeued, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if repo.runs[2].Status != port.RunStatusDeadLetter {
+		t.Fatalf("expected other workflow's dead letters untouched, got %s", repo.runs[2].Status)
+	}
+
+	// all=true：入队剩余全部死信
+	rr = postDebug(h, "/api/v1/runs/dead-letter/requeue", `{"all": true}`)
+	resp = deadLetterRequeueResponse{}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if strings.Join(resp.Data.Requeued, ",") != "run_3" {
+		t.Fatalf("expected run_3 requeued, got body=%s", rr.Body.String())
+	}
+	// 死信运行重新入队后重试次数归零，历次尝试记录保留
+	if run := repo.runs[2]; run.RetryCount != 0 || len(run.Attempts) != 2 {
+		t.Fatalf("expected retry budget reset with attempts kept, got retry_count=%d attempts=%d", run.RetryCount, len(run.Attempts))
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	types "flowweave/internal/domain/workflow/model"
+)
+
+// --- 单节点调试（不创建运行记录） ---
+
+// debugNodeRequest 单节点调试请求体
+type debugNodeRequest struct {
+	DSL       json.RawMessage        `json:"dsl,omitempty"`       // 未保存的 DSL（优先于已保存的工作流 DSL）
+	Inputs    map[string]interface{} `json:"inputs,omitempty"`    // 系统变量（工作流输入）
+	Variables map[string]interface{} `json:"variables,omitempty"` // 变量池快照：node_id → {变量名 → 值}
+}
+
+// DebugWorkflowNode 使用已保存工作流的 DSL（或请求体中的未保存 DSL）调试单个节点
+func (h *WorkflowHandler) DebugWorkflowNode(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	req, ok := decodeDebugNodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if len(req.DSL) == 0 {
+		wf, err := h.repo.GetWorkflow(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get workflow")
+			return
+		}
+		if wf == nil {
+			writeError(w, http.StatusNotFound, "workflow not found")
+			return
+		}
+		req.DSL = wf.DSL
+	}
+	h.debugNode(w, r, req)
+}
+
+// DebugNode 基于请求体中的未保存 DSL 调试单个节点
+func (h *WorkflowHandler) DebugNode(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeDebugNodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if len(req.DSL) == 0 {
+		writeError(w, http.StatusBadRequest, "dsl is required")
+		return
+	}
+	h.debugNode(w, r, req)
+}
+
+func decodeDebugNodeRequest(w http.ResponseWriter, r *http.Request) (*debugNodeRequest, bool) {
+	var req debugNodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return nil, false
+	}
+	return &req, true
+}
+
+func (h *WorkflowHandler) debugNode(w http.ResponseWriter, r *http.Request, req *debugNodeRequest) {
+	ctx, scope := h.injectScope(r.Context())
+
+	var cfg types.GraphConfig
+	if err := json.Unmarshal(req.DSL, &cfg); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, "invalid_dsl", "invalid workflow dsl: "+err.Error())
+		return
+	}
+
+	opts := &workflow.RunOptions{}
+	if scope != nil {
+		opts.OrgID = scope.OrgID
+		opts.TenantID = scope.TenantID
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, h.runTimeout)
+	defer cancel()
+
+	result, err := h.runner.DebugNode(execCtx, &cfg, &workflow.DebugNodeRequest{
+		NodeID:    chi.URLParam(r, "node_id"),
+		Inputs:    req.Inputs,
+		Variables: req.Variables,
+	}, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, workflow.ErrDebugNodeNotFound):
+			writeErrorCode(w, http.StatusNotFound, "node_not_found", err.Error())
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+			writeErrorCode(w, http.StatusGatewayTimeout, "debug_timeout", err.Error())
+		default:
+			writeErrorCode(w, http.StatusBadRequest, "invalid_node", err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
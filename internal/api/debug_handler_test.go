@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockDebugRepo 仅实现 GetWorkflow；调试接口若创建运行记录会因未实现的方法 panic
+type mockDebugRepo struct {
+	port.Repository
+	wf *port.Workflow
+}
+
+func (m *mockDebugRepo) GetWorkflow(ctx context.Context, id string) (*port.Workflow, error) {
+	if m.wf == nil || m.wf.ID != id {
+		return nil, nil
+	}
+	return m.wf, nil
+}
+
+const debugTemplateDSL = `{
+	"nodes": [
+		{"id": "start_1", "data": {"type": "start", "title": "Start"}},
+		{
+			"id": "tpl_1",
+			"data": {
+				"type": "template-transform",
+				"title": "Greeting",
+				"template": "hello {{ name }}",
+				"variables": [{"variable": "name", "value_selector": ["start_1", "name"]}]
+			}
+		}
+	],
+	"edges": [{"source": "start_1", "target": "tpl_1"}]
+}`
+
+func postDebug(h *WorkflowHandler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestDebugSavedWorkflowNode(t *testing.T) {
+	repo := &mockDebugRepo{wf: &port.Workflow{ID: "wf_1", DSL: json.RawMessage(debugTemplateDSL)}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/nodes/tpl_1/debug", `{"variables": {"start_1": {"name": "flowweave"}}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Status  string                 `json:"status"`
+			Outputs map[string]interface{} `json:"outputs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Status != "succeeded" || resp.Data.Outputs["output"] != "hello flowweave" {
+		t.Fatalf("unexpected debug result: %s", rr.Body.String())
+	}
+}
+
+func TestDebugUnsavedDSLNode(t *testing.T) {
+	h := NewWorkflowHandler(&mockDebugRepo{}, nil, 0, RunInputConfig{})
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"dsl":       json.RawMessage(debugTemplateDSL),
+		"variables": map[string]interface{}{"start_1": map[string]interface{}{"name": "draft"}},
+	})
+	rr := postDebug(h, "/api/v1/debug/nodes/tpl_1", string(body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "hello draft") {
+		t.Fatalf("expected rendered output, got body=%s", rr.Body.String())
+	}
+
+	rr = postDebug(h, "/api/v1/debug/nodes/missing", string(body))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
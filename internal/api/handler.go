@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	asrprovider "flowweave/internal/adapter/provider/asr"
 	"flowweave/internal/app/workflow"
@@ -28,6 +29,8 @@ type WorkflowHandler struct {
 	runner     *workflow.WorkflowRunner
 	runTimeout time.Duration
 	runInput   RunInputConfig
+
+	idempotencyKeyTTL time.Duration
 }
 
 // NewWorkflowHandler 创建处理器
@@ -39,10 +42,11 @@ func NewWorkflowHandler(repo port.Repository, runner *workflow.WorkflowRunner, r
 		runTimeout = 5 * time.Minute
 	}
 	return &WorkflowHandler{
-		repo:       repo,
-		runner:     runner,
-		runTimeout: runTimeout,
-		runInput:   normalizeRunInputConfig(runInput),
+		repo:              repo,
+		runner:            runner,
+		runTimeout:        runTimeout,
+		runInput:          normalizeRunInputConfig(runInput),
+		idempotencyKeyTTL: defaultIdempotencyKeyTTL,
 	}
 }
 
@@ -50,6 +54,7 @@ func NewWorkflowHandler(repo port.Repository, runner *workflow.WorkflowRunner, r
 func (h *WorkflowHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/workflows", func(r chi.Router) {
 		r.Post("/", h.CreateWorkflow)
+		r.Post("/validate", h.ValidateWorkflow)
 		r.Get("/", h.ListWorkflows)
 		r.Get("/{id}", h.GetWorkflow)
 		r.Put("/{id}", h.UpdateWorkflow)
@@ -57,9 +62,50 @@ func (h *WorkflowHandler) RegisterRoutes(r chi.Router) {
 		r.Post("/{id}/run", h.RunWorkflow)
 		r.Post("/{id}/run/async", h.RunWorkflowAsync)
 		r.Post("/{id}/run/stream", h.RunWorkflowStream)
+		r.Get("/{id}/runs", h.ListRuns)
+		r.Get("/{id}/versions", h.ListWorkflowVersions)
+		r.Get("/{id}/versions/{version}", h.GetWorkflowVersion)
+		r.Post("/{id}/publish", h.PublishWorkflow)
+		r.Post("/{id}/rollback", h.RollbackWorkflow)
+		r.Post("/{id}/schedules", h.CreateSchedule)
+		r.Get("/{id}/schedules", h.ListSchedules)
+		r.Post("/{id}/webhooks", h.CreateWebhook)
+		r.Get("/{id}/webhooks", h.ListWebhooks)
+		r.Post("/{id}/nodes/{node_id}/debug", h.DebugWorkflowNode)
 	})
+	r.Post("/api/v1/debug/nodes/{node_id}", h.DebugNode)
+	r.Get("/api/v1/ws", h.RunSocket)
+	r.Get("/api/v1/runs/dead-letter", h.ListDeadLetterRuns)
+	r.Get("/api/v1/runs/queue", h.GetQueueStats)
+	r.Post("/api/v1/runs/dead-letter/requeue", h.RequeueDeadLetterRuns)
 	r.Get("/api/v1/runs/{id}", h.GetRun)
 	r.Get("/api/v1/runs/{id}/nodes", h.ListNodeExecutions)
+	r.Get("/api/v1/runs/{id}/events", h.StreamRunEvents)
+	r.Delete("/api/v1/runs/{id}", h.CancelRun)
+	r.Post("/api/v1/runs/{id}/abort", h.AbortRun)
+	r.Post("/api/v1/runs/{id}/pause", h.PauseRun)
+	r.Post("/api/v1/runs/{id}/resume", h.ResumeRun)
+	r.Post("/api/v1/runs/{id}/requeue", h.RequeueRun)
+	r.Post("/api/v1/runs/{id}/replay", h.ReplayRun)
+	r.Get("/api/v1/runs/{id}/notifications", h.ListRunNotifications)
+	r.Get("/api/v1/runs/{id}/pending-input", h.ListPendingInputs)
+	r.Post("/api/v1/runs/{id}/pending-input", h.SubmitPendingInput)
+	r.Get("/api/v1/schedules/{id}", h.GetSchedule)
+	r.Put("/api/v1/schedules/{id}", h.UpdateSchedule)
+	r.Delete("/api/v1/schedules/{id}", h.DeleteSchedule)
+	r.Get("/api/v1/webhooks/{id}", h.GetWebhook)
+	r.Put("/api/v1/webhooks/{id}", h.UpdateWebhook)
+	r.Delete("/api/v1/webhooks/{id}", h.DeleteWebhook)
+	r.Post("/api/v1/webhooks/{id}/rotate-secret", h.RotateWebhookSecret)
+	r.Post("/api/v1/notifications/subscriptions", h.CreateNotificationSubscription)
+	r.Get("/api/v1/notifications/subscriptions", h.ListNotificationSubscriptions)
+	r.Get("/api/v1/notifications/subscriptions/{id}", h.GetNotificationSubscription)
+	r.Put("/api/v1/notifications/subscriptions/{id}", h.UpdateNotificationSubscription)
+	r.Delete("/api/v1/notifications/subscriptions/{id}", h.DeleteNotificationSubscription)
+	r.Post("/api/v1/notifications/subscriptions/{id}/rotate-secret", h.RotateNotificationSubscriptionSecret)
+	r.Get("/api/v1/notifications/deliveries", h.ListNotificationDeliveries)
+	r.Get("/api/v1/notifications/deliveries/{id}", h.GetNotificationDelivery)
+	r.Post("/api/v1/notifications/deliveries/{id}/redeliver", h.RedeliverNotification)
 	r.Get("/api/v1/async-tasks/{id}", h.GetExternalAsyncTask)
 	r.Get("/api/v1/traces/{conversation_id}", h.GetTrace)
 }
@@ -116,6 +162,9 @@ func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "dsl is required")
 		return
 	}
+	if h.rejectInvalidDSL(w, req.DSL) {
+		return
+	}
 
 	wf := &port.Workflow{
 		Name:        req.Name,
@@ -203,6 +252,9 @@ func (h *WorkflowHandler) UpdateWorkflow(w http.ResponseWriter, r *http.Request)
 		wf.Description = *req.Description
 	}
 	if req.DSL != nil {
+		if h.rejectInvalidDSL(w, *req.DSL) {
+			return
+		}
 		wf.DSL = *req.DSL
 	}
 
@@ -240,19 +292,17 @@ func (h *WorkflowHandler) DeleteWorkflow(w http.ResponseWriter, r *http.Request)
 type runWorkflowRequest struct {
 	Inputs         map[string]interface{} `json:"inputs"`
 	ConversationID string                 `json:"conversation_id,omitempty"`
+	Priority       *int                   `json:"priority,omitempty"` // 仅异步运行使用
+	CallbackURL    string                 `json:"callback_url,omitempty"`
+	CallbackSecret string                 `json:"callback_secret,omitempty"`
 }
 
 func (h *WorkflowHandler) RunWorkflowAsync(w http.ResponseWriter, r *http.Request) {
 	ctx, scope := h.injectScope(r.Context())
 	id := chi.URLParam(r, "id")
 
-	wf, err := h.repo.GetWorkflow(ctx, id)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get workflow")
-		return
-	}
-	if wf == nil {
-		writeError(w, http.StatusNotFound, "workflow not found")
+	wf, ok := h.loadRunWorkflow(ctx, w, r, id)
+	if !ok {
 		return
 	}
 
@@ -261,6 +311,17 @@ func (h *WorkflowHandler) RunWorkflowAsync(w http.ResponseWriter, r *http.Reques
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	priority := port.RunPriorityMin
+	if req.Priority != nil {
+		if *req.Priority < port.RunPriorityMin || *req.Priority > port.RunPriorityMax {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("priority must be between %d and %d", port.RunPriorityMin, port.RunPriorityMax))
+			return
+		}
+		priority = *req.Priority
+	}
+	if !h.validateRunCallback(r.Context(), w, req) {
+		return
+	}
 
 	if req.ConversationID != "" {
 		if err := h.ensureConversationOwnership(ctx, req.ConversationID, scope); err != nil {
@@ -273,18 +334,34 @@ func (h *WorkflowHandler) RunWorkflowAsync(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	runID := uuid.NewString()
+	idem, ok := h.reserveIdempotencyKey(ctx, w, r, idempotencyEndpointAsync, wf.ID, req, runID)
+	if !ok {
+		return
+	}
+	if idem.replay != nil {
+		replayAsyncRun(w, idem.replay)
+		return
+	}
+
 	inputsJSON, _ := json.Marshal(req.Inputs)
 	run := &port.WorkflowRun{
-		WorkflowID:     wf.ID,
-		ConversationID: req.ConversationID,
-		Status:         port.RunStatusQueued,
-		Inputs:         inputsJSON,
+		ID:              runID,
+		WorkflowID:      wf.ID,
+		WorkflowVersion: wf.Version,
+		ConversationID:  req.ConversationID,
+		Status:          port.RunStatusQueued,
+		Priority:        priority,
+		Inputs:          inputsJSON,
+		CallbackURL:     req.CallbackURL,
+		CallbackSecret:  req.CallbackSecret,
 	}
 	if scope != nil {
 		run.OrgID = scope.OrgID
 		run.TenantID = scope.TenantID
 	}
 	if err := h.repo.CreateRun(ctx, run); err != nil {
+		h.releaseIdempotencyKey(ctx, idem)
 		writeError(w, http.StatusInternalServerError, "failed to create run")
 		return
 	}
@@ -293,6 +370,7 @@ func (h *WorkflowHandler) RunWorkflowAsync(w http.ResponseWriter, r *http.Reques
 		"run_id":      run.ID,
 		"workflow_id": run.WorkflowID,
 		"status":      run.Status,
+		"priority":    run.Priority,
 		"queued_at":   run.QueuedAt,
 	})
 }
@@ -302,13 +380,8 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// 1. 获取工作流
-	wf, err := h.repo.GetWorkflow(ctx, id)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get workflow")
-		return
-	}
-	if wf == nil {
-		writeError(w, http.StatusNotFound, "workflow not found")
+	wf, ok := h.loadRunWorkflow(ctx, w, r, id)
+	if !ok {
 		return
 	}
 	if err := validateWorkflowSyncCompatible(wf.DSL); err != nil {
@@ -322,6 +395,9 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if !h.validateRunCallback(r.Context(), w, req) {
+		return
+	}
 
 	// 3. 会话归属写校验
 	if req.ConversationID != "" {
@@ -335,19 +411,35 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// 3.1 幂等键：同一 key 的重复请求返回原运行结果
+	runID := uuid.NewString()
+	idem, ok := h.reserveIdempotencyKey(ctx, w, r, idempotencyEndpointSync, wf.ID, req, runID)
+	if !ok {
+		return
+	}
+	if idem.replay != nil {
+		h.replaySyncRun(ctx, w, idem.replay)
+		return
+	}
+
 	// 4. 创建执行记录
 	inputsJSON, _ := json.Marshal(req.Inputs)
 	run := &port.WorkflowRun{
-		WorkflowID:     wf.ID,
-		ConversationID: req.ConversationID,
-		Status:         port.RunStatusRunning,
-		Inputs:         inputsJSON,
+		ID:              runID,
+		WorkflowID:      wf.ID,
+		WorkflowVersion: wf.Version,
+		ConversationID:  req.ConversationID,
+		Status:          port.RunStatusRunning,
+		Inputs:          inputsJSON,
+		CallbackURL:     req.CallbackURL,
+		CallbackSecret:  req.CallbackSecret,
 	}
 	if scope != nil {
 		run.OrgID = scope.OrgID
 		run.TenantID = scope.TenantID
 	}
 	if err := h.repo.CreateRun(ctx, run); err != nil {
+		h.releaseIdempotencyKey(ctx, idem)
 		writeError(w, http.StatusInternalServerError, "failed to create run")
 		return
 	}
@@ -359,6 +451,7 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 
 	opts := &workflow.RunOptions{
 		ConversationID: req.ConversationID,
+		RunID:          run.ID,
 	}
 	if scope != nil {
 		opts.OrgID = scope.OrgID
@@ -367,16 +460,47 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 	result, execErr := h.runner.RunSync(execCtx, wf.DSL, req.Inputs, opts)
 	elapsed := time.Since(startTime).Milliseconds()
 
+	// 5.1 挂起等待人工输入：同步落库检查点与待输入请求，提交后由异步 worker 恢复执行
+	if execErr == nil && result != nil && result.Paused {
+		run.ElapsedMs = elapsed
+		if err := workflow.SuspendRun(h.persistContext(scope), h.repo, run, result); err != nil {
+			applog.Error("[Workflow/Run] Failed to suspend run", "run_id", run.ID, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to suspend run")
+			return
+		}
+		applog.Info("[Workflow/Run] Execution paused for human input",
+			"run_id", run.ID,
+			"workflow_id", wf.ID,
+			"pending_inputs", len(result.PendingInputs),
+		)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"run_id":         run.ID,
+			"status":         run.Status,
+			"pending_inputs": result.PendingInputs,
+			"elapsed_ms":     elapsed,
+		})
+		return
+	}
+
 	// 6. 更新执行记录
 	now := time.Now()
 	run.ElapsedMs = elapsed
 	run.FinishedAt = &now
 
+	if result != nil {
+		run.ExceptionsCount = result.ExceptionsCount
+	}
 	if execErr != nil {
 		run.Status = port.RunStatusFailed
+		if result != nil && result.Aborted {
+			run.Status = port.RunStatusAborted
+		}
 		run.Error = execErr.Error()
 	} else {
 		run.Status = port.RunStatusSucceeded
+		if result != nil && result.PartialSucceeded {
+			run.Status = port.RunStatusPartialSucceeded
+		}
 		if result != nil && result.Outputs != nil {
 			outputsJSON, _ := json.Marshal(result.Outputs)
 			run.Outputs = outputsJSON
@@ -423,10 +547,11 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"run_id":     run.ID,
-		"status":     run.Status,
-		"outputs":    result.Outputs,
-		"elapsed_ms": elapsed,
+		"run_id":           run.ID,
+		"status":           run.Status,
+		"outputs":          result.Outputs,
+		"exceptions_count": run.ExceptionsCount,
+		"elapsed_ms":       elapsed,
 	})
 }
 
@@ -434,28 +559,63 @@ func (h *WorkflowHandler) RunWorkflow(w http.ResponseWriter, r *http.Request) {
 
 func (h *WorkflowHandler) RunWorkflowStream(w http.ResponseWriter, r *http.Request) {
 	ctx, scope := h.injectScope(r.Context())
-	id := chi.URLParam(r, "id")
 
-	// 1. 获取工作流
-	wf, err := h.repo.GetWorkflow(ctx, id)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+	sr, ok := h.createStreamRun(ctx, scope, w, r)
+	if !ok {
 		return
 	}
-	if wf == nil {
-		writeError(w, http.StatusNotFound, "workflow not found")
+
+	// 5. 设置 SSE 响应头
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Run-ID", sr.run.ID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
 		return
 	}
+
+	h.executeStreamRun(ctx, sr, func(eventType string, seq int64, data interface{}) {
+		sseWriteEventWithID(w, flusher, seq, eventType, data)
+	})
+}
+
+// streamEmitter 输出流式运行事件（SSE 或 WebSocket）；seq 为运行事件日志序号，未记录时为 0
+type streamEmitter func(eventType string, seq int64, data interface{})
+
+// streamRun 已创建执行记录、待执行的流式运行
+type streamRun struct {
+	run    *port.WorkflowRun
+	dsl    json.RawMessage
+	inputs map[string]interface{}
+	scope  *Scope
+}
+
+// createStreamRun 校验请求并创建流式运行的执行记录（步骤 1-4）。
+// 返回 false 表示已写出错误响应或幂等重放结果
+func (h *WorkflowHandler) createStreamRun(ctx context.Context, scope *Scope, w http.ResponseWriter, r *http.Request) (*streamRun, bool) {
+	id := chi.URLParam(r, "id")
+
+	// 1. 获取工作流
+	wf, ok := h.loadRunWorkflow(ctx, w, r, id)
+	if !ok {
+		return nil, false
+	}
 	if err := validateWorkflowSyncCompatible(wf.DSL); err != nil {
 		writeErrorCode(w, http.StatusBadRequest, "workflow_sync_unsupported", err.Error())
-		return
+		return nil, false
 	}
 
 	// 2. 解析输入
 	req, err := parseRunWorkflowRequest(r, h.runInput)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
-		return
+		return nil, false
+	}
+	if !h.validateRunCallback(r.Context(), w, req) {
+		return nil, false
 	}
 
 	// 3. 会话归属写校验
@@ -466,54 +626,65 @@ func (h *WorkflowHandler) RunWorkflowStream(w http.ResponseWriter, r *http.Reque
 			} else {
 				writeError(w, http.StatusInternalServerError, "failed to verify conversation ownership")
 			}
-			return
+			return nil, false
 		}
 	}
 
+	// 3.1 幂等键：同一 key 的重复请求返回原运行结果
+	runID := uuid.NewString()
+	idem, ok := h.reserveIdempotencyKey(ctx, w, r, idempotencyEndpointStream, wf.ID, req, runID)
+	if !ok {
+		return nil, false
+	}
+	if idem.replay != nil {
+		h.replayStreamRun(ctx, w, idem.replay)
+		return nil, false
+	}
+
 	// 4. 创建执行记录
 	inputsJSON, _ := json.Marshal(req.Inputs)
 	run := &port.WorkflowRun{
-		WorkflowID:     wf.ID,
-		ConversationID: req.ConversationID,
-		Status:         port.RunStatusRunning,
-		Inputs:         inputsJSON,
+		ID:              runID,
+		WorkflowID:      wf.ID,
+		WorkflowVersion: wf.Version,
+		ConversationID:  req.ConversationID,
+		Status:          port.RunStatusRunning,
+		Inputs:          inputsJSON,
+		CallbackURL:     req.CallbackURL,
+		CallbackSecret:  req.CallbackSecret,
 	}
 	if scope != nil {
 		run.OrgID = scope.OrgID
 		run.TenantID = scope.TenantID
 	}
 	if err := h.repo.CreateRun(ctx, run); err != nil {
+		h.releaseIdempotencyKey(ctx, idem)
 		writeError(w, http.StatusInternalServerError, "failed to create run")
-		return
+		return nil, false
 	}
+	return &streamRun{run: run, dsl: wf.DSL, inputs: req.Inputs, scope: scope}, true
+}
 
-	// 5. 设置 SSE 响应头
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Run-ID", run.ID)
+// executeStreamRun 执行流式运行并逐条输出事件，结束后落库并输出 done（步骤 6-8）
+func (h *WorkflowHandler) executeStreamRun(ctx context.Context, sr *streamRun, emit streamEmitter) {
+	run, scope := sr.run, sr.scope
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		writeError(w, http.StatusInternalServerError, "streaming not supported")
-		return
-	}
-
-	// 6. 流式执行
+	// 6. 流式执行（客户端断开不中止运行，可经 /runs/{id}/events 续传）
 	startTime := time.Now()
-	execCtx, cancel := context.WithTimeout(ctx, h.runTimeout)
+	execCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.runTimeout)
 	defer cancel()
 
 	streamOpts := &workflow.RunOptions{
-		ConversationID: req.ConversationID,
+		ConversationID: run.ConversationID,
+		RunID:          run.ID,
 	}
 	if scope != nil {
 		streamOpts.OrgID = scope.OrgID
 		streamOpts.TenantID = scope.TenantID
 	}
-	eventCh, execErr := h.runner.RunFromDSL(execCtx, wf.DSL, req.Inputs, streamOpts)
+	eventCh, execErr := h.runner.RunFromDSL(execCtx, sr.dsl, sr.inputs, streamOpts)
 	if execErr != nil {
-		sseWriteEvent(w, flusher, "error", map[string]string{"error": execErr.Error()})
+		emit("error", 0, map[string]string{"error": execErr.Error()})
 		return
 	}
 
@@ -521,75 +692,110 @@ func (h *WorkflowHandler) RunWorkflowStream(w http.ResponseWriter, r *http.Reque
 	var finalError string
 	var finalOutputs map[string]interface{}
 	var finalNodeExecs []port.NodeExecution
+	var finalExceptions int
+	var pausedResult *workflow.RunResult
 
 	for evt := range eventCh {
-		sseData := map[string]interface{}{
-			"type": evt.Type,
-		}
-
-		if evt.NodeID != "" {
-			sseData["node_id"] = evt.NodeID
-		}
-		if evt.Chunk != "" {
-			sseData["chunk"] = evt.Chunk
-		}
 		if evt.Outputs != nil {
-			sseData["outputs"] = evt.Outputs
 			finalOutputs = evt.Outputs
 		}
-		if evt.Error != "" {
-			sseData["error"] = evt.Error
-		}
 
-		if evt.Type == event.EventTypeGraphRunFailed {
+		switch evt.Type {
+		case event.EventTypeGraphRunPaused:
+			// 手动暂停为中间状态（随后恢复或中止），人工输入挂起为最终事件
+			finalStatus = port.RunStatusPaused
+			pausedResult = &workflow.RunResult{
+				NodeExecutions: evt.NodeExecutions,
+				Paused:         true,
+				PendingInputs:  evt.PendingInputs,
+				Checkpoint:     evt.Checkpoint,
+			}
+		case event.EventTypeGraphRunResumed:
+			finalStatus = port.RunStatusSucceeded
+			pausedResult = nil
+		case event.EventTypeGraphRunFailed:
 			finalStatus = port.RunStatusFailed
 			finalError = evt.Error
 			finalNodeExecs = evt.NodeExecutions
-		}
-		if evt.Type == event.EventTypeGraphRunSucceeded {
+			finalExceptions = evt.ExceptionsCount
+			pausedResult = nil
+		case event.EventTypeGraphRunAborted:
+			finalStatus = port.RunStatusAborted
+			finalError = evt.Error
+			finalNodeExecs = evt.NodeExecutions
+			pausedResult = nil
+		case event.EventTypeGraphRunSucceeded:
+			finalStatus = port.RunStatusSucceeded
 			finalNodeExecs = evt.NodeExecutions
+			pausedResult = nil
+		case event.EventTypeGraphRunPartialSucceeded:
+			finalStatus = port.RunStatusPartialSucceeded
+			finalNodeExecs = evt.NodeExecutions
+			finalExceptions = evt.ExceptionsCount
+			pausedResult = nil
 		}
 
-		sseWriteEvent(w, flusher, "message", sseData)
+		emit("message", evt.Seq, workflow.StreamEventPayload(evt))
 	}
 
 	// 7. 更新执行记录
 	elapsed := time.Since(startTime).Milliseconds()
+
+	// 7.0 挂起等待人工输入：同步落库检查点与待输入请求
+	if pausedResult != nil {
+		run.ElapsedMs = elapsed
+		if err := workflow.SuspendRun(h.persistContext(scope), h.repo, run, pausedResult); err != nil {
+			applog.Error("[Workflow/Stream] Failed to suspend run", "run_id", run.ID, "error", err)
+			emit("error", 0, map[string]string{"error": "failed to suspend run"})
+			return
+		}
+		emit("done", 0, map[string]interface{}{
+			"run_id":         run.ID,
+			"status":         run.Status,
+			"pending_inputs": pausedResult.PendingInputs,
+			"elapsed_ms":     elapsed,
+		})
+		return
+	}
+
 	now := time.Now()
 	run.Status = finalStatus
 	run.Error = finalError
+	run.ExceptionsCount = finalExceptions
 	run.ElapsedMs = elapsed
 	run.FinishedAt = &now
 	if finalOutputs != nil {
 		outputsJSON, _ := json.Marshal(finalOutputs)
 		run.Outputs = outputsJSON
 	}
-	// 7.1 异步落库 run 与 node executions（不阻塞 SSE done 事件）
-	persistCtx := context.Background()
-	if scope != nil {
-		persistCtx = port.WithRepoScope(persistCtx, scope.OrgID, scope.TenantID)
-	}
+	// 7.1 异步落库 run 与 node executions（不阻塞 done 事件）
 	runSnapshot := *run
 	nodeExecsSnapshot := append([]port.NodeExecution(nil), finalNodeExecs...)
-	go h.persistRunAndNodeExecs(persistCtx, &runSnapshot, nodeExecsSnapshot)
+	go h.persistRunAndNodeExecs(h.persistContext(scope), &runSnapshot, nodeExecsSnapshot)
 
 	// 8. 保存 LLM 调用溯源
-	if req.ConversationID != "" && len(finalNodeExecs) > 0 {
-		traceCtx := context.Background()
-		if scope != nil {
-			traceCtx = port.WithRepoScope(traceCtx, scope.OrgID, scope.TenantID)
-		}
-		go h.saveTraces(traceCtx, req.ConversationID, run.ID, finalNodeExecs)
+	if run.ConversationID != "" && len(finalNodeExecs) > 0 {
+		go h.saveTraces(h.persistContext(scope), run.ConversationID, run.ID, finalNodeExecs)
 	}
 
 	// 发送完成事件
-	sseWriteEvent(w, flusher, "done", map[string]interface{}{
-		"run_id":     run.ID,
-		"status":     finalStatus,
-		"elapsed_ms": elapsed,
+	emit("done", 0, map[string]interface{}{
+		"run_id":           run.ID,
+		"status":           finalStatus,
+		"exceptions_count": finalExceptions,
+		"elapsed_ms":       elapsed,
 	})
 }
 
+// persistContext 构建脱离请求生命周期的落库 context（保留租户 scope）
+func (h *WorkflowHandler) persistContext(scope *Scope) context.Context {
+	ctx := context.Background()
+	if scope != nil {
+		ctx = port.WithRepoScope(ctx, scope.OrgID, scope.TenantID)
+	}
+	return ctx
+}
+
 func (h *WorkflowHandler) persistRunAndNodeExecs(ctx context.Context, run *port.WorkflowRun, nodeExecs []port.NodeExecution) {
 	if run == nil {
 		return
@@ -614,7 +820,9 @@ func (h *WorkflowHandler) persistRunAndNodeExecs(ctx context.Context, run *port.
 	}
 	if err := h.repo.UpdateRun(ctx, run); err != nil {
 		applog.Error("[Workflow/Persist] Failed to update run", "run_id", run.ID, "error", err)
+		return
 	}
+	h.runner.NotifyRunFinished(ctx, run)
 }
 
 // saveTraces 从节点执行明细中提取 LLM 调用记录并保存
@@ -734,16 +942,17 @@ func toNodeExecRecords(runID string, execs []port.NodeExecution) []*port.NodeExe
 	records := make([]*port.NodeExecutionRecord, 0, len(execs))
 	for _, exec := range execs {
 		records = append(records, &port.NodeExecutionRecord{
-			RunID:     runID,
-			NodeID:    exec.NodeID,
-			NodeType:  exec.NodeType,
-			Title:     exec.Title,
-			Status:    exec.Status,
-			Outputs:   exec.Outputs,
-			Error:     exec.Error,
-			Metadata:  exec.Metadata,
-			ElapsedMs: exec.ElapsedMs,
-			StartedAt: exec.StartedAt,
+			RunID:      runID,
+			NodeID:     exec.NodeID,
+			NodeType:   exec.NodeType,
+			Title:      exec.Title,
+			Status:     exec.Status,
+			Outputs:    exec.Outputs,
+			Error:      exec.Error,
+			Metadata:   exec.Metadata,
+			ElapsedMs:  exec.ElapsedMs,
+			StartedAt:  exec.StartedAt,
+			NodeParent: exec.NodeParent,
 		})
 	}
 	return records
@@ -751,6 +960,28 @@ func toNodeExecRecords(runID string, execs []port.NodeExecution) []*port.NodeExe
 
 // --- 查询执行记录 ---
 
+// ListRuns 列出工作流的执行记录（支持按状态过滤，如 partial-succeeded）
+func (h *WorkflowHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	status := r.URL.Query().Get("status")
+
+	result, err := h.repo.ListRuns(ctx, port.ListRunsParams{
+		WorkflowID: id,
+		Status:     port.RunStatus(status),
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list runs")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *WorkflowHandler) GetRun(w http.ResponseWriter, r *http.Request) {
 	ctx, _ := h.injectScope(r.Context())
 	id := chi.URLParam(r, "id")
@@ -1034,3 +1265,11 @@ func sseWriteEvent(w http.ResponseWriter, flusher http.Flusher, eventType string
 	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, string(jsonData))
 	flusher.Flush()
 }
+
+// sseWriteEventWithID 写入带事件 ID 的 SSE 事件（id 为运行事件日志序号，<= 0 时省略）
+func sseWriteEventWithID(w http.ResponseWriter, flusher http.Flusher, id int64, eventType string, data interface{}) {
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	sseWriteEvent(w, flusher, eventType, data)
+}
@@ -44,7 +44,7 @@ func TestRunRejectsASRAsyncMode(t *testing.T) {
 		],
 		"edges":[{"source":"start_1","target":"asr_1"},{"source":"asr_1","target":"end_1"}]
 	}`)
-	repo := &mockWorkflowRepo{wf: &port.Workflow{ID: "wf_1", DSL: dsl}}
+	repo := &mockWorkflowRepo{wf: &port.Workflow{ID: "wf_1", Version: 1, PublishedVersion: 1, DSL: dsl}}
 	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
 
 	chiRouter := hRouter(h)
@@ -74,7 +74,7 @@ func TestRunStreamRejectsASRAsyncOnlyProvider(t *testing.T) {
 		],
 		"edges":[{"source":"start_1","target":"asr_1"},{"source":"asr_1","target":"end_1"}]
 	}`)
-	repo := &mockWorkflowRepo{wf: &port.Workflow{ID: "wf_2", DSL: dsl}}
+	repo := &mockWorkflowRepo{wf: &port.Workflow{ID: "wf_2", Version: 1, PublishedVersion: 1, DSL: dsl}}
 	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
 	chiRouter := hRouter(h)
 
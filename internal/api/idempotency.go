@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// --- 运行创建接口幂等（Idempotency-Key） ---
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotencyReplayHeader  = "Idempotent-Replayed"
+	idempotencyKeyMaxLen     = 255
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+
+	// idempotencyPollInterval 重放时等待原运行结束的轮询间隔
+	idempotencyPollInterval = 200 * time.Millisecond
+)
+
+// 幂等键记录的接口类型：同一 key 换接口调用视为不同请求
+const (
+	idempotencyEndpointSync   = "sync"
+	idempotencyEndpointStream = "stream"
+	idempotencyEndpointAsync  = "async"
+)
+
+// SetIdempotencyKeyTTL 设置 Idempotency-Key 保留时长（<= 0 时使用默认 24 小时）
+func (h *WorkflowHandler) SetIdempotencyKeyTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+	h.idempotencyKeyTTL = ttl
+}
+
+// idempotentRun 幂等键的占用结果
+type idempotentRun struct {
+	key    string            // 请求未携带 Idempotency-Key 时为空
+	replay *port.WorkflowRun // 同一 key、同一请求的原运行；为空表示本次请求需创建运行
+}
+
+// reserveIdempotencyKey 以 runID 占用请求携带的 Idempotency-Key。
+// 同一 key 的原请求与本次请求指纹不同时返回 409 idempotency_key_conflict；
+// 原请求仍在创建运行时返回 409 idempotency_key_in_progress。返回 false 表示已写出错误响应
+func (h *WorkflowHandler) reserveIdempotencyKey(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint, workflowID string, req *runWorkflowRequest, runID string) (*idempotentRun, bool) {
+	key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+	if key == "" {
+		return &idempotentRun{}, true
+	}
+	if len(key) > idempotencyKeyMaxLen {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, idempotencyKeyMaxLen))
+		return nil, false
+	}
+
+	hash := runRequestFingerprint(endpoint, workflowID, r, req)
+	existing, err := h.repo.ReserveIdempotencyKey(ctx, &port.RunIdempotencyKey{
+		Key:         key,
+		Endpoint:    endpoint,
+		WorkflowID:  workflowID,
+		RequestHash: hash,
+		RunID:       runID,
+		ExpiresAt:   time.Now().Add(h.idempotencyKeyTTL),
+	})
+	if err != nil {
+		applog.Error("[Workflow/Idempotency] Failed to reserve key", "key", key, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to reserve idempotency key")
+		return nil, false
+	}
+	if existing == nil {
+		return &idempotentRun{key: key}, true
+	}
+	if existing.RequestHash != hash {
+		writeErrorCode(w, http.StatusConflict, "idempotency_key_conflict", "Idempotency-Key was already used with a different request")
+		return nil, false
+	}
+
+	run, err := h.repo.GetRun(ctx, existing.RunID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return nil, false
+	}
+	if run == nil {
+		writeErrorCode(w, http.StatusConflict, "idempotency_key_in_progress", "A request with this Idempotency-Key is still being processed")
+		return nil, false
+	}
+	applog.Info("[Workflow/Idempotency] Replaying run", "key", key, "run_id", run.ID, "endpoint", endpoint)
+	w.Header().Set(idempotencyReplayHeader, "true")
+	return &idempotentRun{key: key, replay: run}, true
+}
+
+// releaseIdempotencyKey 运行创建失败时释放幂等键，客户端可用同一 key 重试
+func (h *WorkflowHandler) releaseIdempotencyKey(ctx context.Context, idem *idempotentRun) {
+	if idem == nil || idem.key == "" {
+		return
+	}
+	if err := h.repo.DeleteIdempotencyKey(ctx, idem.key); err != nil {
+		applog.Warn("[Workflow/Idempotency] Failed to release key", "key", idem.key, "error", err)
+	}
+}
+
+// runRequestFingerprint 计算运行请求指纹：接口、工作流、版本选择参数与请求体内容。
+// 上传音频按内容摘要计入，忽略每次请求不同的暂存路径
+func runRequestFingerprint(endpoint, workflowID string, r *http.Request, req *runWorkflowRequest) string {
+	inputs := make(map[string]interface{}, len(req.Inputs))
+	for k, v := range req.Inputs {
+		if file, ok := v.(map[string]interface{}); ok && file["temp_path"] != nil {
+			stable := make(map[string]interface{}, len(file))
+			for fk, fv := range file {
+				if fk != "temp_path" {
+					stable[fk] = fv
+				}
+			}
+			v = stable
+		}
+		inputs[k] = v
+	}
+	query := r.URL.Query()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"endpoint":        endpoint,
+		"workflow_id":     workflowID,
+		"version":         query.Get("version"),
+		"draft":           query.Get("draft"),
+		"inputs":          inputs,
+		"conversation_id": req.ConversationID,
+		"priority":        req.Priority,
+		"callback_url":    req.CallbackURL,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// awaitRunSettled 等待原运行结束或挂起等待人工输入（最长为同步执行超时）
+func (h *WorkflowHandler) awaitRunSettled(ctx context.Context, run *port.WorkflowRun) (*port.WorkflowRun, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, h.runTimeout)
+	defer cancel()
+	for {
+		switch run.Status {
+		case port.RunStatusQueued, port.RunStatusRunning:
+		default:
+			return run, nil
+		}
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("run %s is still %s", run.ID, run.Status)
+		case <-time.After(idempotencyPollInterval):
+		}
+		latest, err := h.repo.GetRun(waitCtx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("run %s not found", run.ID)
+		}
+		run = latest
+	}
+}
+
+// replayAsyncRun 按异步提交的响应格式返回原运行
+func replayAsyncRun(w http.ResponseWriter, run *port.WorkflowRun) {
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"run_id":      run.ID,
+		"workflow_id": run.WorkflowID,
+		"status":      run.Status,
+		"priority":    run.Priority,
+		"queued_at":   run.QueuedAt,
+	})
+}
+
+// replaySyncRun 等待原运行结束，按同步执行的响应格式返回其结果
+func (h *WorkflowHandler) replaySyncRun(ctx context.Context, w http.ResponseWriter, run *port.WorkflowRun) {
+	run, err := h.awaitRunSettled(ctx, run)
+	if err != nil {
+		writeErrorCode(w, http.StatusConflict, "idempotency_key_in_progress", "The run for this Idempotency-Key has not finished yet")
+		return
+	}
+	switch run.Status {
+	case port.RunStatusPaused:
+		pending, err := h.repo.ListPendingInputs(ctx, run.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list pending inputs")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"run_id":         run.ID,
+			"status":         run.Status,
+			"pending_inputs": pending,
+			"elapsed_ms":     run.ElapsedMs,
+		})
+	case port.RunStatusSucceeded, port.RunStatusPartialSucceeded:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"run_id":           run.ID,
+			"status":           run.Status,
+			"outputs":          run.Outputs,
+			"exceptions_count": run.ExceptionsCount,
+			"elapsed_ms":       run.ElapsedMs,
+		})
+	default:
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("workflow execution failed: %s", run.Error))
+	}
+}
+
+// replayStreamRun 等待原运行结束，以单个 done 事件返回其结果（原运行的中间事件不重放）
+func (h *WorkflowHandler) replayStreamRun(ctx context.Context, w http.ResponseWriter, run *port.WorkflowRun) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Run-ID", run.ID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	run, err := h.awaitRunSettled(ctx, run)
+	if err != nil {
+		sseWriteEvent(w, flusher, "error", map[string]string{"error": err.Error()})
+		return
+	}
+	done := map[string]interface{}{
+		"run_id":           run.ID,
+		"status":           run.Status,
+		"exceptions_count": run.ExceptionsCount,
+		"elapsed_ms":       run.ElapsedMs,
+	}
+	if len(run.Outputs) > 0 {
+		done["outputs"] = run.Outputs
+	}
+	if run.Error != "" {
+		done["error"] = run.Error
+	}
+	if run.Status == port.RunStatusPaused {
+		if pending, err := h.repo.ListPendingInputs(ctx, run.ID); err == nil {
+			done["pending_inputs"] = pending
+		}
+	}
+	sseWriteEvent(w, flusher, "done", done)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockIdempotencyRepo 在版本仓储之上记录幂等键与运行状态
+type mockIdempotencyRepo struct {
+	*mockVersionRepo
+	keys map[string]*port.RunIdempotencyKey
+}
+
+func newMockIdempotencyRepo() *mockIdempotencyRepo {
+	return &mockIdempotencyRepo{mockVersionRepo: newMockVersionRepo(), keys: map[string]*port.RunIdempotencyKey{}}
+}
+
+func (m *mockIdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, key *port.RunIdempotencyKey) (*port.RunIdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.keys[key.Key]; ok {
+		cp := *existing
+		return &cp, nil
+	}
+	cp := *key
+	m.keys[key.Key] = &cp
+	return nil, nil
+}
+
+func (m *mockIdempotencyRepo) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key)
+	return nil
+}
+
+func (m *mockIdempotencyRepo) CreateRun(ctx context.Context, run *port.WorkflowRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *run
+	m.runs = append(m.runs, &cp)
+	return nil
+}
+
+func (m *mockIdempotencyRepo) UpdateRun(ctx context.Context, run *port.WorkflowRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.runs {
+		if r.ID == run.ID {
+			cp := *run
+			m.runs[i] = &cp
+		}
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepo) GetRun(ctx context.Context, id string) (*port.WorkflowRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.runs {
+		if r.ID == id {
+			cp := *r
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockIdempotencyRepo) runCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.runs)
+}
+
+func postIdempotent(h *WorkflowHandler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, key)
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func decodeRunID(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		Data struct {
+			RunID string `json:"run_id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.Data.RunID
+}
+
+func TestRunAsyncIdempotencyKey(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	first := postIdempotent(h, "/api/v1/workflows/wf_1/run/async", "key-1", `{"inputs": {"q": "a"}}`)
+	if first.Code != http.StatusAccepted || first.Header().Get(idempotencyReplayHeader) != "" {
+		t.Fatalf("expected first submit accepted, got=%d, body=%s", first.Code, first.Body.String())
+	}
+	second := postIdempotent(h, "/api/v1/workflows/wf_1/run/async", "key-1", `{"inputs": {"q": "a"}}`)
+	if second.Code != http.StatusAccepted || second.Header().Get(idempotencyReplayHeader) != "true" {
+		t.Fatalf("expected replayed submit, got=%d, body=%s", second.Code, second.Body.String())
+	}
+	if decodeRunID(t, first) != decodeRunID(t, second) || repo.runCount() != 1 {
+		t.Fatalf("expected one run for a repeated key, got runs=%d", repo.runCount())
+	}
+
+	conflict := postIdempotent(h, "/api/v1/workflows/wf_1/run/async", "key-1", `{"inputs": {"q": "b"}}`)
+	if conflict.Code != http.StatusConflict || !strings.Contains(conflict.Body.String(), "idempotency_key_conflict") {
+		t.Fatalf("expected 409 for a different body, got=%d, body=%s", conflict.Code, conflict.Body.String())
+	}
+	if rr := postIdempotent(h, "/api/v1/workflows/wf_1/run", "key-1", `{"inputs": {"q": "a"}}`); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when reusing a key on another endpoint, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	if rr := postIdempotent(h, "/api/v1/workflows/wf_1/run/async", "key-2", `{"inputs": {"q": "a"}}`); rr.Code != http.StatusAccepted || repo.runCount() != 2 {
+		t.Fatalf("expected a new run for a new key, got=%d, runs=%d", rr.Code, repo.runCount())
+	}
+}
+
+func TestRunSyncIdempotencyReplay(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	first := postIdempotent(h, "/api/v1/workflows/wf_1/run", "sync-1", `{"inputs": {}}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", first.Code, first.Body.String())
+	}
+	second := postIdempotent(h, "/api/v1/workflows/wf_1/run", "sync-1", `{"inputs": {}}`)
+	if second.Code != http.StatusOK || second.Header().Get(idempotencyReplayHeader) != "true" {
+		t.Fatalf("expected replayed result, got=%d, body=%s", second.Code, second.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			RunID   string                 `json:"run_id"`
+			Outputs map[string]interface{} `json:"outputs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(second.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.RunID != decodeRunID(t, first) || resp.Data.Outputs["label"] != "v2" || repo.runCount() != 1 {
+		t.Fatalf("expected original outputs replayed without a new run, got %+v runs=%d", resp.Data, repo.runCount())
+	}
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 运行完成通知（出站 Webhook：callback_url + 租户订阅） ---
+
+const (
+	// notificationURLMaxLen 回调地址长度上限
+	notificationURLMaxLen = 2048
+	// notificationSecretMaxLen callback_secret 长度上限（与 callback_secret 列宽一致）
+	notificationSecretMaxLen = 128
+	// notificationDeliveryListMax 投递记录单次查询上限
+	notificationDeliveryListMax = 500
+)
+
+type notificationSubscriptionRequest struct {
+	URL      *string           `json:"url,omitempty"`
+	Statuses *[]port.RunStatus `json:"statuses,omitempty"`
+	Enabled  *bool             `json:"enabled,omitempty"`
+}
+
+// newSubscriptionView showSecret=false 时隐藏密钥（仅创建与轮换时返回）
+func newSubscriptionView(sub *port.RunNotificationSubscription, showSecret bool) *port.RunNotificationSubscription {
+	cp := *sub
+	if !showSecret {
+		cp.Secret = ""
+	}
+	return &cp
+}
+
+// validateRunCallback 校验运行请求携带的 callback_url / callback_secret；
+// 未配置通知投递、或既无 callback_secret 也无服务端默认密钥时拒绝。返回 false 表示已写出 400
+func (h *WorkflowHandler) validateRunCallback(ctx context.Context, w http.ResponseWriter, req *runWorkflowRequest) bool {
+	req.CallbackURL = strings.TrimSpace(req.CallbackURL)
+	req.CallbackSecret = strings.TrimSpace(req.CallbackSecret)
+	if req.CallbackURL == "" {
+		if req.CallbackSecret != "" {
+			writeError(w, http.StatusBadRequest, "callback_secret requires callback_url")
+			return false
+		}
+		return true
+	}
+	if msg := h.validateNotificationURL(ctx, req.CallbackURL); msg != "" {
+		writeError(w, http.StatusBadRequest, "callback_url "+msg)
+		return false
+	}
+	if len(req.CallbackSecret) > notificationSecretMaxLen {
+		writeError(w, http.StatusBadRequest, "callback_secret must be at most "+strconv.Itoa(notificationSecretMaxLen)+" characters")
+		return false
+	}
+	notifier := h.runner.RunNotifier()
+	if notifier == nil {
+		writeError(w, http.StatusBadRequest, "run notifications are not enabled on this server")
+		return false
+	}
+	if !notifier.CanSign(req.CallbackSecret) {
+		writeError(w, http.StatusBadRequest, "callback_secret is required: no default callback secret is configured")
+		return false
+	}
+	return true
+}
+
+// validateNotificationURL 通知地址须为 http(s) 绝对地址，且不得解析到内网或本地地址；合法时返回空串
+func (h *WorkflowHandler) validateNotificationURL(ctx context.Context, raw string) string {
+	if len(raw) > notificationURLMaxLen {
+		return "must be at most " + strconv.Itoa(notificationURLMaxLen) + " characters"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "must be an absolute http or https URL"
+	}
+	if err := h.runner.RunNotifier().ValidateTarget(ctx, u.Hostname()); err != nil {
+		return "must not resolve to a private or local address"
+	}
+	return ""
+}
+
+// CreateNotificationSubscription 为当前租户创建运行完成通知订阅，响应中返回签名密钥
+func (h *WorkflowHandler) CreateNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := h.injectScope(r.Context())
+
+	var req notificationSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.URL == nil {
+		writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	secret, err := workflow.GenerateWebhookSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate subscription secret")
+		return
+	}
+
+	sub := &port.RunNotificationSubscription{Secret: secret, Enabled: true}
+	if scope != nil {
+		sub.OrgID = scope.OrgID
+		sub.TenantID = scope.TenantID
+	}
+	if !h.applyNotificationSubscriptionRequest(ctx, w, sub, &req) {
+		return
+	}
+	if err := h.repo.CreateNotificationSubscription(ctx, sub); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create notification subscription")
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSubscriptionView(sub, true))
+}
+
+// ListNotificationSubscriptions 列出当前租户的通知订阅（不含密钥）
+func (h *WorkflowHandler) ListNotificationSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	subs, err := h.repo.ListNotificationSubscriptions(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list notification subscriptions")
+		return
+	}
+	views := make([]*port.RunNotificationSubscription, 0, len(subs))
+	for _, sub := range subs {
+		views = append(views, newSubscriptionView(sub, false))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// GetNotificationSubscription 获取通知订阅（不含密钥）
+func (h *WorkflowHandler) GetNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	sub, ok := h.loadNotificationSubscription(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newSubscriptionView(sub, false))
+}
+
+// UpdateNotificationSubscription 更新通知地址、订阅的运行状态或启用状态
+func (h *WorkflowHandler) UpdateNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	sub, ok := h.loadNotificationSubscription(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	var req notificationSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if !h.applyNotificationSubscriptionRequest(ctx, w, sub, &req) {
+		return
+	}
+	if err := h.repo.UpdateNotificationSubscription(ctx, sub); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update notification subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, newSubscriptionView(sub, false))
+}
+
+// RotateNotificationSubscriptionSecret 生成新密钥；已入队的投递仍使用入队时的密钥签名
+func (h *WorkflowHandler) RotateNotificationSubscriptionSecret(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	sub, ok := h.loadNotificationSubscription(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	secret, err := workflow.GenerateWebhookSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate subscription secret")
+		return
+	}
+	sub.Secret = secret
+	if err := h.repo.UpdateNotificationSubscription(ctx, sub); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update notification subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, newSubscriptionView(sub, true))
+}
+
+// DeleteNotificationSubscription 删除通知订阅（已有投递记录保留）
+func (h *WorkflowHandler) DeleteNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if _, ok := h.loadNotificationSubscription(ctx, w, id); !ok {
+		return
+	}
+	if err := h.repo.DeleteNotificationSubscription(ctx, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete notification subscription")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// ListRunNotifications 运行的通知投递记录（含每次尝试的响应码与错误）
+func (h *WorkflowHandler) ListRunNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	deliveries, err := h.repo.ListNotificationDeliveries(ctx, port.ListNotificationDeliveriesParams{RunID: run.ID})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list notification deliveries")
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// ListNotificationDeliveries 按 run_id / status 查询当前租户的通知投递记录，最新的在前
+func (h *WorkflowHandler) ListNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	query := r.URL.Query()
+
+	params := port.ListNotificationDeliveriesParams{
+		RunID:  strings.TrimSpace(query.Get("run_id")),
+		Status: port.NotificationDeliveryStatus(strings.TrimSpace(query.Get("status"))),
+	}
+	switch params.Status {
+	case "", port.NotificationDeliveryPending, port.NotificationDeliverySucceeded, port.NotificationDeliveryFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "status must be one of: pending, succeeded, failed")
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		if limit > notificationDeliveryListMax {
+			limit = notificationDeliveryListMax
+		}
+		params.Limit = limit
+	}
+
+	deliveries, err := h.repo.ListNotificationDeliveries(ctx, params)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list notification deliveries")
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// GetNotificationDelivery 获取单条投递记录
+func (h *WorkflowHandler) GetNotificationDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	d, ok := h.loadNotificationDelivery(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// RedeliverNotification 立即重新投递一次（不论当前投递状态），返回本次尝试后的投递记录
+func (h *WorkflowHandler) RedeliverNotification(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	notifier := h.runner.RunNotifier()
+	if notifier == nil {
+		writeError(w, http.StatusServiceUnavailable, "run notifications are not enabled on this server")
+		return
+	}
+	d, ok := h.loadNotificationDelivery(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, notifier.Redeliver(ctx, d))
+}
+
+func (h *WorkflowHandler) loadNotificationSubscription(ctx context.Context, w http.ResponseWriter, id string) (*port.RunNotificationSubscription, bool) {
+	sub, err := h.repo.GetNotificationSubscription(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get notification subscription")
+		return nil, false
+	}
+	if sub == nil {
+		writeError(w, http.StatusNotFound, "notification subscription not found")
+		return nil, false
+	}
+	return sub, true
+}
+
+func (h *WorkflowHandler) loadNotificationDelivery(ctx context.Context, w http.ResponseWriter, id string) (*port.RunNotificationDelivery, bool) {
+	d, err := h.repo.GetNotificationDelivery(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get notification delivery")
+		return nil, false
+	}
+	if d == nil {
+		writeError(w, http.StatusNotFound, "notification delivery not found")
+		return nil, false
+	}
+	return d, true
+}
+
+// applyNotificationSubscriptionRequest 校验并合并请求字段；校验失败时写入 400 并返回 false
+func (h *WorkflowHandler) applyNotificationSubscriptionRequest(ctx context.Context, w http.ResponseWriter, sub *port.RunNotificationSubscription, req *notificationSubscriptionRequest) bool {
+	if req.URL != nil {
+		u := strings.TrimSpace(*req.URL)
+		if msg := h.validateNotificationURL(ctx, u); msg != "" {
+			writeError(w, http.StatusBadRequest, "url "+msg)
+			return false
+		}
+		sub.URL = u
+	}
+	if req.Statuses != nil {
+		for _, status := range *req.Statuses {
+			if !workflow.IsTerminalRunStatus(status) {
+				writeError(w, http.StatusBadRequest, "statuses must be terminal run statuses: succeeded, partial-succeeded, failed, aborted, canceled, dead-letter")
+				return false
+			}
+		}
+		sub.Statuses = *req.Statuses
+		if len(sub.Statuses) == 0 {
+			sub.Statuses = nil
+		}
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockNotificationRepo 在版本仓储基础上保存通知订阅
+type mockNotificationRepo struct {
+	*mockVersionRepo
+	subs map[string]*port.RunNotificationSubscription
+}
+
+func (m *mockNotificationRepo) CreateNotificationSubscription(ctx context.Context, sub *port.RunNotificationSubscription) error {
+	sub.ID = "sub_1"
+	cp := *sub
+	m.subs[sub.ID] = &cp
+	return nil
+}
+
+func (m *mockNotificationRepo) GetNotificationSubscription(ctx context.Context, id string) (*port.RunNotificationSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (m *mockNotificationRepo) UpdateNotificationSubscription(ctx context.Context, sub *port.RunNotificationSubscription) error {
+	cp := *sub
+	m.subs[sub.ID] = &cp
+	return nil
+}
+
+func TestRunAsyncCallbackURL(t *testing.T) {
+	repo := newMockVersionRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	// 未启用通知投递时拒绝 callback_url
+	rr := postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "callback_url": "https://example.com/hook", "callback_secret": "s"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "not enabled") {
+		t.Fatalf("expected 400 without notifier, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	h.runner.SetRunNotifier(workflow.NewRunNotifier(repo, workflow.RunNotifierConfig{}))
+	for body, want := range map[string]string{
+		`{"inputs": {}, "callback_url": "ftp://example.com/hook", "callback_secret": "s"}`:                  "absolute http or https URL",
+		`{"inputs": {}, "callback_url": "/relative", "callback_secret": "s"}`:                               "absolute http or https URL",
+		`{"inputs": {}, "callback_url": "https://example.com/hook"}`:                                        "callback_secret is required",
+		`{"inputs": {}, "callback_secret": "s"}`:                                                            "callback_secret requires callback_url",
+		`{"inputs": {}, "callback_url": "http://169.254.169.254/latest/meta-data", "callback_secret": "s"}`: "private or local address",
+		`{"inputs": {}, "callback_url": "http://localhost:8080/hook", "callback_secret": "s"}`:              "private or local address",
+	} {
+		rr := postDebug(h, "/api/v1/workflows/wf_1/run/async", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("expected 400 containing %q for %s, got=%d, body=%s", want, body, rr.Code, rr.Body.String())
+		}
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no runs for rejected callbacks, got %d", len(repo.runs))
+	}
+
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "callback_url": " https://example.com/hook ", "callback_secret": "s"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if run := repo.runs[0]; run.CallbackURL != "https://example.com/hook" || run.CallbackSecret != "s" {
+		t.Fatalf("expected callback stored on run, got url=%q secret=%q", run.CallbackURL, run.CallbackSecret)
+	}
+
+	// 配置默认密钥后可省略 callback_secret
+	h.runner.SetRunNotifier(workflow.NewRunNotifier(repo, workflow.RunNotifierConfig{DefaultSecret: "default"}))
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "callback_url": "http://example.com/hook"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202 with default secret, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	// 显式放开内网限制后可回调内部服务
+	h.runner.SetRunNotifier(workflow.NewRunNotifier(repo, workflow.RunNotifierConfig{DefaultSecret: "default", AllowPrivateNetworks: true}))
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "callback_url": "http://10.0.0.5/hook"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202 with private networks allowed, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestNotificationSubscriptionManagement(t *testing.T) {
+	repo := &mockNotificationRepo{mockVersionRepo: newMockVersionRepo(), subs: map[string]*port.RunNotificationSubscription{}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	for body, want := range map[string]string{
+		`{}`:                                  "url is required",
+		`{"url": "example.com/hook"}`:         "absolute http or https URL",
+		`{"url": "http://192.168.1.10/hook"}`: "private or local address",
+		`{"url": "http://[::1]:9000/hook"}`:   "private or local address",
+		`{"url": "https://example.com/hook", "statuses": ["running"]}`: "terminal run statuses",
+	} {
+		rr := postDebug(h, "/api/v1/notifications/subscriptions", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("expected 400 containing %q for %s, got=%d, body=%s", want, body, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := postDebug(h, "/api/v1/notifications/subscriptions", `{"url": "https://example.com/hook", "statuses": ["failed", "dead-letter"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status=201, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	secret := repo.subs["sub_1"].Secret
+	if secret == "" || !strings.Contains(rr.Body.String(), secret) {
+		t.Fatalf("expected secret in create response, body=%s", rr.Body.String())
+	}
+
+	// 查询不返回密钥
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/subscriptions/sub_1", nil)
+	rec := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), secret) {
+		t.Fatalf("expected subscription without secret, got=%d, body=%s", rec.Code, rec.Body.String())
+	}
+
+	// 轮换后旧密钥失效
+	rr = postDebug(h, "/api/v1/notifications/subscriptions/sub_1/rotate-secret", ``)
+	if rr.Code != http.StatusOK || repo.subs["sub_1"].Secret == secret {
+		t.Fatalf("expected rotated secret, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	// 未启用通知投递时无法重新投递
+	rr = postDebug(h, "/api/v1/notifications/deliveries/d_1/redeliver", ``)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status=503, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// --- 人工输入（human-input 节点挂起/恢复） ---
+
+// submitPendingInputRequest 人工输入提交请求
+type submitPendingInputRequest struct {
+	NodeID string                 `json:"node_id"`
+	Action string                 `json:"action"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// ListPendingInputs 查询运行的人工输入请求
+func (h *WorkflowHandler) ListPendingInputs(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+
+	inputs, err := h.repo.ListPendingInputs(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list pending inputs")
+		return
+	}
+	if inputs == nil {
+		inputs = []*port.PendingInput{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"run_id":         run.ID,
+		"status":         run.Status,
+		"pending_inputs": inputs,
+	})
+}
+
+// SubmitPendingInput 提交人工输入，所有输入均已响应后运行重新入队恢复执行
+func (h *WorkflowHandler) SubmitPendingInput(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req submitPendingInputRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	if run.Status != port.RunStatusPaused {
+		writeErrorCode(w, http.StatusConflict, "run_not_paused", "Run is not waiting for input")
+		return
+	}
+
+	inputs, err := h.repo.ListPendingInputs(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list pending inputs")
+		return
+	}
+	pending := findWaitingInput(inputs, req.NodeID)
+	if pending == nil {
+		writeErrorCode(w, http.StatusConflict, "input_not_waiting", "No pending input is waiting for this node")
+		return
+	}
+
+	respondedBy := ""
+	if scope != nil {
+		respondedBy = scope.Subject
+		if pending.Assignee != "" && pending.Assignee != scope.Subject {
+			writeErrorCode(w, http.StatusForbidden, "not_assignee", "Pending input is assigned to another user")
+			return
+		}
+	}
+
+	if err := validatePendingInputResponse(pending, req.Action, req.Values); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submitted, err := h.repo.SubmitPendingInput(ctx, id, pending.NodeID, req.Action, req.Values, respondedBy)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to submit pending input")
+		return
+	}
+	if !submitted {
+		writeErrorCode(w, http.StatusConflict, "input_not_waiting", "Pending input was already answered or timed out")
+		return
+	}
+
+	resumed, err := h.repo.ResumePausedRun(ctx, id)
+	if err != nil {
+		applog.Error("[Workflow/HumanInput] Failed to requeue paused run", "run_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to resume run")
+		return
+	}
+
+	applog.Info("[Workflow/HumanInput] Input submitted",
+		"run_id", id,
+		"node_id", pending.NodeID,
+		"action", req.Action,
+		"resumed", resumed,
+	)
+
+	status := port.RunStatusPaused
+	if resumed {
+		status = port.RunStatusQueued
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"run_id":  id,
+		"node_id": pending.NodeID,
+		"status":  status,
+		"resumed": resumed,
+	})
+}
+
+// findWaitingInput 查找等待中的人工输入；未指定 node_id 时仅在唯一等待项时返回
+func findWaitingInput(inputs []*port.PendingInput, nodeID string) *port.PendingInput {
+	var waiting []*port.PendingInput
+	for _, pi := range inputs {
+		if pi.Status != port.PendingInputStatusWaiting {
+			continue
+		}
+		if nodeID != "" && pi.NodeID == nodeID {
+			return pi
+		}
+		waiting = append(waiting, pi)
+	}
+	if nodeID == "" && len(waiting) == 1 {
+		return waiting[0]
+	}
+	return nil
+}
+
+// validatePendingInputResponse 按节点声明的字段与动作校验提交内容
+func validatePendingInputResponse(pending *port.PendingInput, action string, values map[string]interface{}) error {
+	if len(pending.Actions) > 0 {
+		if action == "" {
+			return fmt.Errorf("action is required")
+		}
+		allowed := false
+		for _, a := range pending.Actions {
+			if a.ID == action {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("unknown action: %s", action)
+		}
+	}
+
+	declared := make(map[string]port.PendingInputField, len(pending.Fields))
+	for _, f := range pending.Fields {
+		declared[f.Name] = f
+	}
+	for name := range values {
+		if _, ok := declared[name]; !ok {
+			return fmt.Errorf("unknown field: %s", name)
+		}
+	}
+
+	for _, f := range pending.Fields {
+		val, ok := values[f.Name]
+		if !ok || val == nil || (isString(val) && strings.TrimSpace(val.(string)) == "") {
+			if f.Required {
+				return fmt.Errorf("field %s is required", f.Name)
+			}
+			continue
+		}
+		switch f.Type {
+		case "number":
+			if _, ok := val.(float64); !ok {
+				return fmt.Errorf("field %s must be a number", f.Name)
+			}
+		case "boolean":
+			if _, ok := val.(bool); !ok {
+				return fmt.Errorf("field %s must be a boolean", f.Name)
+			}
+		case "select":
+			s, ok := val.(string)
+			if !ok || !containsString(f.Options, s) {
+				return fmt.Errorf("field %s must be one of %v", f.Name, f.Options)
+			}
+		default:
+			if !isString(val) {
+				return fmt.Errorf("field %s must be a string", f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+type mockPendingInputRepo struct {
+	port.Repository
+	run       *port.WorkflowRun
+	inputs    []*port.PendingInput
+	submitted map[string]interface{}
+	action    string
+}
+
+func (m *mockPendingInputRepo) GetRun(ctx context.Context, id string) (*port.WorkflowRun, error) {
+	return m.run, nil
+}
+
+func (m *mockPendingInputRepo) ListPendingInputs(ctx context.Context, runID string) ([]*port.PendingInput, error) {
+	return m.inputs, nil
+}
+
+func (m *mockPendingInputRepo) SubmitPendingInput(ctx context.Context, runID, nodeID, action string, response map[string]interface{}, respondedBy string) (bool, error) {
+	m.action = action
+	m.submitted = response
+	return true, nil
+}
+
+func (m *mockPendingInputRepo) ResumePausedRun(ctx context.Context, runID string) (bool, error) {
+	return true, nil
+}
+
+func newPendingInputRepo(status port.RunStatus) *mockPendingInputRepo {
+	return &mockPendingInputRepo{
+		run: &port.WorkflowRun{ID: "run_1", Status: status},
+		inputs: []*port.PendingInput{{
+			RunID:  "run_1",
+			NodeID: "review_1",
+			Status: port.PendingInputStatusWaiting,
+			Fields: []port.PendingInputField{
+				{Name: "comment", Type: "text", Required: true},
+				{Name: "score", Type: "number"},
+			},
+			Actions: []port.PendingInputAction{{ID: "approve"}, {ID: "reject"}},
+		}},
+	}
+}
+
+func postPendingInput(h *WorkflowHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/runs/run_1/pending-input", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestSubmitPendingInputResumesRun(t *testing.T) {
+	repo := newPendingInputRepo(port.RunStatusPaused)
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postPendingInput(h, `{"action":"approve","values":{"comment":"ok","score":5}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if repo.action != "approve" || repo.submitted["comment"] != "ok" {
+		t.Fatalf("expected submission to be stored, got action=%q values=%v", repo.action, repo.submitted)
+	}
+	if !strings.Contains(rr.Body.String(), `"resumed":true`) {
+		t.Fatalf("expected resumed=true, got body=%s", rr.Body.String())
+	}
+}
+
+func TestSubmitPendingInputValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"missing_required_field", `{"action":"approve","values":{}}`},
+		{"unknown_action", `{"action":"escalate","values":{"comment":"ok"}}`},
+		{"unknown_field", `{"action":"approve","values":{"comment":"ok","extra":1}}`},
+		{"wrong_type", `{"action":"approve","values":{"comment":"ok","score":"high"}}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newPendingInputRepo(port.RunStatusPaused)
+			h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+			rr := postPendingInput(h, tc.body)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected status=400, got=%d, body=%s", rr.Code, rr.Body.String())
+			}
+			if repo.submitted != nil {
+				t.Fatal("expected invalid submission not to be stored")
+			}
+		})
+	}
+}
+
+func TestSubmitPendingInputRejectsRunNotPaused(t *testing.T) {
+	repo := newPendingInputRepo(port.RunStatusRunning)
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postPendingInput(h, `{"action":"approve","values":{"comment":"ok"}}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected status=409, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "run_not_paused") {
+		t.Fatalf("expected run_not_paused, got body=%s", rr.Body.String())
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 异步队列观测 ---
+
+type queueStatsResponse struct {
+	Queued  int                      `json:"queued"`
+	Delayed int                      `json:"delayed"`
+	Running int                      `json:"running"`
+	Tenants []*port.TenantQueueStats `json:"tenants"`
+}
+
+// GetQueueStats 按租户返回异步队列深度（排队、退避中、执行中），附带合计；
+// 鉴权模式下仅返回调用方所在租户
+func (h *WorkflowHandler) GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	stats, err := h.repo.ListQueueStats(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get queue stats")
+		return
+	}
+	resp := queueStatsResponse{Tenants: stats}
+	for _, st := range stats {
+		resp.Queued += st.Queued
+		resp.Delayed += st.Delayed
+		resp.Running += st.Running
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
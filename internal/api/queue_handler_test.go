@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockQueueStatsRepo 返回固定的租户队列统计
+type mockQueueStatsRepo struct {
+	port.Repository
+	stats []*port.TenantQueueStats
+}
+
+func (m *mockQueueStatsRepo) ListQueueStats(ctx context.Context) ([]*port.TenantQueueStats, error) {
+	return m.stats, nil
+}
+
+func TestRunAsyncPriority(t *testing.T) {
+	repo := newMockVersionRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	if rr := postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "priority": 10}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for out-of-range priority, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no run for an invalid priority")
+	}
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}, "priority": 7}`)
+	if rr.Code != http.StatusAccepted || repo.runs[0].Priority != 7 {
+		t.Fatalf("expected run queued with priority 7, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}}`)
+	if rr.Code != http.StatusAccepted || repo.runs[1].Priority != port.RunPriorityMin {
+		t.Fatalf("expected default priority 0, got=%d, priority=%d", rr.Code, repo.runs[1].Priority)
+	}
+}
+
+func TestGetQueueStats(t *testing.T) {
+	h := NewWorkflowHandler(&mockQueueStatsRepo{stats: []*port.TenantQueueStats{
+		{OrgID: "org_1", TenantID: "t_1", Queued: 5, Delayed: 1, Running: 2, QueueWeight: 1},
+		{OrgID: "org_1", TenantID: "t_2", Queued: 1, Running: 3, QueueWeight: 3, MaxConcurrentRuns: 4},
+	}}, nil, 0, RunInputConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runs/queue", nil)
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data queueStatsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Queued != 6 || resp.Data.Delayed != 1 || resp.Data.Running != 5 || len(resp.Data.Tenants) != 2 {
+		t.Fatalf("unexpected totals: %s", rr.Body.String())
+	}
+	if resp.Data.Tenants[1].MaxConcurrentRuns != 4 || resp.Data.Tenants[1].QueueWeight != 3 {
+		t.Fatalf("expected tenant limits in response, got %s", rr.Body.String())
+	}
+}
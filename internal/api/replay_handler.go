@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 确定性回放（以录制响应重放历史运行，不创建运行记录） ---
+
+// ReplayRun 使用原始运行的 DSL、输入与录制的 LLM / HTTP / ASR 响应重放运行，并报告路径差异
+func (h *WorkflowHandler) ReplayRun(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	dsl, ok := h.loadReplayDSL(ctx, w, run)
+	if !ok {
+		return
+	}
+
+	var inputs map[string]interface{}
+	if len(run.Inputs) > 0 {
+		if err := json.Unmarshal(run.Inputs, &inputs); err != nil {
+			writeErrorCode(w, http.StatusUnprocessableEntity, "invalid_run_inputs", "stored run inputs are not valid JSON")
+			return
+		}
+	}
+
+	// 加载录制：节点执行记录 + 本次运行的 LLM 调用溯源
+	execs, err := h.repo.ListNodeExecsByRunID(ctx, run.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list node executions")
+		return
+	}
+	var traces []*port.LLMCallTraceRecord
+	if run.ConversationID != "" {
+		all, err := h.repo.ListLLMTraces(ctx, run.ConversationID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list llm traces")
+			return
+		}
+		for _, t := range all {
+			if t.RunID == run.ID {
+				traces = append(traces, t)
+			}
+		}
+	}
+	humanInputs, err := workflow.LoadHumanInputs(ctx, h.repo, run.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load human inputs")
+		return
+	}
+
+	opts := &workflow.RunOptions{HumanInputs: humanInputs}
+	if scope != nil {
+		opts.OrgID = scope.OrgID
+		opts.TenantID = scope.TenantID
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, h.runTimeout)
+	defer cancel()
+
+	result, err := h.runner.Replay(execCtx, dsl, inputs, run, workflow.NewReplayRecording(execs, traces), opts)
+	if err != nil {
+		writeErrorCode(w, http.StatusUnprocessableEntity, "replay_failed", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// loadReplayDSL 加载原始运行执行的 DSL 版本；无版本记录的历史运行回退到当前版本
+func (h *WorkflowHandler) loadReplayDSL(ctx context.Context, w http.ResponseWriter, run *port.WorkflowRun) (json.RawMessage, bool) {
+	if run.WorkflowVersion > 0 {
+		v, err := h.repo.GetWorkflowVersion(ctx, run.WorkflowID, run.WorkflowVersion)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get workflow version")
+			return nil, false
+		}
+		if v == nil {
+			writeError(w, http.StatusNotFound, "workflow version not found")
+			return nil, false
+		}
+		return v.DSL, true
+	}
+	wf, err := h.repo.GetWorkflow(ctx, run.WorkflowID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return nil, false
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return nil, false
+	}
+	return wf.DSL, true
+}
@@ -30,3 +30,14 @@ func writeError(w http.ResponseWriter, status int, message string) {
 		Message: message,
 	})
 }
+
+// writeErrorData 返回带附加数据的错误响应（如校验报告）
+func writeErrorData(w http.ResponseWriter, status int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&APIResponse{
+		Code:    status,
+		Message: message,
+		Data:    data,
+	})
+}
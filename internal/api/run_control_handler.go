@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// --- 运行控制（abort / pause / resume / cancel / requeue，跨实例生效） ---
+
+// requeueableRunStatuses 可重新入队的运行状态
+var requeueableRunStatuses = []port.RunStatus{port.RunStatusFailed, port.RunStatusAborted, port.RunStatusCanceled, port.RunStatusDeadLetter}
+
+// AbortRun 中止运行：排队中或挂起的运行直接标记为 aborted，执行中的运行通知所在实例中止引擎
+func (h *WorkflowHandler) AbortRun(w http.ResponseWriter, r *http.Request) {
+	h.controlRun(w, r, types.CommandAbort,
+		[]port.RunStatus{port.RunStatusQueued, port.RunStatusRunning, port.RunStatusPaused},
+		port.RunStatusAborted,
+	)
+}
+
+// PauseRun 暂停执行中的运行：当前节点执行完后不再调度新节点；异步运行暂停期间引擎仍由原 worker 持有并续约
+func (h *WorkflowHandler) PauseRun(w http.ResponseWriter, r *http.Request) {
+	h.controlRun(w, r, types.CommandPause,
+		[]port.RunStatus{port.RunStatusRunning},
+		port.RunStatusPaused,
+	)
+}
+
+// ResumeRun 恢复手动暂停的运行（等待人工输入的运行需通过 pending-input 提交恢复）
+func (h *WorkflowHandler) ResumeRun(w http.ResponseWriter, r *http.Request) {
+	h.controlRun(w, r, types.CommandResume,
+		[]port.RunStatus{port.RunStatusPaused},
+		port.RunStatusRunning,
+	)
+}
+
+// CancelRun 取消运行：排队中的运行原子地标记为 canceled，不再被 worker 领取；
+// 已被领取执行（或暂停）的运行经命令通道中止，最终状态为 aborted
+func (h *WorkflowHandler) CancelRun(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	canceled, err := h.repo.TransitionRunStatus(ctx, id, []port.RunStatus{port.RunStatusQueued}, port.RunStatusCanceled)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update run status")
+		return
+	}
+	if canceled {
+		applog.Info("[Workflow/Control] Queued run canceled", "run_id", id)
+		if run, err := h.repo.GetRun(ctx, id); err == nil && run != nil {
+			h.runner.NotifyRunFinished(ctx, run)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"run_id": id,
+			"status": port.RunStatusCanceled,
+		})
+		return
+	}
+
+	// 不在排队中（可能刚被 worker 领取）：按中止处理
+	h.controlRun(w, r, types.CommandAbort,
+		[]port.RunStatus{port.RunStatusRunning, port.RunStatusPaused},
+		port.RunStatusAborted,
+	)
+}
+
+// RequeueRun 将失败、中止、取消或死信的运行重新排入异步队列，沿用原运行的输入与固定版本
+func (h *WorkflowHandler) RequeueRun(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	if run.ParentRunID != "" {
+		writeErrorCode(w, http.StatusConflict, "child_run", "Child runs are executed by their parent run; requeue the parent instead")
+		return
+	}
+	if !containsRunStatus(requeueableRunStatuses, run.Status) {
+		writeErrorCode(w, http.StatusConflict, "invalid_run_state", "Run cannot be requeued in status "+string(run.Status))
+		return
+	}
+
+	requeued, err := h.repo.RequeueRun(ctx, id, requeueableRunStatuses)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to requeue run")
+		return
+	}
+	if !requeued {
+		writeErrorCode(w, http.StatusConflict, "invalid_run_state", "Run status changed concurrently")
+		return
+	}
+
+	applog.Info("[Workflow/Control] Run requeued", "run_id", id, "from_status", run.Status, "retry_count", run.RetryCount+1)
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"run_id":      id,
+		"status":      port.RunStatusQueued,
+		"retry_count": run.RetryCount + 1,
+	})
+}
+
+func (h *WorkflowHandler) controlRun(w http.ResponseWriter, r *http.Request, cmdType types.CommandType, from []port.RunStatus, to port.RunStatus) {
+	ctx, scope := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	if !containsRunStatus(from, run.Status) {
+		writeErrorCode(w, http.StatusConflict, "invalid_run_state", "Run cannot be "+commandPastTense(cmdType)+" in status "+string(run.Status))
+		return
+	}
+
+	// 等待人工输入的运行没有执行中的引擎：不可手动恢复，也无需转发中止命令
+	suspended := false
+	if run.Status == port.RunStatusPaused {
+		inputs, err := h.repo.ListPendingInputs(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list pending inputs")
+			return
+		}
+		suspended = hasWaitingInput(inputs)
+	}
+	if cmdType == types.CommandResume && suspended {
+		writeErrorCode(w, http.StatusConflict, "input_required", "Run is waiting for human input; submit it via pending-input")
+		return
+	}
+
+	transitioned, err := h.repo.TransitionRunStatus(ctx, id, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update run status")
+		return
+	}
+	if !transitioned {
+		writeErrorCode(w, http.StatusConflict, "invalid_run_state", "Run status changed concurrently")
+		return
+	}
+
+	// 仅执行中（或手动暂停）的运行需要通知引擎
+	delivered := false
+	if run.Status == port.RunStatusRunning || (run.Status == port.RunStatusPaused && !suspended) {
+		reason := commandPastTense(cmdType) + " via API"
+		if scope != nil && scope.Subject != "" {
+			reason += " by " + scope.Subject
+		}
+		err := h.runner.SendCommand(ctx, id, types.Command{Type: cmdType, Reason: reason})
+		switch {
+		case err == nil:
+			delivered = true
+		case errors.Is(err, workflow.ErrRunNotControllable):
+			applog.Warn("[Workflow/Control] Run is not executing on any reachable instance", "run_id", id, "command", cmdType)
+			// 暂停/恢复无法送达时回滚状态；中止仍以数据库状态为准
+			if cmdType != types.CommandAbort {
+				if _, err := h.repo.TransitionRunStatus(ctx, id, []port.RunStatus{to}, run.Status); err != nil {
+					applog.Error("[Workflow/Control] Failed to roll back run status", "run_id", id, "error", err)
+				}
+				writeErrorCode(w, http.StatusConflict, "run_not_executing", "Run is not executing on any reachable instance")
+				return
+			}
+		default:
+			applog.Error("[Workflow/Control] Failed to send command", "run_id", id, "command", cmdType, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to send run command")
+			return
+		}
+	}
+
+	// 未送达引擎的中止不会再有 worker 写回结果，在此发出完成通知
+	if to == port.RunStatusAborted && !delivered {
+		aborted := *run
+		aborted.Status = to
+		h.runner.NotifyRunFinished(ctx, &aborted)
+	}
+
+	applog.Info("[Workflow/Control] Run command accepted",
+		"run_id", id,
+		"command", cmdType,
+		"from_status", run.Status,
+		"to_status", to,
+		"delivered", delivered,
+	)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"run_id":  id,
+		"command": cmdType,
+		"status":  to,
+	})
+}
+
+func hasWaitingInput(inputs []*port.PendingInput) bool {
+	for _, pi := range inputs {
+		if pi.Status == port.PendingInputStatusWaiting {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRunStatus(list []port.RunStatus, status port.RunStatus) bool {
+	for _, st := range list {
+		if st == status {
+			return true
+		}
+	}
+	return false
+}
+
+func commandPastTense(cmdType types.CommandType) string {
+	switch cmdType {
+	case types.CommandAbort:
+		return "aborted"
+	case types.CommandPause:
+		return "paused"
+	case types.CommandResume:
+		return "resumed"
+	default:
+		return string(cmdType)
+	}
+}
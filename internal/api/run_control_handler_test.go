@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+type mockRunControlRepo struct {
+	port.Repository
+	run         *port.WorkflowRun
+	inputs      []*port.PendingInput
+	transitions []port.RunStatus
+}
+
+func (m *mockRunControlRepo) GetRun(ctx context.Context, id string) (*port.WorkflowRun, error) {
+	run := *m.run
+	return &run, nil
+}
+
+func (m *mockRunControlRepo) ListPendingInputs(ctx context.Context, runID string) ([]*port.PendingInput, error) {
+	return m.inputs, nil
+}
+
+func (m *mockRunControlRepo) TransitionRunStatus(ctx context.Context, id string, from []port.RunStatus, to port.RunStatus) (bool, error) {
+	if !containsRunStatus(from, m.run.Status) {
+		return false, nil
+	}
+	m.transitions = append(m.transitions, to)
+	m.run.Status = to
+	return true, nil
+}
+
+func postRunCommand(h *WorkflowHandler, command string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/runs/run_1/"+command, nil)
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAbortQueuedRun(t *testing.T) {
+	repo := &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusQueued}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postRunCommand(h, "abort")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if repo.run.Status != port.RunStatusAborted {
+		t.Fatalf("expected run to be aborted, got=%s", repo.run.Status)
+	}
+}
+
+func TestResumeRunWaitingForInput(t *testing.T) {
+	repo := &mockRunControlRepo{
+		run:    &port.WorkflowRun{ID: "run_1", Status: port.RunStatusPaused},
+		inputs: []*port.PendingInput{{RunID: "run_1", NodeID: "review_1", Status: port.PendingInputStatusWaiting}},
+	}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postRunCommand(h, "resume")
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected status=409, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "input_required") {
+		t.Fatalf("expected input_required, got body=%s", rr.Body.String())
+	}
+	if len(repo.transitions) != 0 {
+		t.Fatalf("expected no status transition, got %v", repo.transitions)
+	}
+}
+
+func TestPauseRunNotExecutingRollsBack(t *testing.T) {
+	repo := &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusRunning}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postRunCommand(h, "pause")
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected status=409, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "run_not_executing") {
+		t.Fatalf("expected run_not_executing, got body=%s", rr.Body.String())
+	}
+	if repo.run.Status != port.RunStatusRunning {
+		t.Fatalf("expected status to be rolled back to running, got=%s", repo.run.Status)
+	}
+}
+
+func (m *mockRunControlRepo) RequeueRun(ctx context.Context, id string, from []port.RunStatus) (bool, error) {
+	if !containsRunStatus(from, m.run.Status) {
+		return false, nil
+	}
+	m.transitions = append(m.transitions, port.RunStatusQueued)
+	if m.run.Status == port.RunStatusDeadLetter {
+		m.run.RetryCount = 0
+	} else {
+		m.run.RetryCount++
+	}
+	m.run.Status = port.RunStatusQueued
+	return true, nil
+}
+
+func deleteRun(h *WorkflowHandler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/runs/run_1", nil)
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCancelRun(t *testing.T) {
+	// 排队中：直接标记为 canceled
+	repo := &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusQueued}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	if rr := deleteRun(h); rr.Code != http.StatusOK || repo.run.Status != port.RunStatusCanceled {
+		t.Fatalf("expected queued run to be canceled, got=%d status=%s body=%s", rr.Code, repo.run.Status, rr.Body.String())
+	}
+
+	// 执行中：经命令通道中止（无可达实例时仍以数据库状态为准）
+	repo = &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusRunning}}
+	h = NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	if rr := deleteRun(h); rr.Code != http.StatusAccepted || repo.run.Status != port.RunStatusAborted {
+		t.Fatalf("expected running run to be aborted, got=%d status=%s body=%s", rr.Code, repo.run.Status, rr.Body.String())
+	}
+
+	// 已结束：拒绝
+	repo = &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusSucceeded}}
+	h = NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	if rr := deleteRun(h); rr.Code != http.StatusConflict || len(repo.transitions) != 0 {
+		t.Fatalf("expected 409 for finished run, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRequeueRun(t *testing.T) {
+	repo := &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusFailed, Error: "boom"}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postRunCommand(h, "requeue")
+	if rr.Code != http.StatusAccepted || repo.run.Status != port.RunStatusQueued || repo.run.RetryCount != 1 {
+		t.Fatalf("expected failed run to be requeued, got=%d status=%s body=%s", rr.Code, repo.run.Status, rr.Body.String())
+	}
+
+	// 排队中再次 requeue 冲突
+	if rr = postRunCommand(h, "requeue"); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "invalid_run_state") {
+		t.Fatalf("expected 409 invalid_run_state, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// 子工作流运行由父运行驱动，不能单独入队
+	repo = &mockRunControlRepo{run: &port.WorkflowRun{ID: "run_1", ParentRunID: "run_0", Status: port.RunStatusFailed}}
+	h = NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	if rr = postRunCommand(h, "requeue"); rr.Code != http.StatusConflict || len(repo.transitions) != 0 {
+		t.Fatalf("expected child run requeue to be rejected, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
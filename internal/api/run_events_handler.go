@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// --- 运行事件流（SSE 断线续传 / 旁路观察） ---
+
+const (
+	// runEventTailBlock 等待新事件的最长时间，超时后发送心跳并检查运行状态
+	runEventTailBlock = 15 * time.Second
+	// runEventReadBatch 单次读取的事件数
+	runEventReadBatch = 200
+)
+
+// StreamRunEvents 回放运行事件日志中 Last-Event-ID 之后的事件并持续推送新事件，
+// 运行结束（done）或挂起等待人工输入时结束。同步、流式与异步运行均可观察
+func (h *WorkflowHandler) StreamRunEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	afterSeq, err := parseLastEventID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	eventLog := h.runner.RunEventLog()
+	if eventLog == nil {
+		writeError(w, http.StatusServiceUnavailable, "run event log is not enabled on this server")
+		return
+	}
+	run, err := h.repo.GetRun(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get run")
+		return
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Run-ID", run.ID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	flusher.Flush()
+
+	h.followRunEvents(ctx, eventLog, run.ID, afterSeq, func(eventType string, seq int64, data interface{}) {
+		sseWriteEventWithID(w, flusher, seq, eventType, data)
+	}, func() {
+		fmt.Fprint(w, ": keep-alive\n\n")
+		flusher.Flush()
+	})
+}
+
+// followRunEvents 从 afterSeq 之后回放并持续输出运行事件，直到 done、挂起等待人工输入或 ctx 结束；
+// 等待超时仍无新事件时调用 idle（如发送心跳）
+func (h *WorkflowHandler) followRunEvents(ctx context.Context, eventLog port.RunEventLog, runID string, afterSeq int64, emit streamEmitter, idle func()) {
+	block := runEventTailBlock
+	var finished *port.WorkflowRun
+	for {
+		events, err := eventLog.Read(ctx, runID, afterSeq, runEventReadBatch, block)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			applog.Error("[Workflow/Events] Failed to read run events", "run_id", runID, "error", err)
+			emit("error", 0, map[string]string{"error": "failed to read run events"})
+			return
+		}
+		for _, e := range events {
+			afterSeq = e.Seq
+			emit(e.Event, e.Seq, e.Data)
+			if e.Event == workflow.RunEventDone {
+				return
+			}
+			if pending, ok := suspendedPendingInputs(e); ok {
+				emit("done", 0, map[string]interface{}{
+					"run_id":         runID,
+					"status":         port.RunStatusPaused,
+					"pending_inputs": pending,
+				})
+				return
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+
+		// 运行已结束但事件日志中没有 done（已过期或写入失败）：以数据库中的结果结束
+		if finished != nil {
+			emit("done", 0, workflow.RunDonePayload(finished))
+			return
+		}
+		latest, err := h.repo.GetRun(ctx, runID)
+		if err == nil && latest != nil && workflow.IsTerminalRunStatus(latest.Status) {
+			// 再读一次，补齐结果落库前刚写入的事件
+			finished = latest
+			block = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if idle != nil {
+			idle()
+		}
+	}
+}
+
+// parseLastEventID 读取续传位置：Last-Event-ID 请求头（EventSource 重连时自动携带），
+// 或 last_event_id 查询参数；均未提供时从头回放
+func parseLastEventID(r *http.Request) (int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("Last-Event-ID must be a non-negative integer")
+	}
+	return seq, nil
+}
+
+// suspendedPendingInputs 挂起等待人工输入的 paused 事件返回待输入请求；手动暂停不视为结束
+func suspendedPendingInputs(e port.RunEvent) (json.RawMessage, bool) {
+	if e.Event != workflow.RunEventMessage {
+		return nil, false
+	}
+	var data struct {
+		Type          event.EventType `json:"type"`
+		PendingInputs json.RawMessage `json:"pending_inputs"`
+	}
+	if err := json.Unmarshal(e.Data, &data); err != nil || data.Type != event.EventTypeGraphRunPaused {
+		return nil, false
+	}
+	if len(data.PendingInputs) == 0 || string(data.PendingInputs) == "null" {
+		return nil, false
+	}
+	return data.PendingInputs, true
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// fakeRunEventLog 预置事件的运行事件日志（Read 不阻塞）
+type fakeRunEventLog struct {
+	events []port.RunEvent
+}
+
+func (l *fakeRunEventLog) add(event, data string) {
+	l.events = append(l.events, port.RunEvent{Seq: int64(len(l.events) + 1), Event: event, Data: json.RawMessage(data)})
+}
+
+func (l *fakeRunEventLog) Append(_ context.Context, _ string, event string, data json.RawMessage) (int64, error) {
+	l.add(event, string(data))
+	return int64(len(l.events)), nil
+}
+
+func (l *fakeRunEventLog) Read(_ context.Context, _ string, afterSeq int64, limit int, _ time.Duration) ([]port.RunEvent, error) {
+	var out []port.RunEvent
+	for _, e := range l.events {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type mockRunEventsRepo struct {
+	port.Repository
+	run *port.WorkflowRun
+}
+
+func (m *mockRunEventsRepo) GetRun(ctx context.Context, id string) (*port.WorkflowRun, error) {
+	if m.run == nil || m.run.ID != id {
+		return nil, nil
+	}
+	run := *m.run
+	return &run, nil
+}
+
+func getRunEvents(h *WorkflowHandler, path, lastEventID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	rr := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestStreamRunEventsResume(t *testing.T) {
+	repo := &mockRunEventsRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusRunning}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	if rr := getRunEvents(h, "/api/v1/runs/run_1/events", ""); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status=503 without event log, got=%d", rr.Code)
+	}
+
+	log := &fakeRunEventLog{}
+	log.add("message", `{"type":"graph_run_started"}`)
+	log.add("message", `{"type":"node_stream_chunk","node_id":"llm_1","chunk":"hel"}`)
+	log.add("message", `{"type":"graph_run_succeeded","outputs":{"answer":"hello"}}`)
+	log.add("done", `{"run_id":"run_1","status":"succeeded"}`)
+	h.runner.SetRunEventLog(log)
+
+	if rr := getRunEvents(h, "/api/v1/runs/run_1/events", "abc"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status=400 for invalid Last-Event-ID, got=%d", rr.Code)
+	}
+	if rr := getRunEvents(h, "/api/v1/runs/run_2/events", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404 for unknown run, got=%d", rr.Code)
+	}
+
+	rr := getRunEvents(h, "/api/v1/runs/run_1/events", "1")
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected SSE response, got=%d, body=%s", rr.Code, body)
+	}
+	if strings.Contains(body, "id: 1\n") || !strings.Contains(body, "id: 2\nevent: message\n") || !strings.Contains(body, "id: 4\nevent: done\n") {
+		t.Fatalf("expected events 2..4 only, body=%s", body)
+	}
+
+	// EventSource 首次连接无法设置请求头，可用查询参数指定续传位置
+	rr = getRunEvents(h, "/api/v1/runs/run_1/events?last_event_id=3", "")
+	if body := rr.Body.String(); strings.Contains(body, "id: 3\n") || !strings.Contains(body, "id: 4\nevent: done\n") {
+		t.Fatalf("expected only the done event, body=%s", body)
+	}
+}
+
+func TestStreamRunEventsEndsOnSuspend(t *testing.T) {
+	repo := &mockRunEventsRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusPaused}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	log := &fakeRunEventLog{}
+	log.add("message", `{"type":"graph_run_paused"}`)
+	log.add("message", `{"type":"graph_run_resumed"}`)
+	log.add("message", `{"type":"graph_run_paused","pending_inputs":[{"node_id":"approve"}]}`)
+	h.runner.SetRunEventLog(log)
+
+	body := getRunEvents(h, "/api/v1/runs/run_1/events", "").Body.String()
+	if !strings.Contains(body, "id: 3\n") || !strings.Contains(body, `event: done`) || !strings.Contains(body, `"status":"paused"`) {
+		t.Fatalf("expected stream to end on suspension, body=%s", body)
+	}
+	if strings.Count(body, "event: done") != 1 {
+		t.Fatalf("manual pause must not end the stream, body=%s", body)
+	}
+}
+
+func TestStreamRunEventsFallsBackToStoredResult(t *testing.T) {
+	// 排队中被取消的运行没有执行事件，以数据库中的终态结束
+	repo := &mockRunEventsRepo{run: &port.WorkflowRun{ID: "run_1", Status: port.RunStatusCanceled}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+	h.runner.SetRunEventLog(&fakeRunEventLog{})
+
+	body := getRunEvents(h, "/api/v1/runs/run_1/events", "").Body.String()
+	if !strings.Contains(body, "event: done") || !strings.Contains(body, `"status":"canceled"`) {
+		t.Fatalf("expected done from stored run, body=%s", body)
+	}
+}
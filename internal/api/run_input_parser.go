@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -61,6 +62,15 @@ func parseMultipartRunWorkflowRequest(r *http.Request, cfg RunInputConfig) (*run
 	if v := strings.TrimSpace(r.FormValue("conversation_id")); v != "" {
 		req.ConversationID = v
 	}
+	if v := strings.TrimSpace(r.FormValue("priority")); v != "" {
+		priority, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority in multipart form: %w", err)
+		}
+		req.Priority = &priority
+	}
+	req.CallbackURL = strings.TrimSpace(r.FormValue("callback_url"))
+	req.CallbackSecret = strings.TrimSpace(r.FormValue("callback_secret"))
 
 	if rawInputs := strings.TrimSpace(r.FormValue("inputs")); rawInputs != "" {
 		if err := json.Unmarshal([]byte(rawInputs), &req.Inputs); err != nil {
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 定时触发（cron 调度 CRUD / 最近与后续触发时间） ---
+
+// upcomingFireCount 调度详情中展示的后续触发次数
+const upcomingFireCount = 5
+
+type scheduleRequest struct {
+	Name          *string          `json:"name,omitempty"`
+	CronExpr      *string          `json:"cron,omitempty"`
+	Timezone      *string          `json:"timezone,omitempty"`
+	Inputs        *json.RawMessage `json:"inputs,omitempty"`
+	Enabled       *bool            `json:"enabled,omitempty"`
+	MisfirePolicy *string          `json:"misfire_policy,omitempty"`
+}
+
+// scheduleView 调度详情：在调度记录上附加后续触发时间
+type scheduleView struct {
+	*port.WorkflowSchedule
+	UpcomingFireTimes []time.Time `json:"upcoming_fire_times,omitempty"`
+}
+
+func newScheduleView(s *port.WorkflowSchedule, n int) scheduleView {
+	v := scheduleView{WorkflowSchedule: s}
+	if s.Enabled && n > 0 {
+		v.UpcomingFireTimes, _ = workflow.UpcomingFireTimes(s.CronExpr, s.Timezone, time.Now(), n)
+	}
+	return v
+}
+
+// CreateSchedule 为工作流创建定时触发
+func (h *WorkflowHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.CronExpr == nil || strings.TrimSpace(*req.CronExpr) == "" {
+		writeError(w, http.StatusBadRequest, "cron is required")
+		return
+	}
+
+	s := &port.WorkflowSchedule{
+		WorkflowID:    wf.ID,
+		OrgID:         wf.OrgID,
+		TenantID:      wf.TenantID,
+		Timezone:      "UTC",
+		Inputs:        json.RawMessage(`{}`),
+		Enabled:       true,
+		MisfirePolicy: port.MisfirePolicyFireOnce,
+	}
+	if scope != nil {
+		s.OrgID = scope.OrgID
+		s.TenantID = scope.TenantID
+	}
+	if !applyScheduleRequest(w, s, &req) {
+		return
+	}
+	if err := h.repo.CreateSchedule(ctx, s); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create schedule")
+		return
+	}
+	writeJSON(w, http.StatusCreated, newScheduleView(s, upcomingFireCount))
+}
+
+// ListSchedules 列出工作流的定时触发
+func (h *WorkflowHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	schedules, err := h.repo.ListSchedules(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list schedules")
+		return
+	}
+	views := make([]scheduleView, 0, len(schedules))
+	for _, s := range schedules {
+		views = append(views, newScheduleView(s, 0))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// GetSchedule 获取定时触发详情（含最近触发结果与后续触发时间）
+func (h *WorkflowHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	s, err := h.repo.GetSchedule(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get schedule")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, newScheduleView(s, upcomingFireCount))
+}
+
+// UpdateSchedule 更新定时触发；下次触发时间按新配置从当前时间重新计算
+func (h *WorkflowHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	s, err := h.repo.GetSchedule(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get schedule")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if !applyScheduleRequest(w, s, &req) {
+		return
+	}
+	if err := h.repo.UpdateSchedule(ctx, s); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update schedule")
+		return
+	}
+	writeJSON(w, http.StatusOK, newScheduleView(s, upcomingFireCount))
+}
+
+// DeleteSchedule 删除定时触发
+func (h *WorkflowHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	s, err := h.repo.GetSchedule(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get schedule")
+		return
+	}
+	if s == nil {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+	if err := h.repo.DeleteSchedule(ctx, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete schedule")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// applyScheduleRequest 校验并合并请求字段，重新计算 next_fire_at；校验失败时写入 400 并返回 false
+func applyScheduleRequest(w http.ResponseWriter, s *port.WorkflowSchedule, req *scheduleRequest) bool {
+	if req.Name != nil {
+		s.Name = *req.Name
+	}
+	if req.CronExpr != nil {
+		s.CronExpr = strings.TrimSpace(*req.CronExpr)
+	}
+	if req.Timezone != nil {
+		s.Timezone = strings.TrimSpace(*req.Timezone)
+		if s.Timezone == "" {
+			s.Timezone = "UTC"
+		}
+	}
+	if req.Inputs != nil {
+		trimmed := bytes.TrimSpace(*req.Inputs)
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			writeError(w, http.StatusBadRequest, "inputs must be a JSON object")
+			return false
+		}
+		s.Inputs = *req.Inputs
+	}
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+	}
+	if req.MisfirePolicy != nil {
+		switch policy := port.MisfirePolicy(*req.MisfirePolicy); policy {
+		case port.MisfirePolicyFireOnce, port.MisfirePolicySkip:
+			s.MisfirePolicy = policy
+		default:
+			writeError(w, http.StatusBadRequest, "misfire_policy must be one of: fire_once, skip")
+			return false
+		}
+	}
+
+	next, err := workflow.NextFireTime(s.CronExpr, s.Timezone, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	s.NextFireAt = nil
+	if s.Enabled {
+		s.NextFireAt = &next
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockScheduleRepo 内存保存调度记录
+type mockScheduleRepo struct {
+	port.Repository
+	schedules map[string]*port.WorkflowSchedule
+}
+
+func (m *mockScheduleRepo) GetWorkflow(ctx context.Context, id string) (*port.Workflow, error) {
+	if id != "wf_1" {
+		return nil, nil
+	}
+	return &port.Workflow{ID: "wf_1", Version: 1, PublishedVersion: 1}, nil
+}
+
+func (m *mockScheduleRepo) CreateSchedule(ctx context.Context, s *port.WorkflowSchedule) error {
+	s.ID = "sch_1"
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *mockScheduleRepo) GetSchedule(ctx context.Context, id string) (*port.WorkflowSchedule, error) {
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *mockScheduleRepo) UpdateSchedule(ctx context.Context, s *port.WorkflowSchedule) error {
+	m.schedules[s.ID] = s
+	return nil
+}
+
+type scheduleResponse struct {
+	Data struct {
+		ID                string      `json:"id"`
+		CronExpr          string      `json:"cron"`
+		Timezone          string      `json:"timezone"`
+		Enabled           bool        `json:"enabled"`
+		MisfirePolicy     string      `json:"misfire_policy"`
+		NextFireAt        *time.Time  `json:"next_fire_at"`
+		UpcomingFireTimes []time.Time `json:"upcoming_fire_times"`
+	} `json:"data"`
+}
+
+func TestScheduleCRUD(t *testing.T) {
+	repo := &mockScheduleRepo{schedules: map[string]*port.WorkflowSchedule{}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	for body, want := range map[string]string{
+		`{"cron": "61 * * * *"}`:                              "minute",
+		`{"cron": "0 2 * * *", "timezone": "Mars/Olympus"}`:   "invalid timezone",
+		`{"cron": "0 2 * * *", "inputs": [1]}`:                "inputs must be a JSON object",
+		`{"cron": "0 2 * * *", "misfire_policy": "fire_all"}`: "misfire_policy",
+		`{"timezone": "UTC"}`:                                 "cron is required",
+	} {
+		rr := postDebug(h, "/api/v1/workflows/wf_1/schedules", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("expected 400 containing %q for %s, got=%d, body=%s", want, body, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/schedules", `{"name": "nightly", "cron": "0 2 * * *", "timezone": "Asia/Shanghai", "inputs": {"topic": "digest"}}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status=201, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var created scheduleResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !created.Data.Enabled || created.Data.MisfirePolicy != "fire_once" || created.Data.NextFireAt == nil {
+		t.Fatalf("unexpected schedule defaults: %s", rr.Body.String())
+	}
+	if len(created.Data.UpcomingFireTimes) != upcomingFireCount || !created.Data.UpcomingFireTimes[0].Equal(*created.Data.NextFireAt) {
+		t.Fatalf("expected upcoming fire times starting at next_fire_at, got %s", rr.Body.String())
+	}
+
+	// 停用后不再有下次触发时间
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/schedules/sch_1", strings.NewReader(`{"enabled": false}`))
+	rec := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rec.Code, rec.Body.String())
+	}
+	if s := repo.schedules["sch_1"]; s.Enabled || s.NextFireAt != nil || s.CronExpr != "0 2 * * *" {
+		t.Fatalf("expected disabled schedule without next fire time, got %+v", s)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/schedules/missing", nil)
+	rec = httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404, got=%d", rec.Code)
+	}
+
+	rr = postDebug(h, "/api/v1/workflows/wf_missing/schedules", `{"cron": "0 2 * * *"}`)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404 for unknown workflow, got=%d", rr.Code)
+	}
+}
@@ -18,27 +18,29 @@ import (
 
 // ServerConfig 服务配置
 type ServerConfig struct {
-	Host          string
-	Port          int
-	ReadTimeout   time.Duration
-	WriteTimeout  time.Duration
-	RunTimeout    time.Duration // 工作流执行超时（同步/流式）
-	JWTSecret     string        // JWT 签名密钥（必填）
-	JWTIssuer     string        // JWT 签发者（可选）
-	ASRTempDir    string        // ASR multipart 文件暂存目录
-	ASRMaxAudioMB int           // ASR 上传文件大小上限
+	Host              string
+	Port              int
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	RunTimeout        time.Duration // 工作流执行超时（同步/流式）
+	JWTSecret         string        // JWT 签名密钥（必填）
+	JWTIssuer         string        // JWT 签发者（可选）
+	ASRTempDir        string        // ASR multipart 文件暂存目录
+	ASRMaxAudioMB     int           // ASR 上传文件大小上限
+	IdempotencyKeyTTL time.Duration // 运行接口 Idempotency-Key 保留时长
 }
 
 // DefaultServerConfig 默认配置
 func DefaultServerConfig() *ServerConfig {
 	return &ServerConfig{
-		Host:          "0.0.0.0",
-		Port:          8080,
-		ReadTimeout:   30 * time.Second,
-		WriteTimeout:  10 * time.Minute, // SSE 需要较长写超时
-		RunTimeout:    5 * time.Minute,
-		ASRTempDir:    "/tmp/flowweave-asr",
-		ASRMaxAudioMB: 50,
+		Host:              "0.0.0.0",
+		Port:              8080,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      10 * time.Minute, // SSE 需要较长写超时
+		RunTimeout:        5 * time.Minute,
+		ASRTempDir:        "/tmp/flowweave-asr",
+		ASRMaxAudioMB:     50,
+		IdempotencyKeyTTL: defaultIdempotencyKeyTTL,
 	}
 }
 
@@ -128,6 +130,7 @@ func (s *Server) buildRouter() (http.Handler, error) {
 		ASRTempDir:    s.config.ASRTempDir,
 		ASRMaxAudioMB: s.config.ASRMaxAudioMB,
 	})
+	workflowHandler.SetIdempotencyKeyTTL(s.config.IdempotencyKeyTTL)
 	orgHandler := NewOrganizationHandler(s.repo)
 	tenantHandler := NewTenantHandler(s.repo)
 	ragEnabled := s.retriever != nil || s.indexer != nil
@@ -147,6 +150,7 @@ func (s *Server) registerPublicRoutes(r chi.Router, workflowHandler *WorkflowHan
 	orgHandler.RegisterPublicRoutes(r)
 	tenantHandler.RegisterPublicRoutes(r)
 	r.Post("/api/v1/callbacks/async-tasks/{provider}", workflowHandler.HandleAsyncTaskCallback)
+	r.Post("/api/v1/hooks/{id}", workflowHandler.TriggerWebhook)
 }
 
 func (s *Server) registerProtectedRoutes(
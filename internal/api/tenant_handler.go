@@ -186,6 +186,10 @@ func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "org_id, code and name are required")
 		return
 	}
+	if tenant.QueueWeight < 0 || tenant.MaxConcurrentRuns < 0 {
+		writeError(w, http.StatusBadRequest, "queue_weight and max_concurrent_runs must be >= 0")
+		return
+	}
 
 	if err := h.repo.CreateTenant(ctx, &tenant); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create tenant")
@@ -233,6 +237,10 @@ func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "code and name are required")
 		return
 	}
+	if tenant.QueueWeight < 0 || tenant.MaxConcurrentRuns < 0 {
+		writeError(w, http.StatusBadRequest, "queue_weight and max_concurrent_runs must be >= 0")
+		return
+	}
 
 	existing, err := h.repo.GetTenant(ctx, id)
 	if err != nil {
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// --- 工作流静态校验 ---
+
+// validateWorkflowRequest 校验请求体
+type validateWorkflowRequest struct {
+	DSL json.RawMessage `json:"dsl"`
+}
+
+// ValidateWorkflow 静态校验 DSL（不保存、不执行），始终返回完整的问题列表
+func (h *WorkflowHandler) ValidateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req validateWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if len(req.DSL) == 0 {
+		writeError(w, http.StatusBadRequest, "dsl is required")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.runner.Validate(req.DSL))
+}
+
+// rejectInvalidDSL 保存前校验 DSL，存在 error 级别问题时返回 422 与校验报告
+func (h *WorkflowHandler) rejectInvalidDSL(w http.ResponseWriter, dsl json.RawMessage) bool {
+	report := h.runner.Validate(dsl)
+	if report.Valid {
+		return false
+	}
+	writeErrorData(w, http.StatusUnprocessableEntity, "workflow validation failed", report)
+	return true
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockValidateRepo 记录保存的工作流
+type mockValidateRepo struct {
+	port.Repository
+	created []*port.Workflow
+}
+
+func (m *mockValidateRepo) CreateWorkflow(ctx context.Context, wf *port.Workflow) error {
+	wf.ID = "wf_new"
+	m.created = append(m.created, wf)
+	return nil
+}
+
+const brokenEdgeDSL = `{
+	"nodes": [
+		{"id": "start_1", "data": {"type": "start", "title": "Start"}},
+		{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": []}}
+	],
+	"edges": [
+		{"source": "start_1", "target": "end_1"},
+		{"source": "end_1", "target": "ghost_1"}
+	]
+}`
+
+type validateResponse struct {
+	Data struct {
+		Valid  bool `json:"valid"`
+		Issues []struct {
+			Severity string `json:"severity"`
+			Code     string `json:"code"`
+			NodeID   string `json:"node_id"`
+		} `json:"issues"`
+	} `json:"data"`
+}
+
+func TestValidateWorkflowEndpoint(t *testing.T) {
+	h := NewWorkflowHandler(&mockValidateRepo{}, nil, 0, RunInputConfig{})
+
+	body, _ := json.Marshal(map[string]interface{}{"dsl": json.RawMessage(brokenEdgeDSL)})
+	rr := postDebug(h, "/api/v1/workflows/validate", string(body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp validateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Valid || len(resp.Data.Issues) != 1 {
+		t.Fatalf("expected one issue, got %s", rr.Body.String())
+	}
+	if issue := resp.Data.Issues[0]; issue.Code != "unknown_edge_node" || issue.NodeID != "end_1" {
+		t.Fatalf("unexpected issue: %+v", issue)
+	}
+}
+
+func TestCreateWorkflowRejectsInvalidDSL(t *testing.T) {
+	repo := &mockValidateRepo{}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "broken", "dsl": json.RawMessage(brokenEdgeDSL)})
+	rr := postDebug(h, "/api/v1/workflows/", string(body))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status=422, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp validateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Valid || len(resp.Data.Issues) == 0 {
+		t.Fatalf("expected validation report in response, got %s", rr.Body.String())
+	}
+	if len(repo.created) != 0 {
+		t.Fatalf("expected invalid workflow not to be saved")
+	}
+
+	body, _ = json.Marshal(map[string]interface{}{"name": "ok", "dsl": json.RawMessage(debugTemplateDSL)})
+	rr = postDebug(h, "/api/v1/workflows/", string(body))
+	if rr.Code != http.StatusCreated || len(repo.created) != 1 {
+		t.Fatalf("expected valid workflow to be created, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
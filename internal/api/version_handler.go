@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 工作流版本历史与发布（不可变快照 / 草稿发布 / 指定版本运行 / 回滚） ---
+
+// ListWorkflowVersions 列出工作流的版本历史（按版本号倒序，不含 DSL）
+func (h *WorkflowHandler) ListWorkflowVersions(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+	versions, err := h.repo.ListWorkflowVersions(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list workflow versions")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"workflow_id":       wf.ID,
+		"draft_version":     wf.Version,
+		"published_version": wf.PublishedVersion,
+		"versions":          versions,
+	})
+}
+
+// GetWorkflowVersion 获取指定版本的 DSL 快照
+func (h *WorkflowHandler) GetWorkflowVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		writeError(w, http.StatusBadRequest, "version must be a positive integer")
+		return
+	}
+	v, err := h.repo.GetWorkflowVersion(ctx, id, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow version")
+		return
+	}
+	if v == nil {
+		writeError(w, http.StatusNotFound, "workflow version not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// PublishWorkflow 发布当前草稿：校验草稿 DSL 后将草稿版本设为线上版本
+func (h *WorkflowHandler) PublishWorkflow(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+	if h.rejectInvalidDSL(w, wf.DSL) {
+		return
+	}
+	h.publishVersion(ctx, w, id, wf.Version)
+}
+
+type rollbackWorkflowRequest struct {
+	Version int `json:"version"`
+}
+
+// RollbackWorkflow 将线上版本切换为指定历史版本；草稿与版本历史不变
+func (h *WorkflowHandler) RollbackWorkflow(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req rollbackWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.Version <= 0 {
+		writeError(w, http.StatusBadRequest, "version must be a positive integer")
+		return
+	}
+	h.publishVersion(ctx, w, id, req.Version)
+}
+
+func (h *WorkflowHandler) publishVersion(ctx context.Context, w http.ResponseWriter, id string, version int) {
+	ok, err := h.repo.PublishWorkflowVersion(ctx, id, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to publish workflow")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "workflow version not found")
+		return
+	}
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil || wf == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return
+	}
+	writeJSON(w, http.StatusOK, wf)
+}
+
+// loadRunWorkflow 加载待执行的工作流：默认执行已发布版本，?draft=true 执行草稿，
+// ?version=N 执行指定历史版本。返回的 Workflow.DSL / Version 即本次运行执行并记录的版本。
+func (h *WorkflowHandler) loadRunWorkflow(ctx context.Context, w http.ResponseWriter, r *http.Request, id string) (*port.Workflow, bool) {
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return nil, false
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return nil, false
+	}
+
+	query := r.URL.Query()
+	var version int
+	if raw := strings.TrimSpace(query.Get("version")); raw != "" {
+		version, err = strconv.Atoi(raw)
+		if err != nil || version <= 0 {
+			writeError(w, http.StatusBadRequest, "version must be a positive integer")
+			return nil, false
+		}
+	} else if draft, _ := strconv.ParseBool(query.Get("draft")); draft {
+		version = wf.Version
+	} else {
+		version = wf.PublishedVersion
+		if version <= 0 {
+			writeErrorCode(w, http.StatusConflict, "workflow_not_published", "workflow has no published version; publish it or run the draft with ?draft=true")
+			return nil, false
+		}
+	}
+
+	if version == wf.Version {
+		return wf, true
+	}
+	v, err := h.repo.GetWorkflowVersion(ctx, id, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow version")
+		return nil, false
+	}
+	if v == nil {
+		writeError(w, http.StatusNotFound, "workflow version not found")
+		return nil, false
+	}
+	wf.DSL = v.DSL
+	wf.Version = v.Version
+	return wf, true
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockVersionRepo 带版本历史的内存仓储
+type mockVersionRepo struct {
+	port.Repository
+
+	mu       sync.Mutex
+	wf       *port.Workflow
+	versions map[int]json.RawMessage
+	runs     []*port.WorkflowRun
+}
+
+func newMockVersionRepo() *mockVersionRepo {
+	return &mockVersionRepo{
+		wf: &port.Workflow{ID: "wf_1", Version: 2, PublishedVersion: 2, DSL: versionedDSL("v2")},
+		versions: map[int]json.RawMessage{
+			1: versionedDSL("v1"),
+			2: versionedDSL("v2"),
+		},
+	}
+}
+
+func (m *mockVersionRepo) GetWorkflow(ctx context.Context, id string) (*port.Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *m.wf
+	return &cp, nil
+}
+
+func (m *mockVersionRepo) GetWorkflowVersion(ctx context.Context, workflowID string, version int) (*port.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dsl, ok := m.versions[version]
+	if !ok {
+		return nil, nil
+	}
+	return &port.WorkflowVersion{WorkflowID: workflowID, Version: version, DSL: dsl}, nil
+}
+
+func (m *mockVersionRepo) ListWorkflowVersions(ctx context.Context, workflowID string) ([]*port.WorkflowVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*port.WorkflowVersion
+	for v := range m.versions {
+		list = append(list, &port.WorkflowVersion{WorkflowID: workflowID, Version: v})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version > list[j].Version })
+	return list, nil
+}
+
+func (m *mockVersionRepo) PublishWorkflowVersion(ctx context.Context, workflowID string, version int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.versions[version]; !ok {
+		return false, nil
+	}
+	m.wf.PublishedVersion = version
+	m.wf.Status = port.WorkflowStatusPublished
+	return true, nil
+}
+
+// saveDraft 模拟保存草稿：新增版本但不影响已发布版本
+func (m *mockVersionRepo) saveDraft(label string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.wf.Version++
+	m.wf.DSL = versionedDSL(label)
+	m.versions[m.wf.Version] = m.wf.DSL
+}
+
+func (m *mockVersionRepo) CreateRun(ctx context.Context, run *port.WorkflowRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = fmt.Sprintf("run_%d", len(m.runs)+1)
+	cp := *run
+	m.runs = append(m.runs, &cp)
+	return nil
+}
+
+func (m *mockVersionRepo) UpdateRun(ctx context.Context, run *port.WorkflowRun) error {
+	return nil
+}
+
+func (m *mockVersionRepo) BatchCreateNodeExecs(ctx context.Context, records []*port.NodeExecutionRecord) error {
+	return nil
+}
+
+// versionedDSL 输出固定标签的最小工作流，用于区分执行的版本
+func versionedDSL(label string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"nodes": [
+			{"id": "start_1", "data": {"type": "start", "title": "Start"}},
+			{"id": "tpl_1", "data": {"type": "template-transform", "title": "Label", "template": %q, "variables": []}},
+			{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [{"variable": "label", "value_selector": ["tpl_1", "output"]}]}}
+		],
+		"edges": [
+			{"source": "start_1", "target": "tpl_1"},
+			{"source": "tpl_1", "target": "end_1"}
+		]
+	}`, label))
+}
+
+func TestRunWorkflowPinsVersion(t *testing.T) {
+	repo := newMockVersionRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	// 指定历史版本同步执行
+	rr := postDebug(h, "/api/v1/workflows/wf_1/run?version=1", `{"inputs": {}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Outputs map[string]interface{} `json:"outputs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Outputs["label"] != "v1" {
+		t.Fatalf("expected version 1 DSL to run, got %v", resp.Data.Outputs)
+	}
+
+	// 异步入队记录当前版本
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	if len(repo.runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(repo.runs))
+	}
+	if repo.runs[0].WorkflowVersion != 1 || repo.runs[1].WorkflowVersion != 2 {
+		t.Fatalf("expected runs pinned to versions 1 and 2, got %d and %d", repo.runs[0].WorkflowVersion, repo.runs[1].WorkflowVersion)
+	}
+
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run?version=9", `{"inputs": {}}`)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404 for unknown version, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRollbackWorkflow(t *testing.T) {
+	repo := newMockVersionRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/rollback", `{"version": 1}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if repo.wf.PublishedVersion != 1 || repo.wf.Version != 2 {
+		t.Fatalf("expected published version 1 and untouched draft after rollback, got published=%d draft=%d", repo.wf.PublishedVersion, repo.wf.Version)
+	}
+
+	rr = postDebug(h, "/api/v1/workflows/wf_1/rollback", `{"version": 7}`)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status=404 for unknown version, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/workflows/wf_1/versions", nil)
+	rec := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	var list struct {
+		Data struct {
+			PublishedVersion int                     `json:"published_version"`
+			Versions         []*port.WorkflowVersion `json:"versions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if list.Data.PublishedVersion != 1 || len(list.Data.Versions) != 2 || list.Data.Versions[0].Version != 2 {
+		t.Fatalf("unexpected version list: %s", rec.Body.String())
+	}
+}
+
+// runLabel 执行工作流并返回输出中的版本标签
+func runLabel(t *testing.T, h *WorkflowHandler, path string) (int, string) {
+	t.Helper()
+	rr := postDebug(h, path, `{"inputs": {}}`)
+	var resp struct {
+		Data struct {
+			Outputs map[string]interface{} `json:"outputs"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	label, _ := resp.Data.Outputs["label"].(string)
+	return rr.Code, label
+}
+
+func TestDraftAndPublish(t *testing.T) {
+	repo := newMockVersionRepo()
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	// 保存草稿不影响线上运行
+	repo.saveDraft("v3-draft")
+	if code, label := runLabel(t, h, "/api/v1/workflows/wf_1/run"); code != http.StatusOK || label != "v2" {
+		t.Fatalf("expected published v2 to run by default, got %d %q", code, label)
+	}
+	if code, label := runLabel(t, h, "/api/v1/workflows/wf_1/run?draft=true"); code != http.StatusOK || label != "v3-draft" {
+		t.Fatalf("expected draft to run with draft=true, got %d %q", code, label)
+	}
+
+	// 发布草稿后默认运行新版本
+	rr := postDebug(h, "/api/v1/workflows/wf_1/publish", ``)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if repo.wf.PublishedVersion != 3 || repo.wf.Status != port.WorkflowStatusPublished {
+		t.Fatalf("expected draft version 3 published, got %d (%s)", repo.wf.PublishedVersion, repo.wf.Status)
+	}
+	if code, label := runLabel(t, h, "/api/v1/workflows/wf_1/run"); code != http.StatusOK || label != "v3-draft" {
+		t.Fatalf("expected published v3 to run, got %d %q", code, label)
+	}
+	if repo.runs[len(repo.runs)-1].WorkflowVersion != 3 {
+		t.Fatalf("expected run to record version 3, got %d", repo.runs[len(repo.runs)-1].WorkflowVersion)
+	}
+}
+
+func TestRunUnpublishedWorkflow(t *testing.T) {
+	repo := newMockVersionRepo()
+	repo.wf.PublishedVersion = 0
+	repo.wf.Status = port.WorkflowStatusDraft
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/run/async", `{"inputs": {}}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "workflow_not_published") {
+		t.Fatalf("expected 409 workflow_not_published, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no run for an unpublished workflow")
+	}
+	rr = postDebug(h, "/api/v1/workflows/wf_1/run/async?draft=true", `{"inputs": {}}`)
+	if rr.Code != http.StatusAccepted || repo.runs[0].WorkflowVersion != 2 {
+		t.Fatalf("expected draft run to be queued at draft version, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- 入站 Webhook 触发（管理接口 + 公开触发入口） ---
+
+// webhookMaxBodyBytes 入站 Webhook 请求体上限
+const webhookMaxBodyBytes = 1 << 20
+
+type webhookRequest struct {
+	Name         *string            `json:"name,omitempty"`
+	InputMapping *map[string]string `json:"input_mapping,omitempty"`
+	Mode         *string            `json:"mode,omitempty"`
+	Enabled      *bool              `json:"enabled,omitempty"`
+}
+
+// webhookView Webhook 详情：附加触发地址
+type webhookView struct {
+	*port.WorkflowWebhook
+	URL string `json:"url"`
+}
+
+// newWebhookView showSecret=false 时隐藏密钥（仅创建与轮换时返回）
+func newWebhookView(hook *port.WorkflowWebhook, showSecret bool) webhookView {
+	cp := *hook
+	if !showSecret {
+		cp.Secret = ""
+	}
+	return webhookView{WorkflowWebhook: &cp, URL: "/api/v1/hooks/" + hook.ID}
+}
+
+// CreateWebhook 为工作流创建入站 Webhook，响应中返回签名密钥
+func (h *WorkflowHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	wf, err := h.repo.GetWorkflow(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get workflow")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	secret, err := workflow.GenerateWebhookSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate webhook secret")
+		return
+	}
+
+	hook := &port.WorkflowWebhook{
+		WorkflowID: wf.ID,
+		OrgID:      wf.OrgID,
+		TenantID:   wf.TenantID,
+		Secret:     secret,
+		Mode:       port.WebhookModeAsync,
+		Enabled:    true,
+	}
+	if scope != nil {
+		hook.OrgID = scope.OrgID
+		hook.TenantID = scope.TenantID
+	}
+	if !applyWebhookRequest(w, hook, &req) {
+		return
+	}
+	if err := h.repo.CreateWebhook(ctx, hook); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+	writeJSON(w, http.StatusCreated, newWebhookView(hook, true))
+}
+
+// ListWebhooks 列出工作流的入站 Webhook（不含密钥）
+func (h *WorkflowHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	hooks, err := h.repo.ListWebhooks(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+	views := make([]webhookView, 0, len(hooks))
+	for _, hook := range hooks {
+		views = append(views, newWebhookView(hook, false))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// GetWebhook 获取入站 Webhook（不含密钥）
+func (h *WorkflowHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	hook, ok := h.loadWebhook(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newWebhookView(hook, false))
+}
+
+// UpdateWebhook 更新名称、输入映射、默认执行方式或启用状态
+func (h *WorkflowHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	hook, ok := h.loadWebhook(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if !applyWebhookRequest(w, hook, &req) {
+		return
+	}
+	if err := h.repo.UpdateWebhook(ctx, hook); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update webhook")
+		return
+	}
+	writeJSON(w, http.StatusOK, newWebhookView(hook, false))
+}
+
+// RotateWebhookSecret 生成新密钥，旧密钥立即失效
+func (h *WorkflowHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+
+	hook, ok := h.loadWebhook(ctx, w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	secret, err := workflow.GenerateWebhookSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate webhook secret")
+		return
+	}
+	hook.Secret = secret
+	if err := h.repo.UpdateWebhook(ctx, hook); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update webhook")
+		return
+	}
+	writeJSON(w, http.StatusOK, newWebhookView(hook, true))
+}
+
+// DeleteWebhook 删除入站 Webhook
+func (h *WorkflowHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := h.injectScope(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if _, ok := h.loadWebhook(ctx, w, id); !ok {
+		return
+	}
+	if err := h.repo.DeleteWebhook(ctx, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+func (h *WorkflowHandler) loadWebhook(ctx context.Context, w http.ResponseWriter, id string) (*port.WorkflowWebhook, bool) {
+	hook, err := h.repo.GetWebhook(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get webhook")
+		return nil, false
+	}
+	if hook == nil {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return nil, false
+	}
+	return hook, true
+}
+
+// applyWebhookRequest 校验并合并请求字段；校验失败时写入 400 并返回 false
+func applyWebhookRequest(w http.ResponseWriter, hook *port.WorkflowWebhook, req *webhookRequest) bool {
+	if req.Name != nil {
+		hook.Name = *req.Name
+	}
+	if req.InputMapping != nil {
+		for name, path := range *req.InputMapping {
+			if strings.TrimSpace(name) == "" {
+				writeError(w, http.StatusBadRequest, "input_mapping keys must be non-empty")
+				return false
+			}
+			if err := workflow.ValidateJSONPath(path); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return false
+			}
+		}
+		hook.InputMapping = *req.InputMapping
+	}
+	if req.Mode != nil {
+		mode, ok := parseWebhookMode(*req.Mode)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "mode must be one of: async, sync")
+			return false
+		}
+		hook.Mode = mode
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	return true
+}
+
+func parseWebhookMode(raw string) (port.WebhookMode, bool) {
+	switch mode := port.WebhookMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case port.WebhookModeAsync, port.WebhookModeSync:
+		return mode, true
+	}
+	return "", false
+}
+
+// TriggerWebhook 公开入口：校验签名与时间戳后，将请求体映射为输入并执行工作流的已发布版本。
+// 执行方式默认取 Webhook 配置，调用方可用 ?mode=sync|async 覆盖；响应与 run / run/async 一致。
+func (h *WorkflowHandler) TriggerWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.repo.GetWebhook(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get webhook")
+		return
+	}
+	if hook == nil || !hook.Enabled {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "webhook body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "failed to read webhook body")
+		return
+	}
+	if err := workflow.VerifyWebhookSignature(
+		hook.Secret,
+		r.Header.Get(workflow.WebhookTimestampHeader),
+		r.Header.Get(workflow.WebhookSignatureHeader),
+		body, time.Now(), workflow.DefaultWebhookTolerance,
+	); err != nil {
+		writeErrorCode(w, http.StatusUnauthorized, "invalid_signature", err.Error())
+		return
+	}
+
+	var payload interface{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid webhook JSON body")
+			return
+		}
+	} else {
+		payload = map[string]interface{}{}
+	}
+	inputs, err := workflow.MapWebhookInputs(hook.InputMapping, payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mode := hook.Mode
+	if raw := r.URL.Query().Get("mode"); raw != "" {
+		var ok bool
+		if mode, ok = parseWebhookMode(raw); !ok {
+			writeError(w, http.StatusBadRequest, "mode must be one of: async, sync")
+			return
+		}
+	}
+
+	runReq := webhookRunRequest(r, hook, inputs)
+	if mode == port.WebhookModeSync {
+		h.RunWorkflow(w, runReq)
+		return
+	}
+	h.RunWorkflowAsync(w, runReq)
+}
+
+// webhookRunRequest 以 Webhook 所属租户构造运行请求，复用 run / run/async 的执行路径。
+// 查询参数被清空，外部调用方不能通过 ?draft / ?version 执行未发布版本。
+func webhookRunRequest(r *http.Request, hook *port.WorkflowWebhook, inputs map[string]interface{}) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", hook.WorkflowID)
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+	if hook.OrgID != "" || hook.TenantID != "" {
+		ctx = WithScope(ctx, &Scope{OrgID: hook.OrgID, TenantID: hook.TenantID, Subject: "webhook:" + hook.ID})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"inputs": inputs})
+	runReq := r.Clone(ctx)
+	runReq.URL.RawQuery = ""
+	runReq.Body = io.NopCloser(bytes.NewReader(body))
+	runReq.ContentLength = int64(len(body))
+	runReq.Header.Set("Content-Type", "application/json")
+	return runReq
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// mockWebhookRepo 在版本仓储基础上保存 Webhook
+type mockWebhookRepo struct {
+	*mockVersionRepo
+	hooks map[string]*port.WorkflowWebhook
+}
+
+func (m *mockWebhookRepo) CreateWebhook(ctx context.Context, hook *port.WorkflowWebhook) error {
+	hook.ID = "hook_1"
+	cp := *hook
+	m.hooks[hook.ID] = &cp
+	return nil
+}
+
+func (m *mockWebhookRepo) GetWebhook(ctx context.Context, id string) (*port.WorkflowWebhook, error) {
+	hook, ok := m.hooks[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *hook
+	return &cp, nil
+}
+
+func (m *mockWebhookRepo) UpdateWebhook(ctx context.Context, hook *port.WorkflowWebhook) error {
+	cp := *hook
+	m.hooks[hook.ID] = &cp
+	return nil
+}
+
+// webhookRouter 受保护路由 + 公开触发入口
+func webhookRouter(h *WorkflowHandler) http.Handler {
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	r.Post("/api/v1/hooks/{id}", h.TriggerWebhook)
+	return r
+}
+
+func triggerWebhook(h *WorkflowHandler, path, secret, body string, signedAt time.Time) *httptest.ResponseRecorder {
+	ts := strconv.FormatInt(signedAt.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(workflow.WebhookTimestampHeader, ts)
+	req.Header.Set(workflow.WebhookSignatureHeader, workflow.SignWebhookPayload(secret, ts, []byte(body)))
+	rr := httptest.NewRecorder()
+	webhookRouter(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestWebhookTrigger(t *testing.T) {
+	repo := &mockWebhookRepo{mockVersionRepo: newMockVersionRepo(), hooks: map[string]*port.WorkflowWebhook{}}
+	repo.saveDraft("v3-draft")
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/webhooks", `{"name": "crm", "input_mapping": {"ticket_id": "$.ticket.id", "tag": "$.ticket.tags[0]"}}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status=201, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID     string `json:"id"`
+			Secret string `json:"secret"`
+			URL    string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if created.Data.Secret == "" || created.Data.URL != "/api/v1/hooks/hook_1" {
+		t.Fatalf("expected secret and trigger url on create, got %s", rr.Body.String())
+	}
+	secret := created.Data.Secret
+
+	// 异步入队：映射输入，始终执行已发布版本（忽略 ?draft）
+	payload := `{"ticket": {"id": 42, "tags": ["vip", "billing"]}}`
+	rr = triggerWebhook(h, "/api/v1/hooks/hook_1?draft=true", secret, payload, time.Now())
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status=202, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	run := repo.runs[0]
+	if run.WorkflowVersion != 2 || run.Status != port.RunStatusQueued {
+		t.Fatalf("expected queued run of published version 2, got version=%d status=%s", run.WorkflowVersion, run.Status)
+	}
+	var inputs map[string]interface{}
+	_ = json.Unmarshal(run.Inputs, &inputs)
+	if inputs["ticket_id"] != float64(42) || inputs["tag"] != "vip" {
+		t.Fatalf("unexpected mapped inputs: %s", run.Inputs)
+	}
+
+	// 调用方选择同步执行
+	rr = triggerWebhook(h, "/api/v1/hooks/hook_1?mode=sync", secret, payload, time.Now())
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"label":"v2"`) {
+		t.Fatalf("expected sync run output, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	// 签名错误、时间戳超出窗口均拒绝
+	if rr = triggerWebhook(h, "/api/v1/hooks/hook_1", "whsec_wrong", payload, time.Now()); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad signature, got=%d", rr.Code)
+	}
+	if rr = triggerWebhook(h, "/api/v1/hooks/hook_1", secret, payload, time.Now().Add(-10*time.Minute)); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for replayed request, got=%d", rr.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/hook_1", strings.NewReader(payload))
+	rec := httptest.NewRecorder()
+	webhookRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unsigned request, got=%d", rec.Code)
+	}
+
+	// 轮换密钥后旧密钥失效
+	rr = postDebug(h, "/api/v1/webhooks/hook_1/rotate-secret", ``)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = triggerWebhook(h, "/api/v1/hooks/hook_1", secret, payload, time.Now()); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected old secret to be rejected after rotation, got=%d", rr.Code)
+	}
+	if len(repo.runs) != 2 {
+		t.Fatalf("expected only accepted requests to create runs, got %d", len(repo.runs))
+	}
+}
+
+func TestWebhookManagement(t *testing.T) {
+	repo := &mockWebhookRepo{mockVersionRepo: newMockVersionRepo(), hooks: map[string]*port.WorkflowWebhook{}}
+	h := NewWorkflowHandler(repo, nil, 0, RunInputConfig{})
+
+	for body, want := range map[string]string{
+		`{"input_mapping": {"q": "ticket.id"}}`: "must start with $",
+		`{"input_mapping": {"q": "$.a[x]"}}`:    "unsupported selector",
+		`{"mode": "later"}`:                     "mode must be one of",
+	} {
+		rr := postDebug(h, "/api/v1/workflows/wf_1/webhooks", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), want) {
+			t.Fatalf("expected 400 containing %q for %s, got=%d, body=%s", want, body, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := postDebug(h, "/api/v1/workflows/wf_1/webhooks", `{"mode": "sync"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status=201, got=%d, body=%s", rr.Code, rr.Body.String())
+	}
+	secret := repo.hooks["hook_1"].Secret
+
+	// 查询不返回密钥
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/hook_1", nil)
+	rec := httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), secret) {
+		t.Fatalf("expected webhook without secret, got=%d, body=%s", rec.Code, rec.Body.String())
+	}
+
+	// 停用后触发返回 404
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/webhooks/hook_1", strings.NewReader(`{"enabled": false}`))
+	rec = httptest.NewRecorder()
+	hRouter(h).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status=200, got=%d, body=%s", rec.Code, rec.Body.String())
+	}
+	if rr = triggerWebhook(h, "/api/v1/hooks/hook_1", secret, `{}`, time.Now()); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for disabled webhook, got=%d", rr.Code)
+	}
+}
+
+func TestEvalJSONPath(t *testing.T) {
+	var doc interface{}
+	_ = json.Unmarshal([]byte(`{"a": {"b": [1, {"c": "x"}], "d.e": true}}`), &doc)
+
+	cases := []struct {
+		path  string
+		want  interface{}
+		found bool
+	}{
+		{"$.a.b[1].c", "x", true},
+		{"$['a'][\"d.e\"]", true, true},
+		{"$.a.b[-2]", float64(1), true},
+		{"$.a.missing", nil, false},
+		{"$.a.b[5]", nil, false},
+	}
+	for _, tc := range cases {
+		got, found, err := workflow.EvalJSONPath(tc.path, doc)
+		if err != nil || found != tc.found || got != tc.want {
+			t.Fatalf("%s: expected (%v, %v), got (%v, %v, %v)", tc.path, tc.want, tc.found, got, found, err)
+		}
+	}
+	if _, _, err := workflow.EvalJSONPath("$.a..b", doc); err == nil {
+		t.Fatalf("expected empty field name to fail")
+	}
+}
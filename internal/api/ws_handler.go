@@ -0,0 +1,426 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	applog "flowweave/internal/platform/log"
+)
+
+// --- WebSocket 交互式运行（一条连接上启动、控制多个运行并响应人工输入） ---
+
+const (
+	wsWriteWait       = 10 * time.Second
+	wsPongWait        = 60 * time.Second
+	wsPingPeriod      = wsPongWait * 9 / 10
+	wsMaxMessageBytes = 4 << 20
+	// wsMaxActiveRuns 单个连接上同时推送事件的运行数上限
+	wsMaxActiveRuns = 16
+)
+
+// 客户端消息类型
+const (
+	wsTypeStart  = "start"
+	wsTypeAbort  = "abort"
+	wsTypePause  = "pause"
+	wsTypeResume = "resume"
+	wsTypeInput  = "input"
+	wsTypePing   = "ping"
+)
+
+// 服务端消息类型
+const (
+	wsTypeStarted = "started"
+	wsTypeEvent   = "event"
+	wsTypeDone    = "done"
+	wsTypeAck     = "ack"
+	wsTypeError   = "error"
+	wsTypePong    = "pong"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// 鉴权依赖升级请求携带的 JWT 而非 Cookie，跨站页面无法冒用身份，与 CORS 策略一致不限制来源
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsClientMessage 客户端消息
+type wsClientMessage struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id,omitempty"` // 客户端自定义，原样带回对应的响应消息
+
+	// start
+	WorkflowID     string                 `json:"workflow_id,omitempty"`
+	Version        int                    `json:"version,omitempty"`
+	Draft          bool                   `json:"draft,omitempty"`
+	Inputs         map[string]interface{} `json:"inputs,omitempty"`
+	ConversationID string                 `json:"conversation_id,omitempty"`
+	CallbackURL    string                 `json:"callback_url,omitempty"`
+	CallbackSecret string                 `json:"callback_secret,omitempty"`
+
+	// abort / pause / resume / input
+	RunID string `json:"run_id,omitempty"`
+
+	// input
+	NodeID  string                 `json:"node_id,omitempty"`
+	Action  string                 `json:"action,omitempty"`
+	Values  map[string]interface{} `json:"values,omitempty"`
+	LastSeq int64                  `json:"last_seq,omitempty"` // 恢复后从该序号之后推送事件，默认取本连接已推送的最后序号
+}
+
+// wsServerMessage 服务端消息
+type wsServerMessage struct {
+	Type      string      `json:"type"`
+	RequestID string      `json:"request_id,omitempty"`
+	RunID     string      `json:"run_id,omitempty"`
+	Seq       int64       `json:"seq,omitempty"`    // 运行事件日志序号，可用于 /runs/{id}/events 续传
+	Status    int         `json:"status,omitempty"` // ack / error 对应的 HTTP 状态码
+	Code      string      `json:"code,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// RunSocket 交互式运行的 WebSocket 入口：start 启动流式运行并推送事件，
+// abort / pause / resume 控制运行，input 提交人工输入并在运行恢复后继续推送事件。
+// 连接断开不影响已启动的运行
+func (h *WorkflowHandler) RunSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 失败时已写出错误响应
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s := &runSocket{
+		h:       h,
+		conn:    conn,
+		r:       r,
+		ctx:     ctx,
+		active:  make(map[string]bool),
+		lastSeq: make(map[string]int64),
+	}
+	go s.keepAlive()
+	s.readLoop()
+}
+
+// runSocket 单个 WebSocket 连接的状态
+type runSocket struct {
+	h    *WorkflowHandler
+	conn *websocket.Conn
+	r    *http.Request // 升级请求，派生各条消息的内部请求（携带鉴权 scope）
+	ctx  context.Context
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	active  map[string]bool  // 正在推送事件的运行
+	lastSeq map[string]int64 // 各运行已推送的最后事件序号
+}
+
+func (s *runSocket) readLoop() {
+	s.conn.SetReadLimit(wsMaxMessageBytes)
+	_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				applog.Debug("[Workflow/Socket] Connection closed", "error", err)
+			}
+			return
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var msg wsClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.sendError(&msg, "", http.StatusBadRequest, "invalid_message", "invalid JSON message")
+			continue
+		}
+		switch msg.Type {
+		case wsTypeStart:
+			s.start(&msg)
+		case wsTypeAbort:
+			s.control(&msg, s.h.AbortRun)
+		case wsTypePause:
+			s.control(&msg, s.h.PauseRun)
+		case wsTypeResume:
+			s.control(&msg, s.h.ResumeRun)
+		case wsTypeInput:
+			s.submitInput(&msg)
+		case wsTypePing:
+			s.send(wsServerMessage{Type: wsTypePong, RequestID: msg.RequestID})
+		default:
+			s.sendError(&msg, "", http.StatusBadRequest, "invalid_message", "unknown message type: "+msg.Type)
+		}
+	}
+}
+
+func (s *runSocket) keepAlive() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// start 创建流式运行并在后台推送事件，与 POST /workflows/{id}/run/stream 的校验与落库一致
+func (s *runSocket) start(msg *wsClientMessage) {
+	if msg.WorkflowID == "" {
+		s.sendError(msg, "", http.StatusBadRequest, "invalid_message", "workflow_id is required")
+		return
+	}
+	if s.activeRuns() >= wsMaxActiveRuns {
+		s.sendError(msg, "", http.StatusTooManyRequests, "too_many_runs", "Too many active runs on this connection")
+		return
+	}
+
+	query := url.Values{}
+	if msg.Version > 0 {
+		query.Set("version", strconv.Itoa(msg.Version))
+	} else if msg.Draft {
+		query.Set("draft", "true")
+	}
+	req := s.innerRequest(msg.WorkflowID, query, &runWorkflowRequest{
+		Inputs:         msg.Inputs,
+		ConversationID: msg.ConversationID,
+		CallbackURL:    msg.CallbackURL,
+		CallbackSecret: msg.CallbackSecret,
+	})
+	res := newSocketResponse()
+	ctx, scope := s.h.injectScope(req.Context())
+	sr, ok := s.h.createStreamRun(ctx, scope, res, req)
+	if !ok {
+		s.relayError(msg, "", res)
+		return
+	}
+
+	runID := sr.run.ID
+	s.track(runID)
+	s.send(wsServerMessage{
+		Type:      wsTypeStarted,
+		RequestID: msg.RequestID,
+		RunID:     runID,
+		Data: map[string]interface{}{
+			"workflow_id":      sr.run.WorkflowID,
+			"workflow_version": sr.run.WorkflowVersion,
+		},
+	})
+	go s.h.executeStreamRun(ctx, sr, s.emitter(msg.RequestID, runID))
+}
+
+// control 转发 abort / pause / resume，状态校验与跨实例送达同 /runs/{id}/abort|pause|resume
+func (s *runSocket) control(msg *wsClientMessage, handle http.HandlerFunc) {
+	if msg.RunID == "" {
+		s.sendError(msg, "", http.StatusBadRequest, "invalid_message", "run_id is required")
+		return
+	}
+	res := newSocketResponse()
+	handle(res, s.innerRequest(msg.RunID, nil, nil))
+	s.relay(msg, msg.RunID, res)
+}
+
+// submitInput 提交人工输入；运行恢复且启用了事件日志时，继续在本连接推送恢复后的事件
+func (s *runSocket) submitInput(msg *wsClientMessage) {
+	if msg.RunID == "" {
+		s.sendError(msg, "", http.StatusBadRequest, "invalid_message", "run_id is required")
+		return
+	}
+	res := newSocketResponse()
+	s.h.SubmitPendingInput(res, s.innerRequest(msg.RunID, nil, &submitPendingInputRequest{
+		NodeID: msg.NodeID,
+		Action: msg.Action,
+		Values: msg.Values,
+	}))
+	data, ok := s.relay(msg, msg.RunID, res)
+	if !ok {
+		return
+	}
+
+	var result struct {
+		Resumed bool `json:"resumed"`
+	}
+	_ = json.Unmarshal(data, &result)
+	eventLog := s.h.runner.RunEventLog()
+	afterSeq := msg.LastSeq
+	if afterSeq <= 0 {
+		afterSeq = s.seq(msg.RunID)
+	}
+	// 未知续传位置时从头回放会立即停在上一次挂起，交由客户端经 /runs/{id}/events 观察
+	if !result.Resumed || eventLog == nil || afterSeq <= 0 || s.activeRuns() >= wsMaxActiveRuns {
+		return
+	}
+	s.track(msg.RunID)
+	go s.h.followRunEvents(s.ctx, eventLog, msg.RunID, afterSeq, s.emitter(msg.RequestID, msg.RunID), nil)
+}
+
+// emitter 将运行事件转为 event / done / error 消息
+func (s *runSocket) emitter(requestID, runID string) streamEmitter {
+	return func(eventType string, seq int64, data interface{}) {
+		switch eventType {
+		case "message":
+			s.recordSeq(runID, seq)
+			s.send(wsServerMessage{Type: wsTypeEvent, RequestID: requestID, RunID: runID, Seq: seq, Data: data})
+		case "done":
+			s.untrack(runID)
+			s.send(wsServerMessage{Type: wsTypeDone, RequestID: requestID, RunID: runID, Seq: seq, Data: data})
+		default:
+			s.untrack(runID)
+			message := "run failed"
+			if m, ok := data.(map[string]string); ok && m["error"] != "" {
+				message = m["error"]
+			}
+			s.send(wsServerMessage{Type: wsTypeError, RequestID: requestID, RunID: runID, Code: "run_error", Message: message})
+		}
+	}
+}
+
+func (s *runSocket) send(msg wsServerMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		applog.Debug("[Workflow/Socket] Failed to write message", "type", msg.Type, "run_id", msg.RunID, "error", err)
+	}
+}
+
+func (s *runSocket) sendError(msg *wsClientMessage, runID string, status int, code, message string) {
+	s.send(wsServerMessage{
+		Type:      wsTypeError,
+		RequestID: msg.RequestID,
+		RunID:     runID,
+		Status:    status,
+		Code:      code,
+		Message:   message,
+	})
+}
+
+// relay 将内部请求的响应转为 ack 或 error 消息，成功时返回响应 data
+func (s *runSocket) relay(msg *wsClientMessage, runID string, res *socketResponse) (json.RawMessage, bool) {
+	if res.status >= http.StatusBadRequest {
+		s.relayError(msg, runID, res)
+		return nil, false
+	}
+	body := res.decode()
+	s.send(wsServerMessage{Type: wsTypeAck, RequestID: msg.RequestID, RunID: runID, Status: res.status, Data: body.Data})
+	return body.Data, true
+}
+
+func (s *runSocket) relayError(msg *wsClientMessage, runID string, res *socketResponse) {
+	body := res.decode()
+	s.sendError(msg, runID, res.status, body.Error, body.Message)
+}
+
+// innerRequest 以升级请求的鉴权 scope 构造内部请求，复用 HTTP 接口的校验与执行路径
+func (s *runSocket) innerRequest(id string, query url.Values, body interface{}) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(s.ctx, chi.RouteCtxKey, rctx)
+
+	payload := []byte("{}")
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := s.r.Clone(ctx)
+	req.Method = http.MethodPost
+	req.Header = http.Header{"Content-Type": []string{"application/json"}}
+	req.URL.RawQuery = query.Encode()
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.ContentLength = int64(len(payload))
+	return req
+}
+
+func (s *runSocket) track(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[runID] = true
+}
+
+func (s *runSocket) untrack(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, runID)
+}
+
+func (s *runSocket) activeRuns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.active)
+}
+
+func (s *runSocket) recordSeq(runID string, seq int64) {
+	if seq <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSeq[runID] = seq
+}
+
+func (s *runSocket) seq(runID string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeq[runID]
+}
+
+// socketResponse 收集内部请求的响应
+type socketResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newSocketResponse() *socketResponse {
+	return &socketResponse{header: make(http.Header)}
+}
+
+func (r *socketResponse) Header() http.Header { return r.header }
+
+func (r *socketResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *socketResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// socketResponseBody 统一响应（writeJSON / writeError / writeErrorCode）中转发的字段
+type socketResponseBody struct {
+	Error   string          `json:"error"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (r *socketResponse) decode() socketResponseBody {
+	var body socketResponseBody
+	_ = json.Unmarshal(r.body.Bytes(), &body)
+	return body
+}
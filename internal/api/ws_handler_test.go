@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+type mockSocketRepo struct {
+	*mockVersionRepo
+}
+
+func (m *mockSocketRepo) GetRun(ctx context.Context, id string) (*port.WorkflowRun, error) {
+	return nil, nil
+}
+
+func (m *mockSocketRepo) GetOrganization(ctx context.Context, id string) (*port.Organization, error) {
+	return &port.Organization{ID: id, Status: "active"}, nil
+}
+
+func (m *mockSocketRepo) GetTenant(ctx context.Context, id string) (*port.Tenant, error) {
+	return &port.Tenant{ID: id, OrgID: "org_1", Status: "active"}, nil
+}
+
+func dialRunSocket(t *testing.T, handler http.Handler, rawQuery string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/ws"
+	if rawQuery != "" {
+		url += "?" + rawQuery
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial: %v (status=%d)", err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readSocketMessage(t *testing.T, conn *websocket.Conn) wsServerMessage {
+	t.Helper()
+	var msg wsServerMessage
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	return msg
+}
+
+func TestRunSocketConcurrentRuns(t *testing.T) {
+	h := NewWorkflowHandler(&mockSocketRepo{newMockVersionRepo()}, nil, 0, RunInputConfig{})
+	conn := dialRunSocket(t, hRouter(h), "")
+
+	for _, requestID := range []string{"r1", "r2"} {
+		if err := conn.WriteJSON(map[string]interface{}{"type": "start", "request_id": requestID, "workflow_id": "wf_1"}); err != nil {
+			t.Fatalf("write start: %v", err)
+		}
+	}
+
+	started := map[string]string{}
+	done := map[string]interface{}{}
+	outputs := map[string]interface{}{}
+	for len(done) < 2 {
+		msg := readSocketMessage(t, conn)
+		switch msg.Type {
+		case wsTypeStarted:
+			started[msg.RequestID] = msg.RunID
+		case wsTypeEvent:
+			if started[msg.RequestID] != msg.RunID {
+				t.Fatalf("event before started or for another run: %+v", msg)
+			}
+			data := msg.Data.(map[string]interface{})
+			if data["type"] == "graph_run_succeeded" {
+				outputs[msg.RunID] = data["outputs"].(map[string]interface{})["label"]
+			}
+		case wsTypeDone:
+			done[msg.RunID] = msg.Data.(map[string]interface{})["status"]
+		default:
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	}
+
+	if started["r1"] == "" || started["r2"] == "" || started["r1"] == started["r2"] {
+		t.Fatalf("expected two distinct runs, got %v", started)
+	}
+	for _, runID := range started {
+		if done[runID] != string(port.RunStatusSucceeded) || outputs[runID] != "v2" {
+			t.Fatalf("run %s: done=%v outputs=%v", runID, done[runID], outputs[runID])
+		}
+	}
+}
+
+func TestRunSocketErrors(t *testing.T) {
+	h := NewWorkflowHandler(&mockSocketRepo{newMockVersionRepo()}, nil, 0, RunInputConfig{})
+	conn := dialRunSocket(t, hRouter(h), "")
+
+	tests := []struct {
+		name    string
+		message map[string]interface{}
+		status  int
+		code    string
+	}{
+		{"unknown type", map[string]interface{}{"type": "subscribe"}, http.StatusBadRequest, "invalid_message"},
+		{"missing workflow", map[string]interface{}{"type": "start"}, http.StatusBadRequest, "invalid_message"},
+		{"unknown version", map[string]interface{}{"type": "start", "workflow_id": "wf_1", "version": 9}, http.StatusNotFound, ""},
+		{"unknown run", map[string]interface{}{"type": "abort", "run_id": "run_x"}, http.StatusNotFound, ""},
+		{"input for unknown run", map[string]interface{}{"type": "input", "run_id": "run_x", "node_id": "approve"}, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message["request_id"] = tt.name
+			if err := conn.WriteJSON(tt.message); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			msg := readSocketMessage(t, conn)
+			if msg.Type != wsTypeError || msg.RequestID != tt.name || msg.Status != tt.status || msg.Code != tt.code {
+				t.Fatalf("unexpected reply: %+v", msg)
+			}
+		})
+	}
+
+	if err := conn.WriteJSON(map[string]interface{}{"type": "ping", "request_id": "p"}); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	if msg := readSocketMessage(t, conn); msg.Type != wsTypePong || msg.RequestID != "p" {
+		t.Fatalf("expected pong, got %+v", msg)
+	}
+}
+
+func TestRunSocketJWTOnUpgrade(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.JWTSecret = "test-secret"
+	handler := NewServer(cfg, &mockSocketRepo{newMockVersionRepo()}, nil).Handler()
+
+	// 普通请求不接受查询参数中的 token
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/workflows?access_token=abc", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "Missing Authorization header") {
+		t.Fatalf("expected access_token to be ignored outside upgrades, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/ws"
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?access_token=invalid", nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for invalid token, err=%v", err)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"org_id":    "org_1",
+		"tenant_id": "tenant_1",
+		"sub":       "user_1",
+		"exp":       time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	conn := dialRunSocket(t, handler, "access_token="+token)
+	if err := conn.WriteJSON(map[string]interface{}{"type": "ping"}); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	if msg := readSocketMessage(t, conn); msg.Type != wsTypePong {
+		t.Fatalf("expected pong, got %+v", msg)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	_ "flowweave/internal/domain/workflow/node/code"
 	_ "flowweave/internal/domain/workflow/node/end"
 	_ "flowweave/internal/domain/workflow/node/httprequest"
+	_ "flowweave/internal/domain/workflow/node/humaninput"
 	_ "flowweave/internal/domain/workflow/node/ifelse"
 	_ "flowweave/internal/domain/workflow/node/iteration"
 	_ "flowweave/internal/domain/workflow/node/llm"
 	_ "flowweave/internal/domain/workflow/node/loop"
 	_ "flowweave/internal/domain/workflow/node/start"
+	_ "flowweave/internal/domain/workflow/node/subworkflow"
 	_ "flowweave/internal/domain/workflow/node/template"
+	_ "flowweave/internal/domain/workflow/node/variableaggregator"
 )
@@ -5,8 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
 	"flowweave/internal/domain/workflow/port"
 	applog "flowweave/internal/platform/log"
 )
@@ -16,13 +22,43 @@ type AsyncRunManagerConfig struct {
 	Workers      int
 	PollInterval time.Duration
 	RunTimeout   time.Duration
+	// TenantMaxConcurrency caps concurrently running async runs per tenant
+	// unless the tenant overrides it; 0 means unlimited.
+	TenantMaxConcurrency int
+	// LeaseDuration is how long a claimed run stays owned by its worker
+	// without a heartbeat. Heartbeats renew it every LeaseDuration/3.
+	LeaseDuration time.Duration
+	// WorkerLostRetries caps how many times a run whose lease expired is
+	// requeued before it is failed with a worker_lost error. Workflows with
+	// async_retry use its max_retries instead.
+	WorkerLostRetries int
+	// InstanceID prefixes worker IDs so leases stay unique across replicas.
+	// Defaults to hostname-pid.
+	InstanceID string
 }
 
+// runErrorWorkerLost is the error class recorded when a worker stops
+// renewing the lease of a run it claimed.
+const runErrorWorkerLost = "worker_lost"
+
+// expiredLeaseBatch limits how many expired leases one reaper pass recovers.
+const expiredLeaseBatch = 100
+
+// pendingInputSweepInterval controls how often expired human inputs are timed
+// out and their paused runs requeued.
+const pendingInputSweepInterval = 5 * time.Second
+
+// idempotencyKeyPurgeInterval controls how often expired Idempotency-Key
+// records are deleted.
+const idempotencyKeyPurgeInterval = 10 * time.Minute
+
 // AsyncRunManager pulls queued runs from DB and executes them in background.
 type AsyncRunManager struct {
 	repo   port.Repository
 	runner *WorkflowRunner
 	cfg    AsyncRunManagerConfig
+
+	workers sync.WaitGroup
 }
 
 func NewAsyncRunManager(repo port.Repository, runner *WorkflowRunner, cfg AsyncRunManagerConfig) *AsyncRunManager {
@@ -35,6 +71,16 @@ func NewAsyncRunManager(repo port.Repository, runner *WorkflowRunner, cfg AsyncR
 	if cfg.RunTimeout <= 0 {
 		cfg.RunTimeout = 5 * time.Minute
 	}
+	if cfg.LeaseDuration <= 0 {
+		cfg.LeaseDuration = 30 * time.Second
+	}
+	if cfg.WorkerLostRetries < 0 {
+		cfg.WorkerLostRetries = 0
+	}
+	if cfg.InstanceID == "" {
+		host, _ := os.Hostname()
+		cfg.InstanceID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
 	return &AsyncRunManager{
 		repo:   repo,
 		runner: runner,
@@ -42,12 +88,43 @@ func NewAsyncRunManager(repo port.Repository, runner *WorkflowRunner, cfg AsyncR
 	}
 }
 
+// Start launches the workers. Cancelling ctx stops claiming new runs and
+// releases the leases of runs still executing; use Wait to block until then.
 func (m *AsyncRunManager) Start(ctx context.Context) {
 	for i := 0; i < m.cfg.Workers; i++ {
-		workerID := fmt.Sprintf("async-worker-%d", i+1)
-		go m.workerLoop(ctx, workerID)
+		workerID := fmt.Sprintf("%s/async-worker-%d", m.cfg.InstanceID, i+1)
+		m.workers.Add(1)
+		go func() {
+			defer m.workers.Done()
+			m.workerLoop(ctx, workerID)
+		}()
+	}
+	go m.reapLoop(ctx)
+	go m.pendingInputLoop(ctx)
+	go m.idempotencyKeyPurgeLoop(ctx)
+	applog.Info("[AsyncRun] Manager started",
+		"instance_id", m.cfg.InstanceID,
+		"workers", m.cfg.Workers,
+		"poll_interval_ms", m.cfg.PollInterval.Milliseconds(),
+		"lease_ms", m.cfg.LeaseDuration.Milliseconds(),
+		"tenant_max_concurrency", m.cfg.TenantMaxConcurrency,
+	)
+}
+
+// Wait blocks until all workers have exited after the Start context was
+// cancelled, or until ctx expires.
+func (m *AsyncRunManager) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	applog.Info("[AsyncRun] Manager started", "workers", m.cfg.Workers, "poll_interval_ms", m.cfg.PollInterval.Milliseconds())
 }
 
 func (m *AsyncRunManager) workerLoop(ctx context.Context, workerID string) {
@@ -56,7 +133,10 @@ func (m *AsyncRunManager) workerLoop(ctx context.Context, workerID string) {
 			return
 		}
 
-		run, err := m.repo.ClaimNextQueuedRun(ctx, workerID)
+		run, err := m.repo.ClaimNextQueuedRun(ctx, workerID, port.ClaimOptions{
+			TenantMaxConcurrency: m.cfg.TenantMaxConcurrency,
+			LeaseDuration:        m.cfg.LeaseDuration,
+		})
 		if err != nil {
 			if ctx.Err() != nil {
 				return
@@ -78,6 +158,175 @@ func (m *AsyncRunManager) workerLoop(ctx context.Context, workerID string) {
 	}
 }
 
+// reapLoop periodically recovers runs whose worker stopped renewing its lease,
+// on any replica.
+func (m *AsyncRunManager) reapLoop(ctx context.Context) {
+	for {
+		runs, err := m.repo.ListExpiredRunLeases(ctx, expiredLeaseBatch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			applog.Error("[AsyncRun] Failed to list expired run leases", "error", err)
+		}
+		for _, run := range runs {
+			m.recoverLostRun(ctx, run)
+		}
+		if !sleepWithContext(ctx, m.cfg.LeaseDuration/2) {
+			return
+		}
+	}
+}
+
+// recoverLostRun requeues a run whose lease expired so it resumes from its
+// last checkpoint, or fails it with a worker_lost error once the retry limit
+// is reached (dead-letter when the workflow has an async_retry policy). Both transitions only apply while the lease is still expired,
+// so a worker that renews late keeps its run.
+func (m *AsyncRunManager) recoverLostRun(ctx context.Context, run *port.WorkflowRun) {
+	repoCtx := context.Background()
+	if run.OrgID != "" || run.TenantID != "" {
+		repoCtx = port.WithRepoScope(repoCtx, run.OrgID, run.TenantID)
+	}
+
+	limit := m.cfg.WorkerLostRetries
+	exhausted := port.RunStatusFailed
+	if dsl, err := m.loadRunDSL(repoCtx, run); err == nil {
+		if policy := asyncRetryPolicy(dsl); policy != nil {
+			limit = policy.MaxRetries
+			exhausted = port.RunStatusDeadLetter
+		}
+	}
+
+	errMsg := fmt.Sprintf("%s: lease of worker %s expired", runErrorWorkerLost, run.WorkerID)
+	attempt := port.RunAttempt{
+		Attempt:    len(run.Attempts) + 1,
+		WorkerID:   run.WorkerID,
+		Error:      errMsg,
+		ErrorClass: runErrorWorkerLost,
+		StartedAt:  run.StartedAt,
+	}
+	if run.LeaseExpiresAt != nil {
+		attempt.ElapsedMs = run.LeaseExpiresAt.Sub(run.StartedAt).Milliseconds()
+	}
+
+	requeue := run.RetryCount < limit
+	var ok bool
+	var err error
+	if requeue {
+		ok, err = m.repo.RequeueExpiredRun(ctx, run.ID, run.WorkerID)
+	} else {
+		ok, err = m.repo.FailExpiredRun(ctx, run.ID, run.WorkerID, exhausted, errMsg)
+	}
+	if err != nil {
+		applog.Error("[AsyncRun] Failed to recover run with expired lease", "run_id", run.ID, "worker_id", run.WorkerID, "error", err)
+		return
+	}
+	if !ok {
+		return // renewed, finished or recovered by another replica meanwhile
+	}
+
+	if err := m.repo.AppendRunAttempt(repoCtx, run.ID, attempt); err != nil {
+		applog.Error("[AsyncRun] Failed to record run attempt", "run_id", run.ID, "attempt", attempt.Attempt, "error", err)
+	}
+	if requeue {
+		applog.Warn("[AsyncRun] Worker lost, run requeued",
+			"run_id", run.ID,
+			"worker_id", run.WorkerID,
+			"retry_count", run.RetryCount+1,
+		)
+		return
+	}
+	if err := m.repo.DeleteRunCheckpoint(repoCtx, run.ID); err != nil {
+		applog.Warn("[AsyncRun] Failed to delete run checkpoint", "run_id", run.ID, "error", err)
+	}
+	applog.Warn("[AsyncRun] Worker lost and retries exhausted",
+		"run_id", run.ID,
+		"worker_id", run.WorkerID,
+		"retry_count", run.RetryCount,
+		"status", exhausted,
+	)
+	failed := *run
+	now := time.Now()
+	failed.Status = exhausted
+	failed.Error = errMsg
+	failed.Outputs = nil
+	failed.FinishedAt = &now
+	m.runner.NotifyRunFinished(repoCtx, &failed)
+}
+
+// heartbeat renews the run's lease until stop is called. When the lease is
+// lost (the run was recovered or requeued elsewhere) it calls onLost once.
+func (m *AsyncRunManager) heartbeat(ctx context.Context, run *port.WorkflowRun, workerID string, onLost func()) (stop func()) {
+	hbCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for sleepWithContext(hbCtx, m.cfg.LeaseDuration/3) {
+			renewed, err := m.repo.RenewRunLease(hbCtx, run.ID, workerID, m.cfg.LeaseDuration)
+			if err != nil {
+				if hbCtx.Err() == nil {
+					applog.Warn("[AsyncRun] Failed to renew run lease", "run_id", run.ID, "worker_id", workerID, "error", err)
+				}
+				continue
+			}
+			if !renewed {
+				onLost()
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// pendingInputLoop periodically times out expired human inputs and requeues
+// paused runs that no longer wait on any input.
+func (m *AsyncRunManager) pendingInputLoop(ctx context.Context) {
+	for {
+		runIDs, err := m.repo.TimeoutExpiredPendingInputs(ctx, time.Now())
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			applog.Error("[AsyncRun] Failed to time out pending inputs", "error", err)
+		}
+		for _, runID := range runIDs {
+			resumed, err := m.repo.ResumePausedRun(ctx, runID)
+			if err != nil {
+				applog.Error("[AsyncRun] Failed to requeue paused run", "run_id", runID, "error", err)
+				continue
+			}
+			if resumed {
+				applog.Info("[AsyncRun] Requeued paused run after input timeout", "run_id", runID)
+			}
+		}
+		if !sleepWithContext(ctx, pendingInputSweepInterval) {
+			return
+		}
+	}
+}
+
+// idempotencyKeyPurgeLoop deletes Idempotency-Key records past their TTL.
+func (m *AsyncRunManager) idempotencyKeyPurgeLoop(ctx context.Context) {
+	for {
+		purged, err := m.repo.PurgeExpiredIdempotencyKeys(ctx, time.Now())
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			applog.Error("[AsyncRun] Failed to purge idempotency keys", "error", err)
+		}
+		if purged > 0 {
+			applog.Info("[AsyncRun] Purged expired idempotency keys", "count", purged)
+		}
+		if !sleepWithContext(ctx, idempotencyKeyPurgeInterval) {
+			return
+		}
+	}
+}
+
 func (m *AsyncRunManager) executeRun(ctx context.Context, run *port.WorkflowRun, workerID string) {
 	if run == nil {
 		return
@@ -88,11 +337,8 @@ func (m *AsyncRunManager) executeRun(ctx context.Context, run *port.WorkflowRun,
 		repoCtx = port.WithRepoScope(repoCtx, run.OrgID, run.TenantID)
 	}
 
-	wf, err := m.repo.GetWorkflow(repoCtx, run.WorkflowID)
-	if err != nil || wf == nil {
-		if err == nil {
-			err = fmt.Errorf("workflow not found")
-		}
+	dsl, err := m.loadRunDSL(repoCtx, run)
+	if err != nil {
 		m.finalizeFailedRun(repoCtx, run, err, workerID, port.RunStatusFailed, nil)
 		return
 	}
@@ -105,31 +351,94 @@ func (m *AsyncRunManager) executeRun(ctx context.Context, run *port.WorkflowRun,
 		}
 	}
 
-	execCtx, cancel := context.WithTimeout(ctx, m.cfg.RunTimeout)
+	// RunTimeout is enforced by the runner so a manual pause stops the clock.
+	execCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// The heartbeat outlives ctx so the lease stays held until it is released below.
+	var leaseLost atomic.Bool
+	stopHeartbeat := m.heartbeat(context.Background(), run, workerID, func() {
+		leaseLost.Store(true)
+		cancel()
+	})
+	defer stopHeartbeat()
+
 	opts := &RunOptions{
 		ConversationID: run.ConversationID,
 		OrgID:          run.OrgID,
 		TenantID:       run.TenantID,
+		RunID:          run.ID,
+		Timeout:        m.cfg.RunTimeout,
+		Checkpointer: func(_ context.Context, cp *port.RunCheckpoint) error {
+			return m.repo.SaveRunCheckpoint(repoCtx, cp)
+		},
+	}
+	checkpoint, err := m.repo.GetRunCheckpoint(repoCtx, run.ID)
+	if err != nil {
+		applog.Warn("[AsyncRun] Failed to load run checkpoint, starting over", "run_id", run.ID, "error", err)
+	} else if checkpoint != nil {
+		applog.Info("[AsyncRun] Resuming run from checkpoint",
+			"run_id", run.ID,
+			"worker_id", workerID,
+			"sequence", checkpoint.Sequence,
+			"completed_nodes", len(checkpoint.CompletedNodes),
+		)
+		opts.ResumeFrom = checkpoint
+
+		humanInputs, err := LoadHumanInputs(repoCtx, m.repo, run.ID)
+		if err != nil {
+			applog.Warn("[AsyncRun] Failed to load human inputs", "run_id", run.ID, "error", err)
+		}
+		opts.HumanInputs = humanInputs
 	}
 	startTime := time.Now()
-	result, execErr := m.runner.RunSync(execCtx, wf.DSL, inputs, opts)
+	result, execErr := m.runner.RunSync(execCtx, dsl, inputs, opts)
 	elapsed := time.Since(startTime).Milliseconds()
+	stopHeartbeat()
+
+	if leaseLost.Load() {
+		applog.Warn("[AsyncRun] Run lease lost, discarding result", "run_id", run.ID, "worker_id", workerID)
+		return
+	}
+	if execErr != nil && ctx.Err() != nil {
+		// Shutting down: hand the run back so another replica resumes it from
+		// its last checkpoint right away instead of waiting for the lease.
+		released, err := m.repo.ReleaseRunLease(repoCtx, run.ID, workerID)
+		if err != nil {
+			applog.Error("[AsyncRun] Failed to release run lease", "run_id", run.ID, "worker_id", workerID, "error", err)
+		} else if released {
+			applog.Info("[AsyncRun] Run lease released on shutdown", "run_id", run.ID, "worker_id", workerID)
+		}
+		return
+	}
 
 	now := time.Now()
 	run.WorkerID = workerID
 	run.ElapsedMs = elapsed
 	run.FinishedAt = &now
 
+	if execErr == nil && result != nil && result.Paused {
+		if err := SuspendRun(repoCtx, m.repo, run, result); err != nil {
+			applog.Error("[AsyncRun] Failed to suspend run", "run_id", run.ID, "worker_id", workerID, "error", err)
+			return
+		}
+		applog.Info("[AsyncRun] Run paused for human input", "run_id", run.ID, "pending_inputs", len(result.PendingInputs))
+		return
+	}
+
 	status := port.RunStatusSucceeded
 	if execErr != nil {
 		status = port.RunStatusFailed
-		if errors.Is(execCtx.Err(), context.Canceled) {
+		if (result != nil && result.Aborted) || errors.Is(execCtx.Err(), context.Canceled) {
 			status = port.RunStatusAborted
 		}
+	} else if result != nil && result.PartialSucceeded {
+		status = port.RunStatusPartialSucceeded
 	}
 	run.Status = status
+	if result != nil {
+		run.ExceptionsCount = result.ExceptionsCount
+	}
 	if execErr != nil {
 		run.Error = execErr.Error()
 		run.Outputs = nil
@@ -143,8 +452,13 @@ func (m *AsyncRunManager) executeRun(ctx context.Context, run *port.WorkflowRun,
 	if result != nil && len(result.NodeExecutions) > 0 {
 		nodeExecs = result.NodeExecutions
 	}
+	if status == port.RunStatusFailed && m.handleFailedAttempt(repoCtx, run, asyncRetryPolicy(dsl), execErr, nodeExecs, startTime) {
+		return
+	}
 	if err := m.persistRunAndNodeExecs(repoCtx, run, nodeExecs); err != nil {
 		applog.Error("[AsyncRun] Failed to persist run result", "run_id", run.ID, "worker_id", workerID, "error", err)
+	} else if err := m.repo.DeleteRunCheckpoint(repoCtx, run.ID); err != nil {
+		applog.Warn("[AsyncRun] Failed to delete run checkpoint", "run_id", run.ID, "error", err)
 	}
 
 	if run.ConversationID != "" && len(nodeExecs) > 0 {
@@ -154,6 +468,29 @@ func (m *AsyncRunManager) executeRun(ctx context.Context, run *port.WorkflowRun,
 	}
 }
 
+// loadRunDSL loads the DSL pinned at enqueue time. Runs created before version
+// tracking (workflow_version = 0) fall back to the workflow's current DSL.
+func (m *AsyncRunManager) loadRunDSL(ctx context.Context, run *port.WorkflowRun) (json.RawMessage, error) {
+	if run.WorkflowVersion > 0 {
+		v, err := m.repo.GetWorkflowVersion(ctx, run.WorkflowID, run.WorkflowVersion)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("workflow version %d not found", run.WorkflowVersion)
+		}
+		return v.DSL, nil
+	}
+	wf, err := m.repo.GetWorkflow(ctx, run.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if wf == nil {
+		return nil, fmt.Errorf("workflow not found")
+	}
+	return wf.DSL, nil
+}
+
 func (m *AsyncRunManager) finalizeFailedRun(ctx context.Context, run *port.WorkflowRun, execErr error, workerID string, status port.RunStatus, nodeExecs []port.NodeExecution) {
 	now := time.Now()
 	run.WorkerID = workerID
@@ -162,11 +499,109 @@ func (m *AsyncRunManager) finalizeFailedRun(ctx context.Context, run *port.Workf
 	run.ElapsedMs = 0
 	run.FinishedAt = &now
 	run.Outputs = nil
+	if status == port.RunStatusFailed {
+		// The pinned DSL or inputs are unusable, so there is no retry policy to apply.
+		m.handleFailedAttempt(ctx, run, nil, execErr, nodeExecs, now)
+	}
 	if err := m.persistRunAndNodeExecs(ctx, run, nodeExecs); err != nil {
 		applog.Error("[AsyncRun] Failed to persist failed run", "run_id", run.ID, "error", err)
 	}
 }
 
+// handleFailedAttempt records a failed attempt and applies the workflow's
+// async_retry policy. It returns true when the run was requeued with backoff.
+// Otherwise the caller persists the final result: failed, or dead-letter once
+// the policy's retries are exhausted. Non-retryable error classes and child
+// runs (retried by their parent) are never requeued.
+func (m *AsyncRunManager) handleFailedAttempt(ctx context.Context, run *port.WorkflowRun, policy *types.RetryConfig, execErr error, nodeExecs []port.NodeExecution, startedAt time.Time) bool {
+	class := runErrorClass(execErr, nodeExecs)
+	attempt := port.RunAttempt{
+		Attempt:    len(run.Attempts) + 1,
+		WorkerID:   run.WorkerID,
+		Error:      run.Error,
+		ErrorClass: string(class),
+		StartedAt:  startedAt,
+		ElapsedMs:  run.ElapsedMs,
+	}
+
+	retry := false
+	if policy != nil && run.ParentRunID == "" && policy.ShouldRetry(class) {
+		if run.RetryCount < policy.MaxRetries {
+			retry = true
+		} else {
+			run.Status = port.RunStatusDeadLetter
+		}
+	}
+	var delay time.Duration
+	if retry {
+		delay = policy.Backoff(run.RetryCount+1, rand.Float64())
+		attempt.BackoffMs = delay.Milliseconds()
+	}
+
+	if err := m.repo.AppendRunAttempt(ctx, run.ID, attempt); err != nil {
+		applog.Error("[AsyncRun] Failed to record run attempt", "run_id", run.ID, "attempt", attempt.Attempt, "error", err)
+	}
+	run.Attempts = append(run.Attempts, attempt)
+	if !retry {
+		if run.Status == port.RunStatusDeadLetter {
+			applog.Warn("[AsyncRun] Run retries exhausted, moved to dead letter",
+				"run_id", run.ID,
+				"attempts", attempt.Attempt,
+				"error_class", class,
+			)
+		}
+		return false
+	}
+
+	requeued, err := m.repo.ScheduleRunRetry(ctx, run.ID, time.Now().Add(delay))
+	if err != nil {
+		applog.Error("[AsyncRun] Failed to schedule run retry", "run_id", run.ID, "error", err)
+		return false
+	}
+	if !requeued {
+		applog.Warn("[AsyncRun] Run left running state before retry, skipping", "run_id", run.ID)
+		return true
+	}
+	// Retries start over instead of resuming the failed attempt's checkpoint.
+	if err := m.repo.DeleteRunCheckpoint(ctx, run.ID); err != nil {
+		applog.Warn("[AsyncRun] Failed to delete run checkpoint", "run_id", run.ID, "error", err)
+	}
+	applog.Info("[AsyncRun] Run failed, retry scheduled",
+		"run_id", run.ID,
+		"attempt", attempt.Attempt,
+		"error_class", class,
+		"backoff_ms", attempt.BackoffMs,
+	)
+	return true
+}
+
+// asyncRetryPolicy returns the async_retry policy pinned in the run's DSL, or
+// nil when none is configured.
+func asyncRetryPolicy(dsl json.RawMessage) *types.RetryConfig {
+	var cfg types.GraphConfig
+	if err := json.Unmarshal(dsl, &cfg); err != nil || cfg.AsyncRetry == nil {
+		return nil
+	}
+	if err := cfg.AsyncRetry.Validate(); err != nil {
+		return nil
+	}
+	return cfg.AsyncRetry
+}
+
+// runErrorClass classifies a run failure by its last failed node, falling back
+// to the run-level error (e.g. run timeout).
+func runErrorClass(execErr error, nodeExecs []port.NodeExecution) types.ErrorClass {
+	for i := len(nodeExecs) - 1; i >= 0; i-- {
+		if nodeExecs[i].Status != "failed" {
+			continue
+		}
+		if class, ok := nodeExecs[i].Metadata["error_class"].(string); ok && class != "" {
+			return types.ErrorClass(class)
+		}
+	}
+	return node.ClassifyError(execErr)
+}
+
 func (m *AsyncRunManager) persistRunAndNodeExecs(ctx context.Context, run *port.WorkflowRun, nodeExecs []port.NodeExecution) error {
 	if run == nil {
 		return nil
@@ -189,7 +624,11 @@ func (m *AsyncRunManager) persistRunAndNodeExecs(ctx context.Context, run *port.
 			)
 		}
 	}
-	return m.repo.UpdateRun(ctx, run)
+	if err := m.repo.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+	m.runner.NotifyRunFinished(ctx, run)
+	return nil
 }
 
 func (m *AsyncRunManager) saveTraces(ctx context.Context, conversationID string, runID, orgID, tenantID string, nodeExecs []port.NodeExecution) error {
@@ -275,16 +714,17 @@ func toNodeExecRecords(runID string, execs []port.NodeExecution) []*port.NodeExe
 	records := make([]*port.NodeExecutionRecord, 0, len(execs))
 	for _, exec := range execs {
 		records = append(records, &port.NodeExecutionRecord{
-			RunID:     runID,
-			NodeID:    exec.NodeID,
-			NodeType:  exec.NodeType,
-			Title:     exec.Title,
-			Status:    exec.Status,
-			Outputs:   exec.Outputs,
-			Error:     exec.Error,
-			Metadata:  exec.Metadata,
-			ElapsedMs: exec.ElapsedMs,
-			StartedAt: exec.StartedAt,
+			RunID:      runID,
+			NodeID:     exec.NodeID,
+			NodeType:   exec.NodeType,
+			Title:      exec.Title,
+			Status:     exec.Status,
+			Outputs:    exec.Outputs,
+			Error:      exec.Error,
+			Metadata:   exec.Metadata,
+			ElapsedMs:  exec.ElapsedMs,
+			StartedAt:  exec.StartedAt,
+			NodeParent: exec.NodeParent,
 		})
 	}
 	return records
@@ -0,0 +1,190 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/runtime"
+)
+
+// ErrDebugNodeNotFound 调试的节点不在 DSL 中
+var ErrDebugNodeNotFound = errors.New("node not found in workflow DSL")
+
+// DebugNodeRequest 单节点调试请求
+type DebugNodeRequest struct {
+	NodeID    string                 // 待执行的节点 ID
+	Inputs    map[string]interface{} // 系统变量（工作流输入）
+	Variables map[string]interface{} // 变量池快照：node_id → {变量名 → 值}，格式同 VariablePool.Dump
+}
+
+// DebugNodeResult 单节点调试结果
+type DebugNodeResult struct {
+	NodeID     string                    `json:"node_id"`
+	NodeType   types.NodeType            `json:"node_type"`
+	Status     types.NodeExecutionStatus `json:"status"`
+	Outputs    map[string]interface{}    `json:"outputs,omitempty"`
+	Chunks     []string                  `json:"chunks,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+	ErrorClass types.ErrorClass          `json:"error_class,omitempty"`
+	ErrorType  string                    `json:"error_type,omitempty"`
+	Metadata   map[string]interface{}    `json:"metadata,omitempty"`
+	LLMTrace   interface{}               `json:"llm_trace,omitempty"`
+	ElapsedMs  int64                     `json:"elapsed_ms"`
+}
+
+// DebugNode 使用调用方提供的变量池快照，单独执行 DSL 中的一个节点
+// 节点通过 node.Factory 创建并走正常的 Run 路径，但不构建图、不经过引擎调度，
+// 因此不触发错误策略（重试 / 默认值 / 失败分支），也不产生运行记录
+func (r *WorkflowRunner) DebugNode(ctx context.Context, config *types.GraphConfig, req *DebugNodeRequest, opts *RunOptions) (*DebugNodeResult, error) {
+	if config.NodeDefaults != nil {
+		if err := config.NodeDefaults.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid node_defaults: %w", err)
+		}
+	}
+
+	// 1. 定位并创建节点
+	var nodeCfg *types.NodeConfig
+	for i := range config.Nodes {
+		if config.Nodes[i].ID == req.NodeID {
+			nodeCfg = &config.Nodes[i]
+			break
+		}
+	}
+	if nodeCfg == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDebugNodeNotFound, req.NodeID)
+	}
+	n, err := node.NewFactory().CreateNode(*nodeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node: %w", err)
+	}
+
+	// 2. 由快照构建变量池
+	vp := runtime.NewVariablePool()
+	vp.Load(req.Variables)
+	for k, v := range req.Inputs {
+		vp.SetSystem(k, v)
+	}
+	ctx = r.prepareContext(ctx, config, opts)
+	ctx = context.WithValue(ctx, node.ContextKeyVariablePool, vp)
+
+	// 3. 按节点限制执行（与引擎一致：节点配置优先，其次工作流默认值与引擎配置）
+	engineConfig := r.engineConfigFor(config)
+	timeout := engineConfig.NodeTimeout
+	if ms := n.Limits().TimeoutMs; ms > 0 {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	maxOutputBytes := engineConfig.MaxOutputBytes
+	if limit := n.Limits().MaxOutputBytes; limit > 0 {
+		maxOutputBytes = limit
+	}
+	nodeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := &DebugNodeResult{
+		NodeID:   n.ID(),
+		NodeType: n.Type(),
+		Status:   types.NodeExecutionStatusRunning,
+	}
+	startedAt := time.Now()
+	defer func() {
+		result.ElapsedMs = time.Since(startedAt).Milliseconds()
+	}()
+	fail := func(errMsg string, class types.ErrorClass, errType string) *DebugNodeResult {
+		if class == "" {
+			class = types.ErrorClassUnknown
+		}
+		result.Status = types.NodeExecutionStatusFailed
+		result.Error = errMsg
+		result.ErrorClass = class
+		result.ErrorType = errType
+		return result
+	}
+	timedOut := func() *DebugNodeResult {
+		return fail(fmt.Sprintf("%s: node %s exceeded timeout of %s", types.NodeErrorTypeTimeout, n.ID(), timeout),
+			types.ErrorClassTimeout, types.NodeErrorTypeTimeout)
+	}
+
+	eventCh, err := n.Run(nodeCtx)
+	if err != nil {
+		return fail(err.Error(), node.ClassifyError(err), ""), nil
+	}
+
+	// 4. 收集节点事件
+	for {
+		select {
+		case evt, ok := <-eventCh:
+			if !ok {
+				if result.Status == types.NodeExecutionStatusRunning {
+					// 节点未给出终态事件
+					result.Status = types.NodeExecutionStatusSucceeded
+				}
+				return result, nil
+			}
+			switch evt.Type {
+			case event.EventTypeNodeStreamChunk:
+				result.Chunks = append(result.Chunks, evt.Chunk)
+			case event.EventTypeNodeRunSucceeded:
+				if maxOutputBytes > 0 {
+					if size := debugOutputSize(evt.Outputs); size > maxOutputBytes {
+						fail(fmt.Sprintf("%s: node %s output is %d bytes, exceeds limit of %d bytes", types.NodeErrorTypeOutputTooLarge, n.ID(), size, maxOutputBytes),
+							types.ErrorClassSchemaViolation, types.NodeErrorTypeOutputTooLarge)
+						continue
+					}
+				}
+				result.Status = types.NodeExecutionStatusSucceeded
+				result.Outputs = evt.Outputs
+				result.setMetadata(evt.Metadata)
+			case event.EventTypeNodeRunFailed:
+				fail(evt.Error, evt.ErrorClass, "")
+				result.setMetadata(evt.Metadata)
+			case event.EventTypeNodeRunPaused:
+				// 人工输入节点：调试时仅返回挂起信息，不创建待输入记录
+				result.Status = types.NodeExecutionStatusPaused
+				result.setMetadata(evt.Metadata)
+			}
+		case <-nodeCtx.Done():
+			// 超时或请求取消：丢弃节点后续事件，不再等待
+			go func() {
+				for range eventCh {
+				}
+			}()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return timedOut(), nil
+		}
+	}
+}
+
+// setMetadata 记录节点元数据，并将 LLM 调用追踪提升为独立字段
+func (res *DebugNodeResult) setMetadata(metadata map[string]interface{}) {
+	if len(metadata) == 0 {
+		return
+	}
+	res.Metadata = make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if k == "llm_trace" {
+			res.LLMTrace = v
+			continue
+		}
+		res.Metadata[k] = v
+	}
+}
+
+// debugOutputSize 计算节点输出 JSON 序列化后的字节数
+func debugOutputSize(outputs map[string]interface{}) int {
+	if len(outputs) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
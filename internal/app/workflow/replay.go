@@ -0,0 +1,274 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// ReplayDivergenceKind 回放路径与原始运行的差异类型
+type ReplayDivergenceKind string
+
+const (
+	ReplayDivergenceMissingNode      ReplayDivergenceKind = "missing_node"      // 原始运行执行过，回放未执行
+	ReplayDivergenceExtraNode        ReplayDivergenceKind = "extra_node"        // 回放执行了原始运行未执行的节点
+	ReplayDivergenceStatusMismatch   ReplayDivergenceKind = "status_mismatch"   // 节点状态不同
+	ReplayDivergenceOutputMismatch   ReplayDivergenceKind = "output_mismatch"   // 节点输出不同
+	ReplayDivergenceMissingRecording ReplayDivergenceKind = "missing_recording" // 可回放节点没有录制响应
+)
+
+// ReplayDivergence 回放差异
+type ReplayDivergence struct {
+	Kind            ReplayDivergenceKind   `json:"kind"`
+	NodeID          string                 `json:"node_id"`
+	NodeType        string                 `json:"node_type,omitempty"`
+	Occurrence      int                    `json:"occurrence"` // 该节点的第几次执行（从 1 开始）
+	OriginalStatus  string                 `json:"original_status,omitempty"`
+	ReplayedStatus  string                 `json:"replayed_status,omitempty"`
+	OriginalOutputs map[string]interface{} `json:"original_outputs,omitempty"`
+	ReplayedOutputs map[string]interface{} `json:"replayed_outputs,omitempty"`
+	OriginalError   string                 `json:"original_error,omitempty"`
+	ReplayedError   string                 `json:"replayed_error,omitempty"`
+}
+
+// ReplayResult 回放结果
+type ReplayResult struct {
+	OriginalStatus port.RunStatus         `json:"original_status,omitempty"`
+	Status         port.RunStatus         `json:"status"`
+	Outputs        map[string]interface{} `json:"outputs,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	NodeExecutions []port.NodeExecution   `json:"node_executions,omitempty"`
+	ServedCalls    int                    `json:"served_calls"` // 以录制响应代替的外部调用次数
+	Diverged       bool                   `json:"diverged"`
+	Divergences    []ReplayDivergence     `json:"divergences,omitempty"`
+}
+
+// ReplayRecording 历史运行的录制响应，按节点 ID 与调用顺序提供给回放
+type ReplayRecording struct {
+	original []*port.NodeExecutionRecord
+
+	mu      sync.Mutex
+	calls   map[string][]*node.RecordedResponse
+	served  map[string]int
+	missing map[string]int
+	total   int
+}
+
+// NewReplayRecording 由原始运行的节点执行记录与 LLM 调用溯源构建录制
+// execs 需按开始时间排序；没有节点执行记录的 LLM 节点回退使用 traces 中的响应
+func NewReplayRecording(execs []*port.NodeExecutionRecord, traces []*port.LLMCallTraceRecord) *ReplayRecording {
+	rec := &ReplayRecording{
+		original: execs,
+		calls:    make(map[string][]*node.RecordedResponse),
+		served:   make(map[string]int),
+		missing:  make(map[string]int),
+	}
+	for _, exec := range execs {
+		if !node.IsReplayable(types.NodeType(exec.NodeType)) {
+			continue
+		}
+		resp := &node.RecordedResponse{
+			Status:   types.NodeExecutionStatus(exec.Status),
+			Outputs:  exec.Outputs,
+			Error:    exec.Error,
+			Metadata: replayMetadata(exec.Metadata),
+		}
+		if class, ok := exec.Metadata["error_class"].(string); ok {
+			resp.ErrorClass = types.ErrorClass(class)
+		}
+		rec.calls[exec.NodeID] = append(rec.calls[exec.NodeID], resp)
+	}
+
+	traced := make(map[string][]*node.RecordedResponse)
+	for _, trace := range traces {
+		if _, ok := rec.calls[trace.NodeID]; ok {
+			continue
+		}
+		resp := &node.RecordedResponse{
+			Status:   types.NodeExecutionStatusSucceeded,
+			Metadata: map[string]interface{}{"replayed": true},
+		}
+		if trace.Error != "" {
+			resp.Status = types.NodeExecutionStatusFailed
+			resp.Error = trace.Error
+		} else if trace.Response != nil {
+			resp.Outputs = map[string]interface{}{"text": trace.Response.Content}
+		}
+		traced[trace.NodeID] = append(traced[trace.NodeID], resp)
+	}
+	for nodeID, calls := range traced {
+		rec.calls[nodeID] = calls
+	}
+	return rec
+}
+
+// Next 实现 node.ReplaySource：按调用顺序返回录制响应
+// 录制用尽时重复最后一条（重试策略下同一节点会多次调用），路径差异由 compare 报告
+func (rec *ReplayRecording) Next(nodeID string) (*node.RecordedResponse, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	calls := rec.calls[nodeID]
+	if len(calls) == 0 {
+		rec.missing[nodeID]++
+		return nil, false
+	}
+	idx := rec.served[nodeID]
+	if idx >= len(calls) {
+		idx = len(calls) - 1
+	}
+	rec.served[nodeID]++
+	rec.total++
+	return calls[idx], true
+}
+
+// replayMetadata 复制录制的元数据（尝试记录由回放重新生成）
+func replayMetadata(metadata map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		if k == "attempts" || k == "error_class" {
+			continue
+		}
+		result[k] = v
+	}
+	result["replayed"] = true
+	return result
+}
+
+// Replay 以回放模式重新执行历史运行：LLM / HTTP / ASR 节点返回录制响应而不调用 provider，
+// 其余节点正常执行，最后报告回放路径与原始运行的差异。回放不写检查点，也不注册运行控制
+func (r *WorkflowRunner) Replay(ctx context.Context, dslJSON []byte, inputs map[string]interface{}, original *port.WorkflowRun, recording *ReplayRecording, opts *RunOptions) (*ReplayResult, error) {
+	replayOpts := &RunOptions{}
+	if opts != nil {
+		replayOpts.OrgID = opts.OrgID
+		replayOpts.TenantID = opts.TenantID
+		replayOpts.HumanInputs = opts.HumanInputs
+	}
+
+	ctx = node.WithReplaySource(ctx, recording)
+	runResult, err := r.RunSync(ctx, dslJSON, inputs, replayOpts)
+	if runResult == nil {
+		return nil, err
+	}
+
+	result := &ReplayResult{
+		Outputs:        runResult.Outputs,
+		NodeExecutions: runResult.NodeExecutions,
+	}
+	switch {
+	case runResult.Aborted:
+		result.Status = port.RunStatusAborted
+	case err != nil:
+		result.Status = port.RunStatusFailed
+		result.Error = err.Error()
+	case runResult.Paused:
+		result.Status = port.RunStatusPaused
+	case runResult.PartialSucceeded:
+		result.Status = port.RunStatusPartialSucceeded
+	default:
+		result.Status = port.RunStatusSucceeded
+	}
+	if original != nil {
+		result.OriginalStatus = original.Status
+	}
+
+	recording.mu.Lock()
+	result.ServedCalls = recording.total
+	recording.mu.Unlock()
+
+	result.Divergences = recording.compare(runResult.NodeExecutions)
+	result.Diverged = len(result.Divergences) > 0 ||
+		(result.OriginalStatus != "" && result.OriginalStatus != result.Status)
+	return result, nil
+}
+
+// compare 按节点 ID 与执行次序对比回放路径与原始路径
+func (rec *ReplayRecording) compare(replayed []port.NodeExecution) []ReplayDivergence {
+	byNode := make(map[string][]port.NodeExecution)
+	for _, exec := range replayed {
+		byNode[exec.NodeID] = append(byNode[exec.NodeID], exec)
+	}
+
+	var divergences []ReplayDivergence
+	matched := make(map[string]int)
+	for _, orig := range rec.original {
+		idx := matched[orig.NodeID]
+		matched[orig.NodeID]++
+		d := ReplayDivergence{
+			NodeID:          orig.NodeID,
+			NodeType:        orig.NodeType,
+			Occurrence:      idx + 1,
+			OriginalStatus:  orig.Status,
+			OriginalOutputs: orig.Outputs,
+			OriginalError:   orig.Error,
+		}
+		if idx >= len(byNode[orig.NodeID]) {
+			d.Kind = ReplayDivergenceMissingNode
+			divergences = append(divergences, d)
+			continue
+		}
+		got := byNode[orig.NodeID][idx]
+		d.ReplayedStatus = got.Status
+		d.ReplayedOutputs = got.Outputs
+		d.ReplayedError = got.Error
+		switch {
+		case got.Status != orig.Status:
+			d.Kind = ReplayDivergenceStatusMismatch
+		case !sameJSON(got.Outputs, orig.Outputs):
+			d.Kind = ReplayDivergenceOutputMismatch
+		default:
+			continue
+		}
+		divergences = append(divergences, d)
+	}
+
+	seen := make(map[string]int)
+	for _, exec := range replayed {
+		seen[exec.NodeID]++
+		if matched[exec.NodeID] > 0 {
+			matched[exec.NodeID]--
+			continue
+		}
+		kind := ReplayDivergenceExtraNode
+		rec.mu.Lock()
+		if rec.missing[exec.NodeID] > 0 {
+			kind = ReplayDivergenceMissingRecording
+		}
+		rec.mu.Unlock()
+		divergences = append(divergences, ReplayDivergence{
+			Kind:            kind,
+			NodeID:          exec.NodeID,
+			NodeType:        exec.NodeType,
+			Occurrence:      seen[exec.NodeID],
+			ReplayedStatus:  exec.Status,
+			ReplayedOutputs: exec.Outputs,
+			ReplayedError:   exec.Error,
+		})
+	}
+	return divergences
+}
+
+// sameJSON 以 JSON 形式比较两个值（录制值来自数据库反序列化，数值类型可能不同）
+func sameJSON(a, b map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+func normalizeJSON(v map[string]interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
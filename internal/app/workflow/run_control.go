@@ -0,0 +1,190 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// ErrRunNotControllable 运行不在本实例执行且未配置跨实例命令总线
+var ErrRunNotControllable = errors.New("run is not executing on this instance")
+
+// commandRelayRetryInterval 命令订阅断开后的重连间隔
+const commandRelayRetryInterval = 2 * time.Second
+
+// runControl 运行控制：本实例执行中的引擎注册表 + 跨实例命令总线
+type runControl struct {
+	mu   sync.RWMutex
+	runs map[string]*engine.GraphEngine
+	bus  port.RunCommandBus
+}
+
+func newRunControl() *runControl {
+	return &runControl{runs: make(map[string]*engine.GraphEngine)}
+}
+
+// track 注册执行中的引擎，事件流结束时自动注销
+func (c *runControl) track(runID string, eng *engine.GraphEngine, eventCh <-chan event.GraphEvent) <-chan event.GraphEvent {
+	c.mu.Lock()
+	c.runs[runID] = eng
+	c.mu.Unlock()
+
+	out := make(chan event.GraphEvent, cap(eventCh))
+	go func() {
+		defer close(out)
+		defer func() {
+			c.mu.Lock()
+			if c.runs[runID] == eng {
+				delete(c.runs, runID)
+			}
+			c.mu.Unlock()
+		}()
+		for evt := range eventCh {
+			out <- evt
+		}
+	}()
+	return out
+}
+
+// deliver 将命令投递给本实例的引擎，返回是否命中
+func (c *runControl) deliver(runID string, cmd types.Command) bool {
+	c.mu.RLock()
+	eng, ok := c.runs[runID]
+	c.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	eng.SendCommand(cmd)
+	return true
+}
+
+// send 本实例命中则直接投递，否则经命令总线转发给持有该运行的实例
+func (c *runControl) send(ctx context.Context, runID string, cmd types.Command) error {
+	if c.deliver(runID, cmd) {
+		return nil
+	}
+	c.mu.RLock()
+	bus := c.bus
+	c.mu.RUnlock()
+	if bus == nil {
+		return ErrRunNotControllable
+	}
+	return bus.Publish(ctx, &port.RunCommand{
+		RunID:  runID,
+		Type:   string(cmd.Type),
+		Reason: cmd.Reason,
+	})
+}
+
+// relay 订阅命令总线并投递给本实例的引擎，断开后自动重连
+func (c *runControl) relay(ctx context.Context, bus port.RunCommandBus) {
+	for {
+		err := bus.Subscribe(ctx, func(cmd *port.RunCommand) {
+			if c.deliver(cmd.RunID, types.Command{Type: types.CommandType(cmd.Type), Reason: cmd.Reason}) {
+				applog.Info("[RunControl] Applied relayed command", "run_id", cmd.RunID, "command", cmd.Type)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		applog.Warn("[RunControl] Command subscription lost, retrying", "error", err)
+		if !sleepWithContext(ctx, commandRelayRetryInterval) {
+			return
+		}
+	}
+}
+
+// StartCommandRelay 接入跨实例命令总线：本实例发出的命令可路由到其他实例，
+// 其他实例发出的命令可作用于本实例执行中的运行
+func (r *WorkflowRunner) StartCommandRelay(ctx context.Context, bus port.RunCommandBus) {
+	if bus == nil {
+		return
+	}
+	r.control.mu.Lock()
+	r.control.bus = bus
+	r.control.mu.Unlock()
+	go r.control.relay(ctx, bus)
+}
+
+// SendCommand 向运行发送控制命令（abort / pause / resume），无论其在哪个实例执行
+func (r *WorkflowRunner) SendCommand(ctx context.Context, runID string, cmd types.Command) error {
+	return r.control.send(ctx, runID, cmd)
+}
+
+// runDeadline 运行执行超时：手动暂停期间停止计时，恢复后按剩余时长继续。
+// 到达超时后 Err 返回 context.DeadlineExceeded，与 context.WithTimeout 一致
+type runDeadline struct {
+	context.Context
+	done chan struct{}
+
+	mu  sync.Mutex
+	err error
+
+	stopParent func() bool
+
+	// 以下字段仅由 watch 协程访问
+	timer     *time.Timer
+	remaining time.Duration
+	startedAt time.Time
+	paused    bool
+}
+
+// withRunDeadline 返回 timeout 后超时的 ctx，计时随 watch 观察到的暂停 / 恢复事件停止与继续
+func withRunDeadline(parent context.Context, timeout time.Duration) *runDeadline {
+	d := &runDeadline{Context: parent, done: make(chan struct{}), remaining: timeout, startedAt: time.Now()}
+	d.timer = time.AfterFunc(timeout, func() { d.finish(context.DeadlineExceeded) })
+	d.stopParent = context.AfterFunc(parent, func() { d.finish(parent.Err()) })
+	return d
+}
+
+func (d *runDeadline) Done() <-chan struct{} { return d.done }
+
+func (d *runDeadline) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *runDeadline) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
+		close(d.done)
+	}
+}
+
+// watch 转发引擎事件并据此暂停 / 恢复计时；事件流结束后释放 ctx
+func (d *runDeadline) watch(eventCh <-chan event.GraphEvent) <-chan event.GraphEvent {
+	out := make(chan event.GraphEvent, cap(eventCh))
+	go func() {
+		defer close(out)
+		defer d.finish(context.Canceled)
+		defer d.stopParent()
+		defer d.timer.Stop()
+		for evt := range eventCh {
+			switch evt.Type {
+			case event.EventTypeGraphRunPaused:
+				if !d.paused && d.timer.Stop() {
+					d.paused = true
+					d.remaining -= time.Since(d.startedAt)
+				}
+			case event.EventTypeGraphRunResumed:
+				if d.paused {
+					d.paused = false
+					d.startedAt = time.Now()
+					d.timer.Reset(d.remaining)
+				}
+			}
+			out <- evt
+		}
+	}()
+	return out
+}
@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// 运行事件日志中的 SSE 事件名
+const (
+	RunEventMessage = "message" // 引擎事件，data 与流式运行接口的 message 事件一致
+	RunEventDone    = "done"    // 运行进入终态后追加，data 为运行结果摘要
+)
+
+// runEventAppendTimeout 单条事件写入事件日志的超时，事件日志不可用时不阻塞执行
+const runEventAppendTimeout = 2 * time.Second
+
+// SetRunEventLog 设置运行事件日志（可选）：带 run_id 的执行事件按序写入，供断线续传与旁路观察
+func (r *WorkflowRunner) SetRunEventLog(log port.RunEventLog) {
+	r.eventLog = log
+}
+
+// RunEventLog 返回运行事件日志，未配置时为 nil
+func (r *WorkflowRunner) RunEventLog() port.RunEventLog {
+	return r.eventLog
+}
+
+// StreamEventPayload 引擎事件对外输出的内容（不含节点执行明细与检查点）
+func StreamEventPayload(evt event.GraphEvent) map[string]interface{} {
+	data := map[string]interface{}{
+		"type": evt.Type,
+	}
+	if evt.NodeID != "" {
+		data["node_id"] = evt.NodeID
+	}
+	// 迭代 / 循环子图内的节点事件附带层级信息
+	if evt.ParentNodeID != "" {
+		data["parent_node_id"] = evt.ParentNodeID
+		data["parent_execution_id"] = evt.ParentExecutionID
+		if evt.IterationIndex != nil {
+			data["iteration_index"] = *evt.IterationIndex
+		}
+		if evt.LoopRound != nil {
+			data["loop_round"] = *evt.LoopRound
+		}
+	}
+	if evt.Chunk != "" {
+		data["chunk"] = evt.Chunk
+	}
+	if evt.Outputs != nil {
+		data["outputs"] = evt.Outputs
+	}
+	if evt.Error != "" {
+		data["error"] = evt.Error
+	}
+	if len(evt.PendingInputs) > 0 {
+		data["pending_inputs"] = evt.PendingInputs
+	}
+	if evt.ExceptionsCount > 0 {
+		data["exceptions_count"] = evt.ExceptionsCount
+	}
+	return data
+}
+
+// RunDonePayload 运行结束时 done 事件的内容
+func RunDonePayload(run *port.WorkflowRun) map[string]interface{} {
+	data := map[string]interface{}{
+		"run_id":           run.ID,
+		"status":           run.Status,
+		"exceptions_count": run.ExceptionsCount,
+		"elapsed_ms":       run.ElapsedMs,
+	}
+	if len(run.Outputs) > 0 {
+		data["outputs"] = run.Outputs
+	}
+	if run.Error != "" {
+		data["error"] = run.Error
+	}
+	return data
+}
+
+// recordEvents 将事件依次写入事件日志并回填序号后转发。写入失败不影响执行：
+// 首次失败后本次执行的后续事件直接转发、不再写入，事件日志不可用时最多阻塞一次写入超时
+func (r *WorkflowRunner) recordEvents(runID string, eventCh <-chan event.GraphEvent) <-chan event.GraphEvent {
+	out := make(chan event.GraphEvent, cap(eventCh))
+	go func() {
+		defer close(out)
+		failed := false
+		for evt := range eventCh {
+			if !failed {
+				if seq, err := r.appendRunEvent(runID, RunEventMessage, StreamEventPayload(evt)); err != nil {
+					applog.Warn("[RunEvents] Failed to append run event, skipping the rest of this execution",
+						"run_id", runID, "type", evt.Type, "error", err)
+					failed = true
+				} else {
+					evt.Seq = seq
+				}
+			}
+			out <- evt
+		}
+	}()
+	return out
+}
+
+// recordRunFinished 运行进入终态后追加 done 事件，事件日志的观察方据此结束
+func (r *WorkflowRunner) recordRunFinished(run *port.WorkflowRun) {
+	if r.eventLog == nil || run == nil || !IsTerminalRunStatus(run.Status) {
+		return
+	}
+	if _, err := r.appendRunEvent(run.ID, RunEventDone, RunDonePayload(run)); err != nil {
+		applog.Warn("[RunEvents] Failed to append done event", "run_id", run.ID, "error", err)
+	}
+}
+
+func (r *WorkflowRunner) appendRunEvent(runID, name string, payload map[string]interface{}) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runEventAppendTimeout)
+	defer cancel()
+	return r.eventLog.Append(ctx, runID, name, data)
+}
@@ -0,0 +1,391 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+	"flowweave/internal/platform/netguard"
+)
+
+// 出站通知请求头。请求体与入站 Webhook 使用相同的签名方式（见 SignWebhookPayload），
+// 接收方可用 VerifyWebhookSignature 及同样的 X-Webhook-Timestamp / X-Webhook-Signature 校验
+const (
+	NotificationEventHeader    = "X-Webhook-Event"
+	NotificationDeliveryHeader = "X-Webhook-Delivery"
+
+	// RunFinishedEvent 运行进入终态后发送
+	RunFinishedEvent = "run.finished"
+)
+
+// notificationResponseLimit 释放连接前最多读取的接收方响应字节数
+const notificationResponseLimit = 64 << 10
+
+// RunNotifierConfig 运行完成通知投递配置
+type RunNotifierConfig struct {
+	PollInterval time.Duration
+	// Timeout 单次投递的超时
+	Timeout     time.Duration
+	MaxAttempts int
+	// BaseBackoff 每次失败后翻倍，上限为 MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+	// DefaultSecret 未携带 callback_secret 的运行，其 callback_url 投递使用该密钥签名
+	DefaultSecret string
+	// AllowPrivateNetworks 允许通知地址指向回环、内网与链路本地地址；
+	// 默认关闭，防止租户借服务端访问内部服务
+	AllowPrivateNetworks bool
+}
+
+// RunNotificationPayload POST 到 callback_url 与租户订阅地址的 JSON 请求体
+type RunNotificationPayload struct {
+	Event           string          `json:"event"`
+	RunID           string          `json:"run_id"`
+	WorkflowID      string          `json:"workflow_id"`
+	OrgID           string          `json:"org_id,omitempty"`
+	TenantID        string          `json:"tenant_id,omitempty"`
+	Status          port.RunStatus  `json:"status"`
+	Outputs         json.RawMessage `json:"outputs,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	ExceptionsCount int             `json:"exceptions_count"`
+	ElapsedMs       int64           `json:"elapsed_ms"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
+
+// RunNotifier 为运行结束时的每个通知目标记录一条投递并带重试地 POST。
+// 投递记录保存在数据库中，任一副本都可发送，重启后未完成的投递继续进行
+type RunNotifier struct {
+	repo   port.Repository
+	cfg    RunNotifierConfig
+	client *http.Client
+	wake   chan struct{}
+}
+
+// NewRunNotifier 创建运行完成通知投递器，未设置的配置项使用默认值
+func NewRunNotifier(repo port.Repository, cfg RunNotifierConfig) *RunNotifier {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 6
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		// 拨号时再次校验实际连接的地址，防止通过校验的目标经 DNS 重绑定指向内网
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: netguard.DialControl}
+		client.Transport = &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+	return &RunNotifier{
+		repo:   repo,
+		cfg:    cfg,
+		client: client,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// ValidateTarget 通知地址的主机解析到内网或本地地址时返回错误（AllowPrivateNetworks 时不校验）。
+// 暂时无法解析的主机放行，每次投递拨号时仍会校验
+func (n *RunNotifier) ValidateTarget(ctx context.Context, host string) error {
+	if n == nil || !n.cfg.AllowPrivateNetworks {
+		if err := netguard.ValidatePublicHostContext(ctx, host); errors.Is(err, netguard.ErrPrivateAddress) {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanSign 携带该密钥提交的 callback_url 能否签名（使用该密钥或默认密钥）
+func (n *RunNotifier) CanSign(callbackSecret string) bool {
+	return callbackSecret != "" || n.cfg.DefaultSecret != ""
+}
+
+// IsTerminalRunStatus 该状态的运行除非重新入队，否则不会再执行
+func IsTerminalRunStatus(status port.RunStatus) bool {
+	switch status {
+	case port.RunStatusSucceeded, port.RunStatusPartialSucceeded, port.RunStatusFailed,
+		port.RunStatusAborted, port.RunStatusCanceled, port.RunStatusDeadLetter:
+		return true
+	}
+	return false
+}
+
+// Start 启动后台投递轮询，ctx 结束时停止
+func (n *RunNotifier) Start(ctx context.Context) {
+	go n.loop(ctx)
+	applog.Info("[RunNotifier] Started",
+		"poll_interval_ms", n.cfg.PollInterval.Milliseconds(),
+		"max_attempts", n.cfg.MaxAttempts,
+		"timeout_ms", n.cfg.Timeout.Milliseconds(),
+	)
+}
+
+func (n *RunNotifier) loop(ctx context.Context) {
+	for {
+		n.Tick(ctx)
+		timer := time.NewTimer(n.cfg.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-n.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// RunFinished 为运行的 callback_url 以及租户下接受该状态的每个启用订阅各记录一条投递；
+// 子运行与非终态运行忽略
+func (n *RunNotifier) RunFinished(ctx context.Context, run *port.WorkflowRun) {
+	if run == nil || run.ParentRunID != "" || !IsTerminalRunStatus(run.Status) {
+		return
+	}
+
+	// 异步 worker 领取的运行不含回调信息，从存储中读取
+	callbackURL, callbackSecret := run.CallbackURL, run.CallbackSecret
+	if callbackURL == "" {
+		stored, err := n.repo.GetRun(ctx, run.ID)
+		if err != nil {
+			applog.Error("[RunNotifier] Failed to load run callback", "run_id", run.ID, "error", err)
+		} else if stored != nil {
+			callbackURL, callbackSecret = stored.CallbackURL, stored.CallbackSecret
+		}
+	}
+	subs, err := n.repo.ListActiveNotificationSubscriptions(ctx, run.OrgID, run.TenantID)
+	if err != nil {
+		applog.Error("[RunNotifier] Failed to list notification subscriptions", "run_id", run.ID, "error", err)
+	}
+
+	var targets []*port.RunNotificationDelivery
+	if callbackURL != "" {
+		if callbackSecret == "" {
+			callbackSecret = n.cfg.DefaultSecret
+		}
+		targets = append(targets, &port.RunNotificationDelivery{URL: callbackURL, Secret: callbackSecret})
+	}
+	for _, sub := range subs {
+		if len(sub.Statuses) > 0 && !containsStatus(sub.Statuses, run.Status) {
+			continue
+		}
+		targets = append(targets, &port.RunNotificationDelivery{SubscriptionID: sub.ID, URL: sub.URL, Secret: sub.Secret})
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(RunNotificationPayload{
+		Event:           RunFinishedEvent,
+		RunID:           run.ID,
+		WorkflowID:      run.WorkflowID,
+		OrgID:           run.OrgID,
+		TenantID:        run.TenantID,
+		Status:          run.Status,
+		Outputs:         run.Outputs,
+		Error:           run.Error,
+		ExceptionsCount: run.ExceptionsCount,
+		ElapsedMs:       run.ElapsedMs,
+		FinishedAt:      run.FinishedAt,
+	})
+	if err != nil {
+		applog.Error("[RunNotifier] Failed to encode notification", "run_id", run.ID, "error", err)
+		return
+	}
+	now := time.Now()
+	for _, d := range targets {
+		d.RunID = run.ID
+		d.OrgID = run.OrgID
+		d.TenantID = run.TenantID
+		d.RunStatus = run.Status
+		d.Payload = payload
+		d.Status = port.NotificationDeliveryPending
+		d.MaxAttempts = n.cfg.MaxAttempts
+		d.NextAttemptAt = &now
+		if err := n.repo.CreateNotificationDelivery(ctx, d); err != nil {
+			applog.Error("[RunNotifier] Failed to record notification delivery", "run_id", run.ID, "url", d.URL, "error", err)
+		}
+	}
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tick 发送所有到期的投递，返回尝试的数量
+func (n *RunNotifier) Tick(ctx context.Context) int {
+	// 认领的投递在本次尝试必然结束之后才对其他副本可见
+	due, err := n.repo.ClaimDueNotificationDeliveries(ctx, n.cfg.BatchSize, 2*n.cfg.Timeout+time.Second)
+	if err != nil {
+		if ctx.Err() == nil {
+			applog.Error("[RunNotifier] Failed to claim due deliveries", "error", err)
+		}
+		return 0
+	}
+	var wg sync.WaitGroup
+	for _, d := range due {
+		wg.Add(1)
+		go func(d *port.RunNotificationDelivery) {
+			defer wg.Done()
+			n.attempt(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+	return len(due)
+}
+
+// Redeliver 无论当前状态立即重新投递一次；失败时仅在仍有剩余尝试次数时安排重试
+func (n *RunNotifier) Redeliver(ctx context.Context, d *port.RunNotificationDelivery) *port.RunNotificationDelivery {
+	return n.attempt(ctx, d)
+}
+
+// attempt 执行一次投递并记录结果，返回更新后的投递记录
+func (n *RunNotifier) attempt(ctx context.Context, d *port.RunNotificationDelivery) *port.RunNotificationDelivery {
+	result := n.post(ctx, d)
+	result.Attempt = len(d.Attempts) + 1
+
+	status := port.NotificationDeliverySucceeded
+	var next *time.Time
+	if result.Error != "" {
+		status = port.NotificationDeliveryFailed
+		if result.Attempt < d.MaxAttempts {
+			status = port.NotificationDeliveryPending
+			t := time.Now().Add(n.backoff(result.Attempt))
+			next = &t
+		}
+	}
+	if err := n.repo.RecordNotificationAttempt(ctx, d.ID, result, status, next); err != nil {
+		applog.Error("[RunNotifier] Failed to record delivery attempt", "delivery_id", d.ID, "run_id", d.RunID, "error", err)
+	}
+
+	updated := *d
+	updated.Attempts = append(append([]port.NotificationAttempt(nil), d.Attempts...), result)
+	updated.Status = status
+	updated.NextAttemptAt = next
+	switch status {
+	case port.NotificationDeliverySucceeded:
+		updated.DeliveredAt = &result.At
+		applog.Info("[RunNotifier] Notification delivered", "delivery_id", d.ID, "run_id", d.RunID, "status_code", result.StatusCode, "attempt", result.Attempt)
+	case port.NotificationDeliveryPending:
+		applog.Warn("[RunNotifier] Notification delivery failed, retry scheduled",
+			"delivery_id", d.ID,
+			"run_id", d.RunID,
+			"attempt", result.Attempt,
+			"next_attempt_at", next,
+			"error", result.Error,
+		)
+	default:
+		applog.Warn("[RunNotifier] Notification delivery failed, attempts exhausted",
+			"delivery_id", d.ID,
+			"run_id", d.RunID,
+			"attempts", result.Attempt,
+			"error", result.Error,
+		)
+	}
+	return &updated
+}
+
+func (n *RunNotifier) post(ctx context.Context, d *port.RunNotificationDelivery) port.NotificationAttempt {
+	start := time.Now()
+	result := port.NotificationAttempt{At: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		result.Error = err.Error()
+		result.ElapsedMs = time.Since(start).Milliseconds()
+		return result
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "flowweave-webhook")
+	req.Header.Set(NotificationEventHeader, RunFinishedEvent)
+	req.Header.Set(NotificationDeliveryHeader, d.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(d.Secret, timestamp, d.Payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		result.ElapsedMs = time.Since(start).Milliseconds()
+		return result
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, notificationResponseLimit))
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+	}
+	result.ElapsedMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// backoff 第 attempt 次（从 1 开始）失败后的重试间隔
+func (n *RunNotifier) backoff(attempt int) time.Duration {
+	delay := n.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < n.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > n.cfg.MaxBackoff {
+		delay = n.cfg.MaxBackoff
+	}
+	return delay
+}
+
+func containsStatus(list []port.RunStatus, status port.RunStatus) bool {
+	for _, s := range list {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRunNotifier 启用运行完成通知（同步、流式与异步运行路径）
+func (r *WorkflowRunner) SetRunNotifier(n *RunNotifier) {
+	r.notifier = n
+}
+
+// RunNotifier 返回通知投递器，未启用时为 nil
+func (r *WorkflowRunner) RunNotifier() *RunNotifier {
+	return r.notifier
+}
+
+// NotifyRunFinished 为已落库的运行记录完成通知，并向事件日志追加 done 事件
+func (r *WorkflowRunner) NotifyRunFinished(ctx context.Context, run *port.WorkflowRun) {
+	if r == nil {
+		return
+	}
+	r.recordRunFinished(run)
+	if r.notifier != nil {
+		r.notifier.RunFinished(ctx, run)
+	}
+}
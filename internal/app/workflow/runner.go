@@ -4,8 +4,10 @@ import (
 	applog "flowweave/internal/platform/log"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"flowweave/internal/domain/memory"
 	"flowweave/internal/domain/rag"
@@ -28,6 +30,12 @@ type WorkflowRunner struct {
 	engineConfig *engine.Config
 	memoryCoord  *memory.Coordinator
 	retriever    *rag.Retriever
+	repo         port.Repository
+	control      *runControl
+	notifier     *RunNotifier
+	eventLog     port.RunEventLog
+
+	maxSubWorkflowDepth int
 }
 
 // NewWorkflowRunner 创建工作流运行器
@@ -38,6 +46,7 @@ func NewWorkflowRunner(config *engine.Config, memCoord *memory.Coordinator) *Wor
 	return &WorkflowRunner{
 		engineConfig: config,
 		memoryCoord:  memCoord,
+		control:      newRunControl(),
 	}
 }
 
@@ -46,18 +55,53 @@ func (r *WorkflowRunner) SetRetriever(retriever *rag.Retriever) {
 	r.retriever = retriever
 }
 
+// SetRepository 设置仓储（可选，启用 workflow 子工作流节点）
+func (r *WorkflowRunner) SetRepository(repo port.Repository) {
+	r.repo = repo
+}
+
+// SetMaxSubWorkflowDepth 设置子工作流最大嵌套深度（<= 0 时使用默认值）
+func (r *WorkflowRunner) SetMaxSubWorkflowDepth(depth int) {
+	r.maxSubWorkflowDepth = depth
+}
+
+func (r *WorkflowRunner) maxSubWorkflowDepthOrDefault() int {
+	if r.maxSubWorkflowDepth > 0 {
+		return r.maxSubWorkflowDepth
+	}
+	return DefaultMaxSubWorkflowDepth
+}
+
 // RunOptions 执行选项
 type RunOptions struct {
 	ConversationID string // 会话 ID（用于记忆管理）
 	UserID         string // 用户 ID（预留长期记忆）
 	OrgID          string // 组织 ID（用于 RAG 多租户隔离）
 	TenantID       string // 租户 ID（用于 RAG 多租户隔离）
+
+	RunID        string                // 运行 ID（检查点归属）
+	ResumeFrom   *port.RunCheckpoint   // 从检查点恢复执行（可选）
+	Checkpointer engine.CheckpointFunc // 节点完成后的检查点持久化回调（可选）
+
+	HumanInputs map[string]*port.PendingInput // 已响应的人工输入（node_id → 响应），恢复挂起执行时注入
+
+	Timeout time.Duration // 执行超时（可选），手动暂停期间不计时
 }
 
 // RunResult 同步执行结果
 type RunResult struct {
 	Outputs        map[string]interface{} `json:"outputs,omitempty"`
 	NodeExecutions []port.NodeExecution   `json:"node_executions,omitempty"`
+	Aborted        bool                   `json:"aborted,omitempty"`
+
+	// 部分成功：有节点失败但被 fail-branch / default-value 策略吸收
+	PartialSucceeded bool `json:"partial_succeeded,omitempty"`
+	ExceptionsCount  int  `json:"exceptions_count,omitempty"`
+
+	// 挂起等待人工输入
+	Paused        bool                 `json:"paused,omitempty"`
+	PendingInputs []*port.PendingInput `json:"pending_inputs,omitempty"`
+	Checkpoint    *port.RunCheckpoint  `json:"-"`
 }
 
 // RunFromDSL 从 DSL JSON 执行工作流
@@ -74,6 +118,11 @@ func (r *WorkflowRunner) RunFromDSL(ctx context.Context, dslJSON []byte, inputs
 // RunFromConfig 从 GraphConfig 执行工作流
 func (r *WorkflowRunner) RunFromConfig(ctx context.Context, config *types.GraphConfig, inputs map[string]interface{}, opts *RunOptions) (<-chan event.GraphEvent, error) {
 	// 1. 构建图
+	if config.NodeDefaults != nil {
+		if err := config.NodeDefaults.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid node_defaults: %w", err)
+		}
+	}
 	factory := node.NewFactory()
 	g, err := graph.Init(config, factory)
 	if err != nil {
@@ -90,7 +139,53 @@ func (r *WorkflowRunner) RunFromConfig(ctx context.Context, config *types.GraphC
 
 	state := runtime.NewGraphRuntimeState(vp)
 
-	// 3. 注入记忆管理到 context
+	// 3. 注入记忆、RAG scope、工具注册表、人工输入与子工作流调用器
+	ctx = r.prepareContext(ctx, config, opts)
+
+	// 4. 创建引擎并执行（DSL 中的工作流级节点默认值覆盖引擎配置）
+	eng := engine.New(g, state, r.engineConfigFor(config))
+	if opts != nil && (opts.Checkpointer != nil || opts.RunID != "") {
+		eng.SetCheckpointer(opts.RunID, opts.Checkpointer)
+	}
+	if opts != nil && opts.ResumeFrom != nil {
+		if err := eng.Restore(opts.ResumeFrom); err != nil {
+			return nil, fmt.Errorf("failed to restore checkpoint: %w", err)
+		}
+	}
+	var deadline *runDeadline
+	if opts != nil && opts.Timeout > 0 {
+		deadline = withRunDeadline(ctx, opts.Timeout)
+		ctx = deadline
+	}
+	eventCh := eng.Run(ctx)
+	if deadline != nil {
+		eventCh = deadline.watch(eventCh)
+	}
+	if opts != nil && opts.RunID != "" {
+		// 注册到运行控制，供 abort / pause / resume 命令定位
+		eventCh = r.control.track(opts.RunID, eng, eventCh)
+		if r.eventLog != nil {
+			eventCh = r.recordEvents(opts.RunID, eventCh)
+		}
+	}
+	return eventCh, nil
+}
+
+// engineConfigFor 返回应用了工作流级节点默认值的引擎配置
+func (r *WorkflowRunner) engineConfigFor(config *types.GraphConfig) *engine.Config {
+	engineConfig := r.engineConfig
+	if engineConfig == nil {
+		engineConfig = engine.DefaultConfig()
+	}
+	if config.NodeDefaults != nil {
+		engineConfig = engineConfig.WithNodeDefaults(config.NodeDefaults)
+	}
+	return engineConfig
+}
+
+// prepareContext 向 context 注入节点执行所需的依赖（记忆、RAG scope、工具注册表、人工输入、子工作流调用器）
+func (r *WorkflowRunner) prepareContext(ctx context.Context, config *types.GraphConfig, opts *RunOptions) context.Context {
+	// 注入记忆管理到 context
 	if r.memoryCoord != nil && opts != nil {
 		ctx = memory.WithCoordinator(ctx, r.memoryCoord)
 		if opts.ConversationID != "" {
@@ -101,7 +196,7 @@ func (r *WorkflowRunner) RunFromConfig(ctx context.Context, config *types.GraphC
 		}
 	}
 
-	// 4. 注入 RAG 多租户 scope（供 Agent Tool/RAG 使用）
+	// 注入 RAG 多租户 scope（供 Agent Tool/RAG 使用）
 	if opts != nil && (opts.OrgID != "" || opts.TenantID != "") {
 		ctx = rag.WithScopeInfo(ctx, &rag.ScopeInfo{
 			OrgID:    opts.OrgID,
@@ -109,26 +204,25 @@ func (r *WorkflowRunner) RunFromConfig(ctx context.Context, config *types.GraphC
 		})
 	}
 
-	// 5. 根据 DSL tools 配置注入工具注册表（Agent Tool Calling）
+	// 根据 DSL tools 配置注入工具注册表（Agent Tool Calling）
 	toolNames := collectToolNamesFromDSL(config)
 	if len(toolNames) > 0 {
 		toolReg := tool.NewRegistry()
 		registeredCount := 0
 		for _, name := range toolNames {
-			switch name {
-			case "knowledge_search":
-				if r.retriever == nil {
-					applog.Warn("[WorkflowRunner] Tool requested but retriever is not configured",
-						"tool", name,
-					)
-					continue
-				}
-				toolReg.Register(ragtool.NewRAGTool(r.retriever, nil))
-				registeredCount++
-			default:
+			t, err := r.bindTool(name)
+			switch {
+			case errors.Is(err, errToolNotConfigured):
+				applog.Warn("[WorkflowRunner] Tool requested but retriever is not configured",
+					"tool", name,
+				)
+			case err != nil:
 				applog.Warn("[WorkflowRunner] Unknown tool requested in DSL, skip registration",
 					"tool", name,
 				)
+			default:
+				toolReg.Register(t)
+				registeredCount++
 			}
 		}
 		if registeredCount > 0 {
@@ -136,9 +230,21 @@ func (r *WorkflowRunner) RunFromConfig(ctx context.Context, config *types.GraphC
 		}
 	}
 
-	// 6. 创建引擎并执行
-	eng := engine.New(g, state, r.engineConfig)
-	return eng.Run(ctx), nil
+	// 注入已响应的人工输入（恢复挂起执行）
+	if opts != nil && len(opts.HumanInputs) > 0 {
+		ctx = node.WithHumanInputs(ctx, opts.HumanInputs)
+	}
+
+	// 注入子工作流调用器（子运行继承父运行的租户 scope）
+	if r.repo != nil {
+		invoker := &subWorkflowInvoker{runner: r}
+		if opts != nil {
+			invoker.opts = *opts
+		}
+		ctx = node.WithSubWorkflowInvoker(ctx, invoker)
+	}
+
+	return ctx
 }
 
 // RunSync 同步执行工作流并返回结果（含节点执行明细）
@@ -156,12 +262,29 @@ func (r *WorkflowRunner) RunSync(ctx context.Context, dslJSON []byte, inputs map
 		case event.EventTypeGraphRunSucceeded:
 			result.Outputs = evt.Outputs
 			result.NodeExecutions = evt.NodeExecutions
+			result.Paused = false
+		case event.EventTypeGraphRunPartialSucceeded:
+			result.Outputs = evt.Outputs
+			result.NodeExecutions = evt.NodeExecutions
+			result.PartialSucceeded = true
+			result.ExceptionsCount = evt.ExceptionsCount
+			result.Paused = false
 		case event.EventTypeGraphRunFailed:
 			lastError = evt.Error
+			result.ExceptionsCount = evt.ExceptionsCount
 			result.NodeExecutions = evt.NodeExecutions
 		case event.EventTypeGraphRunAborted:
 			lastError = evt.Error
+			result.Aborted = true
+			result.NodeExecutions = evt.NodeExecutions
+		case event.EventTypeGraphRunPaused:
+			// 手动暂停仅为中间状态，挂起（人工输入）时为最终事件
+			result.Paused = true
+			result.PendingInputs = evt.PendingInputs
+			result.Checkpoint = evt.Checkpoint
 			result.NodeExecutions = evt.NodeExecutions
+		case event.EventTypeGraphRunResumed:
+			result.Paused = false
 		}
 	}
 
@@ -172,6 +295,24 @@ func (r *WorkflowRunner) RunSync(ctx context.Context, dslJSON []byte, inputs map
 	return result, nil
 }
 
+var (
+	errUnknownTool       = errors.New("unknown tool")
+	errToolNotConfigured = errors.New("tool dependency is not configured")
+)
+
+// bindTool 按 DSL 中的工具名创建工具实例
+func (r *WorkflowRunner) bindTool(name string) (tool.Tool, error) {
+	switch name {
+	case "knowledge_search":
+		if r.retriever == nil {
+			return nil, errToolNotConfigured
+		}
+		return ragtool.NewRAGTool(r.retriever, nil), nil
+	default:
+		return nil, errUnknownTool
+	}
+}
+
 type dslToolBinding struct {
 	Name string `json:"name"`
 }
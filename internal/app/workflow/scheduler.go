@@ -0,0 +1,217 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// SchedulerConfig 定时调度器配置
+type SchedulerConfig struct {
+	PollInterval time.Duration
+	// MisfireThreshold 触发延迟超过该阈值视为错过，按调度的 misfire_policy 处理
+	MisfireThreshold time.Duration
+	BatchSize        int
+}
+
+// Scheduler 按 cron 调度向 workflow_runs 写入排队运行，由 AsyncRunManager 领取执行。
+// 每个副本都运行调度器，每次触发通过 next_fire_at 的比较并设置认领，只有一个副本会触发
+type Scheduler struct {
+	repo port.Repository
+	cfg  SchedulerConfig
+}
+
+// NewScheduler 创建定时调度器，未设置的配置项使用默认值
+func NewScheduler(repo port.Repository, cfg SchedulerConfig) *Scheduler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.MisfireThreshold <= 0 {
+		cfg.MisfireThreshold = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Scheduler{repo: repo, cfg: cfg}
+}
+
+// Start 启动后台轮询，ctx 结束时停止
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.loop(ctx)
+	applog.Info("[Scheduler] Started", "poll_interval_ms", s.cfg.PollInterval.Milliseconds(), "misfire_threshold_s", int(s.cfg.MisfireThreshold.Seconds()))
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	for {
+		s.Tick(ctx, time.Now())
+		if !sleepWithContext(ctx, s.cfg.PollInterval) {
+			return
+		}
+	}
+}
+
+// Tick 触发 now 时刻所有到期的调度，返回入队的运行数
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) int {
+	due, err := s.repo.ListDueSchedules(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			applog.Error("[Scheduler] Failed to list due schedules", "error", err)
+		}
+		return 0
+	}
+	fired := 0
+	for _, sched := range due {
+		if s.fire(ctx, sched, now) {
+			fired++
+		}
+	}
+	return fired
+}
+
+func (s *Scheduler) fire(ctx context.Context, sched *port.WorkflowSchedule, now time.Time) bool {
+	if sched.NextFireAt == nil {
+		return false
+	}
+	expected := *sched.NextFireAt
+
+	next, err := NextFireTime(sched.CronExpr, sched.Timezone, now)
+	if err != nil {
+		// 无法解析的调度会被无限重试：清空下次触发时间并记录错误
+		if ok, _ := s.repo.AdvanceSchedule(ctx, sched.ID, expected, time.Time{}, nil); ok {
+			s.record(ctx, sched, "", err.Error())
+		}
+		return false
+	}
+
+	// 阈值内错过的多次触发合并为一次；超过阈值按 misfire_policy 处理
+	if late := now.Sub(expected); late > s.cfg.MisfireThreshold && sched.MisfirePolicy == port.MisfirePolicySkip {
+		ok, err := s.repo.AdvanceSchedule(ctx, sched.ID, expected, next, nil)
+		if err != nil {
+			applog.Error("[Scheduler] Failed to advance schedule", "schedule_id", sched.ID, "error", err)
+		} else if ok {
+			applog.Warn("[Scheduler] Skipped misfired schedule", "schedule_id", sched.ID, "scheduled_at", expected, "late_s", int(late.Seconds()))
+			s.record(ctx, sched, "", fmt.Sprintf("misfired: skipped tick scheduled at %s", expected.Format(time.RFC3339)))
+		}
+		return false
+	}
+
+	firedAt := now
+	ok, err := s.repo.AdvanceSchedule(ctx, sched.ID, expected, next, &firedAt)
+	if err != nil {
+		applog.Error("[Scheduler] Failed to advance schedule", "schedule_id", sched.ID, "error", err)
+		return false
+	}
+	if !ok {
+		// 其他副本已认领本次触发，或调度在此期间被修改
+		return false
+	}
+
+	run, err := s.enqueue(ctx, sched)
+	if err != nil {
+		applog.Error("[Scheduler] Failed to enqueue scheduled run", "schedule_id", sched.ID, "workflow_id", sched.WorkflowID, "error", err)
+		s.record(ctx, sched, "", err.Error())
+		return false
+	}
+	applog.Info("[Scheduler] Enqueued scheduled run",
+		"schedule_id", sched.ID,
+		"workflow_id", sched.WorkflowID,
+		"run_id", run.ID,
+		"scheduled_at", expected,
+		"next_fire_at", next,
+	)
+	s.record(ctx, sched, run.ID, "")
+	return true
+}
+
+// enqueue 以工作流的已发布版本创建一条排队运行
+func (s *Scheduler) enqueue(ctx context.Context, sched *port.WorkflowSchedule) (*port.WorkflowRun, error) {
+	repoCtx := ctx
+	if sched.OrgID != "" || sched.TenantID != "" {
+		repoCtx = port.WithRepoScope(ctx, sched.OrgID, sched.TenantID)
+	}
+	wf, err := s.repo.GetWorkflow(repoCtx, sched.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if wf == nil {
+		return nil, fmt.Errorf("workflow not found")
+	}
+	if wf.PublishedVersion <= 0 {
+		return nil, fmt.Errorf("workflow has no published version")
+	}
+
+	inputs := sched.Inputs
+	if len(inputs) == 0 {
+		inputs = json.RawMessage(`{}`)
+	}
+	run := &port.WorkflowRun{
+		WorkflowID:      wf.ID,
+		WorkflowVersion: wf.PublishedVersion,
+		OrgID:           sched.OrgID,
+		TenantID:        sched.TenantID,
+		Status:          port.RunStatusQueued,
+		Inputs:          inputs,
+	}
+	if err := s.repo.CreateRun(repoCtx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *Scheduler) record(ctx context.Context, sched *port.WorkflowSchedule, runID, lastError string) {
+	if err := s.repo.RecordScheduleRun(ctx, sched.ID, runID, lastError); err != nil {
+		applog.Warn("[Scheduler] Failed to record schedule result", "schedule_id", sched.ID, "error", err)
+	}
+}
+
+// ParseScheduleSpec 校验 cron 表达式与 IANA 时区（为空表示 UTC）
+func ParseScheduleSpec(cronExpr, timezone string) (*CronSchedule, *time.Location, error) {
+	cron, err := ParseCron(cronExpr)
+	if err != nil {
+		return nil, nil, err
+	}
+	tz := strings.TrimSpace(timezone)
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return cron, loc, nil
+}
+
+// NextFireTime 返回 after 之后的首个触发时间
+func NextFireTime(cronExpr, timezone string, after time.Time) (time.Time, error) {
+	times, err := UpcomingFireTimes(cronExpr, timezone, after, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return times[0], nil
+}
+
+// UpcomingFireTimes 返回 after 之后的 n 个触发时间
+func UpcomingFireTimes(cronExpr, timezone string, after time.Time, n int) ([]time.Time, error) {
+	cron, loc, err := ParseScheduleSpec(cronExpr, timezone)
+	if err != nil {
+		return nil, err
+	}
+	times := make([]time.Time, 0, n)
+	t := after.In(loc)
+	for len(times) < n {
+		t = cron.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("cron expression %q never fires", cronExpr)
+	}
+	return times, nil
+}
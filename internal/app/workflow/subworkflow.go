@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// DefaultMaxSubWorkflowDepth 子工作流默认最大嵌套深度
+const DefaultMaxSubWorkflowDepth = 5
+
+// subWorkflowError 子工作流调用错误（携带错误分类，供 retry 策略判断）
+type subWorkflowError struct {
+	class types.ErrorClass
+	msg   string
+}
+
+func (e *subWorkflowError) Error() string                { return e.msg }
+func (e *subWorkflowError) ErrorClass() types.ErrorClass { return e.class }
+
+type subWorkflowDepthKey struct{}
+
+// subWorkflowDepth 返回当前运行的嵌套深度（顶层运行为 0）
+func subWorkflowDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(subWorkflowDepthKey{}).(int)
+	return depth
+}
+
+// subWorkflowInvoker 以子运行的方式执行已保存的工作流
+// 子运行使用父运行的租户 scope，并通过 parent_run_id 关联父运行
+type subWorkflowInvoker struct {
+	runner *WorkflowRunner
+	opts   RunOptions
+}
+
+// InvokeWorkflow 实现 node.SubWorkflowInvoker
+func (i *subWorkflowInvoker) InvokeWorkflow(ctx context.Context, req *node.SubWorkflowRequest) (*node.SubWorkflowResult, error) {
+	r := i.runner
+	depth := subWorkflowDepth(ctx) + 1
+	if max := r.maxSubWorkflowDepthOrDefault(); depth > max {
+		return nil, &subWorkflowError{
+			class: types.ErrorClassValidation,
+			msg:   fmt.Sprintf("sub-workflow depth limit exceeded (max %d) invoking workflow %s", max, req.WorkflowID),
+		}
+	}
+
+	repoCtx := ctx
+	if i.opts.OrgID != "" || i.opts.TenantID != "" {
+		repoCtx = port.WithRepoScope(repoCtx, i.opts.OrgID, i.opts.TenantID)
+	}
+
+	// 1. 加载被调用的工作流（按父运行 scope 隔离）
+	wf, err := r.repo.GetWorkflow(repoCtx, req.WorkflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow %s: %w", req.WorkflowID, err)
+	}
+	if wf == nil {
+		return nil, &subWorkflowError{class: types.ErrorClassValidation, msg: fmt.Sprintf("workflow %s not found", req.WorkflowID)}
+	}
+	// 未指定版本时执行已发布版本
+	version := req.Version
+	if version <= 0 {
+		version = wf.PublishedVersion
+	}
+	if version <= 0 {
+		return nil, &subWorkflowError{class: types.ErrorClassValidation, msg: fmt.Sprintf("workflow %s has no published version", req.WorkflowID)}
+	}
+	dsl := wf.DSL
+	if version != wf.Version {
+		v, err := r.repo.GetWorkflowVersion(repoCtx, req.WorkflowID, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workflow %s version %d: %w", req.WorkflowID, version, err)
+		}
+		if v == nil {
+			return nil, &subWorkflowError{
+				class: types.ErrorClassValidation,
+				msg:   fmt.Sprintf("workflow %s version %d not found", req.WorkflowID, version),
+			}
+		}
+		dsl = v.DSL
+	}
+
+	// 2. 创建子运行记录
+	inputsJSON, _ := json.Marshal(req.Inputs)
+	child := &port.WorkflowRun{
+		WorkflowID:      wf.ID,
+		WorkflowVersion: version,
+		OrgID:           i.opts.OrgID,
+		TenantID:        i.opts.TenantID,
+		ParentRunID:     i.opts.RunID,
+		ConversationID:  i.opts.ConversationID,
+		Status:          port.RunStatusRunning,
+		Inputs:          inputsJSON,
+	}
+	if err := r.repo.CreateRun(repoCtx, child); err != nil {
+		return nil, fmt.Errorf("failed to create child run: %w", err)
+	}
+
+	// 3. 同步执行子工作流
+	childOpts := &RunOptions{
+		ConversationID: i.opts.ConversationID,
+		UserID:         i.opts.UserID,
+		OrgID:          i.opts.OrgID,
+		TenantID:       i.opts.TenantID,
+		RunID:          child.ID,
+	}
+	// 父运行恢复时注入的人工输入只属于父运行，不能被子运行中同 ID 的节点消费
+	childCtx := node.WithHumanInputs(context.WithValue(ctx, subWorkflowDepthKey{}, depth), nil)
+	startTime := time.Now()
+	result, execErr := r.RunSync(childCtx, dsl, req.Inputs, childOpts)
+	if execErr == nil && result != nil && result.Paused {
+		execErr = &subWorkflowError{class: types.ErrorClassValidation, msg: "sub-workflow paused for human input, which is not supported in child runs"}
+	}
+
+	// 4. 持久化子运行结果
+	now := time.Now()
+	child.ElapsedMs = time.Since(startTime).Milliseconds()
+	child.FinishedAt = &now
+	child.Status = port.RunStatusSucceeded
+	if execErr != nil {
+		child.Status = port.RunStatusFailed
+		if (result != nil && result.Aborted) || errors.Is(ctx.Err(), context.Canceled) {
+			child.Status = port.RunStatusAborted
+		}
+		child.Error = execErr.Error()
+	} else {
+		if result.PartialSucceeded {
+			child.Status = port.RunStatusPartialSucceeded
+		}
+		child.Outputs, _ = json.Marshal(result.Outputs)
+	}
+	if result != nil {
+		child.ExceptionsCount = result.ExceptionsCount
+	}
+
+	persistCtx := context.WithoutCancel(repoCtx)
+	if result != nil && len(result.NodeExecutions) > 0 {
+		if err := r.repo.BatchCreateNodeExecs(persistCtx, toNodeExecRecords(child.ID, result.NodeExecutions)); err != nil {
+			applog.Error("[SubWorkflow] Failed to persist child node executions", "run_id", child.ID, "error", err)
+		}
+	}
+	if err := r.repo.UpdateRun(persistCtx, child); err != nil {
+		applog.Error("[SubWorkflow] Failed to persist child run", "run_id", child.ID, "error", err)
+	}
+
+	res := &node.SubWorkflowResult{RunID: child.ID, Status: string(child.Status), Version: version, Depth: depth}
+	if execErr != nil {
+		return res, fmt.Errorf("sub-workflow %s failed: %w", req.WorkflowID, execErr)
+	}
+	res.Outputs = result.Outputs
+	return res, nil
+}
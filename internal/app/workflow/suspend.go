@@ -0,0 +1,55 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// SuspendRun 持久化挂起的运行：保存检查点与待输入请求，并将运行置为 paused。
+// 节点执行明细保留在检查点中，待运行最终结束时统一落库，避免恢复后重复写入。
+func SuspendRun(ctx context.Context, repo port.Repository, run *port.WorkflowRun, result *RunResult) error {
+	if run == nil || result == nil || !result.Paused {
+		return nil
+	}
+
+	if cp := result.Checkpoint; cp != nil {
+		cp.RunID = run.ID
+		if err := repo.SaveRunCheckpoint(ctx, cp); err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+
+	for _, pi := range result.PendingInputs {
+		pi.RunID = run.ID
+		pi.WorkflowID = run.WorkflowID
+		pi.OrgID = run.OrgID
+		pi.TenantID = run.TenantID
+		if err := repo.UpsertPendingInput(ctx, pi); err != nil {
+			return fmt.Errorf("save pending input %s: %w", pi.NodeID, err)
+		}
+	}
+
+	run.Status = port.RunStatusPaused
+	run.Error = ""
+	run.Outputs = nil
+	run.FinishedAt = nil
+	return repo.UpdateRun(ctx, run)
+}
+
+// LoadHumanInputs 加载运行中已响应（提交或超时）的人工输入，供恢复执行时注入
+func LoadHumanInputs(ctx context.Context, repo port.Repository, runID string) (map[string]*port.PendingInput, error) {
+	inputs, err := repo.ListPendingInputs(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	responded := make(map[string]*port.PendingInput)
+	for _, pi := range inputs {
+		if pi.Status == port.PendingInputStatusWaiting {
+			continue
+		}
+		responded[pi.NodeID] = pi
+	}
+	return responded, nil
+}
@@ -0,0 +1,503 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	asrprovider "flowweave/internal/adapter/provider/asr"
+	llmprovider "flowweave/internal/adapter/provider/llm"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/node/code"
+)
+
+// ValidationSeverity 校验问题级别
+type ValidationSeverity string
+
+const (
+	ValidationSeverityError   ValidationSeverity = "error"   // 运行必然失败或行为错误，保存时拒绝
+	ValidationSeverityWarning ValidationSeverity = "warning" // 可运行但很可能不是预期行为
+)
+
+// 校验问题代码
+const (
+	ValidationInvalidDSL           = "invalid_dsl"
+	ValidationNoRootNode           = "no_root_node"
+	ValidationDuplicateNode        = "duplicate_node"
+	ValidationUnknownNodeType      = "unknown_node_type"
+	ValidationInvalidNodeConfig    = "invalid_node_config"
+	ValidationUnknownEdgeNode      = "unknown_edge_node"
+	ValidationCycle                = "cycle"
+	ValidationUnreachableNode      = "unreachable_node"
+	ValidationUnknownReference     = "unknown_reference"
+	ValidationReferenceNotUpstream = "reference_not_upstream"
+	ValidationUnknownProvider      = "unknown_provider"
+	ValidationUnknownFunction      = "unknown_function"
+	ValidationUnknownTool          = "unknown_tool"
+	ValidationToolNotConfigured    = "tool_not_configured"
+	ValidationMissingBranchEdge    = "missing_branch_edge"
+	ValidationUnknownBranchHandle  = "unknown_branch_handle"
+)
+
+// ValidationIssue 单个校验问题
+type ValidationIssue struct {
+	Severity ValidationSeverity `json:"severity"`
+	Code     string             `json:"code"`
+	NodeID   string             `json:"node_id,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// ValidationReport 工作流静态校验结果
+type ValidationReport struct {
+	Valid  bool              `json:"valid"` // 没有 error 级别问题
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// ErrorCount 返回 error 级别问题数
+func (rep *ValidationReport) ErrorCount() int {
+	count := 0
+	for _, issue := range rep.Issues {
+		if issue.Severity == ValidationSeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// templateRefPattern 匹配 {{#node_id.var_name#}} 模板引用
+var templateRefPattern = regexp.MustCompile(`\{\{#([^#.}]+)\.([^#}]+)#\}\}`)
+
+// validateNodeData 校验所需的节点配置字段（覆盖各节点类型中与校验相关的部分）
+type validateNodeData struct {
+	Type  string `json:"type"`
+	Model struct {
+		Provider string `json:"provider"`
+	} `json:"model"`
+	Provider    string           `json:"provider"`
+	FunctionRef string           `json:"function_ref"`
+	Tools       []dslToolBinding `json:"tools"`
+	Conditions  []struct {
+		ID string `json:"id"`
+	} `json:"conditions"`
+	Subgraph *struct {
+		Start string             `json:"start"`
+		Nodes []types.NodeConfig `json:"nodes"`
+		Edges []types.EdgeConfig `json:"edges"`
+	} `json:"subgraph"`
+}
+
+// graphLevel 一层图（顶层图或容器节点的子图）的校验上下文
+type graphLevel struct {
+	containerID string          // 所属容器节点（顶层为空）
+	outerNodes  map[string]bool // 外层所有节点（引用存在性判断）
+	outerUp     map[string]bool // 容器节点在外层的上游（子图节点可引用）
+}
+
+// Validate 对工作流 DSL 做静态校验：图结构、变量引用、节点类型、provider、函数与工具绑定
+func (r *WorkflowRunner) Validate(dslJSON []byte) *ValidationReport {
+	v := &dslValidator{runner: r, factory: node.NewFactory()}
+
+	var config types.GraphConfig
+	if err := json.Unmarshal(dslJSON, &config); err != nil {
+		v.add(ValidationSeverityError, ValidationInvalidDSL, "", "failed to parse workflow DSL: %v", err)
+		return v.report()
+	}
+	if len(config.Nodes) == 0 {
+		v.add(ValidationSeverityError, ValidationInvalidDSL, "", "workflow must have at least one node")
+		return v.report()
+	}
+	if config.NodeDefaults != nil {
+		if err := config.NodeDefaults.Validate(); err != nil {
+			v.add(ValidationSeverityError, ValidationInvalidDSL, "", "invalid node_defaults: %v", err)
+		}
+	}
+	if config.AsyncRetry != nil {
+		if err := config.AsyncRetry.Validate(); err != nil {
+			v.add(ValidationSeverityError, ValidationInvalidDSL, "", "invalid async_retry: %v", err)
+		}
+	}
+
+	v.validateLevel(config.Nodes, config.Edges, "", &graphLevel{})
+	return v.report()
+}
+
+type dslValidator struct {
+	runner  *WorkflowRunner
+	factory *node.Factory
+	issues  []ValidationIssue
+}
+
+func (v *dslValidator) add(severity ValidationSeverity, code, nodeID, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{
+		Severity: severity,
+		Code:     code,
+		NodeID:   nodeID,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *dslValidator) report() *ValidationReport {
+	rep := &ValidationReport{Issues: v.issues}
+	if rep.Issues == nil {
+		rep.Issues = []ValidationIssue{}
+	}
+	rep.Valid = rep.ErrorCount() == 0
+	return rep
+}
+
+// validateLevel 校验一层图；startID 为空时按 graph.Init 的规则推断根节点
+func (v *dslValidator) validateLevel(nodeConfigs []types.NodeConfig, edgeConfigs []types.EdgeConfig, startID string, level *graphLevel) {
+	// 1. 节点与节点类型
+	nodes := make(map[string]validateNodeData, len(nodeConfigs))
+	raw := make(map[string]json.RawMessage, len(nodeConfigs))
+	order := make([]string, 0, len(nodeConfigs))
+	for _, nc := range nodeConfigs {
+		if nc.Type == "custom-note" {
+			continue
+		}
+		if _, dup := nodes[nc.ID]; dup {
+			v.add(ValidationSeverityError, ValidationDuplicateNode, nc.ID, "duplicate node id %s", nc.ID)
+			continue
+		}
+		var data validateNodeData
+		if err := json.Unmarshal(nc.Data, &data); err != nil {
+			v.add(ValidationSeverityError, ValidationInvalidNodeConfig, nc.ID, "invalid node data: %v", err)
+		}
+		nodes[nc.ID] = data
+		raw[nc.ID] = nc.Data
+		order = append(order, nc.ID)
+
+		if _, ok := node.GetRegistry().Get(types.NodeType(data.Type)); !ok {
+			v.add(ValidationSeverityError, ValidationUnknownNodeType, nc.ID, "unknown node type %q", data.Type)
+			continue
+		}
+		if _, err := v.factory.CreateNode(nc); err != nil {
+			v.add(ValidationSeverityError, ValidationInvalidNodeConfig, nc.ID, "%v", err)
+		}
+	}
+
+	// 2. 边
+	out := make(map[string][]types.EdgeConfig)
+	in := make(map[string][]string)
+	for _, ec := range edgeConfigs {
+		if ec.Source == "" || ec.Target == "" {
+			continue
+		}
+		_, srcOK := nodes[ec.Source]
+		_, dstOK := nodes[ec.Target]
+		if !srcOK || !dstOK {
+			missing, owner := ec.Source, ec.Target
+			if srcOK {
+				missing, owner = ec.Target, ec.Source
+			}
+			if _, ok := nodes[owner]; !ok {
+				owner = ""
+			}
+			v.add(ValidationSeverityError, ValidationUnknownEdgeNode, owner, "edge %s -> %s references unknown node %s", ec.Source, ec.Target, missing)
+			continue
+		}
+		out[ec.Source] = append(out[ec.Source], ec)
+		in[ec.Target] = append(in[ec.Target], ec.Source)
+	}
+
+	// 3. 根节点、环与可达性
+	rootID := startID
+	if rootID == "" {
+		rootID = findValidateRoot(order, nodes, in)
+	}
+	if _, ok := nodes[rootID]; !ok {
+		if startID != "" {
+			v.add(ValidationSeverityError, ValidationNoRootNode, level.containerID, "subgraph start node %s not found", startID)
+		} else {
+			v.add(ValidationSeverityError, ValidationNoRootNode, level.containerID, "unable to determine root node: every node has an incoming edge")
+		}
+		rootID = ""
+	}
+	for _, id := range findCycleNodes(order, out) {
+		v.add(ValidationSeverityError, ValidationCycle, id, "node %s is part of a cycle; use a loop or iteration node for repetition", id)
+	}
+	if rootID != "" {
+		reachable := reachableFrom(rootID, out)
+		for _, id := range order {
+			if reachable[id] {
+				continue
+			}
+			// 其他 start 节点是非活跃入口（graph.Init 会将其标记为跳过）
+			if types.NodeType(nodes[id].Type).IsStartNode() {
+				continue
+			}
+			v.add(ValidationSeverityWarning, ValidationUnreachableNode, id, "node %s is not reachable from %s", id, rootID)
+		}
+	}
+
+	// 4. 逐节点检查
+	levelNodes := make(map[string]bool, len(nodes))
+	for id := range nodes {
+		levelNodes[id] = true
+	}
+	for _, id := range order {
+		data := nodes[id]
+		upstream := ancestorsOf(id, in)
+
+		// 容器节点自身配置可引用其子图节点（如 result_selector / state_update）
+		subgraphNodes := make(map[string]bool)
+		if data.Subgraph != nil {
+			for _, sn := range data.Subgraph.Nodes {
+				subgraphNodes[sn.ID] = true
+			}
+		}
+		for _, ref := range collectReferences(raw[id]) {
+			switch {
+			case ref == "sys" || ref == id || ref == level.containerID || subgraphNodes[ref]:
+			case upstream[ref] || level.outerUp[ref]:
+			case levelNodes[ref] || level.outerNodes[ref]:
+				v.add(ValidationSeverityError, ValidationReferenceNotUpstream, id, "node %s references %s, which is not upstream", id, ref)
+			default:
+				v.add(ValidationSeverityError, ValidationUnknownReference, id, "node %s references unknown node %s", id, ref)
+			}
+		}
+
+		v.validateBindings(id, data, out[id])
+
+		// 容器子图：子图节点可引用容器的上游、容器本身与外层所有上游
+		if data.Subgraph != nil {
+			inner := &graphLevel{
+				containerID: id,
+				outerNodes:  mergeSets(level.outerNodes, levelNodes),
+				outerUp:     mergeSets(level.outerUp, upstream),
+			}
+			v.validateLevel(data.Subgraph.Nodes, data.Subgraph.Edges, data.Subgraph.Start, inner)
+		}
+	}
+}
+
+// validateBindings 检查节点对外部依赖的绑定：LLM / ASR provider、本地函数、工具与分支出边
+func (v *dslValidator) validateBindings(id string, data validateNodeData, outEdges []types.EdgeConfig) {
+	switch types.NodeType(data.Type) {
+	case types.NodeTypeLLM:
+		if p := data.Model.Provider; p != "" {
+			if _, err := llmprovider.GetProvider(p); err != nil {
+				v.add(ValidationSeverityError, ValidationUnknownProvider, id, "LLM provider %q is not registered", p)
+			}
+		}
+	case types.NodeTypeASR:
+		if p := strings.TrimSpace(data.Provider); p != "" && !asrprovider.HasASRProvider(p) && !asrprovider.HasASRAsyncProvider(p) {
+			v.add(ValidationSeverityError, ValidationUnknownProvider, id, "ASR provider %q is not registered", p)
+		}
+	case types.NodeTypeFunc:
+		if ref := data.FunctionRef; ref != "" {
+			if _, ok := code.GetFunction(ref); !ok {
+				v.add(ValidationSeverityError, ValidationUnknownFunction, id, "function_ref %q is not registered", ref)
+			}
+		}
+	case types.NodeTypeIfElse:
+		handles := map[string]bool{"false": true}
+		for _, c := range data.Conditions {
+			handles[c.ID] = true
+		}
+		used := make(map[string]bool)
+		for _, ec := range outEdges {
+			handle := ec.SourceHandle
+			used[handle] = true
+			if handle == "" || handle == "source" || handles[handle] ||
+				handle == string(types.FailBranchSourceHandleFailed) || handle == string(types.FailBranchSourceHandleSuccess) {
+				continue
+			}
+			v.add(ValidationSeverityWarning, ValidationUnknownBranchHandle, id, "edge %s -> %s uses handle %q, which the if-else node never emits", ec.Source, ec.Target, handle)
+		}
+		for _, c := range data.Conditions {
+			if !used[c.ID] {
+				v.add(ValidationSeverityWarning, ValidationMissingBranchEdge, id, "if-else case %q has no outgoing edge", c.ID)
+			}
+		}
+		if !used["false"] {
+			v.add(ValidationSeverityWarning, ValidationMissingBranchEdge, id, "if-else else branch (handle \"false\") has no outgoing edge")
+		}
+	}
+
+	for _, tb := range data.Tools {
+		if tb.Name == "" {
+			continue
+		}
+		if _, err := v.runner.bindTool(tb.Name); err != nil {
+			if errors.Is(err, errToolNotConfigured) {
+				v.add(ValidationSeverityWarning, ValidationToolNotConfigured, id, "tool %q is not available in this deployment and will be skipped", tb.Name)
+			} else {
+				v.add(ValidationSeverityError, ValidationUnknownTool, id, "unknown tool %q", tb.Name)
+			}
+		}
+	}
+}
+
+// findValidateRoot 与 graph.Init 相同：无入边的节点中优先选择 start 节点
+func findValidateRoot(order []string, nodes map[string]validateNodeData, in map[string][]string) string {
+	var candidates []string
+	for _, id := range order {
+		if len(in[id]) == 0 {
+			candidates = append(candidates, id)
+		}
+	}
+	for _, id := range candidates {
+		if types.NodeType(nodes[id].Type).IsStartNode() {
+			return id
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// findCycleNodes 返回处于环上的节点（按 DSL 顺序）
+func findCycleNodes(order []string, out map[string][]types.EdgeConfig) []string {
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make(map[string]int, len(order))
+	onCycle := make(map[string]bool)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		color[id] = grey
+		stack = append(stack, id)
+		for _, ec := range out[id] {
+			switch color[ec.Target] {
+			case white:
+				visit(ec.Target)
+			case grey:
+				// 回边：栈中从 target 到当前节点的部分构成环
+				for i := len(stack) - 1; i >= 0; i-- {
+					onCycle[stack[i]] = true
+					if stack[i] == ec.Target {
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[id] = black
+	}
+	for _, id := range order {
+		if color[id] == white {
+			visit(id)
+		}
+	}
+
+	var result []string
+	for _, id := range order {
+		if onCycle[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func reachableFrom(rootID string, out map[string][]types.EdgeConfig) map[string]bool {
+	seen := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, ec := range out[id] {
+			if !seen[ec.Target] {
+				seen[ec.Target] = true
+				queue = append(queue, ec.Target)
+			}
+		}
+	}
+	return seen
+}
+
+func ancestorsOf(id string, in map[string][]string) map[string]bool {
+	seen := make(map[string]bool)
+	queue := append([]string(nil), in[id]...)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		queue = append(queue, in[cur]...)
+	}
+	return seen
+}
+
+func mergeSets(a, b map[string]bool) map[string]bool {
+	merged := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		merged[k] = true
+	}
+	for k := range b {
+		merged[k] = true
+	}
+	return merged
+}
+
+// collectReferences 收集节点配置中引用的节点 ID：
+// *_selector 字段、variables 中的选择器数组，以及字符串中的 {{#node_id.var#}} 模板引用（不含子图）
+func collectReferences(data json.RawMessage) []string {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var refs []string
+	addRef := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			refs = append(refs, id)
+		}
+	}
+	addSelector := func(v interface{}) bool {
+		arr, ok := v.([]interface{})
+		if !ok || len(arr) < 2 {
+			return false
+		}
+		for _, part := range arr {
+			if _, ok := part.(string); !ok {
+				return false
+			}
+		}
+		addRef(arr[0].(string))
+		return true
+	}
+
+	var walk func(key string, v interface{})
+	walk = func(key string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				if k == "subgraph" {
+					continue
+				}
+				walk(k, child)
+			}
+		case []interface{}:
+			if strings.HasSuffix(key, "selector") && addSelector(val) {
+				return
+			}
+			for _, item := range val {
+				if key == "variables" && addSelector(item) {
+					continue
+				}
+				walk(key, item)
+			}
+		case string:
+			for _, m := range templateRefPattern.FindAllStringSubmatch(val, -1) {
+				addRef(m[1])
+			}
+		}
+	}
+	walk("", root)
+	sort.Strings(refs)
+	return refs
+}
@@ -0,0 +1,187 @@
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook 签名请求头：签名内容为 "<timestamp>.<原始请求体>"，算法 HMAC-SHA256
+const (
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// DefaultWebhookTolerance 签名时间戳与服务器时间允许的最大偏差，超出视为重放
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var (
+	ErrWebhookSignatureMissing = errors.New("missing webhook signature or timestamp")
+	ErrWebhookTimestamp        = errors.New("webhook timestamp outside tolerance window")
+	ErrWebhookSignature        = errors.New("invalid webhook signature")
+)
+
+// GenerateWebhookSecret 生成 Webhook 签名密钥
+func GenerateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// SignWebhookPayload 计算签名头的值（sha256=<hex>）
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 校验签名与时间戳窗口；timestamp 为 Unix 秒
+func VerifyWebhookSignature(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	timestamp = strings.TrimSpace(timestamp)
+	signature = strings.ToLower(strings.TrimSpace(signature))
+	if timestamp == "" || signature == "" {
+		return ErrWebhookSignatureMissing
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookTimestamp
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > tolerance || skew < -tolerance {
+		return ErrWebhookTimestamp
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		signature = "sha256=" + signature
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignWebhookPayload(secret, timestamp, body))) {
+		return ErrWebhookSignature
+	}
+	return nil
+}
+
+// MapWebhookInputs 按 JSONPath 映射将 Webhook 请求体转为开始节点输入。
+// 映射为空时请求体须为 JSON 对象，直接作为输入；路径未命中的变量不写入输入，
+// 由开始节点的必填校验处理。
+func MapWebhookInputs(mapping map[string]string, payload interface{}) (map[string]interface{}, error) {
+	if len(mapping) == 0 {
+		obj, ok := payload.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("payload must be a JSON object when no input_mapping is configured")
+		}
+		return obj, nil
+	}
+	inputs := make(map[string]interface{}, len(mapping))
+	for name, path := range mapping {
+		value, ok, err := EvalJSONPath(path, payload)
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %w", name, err)
+		}
+		if ok {
+			inputs[name] = value
+		}
+	}
+	return inputs, nil
+}
+
+// jsonPathStep JSONPath 的一级访问：对象字段或数组下标
+type jsonPathStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// ValidateJSONPath 校验 JSONPath 语法（保存映射时使用）
+func ValidateJSONPath(path string) error {
+	_, err := parseJSONPath(path)
+	return err
+}
+
+// parseJSONPath 解析 JSONPath 子集：$、.field、['field']、[index]（负数从末尾计）
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	p := strings.TrimSpace(path)
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+	p = p[1:]
+	var steps []jsonPathStep
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSONPath %q has an empty field name", path)
+			}
+			steps = append(steps, jsonPathStep{key: p[:end]})
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unclosed bracket", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			if n := len(inner); n >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[n-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : n-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("JSONPath %q: unsupported selector [%s]", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: idx, isIdx: true})
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", path, p[0])
+		}
+	}
+	return steps, nil
+}
+
+// EvalJSONPath 在已解码的 JSON 值上求值；ok=false 表示路径未命中
+func EvalJSONPath(path string, doc interface{}) (value interface{}, ok bool, err error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	cur := doc
+	for _, step := range steps {
+		if step.isIdx {
+			arr, isArr := cur.([]interface{})
+			if !isArr {
+				return nil, false, nil
+			}
+			i := step.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, false, nil
+			}
+			cur = arr[i]
+			continue
+		}
+		obj, isObj := cur.(map[string]interface{})
+		if !isObj {
+			return nil, false, nil
+		}
+		if cur, ok = obj[step.key]; !ok {
+			return nil, false, nil
+		}
+	}
+	return cur, true, nil
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestRepository 在独立 schema 中按 schema.sql 建表；需设置 FLOWWEAVE_TEST_DATABASE_URL，未设置时跳过
+func openTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	dsn := os.Getenv("FLOWWEAVE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("FLOWWEAVE_TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	schema := fmt.Sprintf("flowweave_test_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS pgcrypto; CREATE SCHEMA `+schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`)
+		admin.Close()
+	})
+
+	// 连接参数中的 search_path 作为会话参数生效，连接池中的每个连接都使用测试 schema
+	scoped := dsn + " search_path=" + schema + ",public"
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		scoped = dsn + sep + "search_path=" + schema + ",public"
+	}
+	db, err := sql.Open("postgres", scoped)
+	if err != nil {
+		t.Fatalf("open scoped database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ddl, err := os.ReadFile("../../../migrations/postgres/schema.sql")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, string(ddl)); err != nil {
+		t.Fatalf("apply schema: %v", err)
+	}
+	return NewRepository(db)
+}
+
+func TestClaimNextQueuedRun_IgnoresSyncRuns(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	wf := &Workflow{Name: "claim", DSL: json.RawMessage(`{"nodes":[],"edges":[]}`)}
+	if err := repo.CreateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	// 同一租户（无 scope）正在执行一个同步运行（没有 worker）
+	if err := repo.CreateRun(ctx, &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusRunning}); err != nil {
+		t.Fatalf("create sync run: %v", err)
+	}
+	queued := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	if err := repo.CreateRun(ctx, queued); err != nil {
+		t.Fatalf("create queued run: %v", err)
+	}
+
+	opts := ClaimOptions{TenantMaxConcurrency: 1, LeaseDuration: time.Minute}
+	run, err := repo.ClaimNextQueuedRun(ctx, "worker-1", opts)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if run == nil || run.ID != queued.ID {
+		t.Fatalf("expected queued run to be claimed despite the running sync run, got %+v", run)
+	}
+
+	// 认领的异步运行占用配额
+	second := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	if err := repo.CreateRun(ctx, second); err != nil {
+		t.Fatalf("create second queued run: %v", err)
+	}
+	if run, err := repo.ClaimNextQueuedRun(ctx, "worker-2", opts); err != nil || run != nil {
+		t.Fatalf("expected tenant cap to block the second claim, got run=%+v err=%v", run, err)
+	}
+
+	stats, err := repo.ListQueueStats(ctx)
+	if err != nil {
+		t.Fatalf("queue stats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Running != 1 || stats[0].Queued != 1 {
+		t.Fatalf("expected one running async run and one queued, got %+v", stats)
+	}
+}
+
+func TestClaimNextQueuedRun_PausedRunHoldsSlot(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	wf := &Workflow{Name: "claim", DSL: json.RawMessage(`{"nodes":[],"edges":[]}`)}
+	if err := repo.CreateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	opts := ClaimOptions{TenantMaxConcurrency: 1, LeaseDuration: time.Minute}
+	first := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	if err := repo.CreateRun(ctx, first); err != nil {
+		t.Fatalf("create queued run: %v", err)
+	}
+	if run, err := repo.ClaimNextQueuedRun(ctx, "worker-1", opts); err != nil || run == nil {
+		t.Fatalf("claim first run: run=%+v err=%v", run, err)
+	}
+	// 手动暂停：引擎仍在 worker-1 上并持有租约
+	if _, err := repo.db.ExecContext(ctx, `UPDATE workflow_runs SET status = $1 WHERE id = $2`, RunStatusPaused, first.ID); err != nil {
+		t.Fatalf("pause run: %v", err)
+	}
+
+	second := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	if err := repo.CreateRun(ctx, second); err != nil {
+		t.Fatalf("create second queued run: %v", err)
+	}
+	if run, err := repo.ClaimNextQueuedRun(ctx, "worker-2", opts); err != nil || run != nil {
+		t.Fatalf("expected the manually paused run to hold the tenant slot, got run=%+v err=%v", run, err)
+	}
+	stats, err := repo.ListQueueStats(ctx)
+	if err != nil {
+		t.Fatalf("queue stats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Running != 1 || stats[0].Queued != 1 {
+		t.Fatalf("expected the paused run counted as running, got %+v", stats)
+	}
+
+	// 等待人工输入的运行挂起时清除租约，不再占用配额
+	if _, err := repo.db.ExecContext(ctx, `UPDATE workflow_runs SET lease_expires_at = NULL WHERE id = $1`, first.ID); err != nil {
+		t.Fatalf("suspend run: %v", err)
+	}
+	run, err := repo.ClaimNextQueuedRun(ctx, "worker-2", opts)
+	if err != nil || run == nil || run.ID != second.ID {
+		t.Fatalf("expected a run waiting for input not to hold the slot, got run=%+v err=%v", run, err)
+	}
+}
@@ -15,6 +15,15 @@ import (
 )
 
 type Workflow = port.Workflow
+type WorkflowVersion = port.WorkflowVersion
+type WorkflowSchedule = port.WorkflowSchedule
+type WorkflowWebhook = port.WorkflowWebhook
+type RunIdempotencyKey = port.RunIdempotencyKey
+type RunNotificationSubscription = port.RunNotificationSubscription
+type RunNotificationDelivery = port.RunNotificationDelivery
+type NotificationAttempt = port.NotificationAttempt
+type NotificationDeliveryStatus = port.NotificationDeliveryStatus
+type ListNotificationDeliveriesParams = port.ListNotificationDeliveriesParams
 type WorkflowStatus = port.WorkflowStatus
 type ListWorkflowsParams = port.ListWorkflowsParams
 type ListWorkflowsResult = port.ListWorkflowsResult
@@ -26,9 +35,16 @@ type Dataset = port.Dataset
 type Document = port.Document
 
 type WorkflowRun = port.WorkflowRun
+type ClaimOptions = port.ClaimOptions
+type TenantQueueStats = port.TenantQueueStats
+type RunAttempt = port.RunAttempt
 type RunStatus = port.RunStatus
+type ListRunsParams = port.ListRunsParams
+type ListRunsResult = port.ListRunsResult
 
 type NodeExecutionRecord = port.NodeExecutionRecord
+type RunCheckpoint = port.RunCheckpoint
+type PendingInput = port.PendingInput
 
 type LLMCallTrace = port.LLMCallTrace
 type ConversationTrace = port.ConversationTrace
@@ -38,9 +54,19 @@ type LLMTraceResponse = port.LLMTraceResponse
 type ExternalAsyncTask = port.ExternalAsyncTask
 
 const (
-	WorkflowStatusActive = port.WorkflowStatusActive
-	RunStatusQueued      = port.RunStatusQueued
-	RunStatusRunning     = port.RunStatusRunning
+	WorkflowStatusDraft     = port.WorkflowStatusDraft
+	WorkflowStatusPublished = port.WorkflowStatusPublished
+	RunStatusQueued         = port.RunStatusQueued
+	RunStatusRunning        = port.RunStatusRunning
+	RunStatusFailed         = port.RunStatusFailed
+	RunStatusPaused         = port.RunStatusPaused
+	RunStatusAborted        = port.RunStatusAborted
+	RunStatusCanceled       = port.RunStatusCanceled
+	RunStatusDeadLetter     = port.RunStatusDeadLetter
+
+	PendingInputStatusWaiting   = port.PendingInputStatusWaiting
+	PendingInputStatusSubmitted = port.PendingInputStatusSubmitted
+	PendingInputStatusTimeout   = port.PendingInputStatusTimeout
 )
 
 type Repository struct {
@@ -75,6 +101,8 @@ func (r *Repository) EnsureTenantTables(ctx context.Context) error {
 		UNIQUE (org_id, code),
 		UNIQUE (org_id, id)
 	);
+	ALTER TABLE tenants ADD COLUMN IF NOT EXISTS queue_weight INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE tenants ADD COLUMN IF NOT EXISTS max_concurrent_runs INTEGER NOT NULL DEFAULT 0;
 
 	CREATE TABLE IF NOT EXISTS conversations (
 		conversation_id VARCHAR(255) PRIMARY KEY,
@@ -143,7 +171,22 @@ func (r *Repository) EnsureRunColumns(ctx context.Context) error {
 		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS picked_at TIMESTAMP WITH TIME ZONE`,
 		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS worker_id VARCHAR(128) DEFAULT ''`,
 		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS exceptions_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS parent_run_id UUID REFERENCES workflow_runs(id) ON DELETE SET NULL`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS attempts JSONB`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS callback_url TEXT DEFAULT ''`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS callback_secret VARCHAR(128) DEFAULT ''`,
 		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_queued_pick ON workflow_runs(status, queued_at ASC, started_at ASC)`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_status ON workflow_runs(workflow_id, status, started_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_parent ON workflow_runs(parent_run_id) WHERE parent_run_id IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_dead_letter ON workflow_runs(started_at DESC) WHERE status = 'dead-letter'`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_fair_pick ON workflow_runs(org_id, tenant_id, priority DESC, queued_at ASC) WHERE status = 'queued'`,
+		`DROP INDEX IF EXISTS idx_workflow_runs_lease`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_lease_active ON workflow_runs(lease_expires_at) WHERE status IN ('running', 'paused')`,
 	}
 	for _, q := range queries {
 		if _, err := r.db.ExecContext(ctx, q); err != nil {
@@ -168,14 +211,35 @@ func (r *Repository) EnsureNodeExecTable(ctx context.Context) error {
 		metadata    JSONB,
 		elapsed_ms  BIGINT DEFAULT 0,
 		started_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		UNIQUE (run_id, node_id)
+		parent_node_id      VARCHAR(255) NOT NULL DEFAULT '',
+		parent_execution_id VARCHAR(64) NOT NULL DEFAULT '',
+		iteration_index     INTEGER,
+		loop_round          INTEGER
 	);
 	CREATE INDEX IF NOT EXISTS idx_node_exec_run ON node_executions(run_id);
 	CREATE INDEX IF NOT EXISTS idx_node_exec_type ON node_executions(node_type);
 	CREATE INDEX IF NOT EXISTS idx_node_exec_status ON node_executions(status);
 	`
-	_, err := r.db.ExecContext(ctx, ddl)
-	return err
+	if _, err := r.db.ExecContext(ctx, ddl); err != nil {
+		return err
+	}
+
+	// 迭代 / 循环子图内的节点每项各执行一次：唯一键加入 parent_execution_id（兼容旧表）
+	queries := []string{
+		`ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS parent_node_id VARCHAR(255) NOT NULL DEFAULT ''`,
+		`ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS parent_execution_id VARCHAR(64) NOT NULL DEFAULT ''`,
+		`ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS iteration_index INTEGER`,
+		`ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS loop_round INTEGER`,
+		`ALTER TABLE node_executions DROP CONSTRAINT IF EXISTS node_executions_run_id_node_id_key`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uq_node_exec_run_node_parent ON node_executions(run_id, node_id, parent_execution_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_node_exec_parent ON node_executions(run_id, parent_node_id) WHERE parent_node_id <> ''`,
+	}
+	for _, q := range queries {
+		if _, err := r.db.ExecContext(ctx, q); err != nil {
+			applog.Warn("[Storage] ALTER TABLE failed (may already exist)", "error", err)
+		}
+	}
+	return nil
 }
 
 // EnsureLLMTracesTable 确保 llm_call_traces 独立表存在
@@ -251,6 +315,207 @@ func (r *Repository) EnsureExternalAsyncTaskTable(ctx context.Context) error {
 	return err
 }
 
+// EnsureRunCheckpointTable 确保 run_checkpoints 检查点表存在
+func (r *Repository) EnsureRunCheckpointTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS run_checkpoints (
+		run_id          UUID PRIMARY KEY REFERENCES workflow_runs(id) ON DELETE CASCADE,
+		sequence        BIGINT NOT NULL DEFAULT 0,
+		state           JSONB,
+		variable_pool   JSONB,
+		completed_nodes JSONB NOT NULL DEFAULT '[]',
+		node_states     JSONB,
+		edge_states     JSONB,
+		node_executions JSONB,
+		updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_run_checkpoints_updated ON run_checkpoints(updated_at);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsureWorkflowVersionTable 确保 workflow_versions 版本历史表存在，并为已有工作流补齐当前版本
+func (r *Repository) EnsureWorkflowVersionTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS workflow_versions (
+		workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+		version     INTEGER NOT NULL,
+		dsl_json    JSONB NOT NULL,
+		created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (workflow_id, version)
+	);
+	INSERT INTO workflow_versions (workflow_id, version, dsl_json, created_at)
+	SELECT id, version, dsl_json, updated_at FROM workflows
+	ON CONFLICT (workflow_id, version) DO NOTHING;
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsureWorkflowPublishColumns 确保草稿 / 发布字段存在；旧版 active 状态的工作流视为已发布当前版本
+func (r *Repository) EnsureWorkflowPublishColumns(ctx context.Context) error {
+	queries := []string{
+		`ALTER TABLE workflows ADD COLUMN IF NOT EXISTS published_version INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE workflows ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE workflows ALTER COLUMN status SET DEFAULT 'draft'`,
+		`UPDATE workflows SET status = 'published', published_version = version, published_at = updated_at WHERE status = 'active'`,
+	}
+	for _, q := range queries {
+		if _, err := r.db.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureScheduleTable 确保 workflow_schedules 定时触发表存在
+func (r *Repository) EnsureScheduleTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS workflow_schedules (
+		id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		workflow_id    UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+		org_id         UUID,
+		tenant_id      UUID,
+		name           VARCHAR(255) DEFAULT '',
+		cron_expr      VARCHAR(128) NOT NULL,
+		timezone       VARCHAR(64) NOT NULL DEFAULT 'UTC',
+		inputs         JSONB,
+		enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+		misfire_policy VARCHAR(32) NOT NULL DEFAULT 'fire_once',
+		next_fire_at   TIMESTAMP WITH TIME ZONE,
+		last_fire_at   TIMESTAMP WITH TIME ZONE,
+		last_run_id    UUID REFERENCES workflow_runs(id) ON DELETE SET NULL,
+		last_error     TEXT DEFAULT '',
+		created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_workflow_schedules_due ON workflow_schedules(next_fire_at) WHERE enabled;
+	CREATE INDEX IF NOT EXISTS idx_workflow_schedules_workflow ON workflow_schedules(workflow_id);
+	CREATE INDEX IF NOT EXISTS idx_workflow_schedules_scope ON workflow_schedules(org_id, tenant_id);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsureWebhookTable 确保 workflow_webhooks 入站 Webhook 表存在
+func (r *Repository) EnsureWebhookTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS workflow_webhooks (
+		id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		workflow_id   UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+		org_id        UUID,
+		tenant_id     UUID,
+		name          VARCHAR(255) DEFAULT '',
+		secret        VARCHAR(128) NOT NULL,
+		input_mapping JSONB,
+		mode          VARCHAR(16) NOT NULL DEFAULT 'async',
+		enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_workflow ON workflow_webhooks(workflow_id);
+	CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_scope ON workflow_webhooks(org_id, tenant_id);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsureIdempotencyKeyTable 确保 run_idempotency_keys 运行幂等键表存在
+func (r *Repository) EnsureIdempotencyKeyTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS run_idempotency_keys (
+		idem_key     VARCHAR(255) NOT NULL,
+		org_id       UUID,
+		tenant_id    UUID,
+		endpoint     VARCHAR(16) NOT NULL,
+		workflow_id  UUID NOT NULL,
+		request_hash VARCHAR(64) NOT NULL,
+		run_id       UUID NOT NULL,
+		created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		expires_at   TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS uq_run_idempotency_keys_scope ON run_idempotency_keys((COALESCE(org_id::text, '')), (COALESCE(tenant_id::text, '')), idem_key);
+	CREATE INDEX IF NOT EXISTS idx_run_idempotency_keys_expires ON run_idempotency_keys(expires_at);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsureNotificationTables 确保运行完成通知的订阅表与投递记录表存在
+func (r *Repository) EnsureNotificationTables(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS run_notification_subscriptions (
+		id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		org_id     UUID,
+		tenant_id  UUID,
+		url        TEXT NOT NULL,
+		secret     VARCHAR(128) NOT NULL,
+		statuses   JSONB,
+		enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_run_notification_subscriptions_scope ON run_notification_subscriptions(org_id, tenant_id);
+
+	CREATE TABLE IF NOT EXISTS run_notification_deliveries (
+		id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		run_id          UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+		org_id          UUID,
+		tenant_id       UUID,
+		subscription_id UUID REFERENCES run_notification_subscriptions(id) ON DELETE SET NULL,
+		url             TEXT NOT NULL,
+		secret          VARCHAR(128) NOT NULL,
+		run_status      VARCHAR(32) NOT NULL,
+		payload         JSONB NOT NULL,
+		status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+		attempts        JSONB,
+		max_attempts    INTEGER NOT NULL DEFAULT 1,
+		next_attempt_at TIMESTAMP WITH TIME ZONE,
+		delivered_at    TIMESTAMP WITH TIME ZONE,
+		created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_run ON run_notification_deliveries(run_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_due ON run_notification_deliveries(next_attempt_at) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_scope ON run_notification_deliveries(org_id, tenant_id, status);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
+// EnsurePendingInputTable 确保 run_pending_inputs 人工输入表存在
+func (r *Repository) EnsurePendingInputTable(ctx context.Context) error {
+	ddl := `
+	CREATE TABLE IF NOT EXISTS run_pending_inputs (
+		id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		run_id         UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+		workflow_id    UUID,
+		node_id        VARCHAR(255) NOT NULL,
+		org_id         UUID,
+		tenant_id      UUID,
+		title          VARCHAR(255) DEFAULT '',
+		prompt         TEXT DEFAULT '',
+		fields         JSONB,
+		actions        JSONB,
+		assignee       VARCHAR(255) DEFAULT '',
+		timeout_branch VARCHAR(128) DEFAULT '',
+		status         VARCHAR(32) NOT NULL DEFAULT 'waiting',
+		action         VARCHAR(128) DEFAULT '',
+		response       JSONB,
+		responded_by   VARCHAR(255) DEFAULT '',
+		expires_at     TIMESTAMP WITH TIME ZONE,
+		created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		responded_at   TIMESTAMP WITH TIME ZONE,
+		UNIQUE (run_id, node_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_pending_inputs_status_expires ON run_pending_inputs(status, expires_at);
+	CREATE INDEX IF NOT EXISTS idx_pending_inputs_scope_assignee ON run_pending_inputs(org_id, tenant_id, assignee);
+	`
+	_, err := r.db.ExecContext(ctx, ddl)
+	return err
+}
+
 // --- 会话归属校验 ---
 
 // EnsureConversationOwnership 注册 + 校验会话归属（写请求）
@@ -306,16 +571,36 @@ func (r *Repository) CreateWorkflow(ctx context.Context, w *Workflow) error {
 	w.CreatedAt = now
 	w.UpdatedAt = now
 	if w.Status == "" {
-		w.Status = WorkflowStatusActive
+		w.Status = WorkflowStatusDraft
 	}
 	if w.Version == 0 {
 		w.Version = 1
 	}
 
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO workflows (id, org_id, tenant_id, name, description, dsl_json, version, status, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
-		w.ID, nullIfEmpty(w.OrgID), nullIfEmpty(w.TenantID), w.Name, w.Description, w.DSL, w.Version, w.Status, w.CreatedAt, w.UpdatedAt,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO workflows (id, org_id, tenant_id, name, description, dsl_json, version, published_version, published_at, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		w.ID, nullIfEmpty(w.OrgID), nullIfEmpty(w.TenantID), w.Name, w.Description, w.DSL, w.Version, w.PublishedVersion, w.PublishedAt, w.Status, w.CreatedAt, w.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	if err := insertWorkflowVersion(ctx, tx, w.ID, w.Version, w.DSL, w.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertWorkflowVersion 写入一条不可变的 DSL 版本快照
+func insertWorkflowVersion(ctx context.Context, tx *sql.Tx, workflowID string, version int, dsl []byte, createdAt time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO workflow_versions (workflow_id, version, dsl_json, created_at) VALUES ($1, $2, $3, $4)`,
+		workflowID, version, dsl, createdAt,
 	)
 	return err
 }
@@ -323,7 +608,7 @@ func (r *Repository) CreateWorkflow(ctx context.Context, w *Workflow) error {
 func (r *Repository) GetWorkflow(ctx context.Context, id string) (*Workflow, error) {
 	w := &Workflow{}
 	var orgID, tenantID sql.NullString
-	query := `SELECT id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), name, description, dsl_json, version, status, created_at, updated_at
+	query := `SELECT id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), name, description, dsl_json, version, published_version, published_at, status, created_at, updated_at
 		 FROM workflows WHERE id = $1`
 	args := []interface{}{id}
 	if scope := scopeFromContext(ctx); scope != nil {
@@ -331,7 +616,7 @@ func (r *Repository) GetWorkflow(ctx context.Context, id string) (*Workflow, err
 		args = append(args, scope.OrgID, scope.TenantID)
 	}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&w.ID, &orgID, &tenantID, &w.Name, &w.Description, &w.DSL, &w.Version, &w.Status, &w.CreatedAt, &w.UpdatedAt,
+		&w.ID, &orgID, &tenantID, &w.Name, &w.Description, &w.DSL, &w.Version, &w.PublishedVersion, &w.PublishedAt, &w.Status, &w.CreatedAt, &w.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -341,21 +626,59 @@ func (r *Repository) GetWorkflow(ctx context.Context, id string) (*Workflow, err
 	return w, err
 }
 
+// UpdateWorkflow 更新工作流；DSL 发生变化时分配新版本号并写入版本历史。
+// 新版本号取历史最大版本 + 1，回滚后再次保存不会覆盖已有版本。
 func (r *Repository) UpdateWorkflow(ctx context.Context, w *Workflow) error {
-	w.UpdatedAt = time.Now()
-	w.Version++
-	query := `UPDATE workflows SET name=$1, description=$2, dsl_json=$3, version=$4, status=$5, updated_at=$6
-		 WHERE id=$7`
-	args := []interface{}{w.Name, w.Description, w.DSL, w.Version, w.Status, w.UpdatedAt, w.ID}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 1. 锁定工作流行，判断 DSL 是否变化
+	query := `SELECT dsl_json = $2::jsonb FROM workflows WHERE id = $1`
+	args := []interface{}{w.ID, w.DSL}
 	if scope := scopeFromContext(ctx); scope != nil {
-		query += ` AND org_id=$8 AND tenant_id=$9`
+		query += ` AND org_id = $3 AND tenant_id = $4`
 		args = append(args, scope.OrgID, scope.TenantID)
 	} else {
-		query += ` AND ($8::uuid IS NULL OR org_id=$8) AND ($9::uuid IS NULL OR tenant_id=$9)`
+		query += ` AND ($3::uuid IS NULL OR org_id = $3) AND ($4::uuid IS NULL OR tenant_id = $4)`
 		args = append(args, nullIfEmpty(w.OrgID), nullIfEmpty(w.TenantID))
 	}
-	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+	var unchanged bool
+	err = tx.QueryRowContext(ctx, query+` FOR UPDATE`, args...).Scan(&unchanged)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	w.UpdatedAt = time.Now()
+
+	// 2. DSL 变化 → 新版本
+	if !unchanged {
+		var next int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT GREATEST(COALESCE((SELECT MAX(version) FROM workflow_versions WHERE workflow_id = $1), 0), version) + 1
+			 FROM workflows WHERE id = $1`,
+			w.ID,
+		).Scan(&next); err != nil {
+			return err
+		}
+		w.Version = next
+		if err := insertWorkflowVersion(ctx, tx, w.ID, w.Version, w.DSL, w.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE workflows SET name=$1, description=$2, dsl_json=$3, version=$4, status=$5, updated_at=$6 WHERE id=$7`,
+		w.Name, w.Description, w.DSL, w.Version, w.Status, w.UpdatedAt, w.ID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *Repository) DeleteWorkflow(ctx context.Context, id string) error {
@@ -417,7 +740,7 @@ func (r *Repository) ListWorkflows(ctx context.Context, params ListWorkflowsPara
 	// Query
 	offset := (params.Page - 1) * params.PageSize
 	query := fmt.Sprintf(
-		`SELECT id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), name, description, dsl_json, version, status, created_at, updated_at
+		`SELECT id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), name, description, dsl_json, version, published_version, published_at, status, created_at, updated_at
 		 FROM workflows %s ORDER BY updated_at DESC LIMIT $%d OFFSET $%d`,
 		whereClause, argIdx, argIdx+1,
 	)
@@ -433,7 +756,7 @@ func (r *Repository) ListWorkflows(ctx context.Context, params ListWorkflowsPara
 	for rows.Next() {
 		w := &Workflow{}
 		var orgID, tenantID sql.NullString
-		if err := rows.Scan(&w.ID, &orgID, &tenantID, &w.Name, &w.Description, &w.DSL, &w.Version, &w.Status, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		if err := rows.Scan(&w.ID, &orgID, &tenantID, &w.Name, &w.Description, &w.DSL, &w.Version, &w.PublishedVersion, &w.PublishedAt, &w.Status, &w.CreatedAt, &w.UpdatedAt); err != nil {
 			return nil, err
 		}
 		w.OrgID = orgID.String
@@ -449,6 +772,681 @@ func (r *Repository) ListWorkflows(ctx context.Context, params ListWorkflowsPara
 	}, nil
 }
 
+// --- WorkflowVersion ---
+
+// GetWorkflowVersion 获取指定版本的 DSL 快照（按所属工作流的 scope 隔离）
+func (r *Repository) GetWorkflowVersion(ctx context.Context, workflowID string, version int) (*WorkflowVersion, error) {
+	v := &WorkflowVersion{}
+	query := `SELECT v.workflow_id, v.version, v.dsl_json, v.created_at
+		 FROM workflow_versions v JOIN workflows w ON w.id = v.workflow_id
+		 WHERE v.workflow_id = $1 AND v.version = $2`
+	args := []interface{}{workflowID, version}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND w.org_id = $3 AND w.tenant_id = $4`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&v.WorkflowID, &v.Version, &v.DSL, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// ListWorkflowVersions 按版本号倒序列出版本历史（不含 DSL）
+func (r *Repository) ListWorkflowVersions(ctx context.Context, workflowID string) ([]*WorkflowVersion, error) {
+	query := `SELECT v.workflow_id, v.version, v.created_at
+		 FROM workflow_versions v JOIN workflows w ON w.id = v.workflow_id
+		 WHERE v.workflow_id = $1`
+	args := []interface{}{workflowID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND w.org_id = $2 AND w.tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` ORDER BY v.version DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*WorkflowVersion{}
+	for rows.Next() {
+		v := &WorkflowVersion{}
+		if err := rows.Scan(&v.WorkflowID, &v.Version, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// PublishWorkflowVersion 将指定版本设为线上版本（发布草稿或回滚到历史版本），不产生新版本。
+// 返回 false 表示工作流或版本不存在。
+func (r *Repository) PublishWorkflowVersion(ctx context.Context, workflowID string, version int) (bool, error) {
+	query := `UPDATE workflows w SET published_version = v.version, published_at = NOW(), status = $3, updated_at = NOW()
+		 FROM workflow_versions v
+		 WHERE w.id = $1 AND v.workflow_id = w.id AND v.version = $2`
+	args := []interface{}{workflowID, version, WorkflowStatusPublished}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND w.org_id = $4 AND w.tenant_id = $5`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// --- WorkflowSchedule ---
+
+const scheduleColumns = `id, workflow_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(name,''), cron_expr, timezone,
+	COALESCE(inputs,'{}'::jsonb), enabled, misfire_policy, next_fire_at, last_fire_at, COALESCE(last_run_id::text,''), COALESCE(last_error,''), created_at, updated_at`
+
+type scheduleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row scheduleScanner) (*WorkflowSchedule, error) {
+	s := &WorkflowSchedule{}
+	err := row.Scan(&s.ID, &s.WorkflowID, &s.OrgID, &s.TenantID, &s.Name, &s.CronExpr, &s.Timezone,
+		&s.Inputs, &s.Enabled, &s.MisfirePolicy, &s.NextFireAt, &s.LastFireAt, &s.LastRunID, &s.LastError, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+func (r *Repository) CreateSchedule(ctx context.Context, s *WorkflowSchedule) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO workflow_schedules (id, workflow_id, org_id, tenant_id, name, cron_expr, timezone, inputs, enabled, misfire_policy, next_fire_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		s.ID, s.WorkflowID, nullIfEmpty(s.OrgID), nullIfEmpty(s.TenantID), s.Name, s.CronExpr, s.Timezone, s.Inputs, s.Enabled, s.MisfirePolicy,
+		s.NextFireAt, s.CreatedAt, s.UpdatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetSchedule(ctx context.Context, id string) (*WorkflowSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM workflow_schedules WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *Repository) ListSchedules(ctx context.Context, workflowID string) ([]*WorkflowSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM workflow_schedules WHERE workflow_id = $1`
+	args := []interface{}{workflowID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` ORDER BY created_at`
+	return r.querySchedules(ctx, query, args...)
+}
+
+func (r *Repository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]*WorkflowSchedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*WorkflowSchedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *Repository) UpdateSchedule(ctx context.Context, s *WorkflowSchedule) error {
+	s.UpdatedAt = time.Now()
+	query := `UPDATE workflow_schedules SET name=$1, cron_expr=$2, timezone=$3, inputs=$4, enabled=$5, misfire_policy=$6, next_fire_at=$7, updated_at=$8
+		 WHERE id=$9`
+	args := []interface{}{s.Name, s.CronExpr, s.Timezone, s.Inputs, s.Enabled, s.MisfirePolicy, s.NextFireAt, s.UpdatedAt, s.ID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $10 AND tenant_id = $11`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *Repository) DeleteSchedule(ctx context.Context, id string) error {
+	query := `DELETE FROM workflow_schedules WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ListDueSchedules 列出已到触发时间的启用调度（调度器使用，跨租户）
+func (r *Repository) ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]*WorkflowSchedule, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `SELECT ` + scheduleColumns + ` FROM workflow_schedules
+		 WHERE enabled AND next_fire_at IS NOT NULL AND next_fire_at <= $1
+		 ORDER BY next_fire_at LIMIT $2`
+	return r.querySchedules(ctx, query, now, limit)
+}
+
+// AdvanceSchedule 以 CAS 方式将 next_fire_at 从 expected 推进到 next。
+// 多个副本同时处理同一触发时间时只有一个返回 true，由它负责创建运行。
+// firedAt 为空表示本次触发被跳过（misfire skip），不更新 last_fire_at。
+func (r *Repository) AdvanceSchedule(ctx context.Context, id string, expected, next time.Time, firedAt *time.Time) (bool, error) {
+	var nextAt interface{}
+	if !next.IsZero() {
+		nextAt = next
+	}
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_schedules
+		 SET next_fire_at = $3, last_fire_at = COALESCE($4, last_fire_at), updated_at = NOW()
+		 WHERE id = $1 AND enabled AND next_fire_at = $2`,
+		id, expected, nextAt, firedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RecordScheduleRun 记录最近一次触发创建的运行与错误
+func (r *Repository) RecordScheduleRun(ctx context.Context, id, runID, lastError string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_schedules SET last_run_id = COALESCE($2::uuid, last_run_id), last_error = $3, updated_at = NOW() WHERE id = $1`,
+		id, nullIfEmpty(runID), lastError,
+	)
+	return err
+}
+
+// --- WorkflowWebhook ---
+
+const webhookColumns = `id, workflow_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(name,''), secret,
+	COALESCE(input_mapping,'{}'::jsonb), mode, enabled, created_at, updated_at`
+
+func scanWebhook(row scheduleScanner) (*WorkflowWebhook, error) {
+	hook := &WorkflowWebhook{}
+	var mapping []byte
+	err := row.Scan(&hook.ID, &hook.WorkflowID, &hook.OrgID, &hook.TenantID, &hook.Name, &hook.Secret,
+		&mapping, &hook.Mode, &hook.Enabled, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapping) > 0 {
+		if err := json.Unmarshal(mapping, &hook.InputMapping); err != nil {
+			return nil, err
+		}
+	}
+	return hook, nil
+}
+
+func (r *Repository) CreateWebhook(ctx context.Context, hook *WorkflowWebhook) error {
+	if hook.ID == "" {
+		hook.ID = uuid.New().String()
+	}
+	now := time.Now()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	mapping, err := json.Marshal(hook.InputMapping)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO workflow_webhooks (id, workflow_id, org_id, tenant_id, name, secret, input_mapping, mode, enabled, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		hook.ID, hook.WorkflowID, nullIfEmpty(hook.OrgID), nullIfEmpty(hook.TenantID), hook.Name, hook.Secret, mapping, hook.Mode, hook.Enabled,
+		hook.CreatedAt, hook.UpdatedAt,
+	)
+	return err
+}
+
+// GetWebhook 获取 Webhook（含 secret）；无 scope 时不做租户过滤，供公开触发路由按 ID 查询
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*WorkflowWebhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM workflow_webhooks WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	hook, err := scanWebhook(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context, workflowID string) ([]*WorkflowWebhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM workflow_webhooks WHERE workflow_id = $1`
+	args := []interface{}{workflowID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*WorkflowWebhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+func (r *Repository) UpdateWebhook(ctx context.Context, hook *WorkflowWebhook) error {
+	hook.UpdatedAt = time.Now()
+	mapping, err := json.Marshal(hook.InputMapping)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE workflow_webhooks SET name=$1, secret=$2, input_mapping=$3, mode=$4, enabled=$5, updated_at=$6
+		 WHERE id=$7`
+	args := []interface{}{hook.Name, hook.Secret, mapping, hook.Mode, hook.Enabled, hook.UpdatedAt, hook.ID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $8 AND tenant_id = $9`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	query := `DELETE FROM workflow_webhooks WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// --- RunIdempotencyKey ---
+
+// ReserveIdempotencyKey 以 key.RunID 占用幂等键（租户取自 scope）。占用成功返回 nil；
+// 键已被未过期的请求占用时返回该记录，由调用方比对请求指纹。已过期的键直接被覆盖
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, key *RunIdempotencyKey) (*RunIdempotencyKey, error) {
+	var orgID, tenantID string
+	if scope := scopeFromContext(ctx); scope != nil {
+		orgID, tenantID = scope.OrgID, scope.TenantID
+	}
+	// 原请求失败释放键后可能查不到记录，重试一次占用
+	for i := 0; i < 2; i++ {
+		var runID string
+		err := r.db.QueryRowContext(ctx,
+			`INSERT INTO run_idempotency_keys (idem_key, org_id, tenant_id, endpoint, workflow_id, request_hash, run_id, created_at, expires_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), $8)
+			 ON CONFLICT ((COALESCE(org_id::text, '')), (COALESCE(tenant_id::text, '')), idem_key) DO UPDATE
+			 SET endpoint = EXCLUDED.endpoint, workflow_id = EXCLUDED.workflow_id, request_hash = EXCLUDED.request_hash,
+			     run_id = EXCLUDED.run_id, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			 WHERE run_idempotency_keys.expires_at <= NOW()
+			 RETURNING run_id`,
+			key.Key, nullIfEmpty(orgID), nullIfEmpty(tenantID), key.Endpoint, key.WorkflowID, key.RequestHash, key.RunID, key.ExpiresAt,
+		).Scan(&runID)
+		if err == nil {
+			return nil, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		existing := &RunIdempotencyKey{}
+		err = r.db.QueryRowContext(ctx,
+			`SELECT idem_key, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), endpoint, workflow_id, request_hash, run_id, created_at, expires_at
+			 FROM run_idempotency_keys
+			 WHERE COALESCE(org_id::text, '') = $1 AND COALESCE(tenant_id::text, '') = $2 AND idem_key = $3`,
+			orgID, tenantID, key.Key,
+		).Scan(&existing.Key, &existing.OrgID, &existing.TenantID, &existing.Endpoint, &existing.WorkflowID,
+			&existing.RequestHash, &existing.RunID, &existing.CreatedAt, &existing.ExpiresAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	return nil, fmt.Errorf("idempotency key %q is contended", key.Key)
+}
+
+// DeleteIdempotencyKey 释放幂等键（运行创建失败时调用，允许客户端用同一 key 重试）
+func (r *Repository) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	var orgID, tenantID string
+	if scope := scopeFromContext(ctx); scope != nil {
+		orgID, tenantID = scope.OrgID, scope.TenantID
+	}
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM run_idempotency_keys WHERE COALESCE(org_id::text, '') = $1 AND COALESCE(tenant_id::text, '') = $2 AND idem_key = $3`,
+		orgID, tenantID, key,
+	)
+	return err
+}
+
+// PurgeExpiredIdempotencyKeys 清理已过期的幂等键
+func (r *Repository) PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM run_idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// --- RunNotification ---
+
+const notificationSubscriptionColumns = `id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), url, secret,
+	COALESCE(statuses,'[]'::jsonb), enabled, created_at, updated_at`
+
+func scanNotificationSubscription(row scheduleScanner) (*RunNotificationSubscription, error) {
+	sub := &RunNotificationSubscription{}
+	var statuses []byte
+	err := row.Scan(&sub.ID, &sub.OrgID, &sub.TenantID, &sub.URL, &sub.Secret, &statuses, &sub.Enabled, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		if err := json.Unmarshal(statuses, &sub.Statuses); err != nil {
+			return nil, err
+		}
+	}
+	if len(sub.Statuses) == 0 {
+		sub.Statuses = nil
+	}
+	return sub, nil
+}
+
+func (r *Repository) CreateNotificationSubscription(ctx context.Context, sub *RunNotificationSubscription) error {
+	if sub.ID == "" {
+		sub.ID = uuid.New().String()
+	}
+	now := time.Now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	statuses, err := json.Marshal(sub.Statuses)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO run_notification_subscriptions (id, org_id, tenant_id, url, secret, statuses, enabled, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		sub.ID, nullIfEmpty(sub.OrgID), nullIfEmpty(sub.TenantID), sub.URL, sub.Secret, statuses, sub.Enabled, sub.CreatedAt, sub.UpdatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetNotificationSubscription(ctx context.Context, id string) (*RunNotificationSubscription, error) {
+	query := `SELECT ` + notificationSubscriptionColumns + ` FROM run_notification_subscriptions WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	sub, err := scanNotificationSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *Repository) ListNotificationSubscriptions(ctx context.Context) ([]*RunNotificationSubscription, error) {
+	query := `SELECT ` + notificationSubscriptionColumns + ` FROM run_notification_subscriptions`
+	var args []interface{}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` WHERE org_id = $1 AND tenant_id = $2`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` ORDER BY created_at`
+	return r.queryNotificationSubscriptions(ctx, query, args...)
+}
+
+// ListActiveNotificationSubscriptions 列出运行所属租户已启用的订阅（精确匹配，未分租户的运行只匹配未分租户的订阅）
+func (r *Repository) ListActiveNotificationSubscriptions(ctx context.Context, orgID, tenantID string) ([]*RunNotificationSubscription, error) {
+	return r.queryNotificationSubscriptions(ctx,
+		`SELECT `+notificationSubscriptionColumns+` FROM run_notification_subscriptions
+		 WHERE enabled AND COALESCE(org_id::text, '') = $1 AND COALESCE(tenant_id::text, '') = $2
+		 ORDER BY created_at`,
+		orgID, tenantID,
+	)
+}
+
+func (r *Repository) queryNotificationSubscriptions(ctx context.Context, query string, args ...interface{}) ([]*RunNotificationSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*RunNotificationSubscription{}
+	for rows.Next() {
+		sub, err := scanNotificationSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *Repository) UpdateNotificationSubscription(ctx context.Context, sub *RunNotificationSubscription) error {
+	sub.UpdatedAt = time.Now()
+	statuses, err := json.Marshal(sub.Statuses)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE run_notification_subscriptions SET url=$1, secret=$2, statuses=$3, enabled=$4, updated_at=$5
+		 WHERE id=$6`
+	args := []interface{}{sub.URL, sub.Secret, statuses, sub.Enabled, sub.UpdatedAt, sub.ID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $7 AND tenant_id = $8`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *Repository) DeleteNotificationSubscription(ctx context.Context, id string) error {
+	query := `DELETE FROM run_notification_subscriptions WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+const notificationDeliveryColumns = `id, run_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(subscription_id::text,''),
+	url, secret, run_status, payload, status, COALESCE(attempts,'[]'::jsonb), max_attempts, next_attempt_at, delivered_at, created_at, updated_at`
+
+func scanNotificationDelivery(row scheduleScanner) (*RunNotificationDelivery, error) {
+	d := &RunNotificationDelivery{}
+	var attempts []byte
+	err := row.Scan(&d.ID, &d.RunID, &d.OrgID, &d.TenantID, &d.SubscriptionID, &d.URL, &d.Secret, &d.RunStatus, &d.Payload,
+		&d.Status, &attempts, &d.MaxAttempts, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) > 0 {
+		if err := json.Unmarshal(attempts, &d.Attempts); err != nil {
+			return nil, fmt.Errorf("decode notification attempts: %w", err)
+		}
+	}
+	if len(d.Attempts) == 0 {
+		d.Attempts = nil
+	}
+	return d, nil
+}
+
+func (r *Repository) CreateNotificationDelivery(ctx context.Context, d *RunNotificationDelivery) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	now := time.Now()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	if d.Status == "" {
+		d.Status = port.NotificationDeliveryPending
+	}
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO run_notification_deliveries (id, run_id, org_id, tenant_id, subscription_id, url, secret, run_status, payload, status, max_attempts, next_attempt_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		d.ID, d.RunID, nullIfEmpty(d.OrgID), nullIfEmpty(d.TenantID), nullIfEmpty(d.SubscriptionID), d.URL, d.Secret, d.RunStatus, []byte(d.Payload),
+		d.Status, d.MaxAttempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetNotificationDelivery(ctx context.Context, id string) (*RunNotificationDelivery, error) {
+	query := `SELECT ` + notificationDeliveryColumns + ` FROM run_notification_deliveries WHERE id = $1`
+	args := []interface{}{id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	d, err := scanNotificationDelivery(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListNotificationDeliveries 按运行或投递状态查询投递记录，最新的在前
+func (r *Repository) ListNotificationDeliveries(ctx context.Context, params ListNotificationDeliveriesParams) ([]*RunNotificationDelivery, error) {
+	query := `SELECT ` + notificationDeliveryColumns + ` FROM run_notification_deliveries WHERE 1=1`
+	var args []interface{}
+	if params.RunID != "" {
+		args = append(args, params.RunID)
+		query += fmt.Sprintf(` AND run_id = $%d`, len(args))
+	}
+	if params.Status != "" {
+		args = append(args, params.Status)
+		query += fmt.Sprintf(` AND status = $%d`, len(args))
+	}
+	if scope := scopeFromContext(ctx); scope != nil {
+		args = append(args, scope.OrgID, scope.TenantID)
+		query += fmt.Sprintf(` AND org_id = $%d AND tenant_id = $%d`, len(args)-1, len(args))
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*RunNotificationDelivery{}
+	for rows.Next() {
+		d, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDueNotificationDeliveries 认领到期的待投递记录：next_attempt_at 顺延 lock，
+// 结果写回前其他实例不会重复认领；实例崩溃时 lock 到期后自动重新投递
+func (r *Repository) ClaimDueNotificationDeliveries(ctx context.Context, limit int, lock time.Duration) ([]*RunNotificationDelivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE run_notification_deliveries SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 WHERE id IN (
+			SELECT id FROM run_notification_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+notificationDeliveryColumns,
+		limit, lock.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*RunNotificationDelivery
+	for rows.Next() {
+		d, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordNotificationAttempt 追加一次投递尝试并更新投递状态；nextAttemptAt 为空表示不再自动重试
+func (r *Repository) RecordNotificationAttempt(ctx context.Context, id string, attempt NotificationAttempt, status NotificationDeliveryStatus, nextAttemptAt *time.Time) error {
+	attemptJSON, err := json.Marshal([]NotificationAttempt{attempt})
+	if err != nil {
+		return fmt.Errorf("marshal notification attempt: %w", err)
+	}
+	query := `UPDATE run_notification_deliveries
+		 SET attempts = COALESCE(attempts, '[]'::jsonb) || $1::jsonb, status = $2, next_attempt_at = $3,
+		     delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END, updated_at = NOW()
+		 WHERE id = $4`
+	args := []interface{}{attemptJSON, status, nextAttemptAt, id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $5 AND tenant_id = $6`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
 // --- WorkflowRun CRUD ---
 
 func (r *Repository) CreateRun(ctx context.Context, run *WorkflowRun) error {
@@ -475,10 +1473,11 @@ func (r *Repository) CreateRun(ctx context.Context, run *WorkflowRun) error {
 	}
 
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO workflow_runs (id, workflow_id, org_id, tenant_id, conversation_id, status, inputs, outputs, error, total_tokens, total_steps, elapsed_ms, started_at, finished_at, queued_at, picked_at, worker_id, retry_count)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+		`INSERT INTO workflow_runs (id, workflow_id, org_id, tenant_id, conversation_id, status, inputs, outputs, error, total_tokens, total_steps, elapsed_ms, started_at, finished_at, queued_at, picked_at, worker_id, retry_count, exceptions_count, parent_run_id, workflow_version, priority, callback_url, callback_secret)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)`,
 		run.ID, run.WorkflowID, nullIfEmpty(run.OrgID), nullIfEmpty(run.TenantID), run.ConversationID, run.Status, run.Inputs, run.Outputs, run.Error,
-		run.TotalTokens, run.TotalSteps, run.ElapsedMs, run.StartedAt, run.FinishedAt, queuedAt, pickedAt, workerID, run.RetryCount,
+		run.TotalTokens, run.TotalSteps, run.ElapsedMs, run.StartedAt, run.FinishedAt, queuedAt, pickedAt, workerID, run.RetryCount, run.ExceptionsCount, nullIfEmpty(run.ParentRunID), run.WorkflowVersion, run.Priority,
+		run.CallbackURL, run.CallbackSecret,
 	)
 	return err
 }
@@ -486,7 +1485,9 @@ func (r *Repository) CreateRun(ctx context.Context, run *WorkflowRun) error {
 func (r *Repository) GetRun(ctx context.Context, id string) (*WorkflowRun, error) {
 	run := &WorkflowRun{}
 	var orgID, tenantID sql.NullString
-	query := `SELECT id, workflow_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(conversation_id,''), status, COALESCE(worker_id,''), retry_count, COALESCE(inputs,'{}'::jsonb), COALESCE(outputs,'{}'::jsonb), COALESCE(error,''), total_tokens, total_steps, elapsed_ms, queued_at, picked_at, started_at, finished_at
+	var attemptsJSON []byte
+	query := `SELECT id, workflow_id, workflow_version, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(parent_run_id::text,''), COALESCE(conversation_id,''), status, COALESCE(worker_id,''), retry_count, priority, COALESCE(inputs,'{}'::jsonb), COALESCE(outputs,'{}'::jsonb), COALESCE(error,''), exceptions_count, total_tokens, total_steps, elapsed_ms, queued_at, picked_at, next_attempt_at, lease_expires_at, started_at, finished_at, COALESCE(attempts,'[]'::jsonb),
+		        COALESCE(callback_url,''), COALESCE(callback_secret,'')
 		 FROM workflow_runs WHERE id = $1`
 	args := []interface{}{id}
 	if scope := scopeFromContext(ctx); scope != nil {
@@ -494,58 +1495,185 @@ func (r *Repository) GetRun(ctx context.Context, id string) (*WorkflowRun, error
 		args = append(args, scope.OrgID, scope.TenantID)
 	}
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&run.ID, &run.WorkflowID, &orgID, &tenantID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount, &run.Inputs, &run.Outputs, &run.Error,
-		&run.TotalTokens, &run.TotalSteps, &run.ElapsedMs, &run.QueuedAt, &run.PickedAt, &run.StartedAt, &run.FinishedAt,
+		&run.ID, &run.WorkflowID, &run.WorkflowVersion, &orgID, &tenantID, &run.ParentRunID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount, &run.Priority, &run.Inputs, &run.Outputs, &run.Error,
+		&run.ExceptionsCount, &run.TotalTokens, &run.TotalSteps, &run.ElapsedMs, &run.QueuedAt, &run.PickedAt, &run.NextAttemptAt, &run.LeaseExpiresAt, &run.StartedAt, &run.FinishedAt, &attemptsJSON,
+		&run.CallbackURL, &run.CallbackSecret,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 	run.OrgID = orgID.String
 	run.TenantID = tenantID.String
-	return run, err
+	return run, decodeRunAttempts(run, attemptsJSON)
+}
+
+// decodeRunAttempts 解析 attempts JSONB 列
+func decodeRunAttempts(run *WorkflowRun, raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &run.Attempts); err != nil {
+		return fmt.Errorf("decode run attempts: %w", err)
+	}
+	if len(run.Attempts) == 0 {
+		run.Attempts = nil
+	}
+	return nil
 }
 
 func (r *Repository) UpdateRun(ctx context.Context, run *WorkflowRun) error {
-	query := `UPDATE workflow_runs SET status=$1, outputs=$2, error=$3, total_tokens=$4, total_steps=$5, elapsed_ms=$6, finished_at=$7, conversation_id=$8
-		 WHERE id=$9`
-	args := []interface{}{run.Status, run.Outputs, run.Error, run.TotalTokens, run.TotalSteps, run.ElapsedMs, run.FinishedAt, run.ConversationID, run.ID}
+	// 运行结束或挂起等待人工输入后不再有 worker 持有租约
+	query := `UPDATE workflow_runs SET status=$1, outputs=$2, error=$3, total_tokens=$4, total_steps=$5, elapsed_ms=$6, finished_at=$7, conversation_id=$8, exceptions_count=$9,
+		 lease_expires_at = CASE WHEN $1 = 'running' THEN lease_expires_at ELSE NULL END
+		 WHERE id=$10`
+	args := []interface{}{run.Status, run.Outputs, run.Error, run.TotalTokens, run.TotalSteps, run.ElapsedMs, run.FinishedAt, run.ConversationID, run.ExceptionsCount, run.ID}
 	if scope := scopeFromContext(ctx); scope != nil {
-		query += ` AND org_id = $10 AND tenant_id = $11`
+		query += ` AND org_id = $11 AND tenant_id = $12`
 		args = append(args, scope.OrgID, scope.TenantID)
 	}
 	_, err := r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (r *Repository) ClaimNextQueuedRun(ctx context.Context, workerID string) (*WorkflowRun, error) {
+// claimCandidateLimit 每次认领时参与排序的租户队首数量
+const claimCandidateLimit = 16
+
+// runHoldsWorkerSlot 占用租户并发配额的异步运行：worker 执行中或手动暂停（引擎仍在 worker 上并续约）；
+// 等待人工输入的运行挂起时已清除租约，不占用配额
+const runHoldsWorkerSlot = `COALESCE(worker_id, '') <> '' AND (
+	status = 'running' OR (status = 'paused' AND lease_expires_at IS NOT NULL))`
+
+// ClaimNextQueuedRun 按租户加权公平调度认领一个排队运行：
+// 每个 (org_id, tenant_id) 取优先级最高、最早入队的运行作为队首，按 执行中数量/权重 升序挑选租户，
+// 同等份额时优先级高、入队早者优先；达到并发上限的租户跳过。执行中数量只统计 worker 认领的异步运行（含手动暂停、引擎仍在 worker 上的运行），
+// 同步 / 流式运行和等待人工输入的运行不占用配额。
+// 有上限的租户在事务级 advisory lock 下重新计数，多个 worker 并发认领时上限仍然严格生效。
+func (r *Repository) ClaimNextQueuedRun(ctx context.Context, workerID string, opts ClaimOptions) (*WorkflowRun, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	type claimCandidate struct {
+		id, orgID, tenantID string
+		limit               int
+	}
+	rows, err := tx.QueryContext(ctx, `
+	WITH heads AS (
+		SELECT DISTINCT ON (org_id, tenant_id) id, org_id, tenant_id, priority, queued_at, started_at
+		FROM workflow_runs
+		WHERE status = $1
+		  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+		ORDER BY org_id, tenant_id, priority DESC, queued_at ASC NULLS LAST, started_at ASC, id ASC
+	),
+	running AS (
+		SELECT org_id, tenant_id, COUNT(*) AS n
+		FROM workflow_runs
+		WHERE `+runHoldsWorkerSlot+`
+		GROUP BY org_id, tenant_id
+	),
+	ranked AS (
+		SELECT h.id, h.org_id, h.tenant_id, h.priority, h.queued_at, h.started_at,
+		       COALESCE(rn.n, 0) AS running,
+		       GREATEST(COALESCE(t.queue_weight, 1), 1) AS weight,
+		       COALESCE(NULLIF(t.max_concurrent_runs, 0), $2) AS max_running
+		FROM heads h
+		LEFT JOIN running rn ON rn.org_id IS NOT DISTINCT FROM h.org_id AND rn.tenant_id IS NOT DISTINCT FROM h.tenant_id
+		LEFT JOIN tenants t ON t.id = h.tenant_id
+	)
+	SELECT id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), max_running
+	FROM ranked
+	WHERE max_running <= 0 OR running < max_running
+	ORDER BY running::float8 / weight ASC, priority DESC, queued_at ASC NULLS LAST, started_at ASC, id ASC
+	LIMIT $3`,
+		RunStatusQueued, opts.TenantMaxConcurrency, claimCandidateLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []claimCandidate
+	for rows.Next() {
+		var c claimCandidate
+		if err := rows.Scan(&c.id, &c.orgID, &c.tenantID, &c.limit); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range candidates {
+		if c.limit > 0 {
+			var locked bool
+			if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1))`,
+				"flowweave:run-claim:"+c.orgID+"/"+c.tenantID).Scan(&locked); err != nil {
+				return nil, err
+			}
+			if !locked {
+				continue
+			}
+			var running int
+			if err := tx.QueryRowContext(ctx,
+				`SELECT COUNT(*) FROM workflow_runs WHERE `+runHoldsWorkerSlot+` AND org_id IS NOT DISTINCT FROM $1 AND tenant_id IS NOT DISTINCT FROM $2`,
+				nullIfEmpty(c.orgID), nullIfEmpty(c.tenantID)).Scan(&running); err != nil {
+				return nil, err
+			}
+			if running >= c.limit {
+				continue
+			}
+		}
+
+		run, err := claimQueuedRun(ctx, tx, c.id, workerID, opts.LeaseDuration)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			continue // 已被其他 worker 认领
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return run, nil
+	}
+	return nil, nil
+}
+
+// claimQueuedRun 将指定排队运行标记为 running 并授予 worker 租约；已被其他 worker 锁定或认领时返回 nil
+func claimQueuedRun(ctx context.Context, tx *sql.Tx, id, workerID string, lease time.Duration) (*WorkflowRun, error) {
 	run := &WorkflowRun{}
 	var orgID, tenantID sql.NullString
-	var inputsJSON, outputsJSON []byte
+	var inputsJSON, outputsJSON, attemptsJSON []byte
 	query := `
 	WITH picked AS (
 		SELECT id
 		FROM workflow_runs
-		WHERE status = $1
-		ORDER BY queued_at ASC NULLS LAST, started_at ASC, id ASC
+		WHERE id = $4 AND status = $1
 		FOR UPDATE SKIP LOCKED
-		LIMIT 1
 	)
 	UPDATE workflow_runs wr
 	SET status = $2,
 		picked_at = NOW(),
 		started_at = NOW(),
-		worker_id = $3
+		worker_id = $3,
+		lease_expires_at = NOW() + make_interval(secs => $5)
 	FROM picked
 	WHERE wr.id = picked.id
-	RETURNING wr.id, wr.workflow_id, COALESCE(wr.org_id::text,''), COALESCE(wr.tenant_id::text,''),
-	          COALESCE(wr.conversation_id,''), wr.status, COALESCE(wr.worker_id,''), wr.retry_count,
-	          COALESCE(wr.inputs,'{}'::jsonb), COALESCE(wr.outputs,'{}'::jsonb), COALESCE(wr.error,''), wr.total_tokens, wr.total_steps, wr.elapsed_ms,
-	          wr.queued_at, wr.picked_at, wr.started_at, wr.finished_at`
-
-	err := r.db.QueryRowContext(ctx, query, RunStatusQueued, RunStatusRunning, workerID).Scan(
-		&run.ID, &run.WorkflowID, &orgID, &tenantID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount,
-		&inputsJSON, &outputsJSON, &run.Error, &run.TotalTokens, &run.TotalSteps, &run.ElapsedMs,
-		&run.QueuedAt, &run.PickedAt, &run.StartedAt, &run.FinishedAt,
+	RETURNING wr.id, wr.workflow_id, wr.workflow_version, COALESCE(wr.org_id::text,''), COALESCE(wr.tenant_id::text,''), COALESCE(wr.parent_run_id::text,''),
+	          COALESCE(wr.conversation_id,''), wr.status, COALESCE(wr.worker_id,''), wr.retry_count, wr.priority,
+	          COALESCE(wr.inputs,'{}'::jsonb), COALESCE(wr.outputs,'{}'::jsonb), COALESCE(wr.error,''), wr.exceptions_count, wr.total_tokens, wr.total_steps, wr.elapsed_ms,
+	          wr.queued_at, wr.picked_at, wr.next_attempt_at, wr.lease_expires_at, wr.started_at, wr.finished_at, COALESCE(wr.attempts,'[]'::jsonb)`
+
+	err := tx.QueryRowContext(ctx, query, RunStatusQueued, RunStatusRunning, workerID, id, lease.Seconds()).Scan(
+		&run.ID, &run.WorkflowID, &run.WorkflowVersion, &orgID, &tenantID, &run.ParentRunID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount, &run.Priority,
+		&inputsJSON, &outputsJSON, &run.Error, &run.ExceptionsCount, &run.TotalTokens, &run.TotalSteps, &run.ElapsedMs,
+		&run.QueuedAt, &run.PickedAt, &run.NextAttemptAt, &run.LeaseExpiresAt, &run.StartedAt, &run.FinishedAt, &attemptsJSON,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -561,25 +1689,63 @@ func (r *Repository) ClaimNextQueuedRun(ctx context.Context, workerID string) (*
 	if len(outputsJSON) > 0 {
 		run.Outputs = append(run.Outputs[:0], outputsJSON...)
 	}
-	return run, nil
+	return run, decodeRunAttempts(run, attemptsJSON)
+}
+
+// ListQueueStats 按租户统计异步队列深度（有 scope 时仅返回当前租户）
+func (r *Repository) ListQueueStats(ctx context.Context) ([]*TenantQueueStats, error) {
+	query := `SELECT COALESCE(wr.org_id::text,''), COALESCE(wr.tenant_id::text,''),
+		       COUNT(*) FILTER (WHERE wr.status = $1 AND (wr.next_attempt_at IS NULL OR wr.next_attempt_at <= NOW())),
+		       COUNT(*) FILTER (WHERE wr.status = $1 AND wr.next_attempt_at > NOW()),
+		       COUNT(*) FILTER (WHERE COALESCE(wr.worker_id, '') <> '' AND (wr.status = $2 OR (wr.status = $3 AND wr.lease_expires_at IS NOT NULL))),
+		       MIN(wr.queued_at) FILTER (WHERE wr.status = $1),
+		       GREATEST(COALESCE(MAX(t.queue_weight), 1), 1),
+		       COALESCE(MAX(t.max_concurrent_runs), 0)
+		 FROM workflow_runs wr
+		 LEFT JOIN tenants t ON t.id = wr.tenant_id
+		 WHERE wr.status IN ($1, $2, $3)`
+	args := []interface{}{RunStatusQueued, RunStatusRunning, RunStatusPaused}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND wr.org_id = $4 AND wr.tenant_id = $5`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` GROUP BY wr.org_id, wr.tenant_id ORDER BY 3 DESC, 5 DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*TenantQueueStats{}
+	for rows.Next() {
+		st := &TenantQueueStats{}
+		if err := rows.Scan(&st.OrgID, &st.TenantID, &st.Queued, &st.Delayed, &st.Running, &st.OldestQueuedAt, &st.QueueWeight, &st.MaxConcurrentRuns); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
 }
 
-func (r *Repository) ListRuns(ctx context.Context, workflowID string, page, pageSize int) ([]*WorkflowRun, error) {
-	if page <= 0 {
-		page = 1
+func (r *Repository) ListRuns(ctx context.Context, params ListRunsParams) (*ListRunsResult, error) {
+	if params.Page <= 0 {
+		params.Page = 1
 	}
-	if pageSize <= 0 || pageSize > 100 {
-		pageSize = 20
+	if params.PageSize <= 0 || params.PageSize > 100 {
+		params.PageSize = 20
 	}
-	offset := (page - 1) * pageSize
+	offset := (params.Page - 1) * params.PageSize
 
 	var where []string
 	var args []interface{}
 	argIdx := 1
 
-	where = append(where, fmt.Sprintf("workflow_id = $%d", argIdx))
-	args = append(args, workflowID)
-	argIdx++
+	if params.WorkflowID != "" {
+		where = append(where, fmt.Sprintf("workflow_id = $%d", argIdx))
+		args = append(args, params.WorkflowID)
+		argIdx++
+	}
 
 	// 从 context 提取 scope（如有）
 	if scope := scopeFromContext(ctx); scope != nil {
@@ -591,14 +1757,30 @@ func (r *Repository) ListRuns(ctx context.Context, workflowID string, page, page
 		argIdx++
 	}
 
-	whereClause := "WHERE " + strings.Join(where, " AND ")
+	if params.Status != "" {
+		where = append(where, fmt.Sprintf("status = $%d", argIdx))
+		args = append(args, params.Status)
+		argIdx++
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	// Count
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM workflow_runs %s", whereClause)
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, err
+	}
 
 	query := fmt.Sprintf(
-		`SELECT id, workflow_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(conversation_id,''), status, COALESCE(worker_id,''), retry_count, COALESCE(inputs,'{}'::jsonb), COALESCE(outputs,'{}'::jsonb), COALESCE(error,''), total_tokens, total_steps, elapsed_ms, queued_at, picked_at, started_at, finished_at
+		`SELECT id, workflow_id, workflow_version, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(parent_run_id::text,''), COALESCE(conversation_id,''), status, COALESCE(worker_id,''), retry_count, priority, COALESCE(inputs,'{}'::jsonb), COALESCE(outputs,'{}'::jsonb), COALESCE(error,''), exceptions_count, total_tokens, total_steps, elapsed_ms, queued_at, picked_at, next_attempt_at, lease_expires_at, started_at, finished_at, COALESCE(attempts,'[]'::jsonb)
 		 FROM workflow_runs %s ORDER BY started_at DESC LIMIT $%d OFFSET $%d`,
 		whereClause, argIdx, argIdx+1,
 	)
-	args = append(args, pageSize, offset)
+	args = append(args, params.PageSize, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -606,19 +1788,449 @@ func (r *Repository) ListRuns(ctx context.Context, workflowID string, page, page
 	}
 	defer rows.Close()
 
-	var runs []*WorkflowRun
+	runs := []*WorkflowRun{}
 	for rows.Next() {
 		run := &WorkflowRun{}
 		var orgID, tenantID sql.NullString
-		if err := rows.Scan(&run.ID, &run.WorkflowID, &orgID, &tenantID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount, &run.Inputs, &run.Outputs, &run.Error,
-			&run.TotalTokens, &run.TotalSteps, &run.ElapsedMs, &run.QueuedAt, &run.PickedAt, &run.StartedAt, &run.FinishedAt); err != nil {
+		var attemptsJSON []byte
+		if err := rows.Scan(&run.ID, &run.WorkflowID, &run.WorkflowVersion, &orgID, &tenantID, &run.ParentRunID, &run.ConversationID, &run.Status, &run.WorkerID, &run.RetryCount, &run.Priority, &run.Inputs, &run.Outputs, &run.Error,
+			&run.ExceptionsCount, &run.TotalTokens, &run.TotalSteps, &run.ElapsedMs, &run.QueuedAt, &run.PickedAt, &run.NextAttemptAt, &run.LeaseExpiresAt, &run.StartedAt, &run.FinishedAt, &attemptsJSON); err != nil {
 			return nil, err
 		}
 		run.OrgID = orgID.String
 		run.TenantID = tenantID.String
+		if err := decodeRunAttempts(run, attemptsJSON); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ListRunsResult{
+		Runs:     runs,
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}, nil
+}
+
+// TransitionRunStatus 条件更新运行状态（仅当当前状态属于 from 时生效，返回是否更新）
+// 迁移到 aborted 时同时写入 finished_at
+func (r *Repository) TransitionRunStatus(ctx context.Context, id string, from []RunStatus, to RunStatus) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
+	args := []interface{}{to, id, to == RunStatusAborted || to == RunStatusCanceled}
+	placeholders := make([]string, 0, len(from))
+	for _, st := range from {
+		args = append(args, st)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	query := `UPDATE workflow_runs
+		 SET status = $1,
+		     finished_at = CASE WHEN $3 THEN NOW() ELSE finished_at END
+		 WHERE id = $2 AND status IN (` + strings.Join(placeholders, ", ") + `)`
+	if scope := scopeFromContext(ctx); scope != nil {
+		args = append(args, scope.OrgID, scope.TenantID)
+		query += fmt.Sprintf(` AND org_id = $%d AND tenant_id = $%d`, len(args)-1, len(args))
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RequeueRun 将已结束的运行重新入队：清空上次的结果与 worker，retry_count 加一；
+// 死信运行的重试次数已耗尽，重新入队时 retry_count 归零以获得完整的自动重试次数（保留 attempts 历史）。
+// 仅当当前状态属于 from 时生效
+func (r *Repository) RequeueRun(ctx context.Context, id string, from []RunStatus) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
+	args := []interface{}{RunStatusQueued, id, RunStatusDeadLetter}
+	placeholders := make([]string, 0, len(from))
+	for _, st := range from {
+		args = append(args, st)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	query := `UPDATE workflow_runs
+		 SET status = $1, queued_at = NOW(), next_attempt_at = NULL, worker_id = '', lease_expires_at = NULL, error = '', outputs = NULL,
+		     elapsed_ms = 0, finished_at = NULL, retry_count = CASE WHEN status = $3 THEN 0 ELSE retry_count + 1 END
+		 WHERE id = $2 AND status IN (` + strings.Join(placeholders, ", ") + `)`
+	if scope := scopeFromContext(ctx); scope != nil {
+		args = append(args, scope.OrgID, scope.TenantID)
+		query += fmt.Sprintf(` AND org_id = $%d AND tenant_id = $%d`, len(args)-1, len(args))
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// AppendRunAttempt 追加一次失败尝试记录（保留历次失败原因）
+func (r *Repository) AppendRunAttempt(ctx context.Context, id string, attempt RunAttempt) error {
+	attemptJSON, err := json.Marshal([]RunAttempt{attempt})
+	if err != nil {
+		return fmt.Errorf("marshal run attempt: %w", err)
+	}
+	query := `UPDATE workflow_runs SET attempts = COALESCE(attempts, '[]'::jsonb) || $1::jsonb WHERE id = $2`
+	args := []interface{}{attemptJSON, id}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $3 AND tenant_id = $4`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ScheduleRunRetry 将执行失败的 running 运行重新入队，notBefore 之前不会被认领；
+// 保留最近一次错误，retry_count 加一
+func (r *Repository) ScheduleRunRetry(ctx context.Context, id string, notBefore time.Time) (bool, error) {
+	query := `UPDATE workflow_runs
+		 SET status = $1, queued_at = NOW(), next_attempt_at = $2, worker_id = '', lease_expires_at = NULL, outputs = NULL,
+		     elapsed_ms = 0, finished_at = NULL, retry_count = retry_count + 1
+		 WHERE id = $3 AND status = $4`
+	args := []interface{}{RunStatusQueued, notBefore, id, RunStatusRunning}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $5 AND tenant_id = $6`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// runLeaseExpired 租约已过期的执行中或手动暂停的异步运行（租约上线前认领、没有租约的执行中运行视为已过期）；
+// 等待人工输入的运行挂起时已清除租约，不在此列
+const runLeaseExpired = `COALESCE(worker_id, '') <> '' AND (
+	(status = 'running' AND (lease_expires_at IS NULL OR lease_expires_at < NOW()))
+	OR (status = 'paused' AND lease_expires_at < NOW()))`
+
+// RenewRunLease 续约：仅当运行仍由该 worker 执行时延长租约，返回 false 表示租约已丢失。
+// 手动暂停的运行引擎仍在本 worker 上等待恢复；已标记中止的运行在引擎停止、结果落库前仍归本 worker
+func (r *Repository) RenewRunLease(ctx context.Context, id, workerID string, lease time.Duration) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_runs SET lease_expires_at = NOW() + make_interval(secs => $1)
+		 WHERE id = $2 AND status IN ($3, $4, $5) AND worker_id = $6`,
+		lease.Seconds(), id, RunStatusRunning, RunStatusPaused, RunStatusAborted, workerID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ReleaseRunLease 主动释放租约（优雅停机）：执行中或手动暂停的运行立即回到队列，由其他实例从检查点继续，不计重试次数
+func (r *Repository) ReleaseRunLease(ctx context.Context, id, workerID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_runs
+		 SET status = $1, queued_at = NOW(), next_attempt_at = NULL, worker_id = '', lease_expires_at = NULL
+		 WHERE id = $2 AND status IN ($3, $4) AND worker_id = $5`,
+		RunStatusQueued, id, RunStatusRunning, RunStatusPaused, workerID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListExpiredRunLeases 列出租约已过期（worker 失联）的执行中或手动暂停的运行，跨租户
+func (r *Repository) ListExpiredRunLeases(ctx context.Context, limit int) ([]*WorkflowRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, workflow_id, workflow_version, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''), COALESCE(parent_run_id::text,''),
+		        status, worker_id, retry_count, picked_at, lease_expires_at, started_at, COALESCE(attempts,'[]'::jsonb)
+		 FROM workflow_runs
+		 WHERE `+runLeaseExpired+`
+		 ORDER BY lease_expires_at ASC NULLS FIRST
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*WorkflowRun
+	for rows.Next() {
+		run := &WorkflowRun{}
+		var attemptsJSON []byte
+		if err := rows.Scan(&run.ID, &run.WorkflowID, &run.WorkflowVersion, &run.OrgID, &run.TenantID, &run.ParentRunID,
+			&run.Status, &run.WorkerID, &run.RetryCount, &run.PickedAt, &run.LeaseExpiresAt, &run.StartedAt, &attemptsJSON); err != nil {
+			return nil, err
+		}
+		if err := decodeRunAttempts(run, attemptsJSON); err != nil {
+			return nil, err
+		}
 		runs = append(runs, run)
 	}
-	return runs, nil
+	return runs, rows.Err()
+}
+
+// RequeueExpiredRun 将租约过期的运行重新入队（保留检查点，重试次数加一）；
+// 租约在此期间被原 worker 续约时不做修改
+func (r *Repository) RequeueExpiredRun(ctx context.Context, id, workerID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_runs
+		 SET status = $1, queued_at = NOW(), next_attempt_at = NULL, worker_id = '', lease_expires_at = NULL, retry_count = retry_count + 1
+		 WHERE id = $2 AND worker_id = $3 AND `+runLeaseExpired,
+		RunStatusQueued, id, workerID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// FailExpiredRun 将租约过期且重试次数耗尽的运行结束为 status（failed，配置了 async_retry 时为 dead-letter）
+func (r *Repository) FailExpiredRun(ctx context.Context, id, workerID string, status RunStatus, errMsg string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workflow_runs
+		 SET status = $1, error = $2, outputs = NULL, lease_expires_at = NULL, finished_at = NOW(),
+		     elapsed_ms = (EXTRACT(EPOCH FROM NOW() - started_at) * 1000)::bigint
+		 WHERE id = $3 AND worker_id = $4 AND `+runLeaseExpired,
+		status, errMsg, id, workerID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// --- RunCheckpoint ---
+
+func (r *Repository) SaveRunCheckpoint(ctx context.Context, cp *RunCheckpoint) error {
+	completedJSON, err := json.Marshal(cp.CompletedNodes)
+	if err != nil {
+		return fmt.Errorf("marshal completed nodes: %w", err)
+	}
+	nodeStatesJSON, _ := json.Marshal(cp.NodeStates)
+	edgeStatesJSON, _ := json.Marshal(cp.EdgeStates)
+	nodeExecsJSON, err := json.Marshal(cp.NodeExecutions)
+	if err != nil {
+		return fmt.Errorf("marshal node executions: %w", err)
+	}
+	cp.UpdatedAt = time.Now()
+
+	// 仅接受更新的序号，避免乱序写入覆盖较新的检查点
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO run_checkpoints (run_id, sequence, state, variable_pool, completed_nodes, node_states, edge_states, node_executions, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (run_id) DO UPDATE
+		 SET sequence = EXCLUDED.sequence,
+		     state = EXCLUDED.state,
+		     variable_pool = EXCLUDED.variable_pool,
+		     completed_nodes = EXCLUDED.completed_nodes,
+		     node_states = EXCLUDED.node_states,
+		     edge_states = EXCLUDED.edge_states,
+		     node_executions = EXCLUDED.node_executions,
+		     updated_at = EXCLUDED.updated_at
+		 WHERE run_checkpoints.sequence < EXCLUDED.sequence`,
+		cp.RunID, cp.Sequence, []byte(cp.State), []byte(cp.VariablePool), completedJSON, nodeStatesJSON, edgeStatesJSON, nodeExecsJSON, cp.UpdatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetRunCheckpoint(ctx context.Context, runID string) (*RunCheckpoint, error) {
+	cp := &RunCheckpoint{}
+	var state, variablePool, completedJSON, nodeStatesJSON, edgeStatesJSON, nodeExecsJSON []byte
+	query := `SELECT cp.run_id, cp.sequence, cp.state, cp.variable_pool, cp.completed_nodes, cp.node_states, cp.edge_states, cp.node_executions, cp.updated_at
+		 FROM run_checkpoints cp
+		 JOIN workflow_runs wr ON wr.id = cp.run_id
+		 WHERE cp.run_id = $1`
+	args := []interface{}{runID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND wr.org_id = $2 AND wr.tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&cp.RunID, &cp.Sequence, &state, &variablePool, &completedJSON, &nodeStatesJSON, &edgeStatesJSON, &nodeExecsJSON, &cp.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get run checkpoint: %w", err)
+	}
+	cp.State = state
+	cp.VariablePool = variablePool
+	if len(completedJSON) > 0 {
+		_ = json.Unmarshal(completedJSON, &cp.CompletedNodes)
+	}
+	if len(nodeStatesJSON) > 0 {
+		_ = json.Unmarshal(nodeStatesJSON, &cp.NodeStates)
+	}
+	if len(edgeStatesJSON) > 0 {
+		_ = json.Unmarshal(edgeStatesJSON, &cp.EdgeStates)
+	}
+	if len(nodeExecsJSON) > 0 {
+		_ = json.Unmarshal(nodeExecsJSON, &cp.NodeExecutions)
+	}
+	return cp, nil
+}
+
+func (r *Repository) DeleteRunCheckpoint(ctx context.Context, runID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM run_checkpoints WHERE run_id = $1`, runID)
+	return err
+}
+
+// --- PendingInput ---
+
+// UpsertPendingInput 写入挂起的人工输入（同一 run/node 重复挂起时重置为 waiting）
+func (r *Repository) UpsertPendingInput(ctx context.Context, input *PendingInput) error {
+	if input.ID == "" {
+		input.ID = uuid.New().String()
+	}
+	if input.Status == "" {
+		input.Status = PendingInputStatusWaiting
+	}
+	input.CreatedAt = time.Now()
+	fieldsJSON, _ := json.Marshal(input.Fields)
+	actionsJSON, _ := json.Marshal(input.Actions)
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO run_pending_inputs (id, run_id, workflow_id, node_id, org_id, tenant_id, title, prompt, fields, actions, assignee, timeout_branch, status, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		 ON CONFLICT (run_id, node_id) DO UPDATE
+		 SET title = EXCLUDED.title,
+		     prompt = EXCLUDED.prompt,
+		     fields = EXCLUDED.fields,
+		     actions = EXCLUDED.actions,
+		     assignee = EXCLUDED.assignee,
+		     timeout_branch = EXCLUDED.timeout_branch,
+		     status = EXCLUDED.status,
+		     action = '',
+		     response = NULL,
+		     responded_by = '',
+		     responded_at = NULL,
+		     expires_at = EXCLUDED.expires_at`,
+		input.ID, input.RunID, nullIfEmpty(input.WorkflowID), input.NodeID, nullIfEmpty(input.OrgID), nullIfEmpty(input.TenantID),
+		input.Title, input.Prompt, fieldsJSON, actionsJSON, input.Assignee, input.TimeoutBranch, input.Status, input.ExpiresAt, input.CreatedAt,
+	)
+	return err
+}
+
+func (r *Repository) ListPendingInputs(ctx context.Context, runID string) ([]*PendingInput, error) {
+	query := `SELECT id, run_id, COALESCE(workflow_id::text,''), node_id, COALESCE(org_id::text,''), COALESCE(tenant_id::text,''),
+		 COALESCE(title,''), COALESCE(prompt,''), fields, actions, COALESCE(assignee,''), COALESCE(timeout_branch,''),
+		 status, COALESCE(action,''), response, COALESCE(responded_by,''), expires_at, created_at, responded_at
+		 FROM run_pending_inputs WHERE run_id = $1`
+	args := []interface{}{runID}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $2 AND tenant_id = $3`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []*PendingInput
+	for rows.Next() {
+		pi := &PendingInput{}
+		var fieldsJSON, actionsJSON, responseJSON []byte
+		if err := rows.Scan(&pi.ID, &pi.RunID, &pi.WorkflowID, &pi.NodeID, &pi.OrgID, &pi.TenantID,
+			&pi.Title, &pi.Prompt, &fieldsJSON, &actionsJSON, &pi.Assignee, &pi.TimeoutBranch,
+			&pi.Status, &pi.Action, &responseJSON, &pi.RespondedBy, &pi.ExpiresAt, &pi.CreatedAt, &pi.RespondedAt); err != nil {
+			return nil, err
+		}
+		if len(fieldsJSON) > 0 {
+			_ = json.Unmarshal(fieldsJSON, &pi.Fields)
+		}
+		if len(actionsJSON) > 0 {
+			_ = json.Unmarshal(actionsJSON, &pi.Actions)
+		}
+		if len(responseJSON) > 0 {
+			_ = json.Unmarshal(responseJSON, &pi.Response)
+		}
+		inputs = append(inputs, pi)
+	}
+	return inputs, rows.Err()
+}
+
+// SubmitPendingInput 提交人工输入响应（仅 waiting 状态可提交，返回是否提交成功）
+func (r *Repository) SubmitPendingInput(ctx context.Context, runID, nodeID, action string, response map[string]interface{}, respondedBy string) (bool, error) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return false, fmt.Errorf("marshal response: %w", err)
+	}
+	query := `UPDATE run_pending_inputs
+		 SET status = $1, action = $2, response = $3, responded_by = $4, responded_at = NOW()
+		 WHERE run_id = $5 AND node_id = $6 AND status = $7`
+	args := []interface{}{PendingInputStatusSubmitted, action, responseJSON, respondedBy, runID, nodeID, PendingInputStatusWaiting}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $8 AND tenant_id = $9`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TimeoutExpiredPendingInputs 将已过期的 waiting 人工输入标记为超时，返回受影响的 run_id
+func (r *Repository) TimeoutExpiredPendingInputs(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE run_pending_inputs
+		 SET status = $1, action = timeout_branch, responded_at = $2
+		 WHERE status = $3 AND expires_at IS NOT NULL AND expires_at <= $2
+		 RETURNING run_id`,
+		PendingInputStatusTimeout, now, PendingInputStatusWaiting,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	var runIDs []string
+	for rows.Next() {
+		var runID string
+		if err := rows.Scan(&runID); err != nil {
+			return nil, err
+		}
+		if !seen[runID] {
+			seen[runID] = true
+			runIDs = append(runIDs, runID)
+		}
+	}
+	return runIDs, rows.Err()
+}
+
+// ResumePausedRun 所有人工输入均已响应时，将 paused 运行重新入队（返回是否入队）
+func (r *Repository) ResumePausedRun(ctx context.Context, runID string) (bool, error) {
+	query := `UPDATE workflow_runs
+		 SET status = $1, queued_at = NOW(), worker_id = ''
+		 WHERE id = $2 AND status = $3
+		   AND NOT EXISTS (SELECT 1 FROM run_pending_inputs pi WHERE pi.run_id = $2 AND pi.status = $4)`
+	args := []interface{}{RunStatusQueued, runID, RunStatusPaused, PendingInputStatusWaiting}
+	if scope := scopeFromContext(ctx); scope != nil {
+		query += ` AND org_id = $5 AND tenant_id = $6`
+		args = append(args, scope.OrgID, scope.TenantID)
+	}
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
 }
 
 // --- ConversationTrace ---
@@ -693,9 +2305,10 @@ func (r *Repository) BatchCreateNodeExecs(ctx context.Context, records []*NodeEx
 	}
 	// 构建批量 INSERT
 	var sb strings.Builder
-	sb.WriteString(`INSERT INTO node_executions (id, run_id, node_id, node_type, title, status, outputs, error, metadata, elapsed_ms, started_at) VALUES `)
+	sb.WriteString(`INSERT INTO node_executions (id, run_id, node_id, node_type, title, status, outputs, error, metadata, elapsed_ms, started_at,
+		parent_node_id, parent_execution_id, iteration_index, loop_round) VALUES `)
 
-	args := make([]interface{}, 0, len(records)*11)
+	args := make([]interface{}, 0, len(records)*15)
 	for i, rec := range records {
 		if rec.ID == "" {
 			rec.ID = uuid.New().String()
@@ -703,23 +2316,26 @@ func (r *Repository) BatchCreateNodeExecs(ctx context.Context, records []*NodeEx
 		if i > 0 {
 			sb.WriteString(", ")
 		}
-		base := i * 11
-		sb.WriteString(fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)",
-			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11))
+		base := i * 15
+		sb.WriteString(fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11,
+			base+12, base+13, base+14, base+15))
 
 		outputsJSON, _ := json.Marshal(rec.Outputs)
 		metadataJSON, _ := json.Marshal(rec.Metadata)
 		args = append(args, rec.ID, rec.RunID, rec.NodeID, rec.NodeType, rec.Title, rec.Status,
-			outputsJSON, rec.Error, metadataJSON, rec.ElapsedMs, rec.StartedAt)
+			outputsJSON, rec.Error, metadataJSON, rec.ElapsedMs, rec.StartedAt,
+			rec.ParentNodeID, rec.ParentExecutionID, rec.IterationIndex, rec.LoopRound)
 	}
-	sb.WriteString(" ON CONFLICT (run_id, node_id) DO NOTHING")
+	sb.WriteString(" ON CONFLICT (run_id, node_id, parent_execution_id) DO NOTHING")
 
 	_, err := r.db.ExecContext(ctx, sb.String(), args...)
 	return err
 }
 
 func (r *Repository) ListNodeExecsByRunID(ctx context.Context, runID string) ([]*NodeExecutionRecord, error) {
-	query := `SELECT ne.id, ne.run_id, ne.node_id, ne.node_type, ne.title, ne.status, ne.outputs, ne.error, ne.metadata, ne.elapsed_ms, ne.started_at
+	query := `SELECT ne.id, ne.run_id, ne.node_id, ne.node_type, ne.title, ne.status, ne.outputs, ne.error, ne.metadata, ne.elapsed_ms, ne.started_at,
+		        ne.parent_node_id, ne.parent_execution_id, ne.iteration_index, ne.loop_round
 		 FROM node_executions ne
 		 JOIN workflow_runs wr ON wr.id = ne.run_id
 		 WHERE ne.run_id = $1`
@@ -739,10 +2355,20 @@ func (r *Repository) ListNodeExecsByRunID(ctx context.Context, runID string) ([]
 	for rows.Next() {
 		rec := &NodeExecutionRecord{}
 		var outputsJSON, metadataJSON json.RawMessage
+		var iterationIndex, loopRound sql.NullInt64
 		if err := rows.Scan(&rec.ID, &rec.RunID, &rec.NodeID, &rec.NodeType, &rec.Title, &rec.Status,
-			&outputsJSON, &rec.Error, &metadataJSON, &rec.ElapsedMs, &rec.StartedAt); err != nil {
+			&outputsJSON, &rec.Error, &metadataJSON, &rec.ElapsedMs, &rec.StartedAt,
+			&rec.ParentNodeID, &rec.ParentExecutionID, &iterationIndex, &loopRound); err != nil {
 			return nil, err
 		}
+		if iterationIndex.Valid {
+			idx := int(iterationIndex.Int64)
+			rec.IterationIndex = &idx
+		}
+		if loopRound.Valid {
+			round := int(loopRound.Int64)
+			rec.LoopRound = &round
+		}
 		if len(outputsJSON) > 0 {
 			_ = json.Unmarshal(outputsJSON, &rec.Outputs)
 		}
@@ -1061,22 +2687,25 @@ func (r *Repository) CreateTenant(ctx context.Context, tenant *Tenant) error {
 	if tenant.Status == "" {
 		tenant.Status = "active"
 	}
+	if tenant.QueueWeight <= 0 {
+		tenant.QueueWeight = 1
+	}
 	now := time.Now()
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO tenants (id, org_id, code, name, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		tenant.ID, tenant.OrgID, tenant.Code, tenant.Name, tenant.Status, now, now)
+		`INSERT INTO tenants (id, org_id, code, name, status, queue_weight, max_concurrent_runs, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		tenant.ID, tenant.OrgID, tenant.Code, tenant.Name, tenant.Status, tenant.QueueWeight, tenant.MaxConcurrentRuns, now, now)
 	return err
 }
 
 func (r *Repository) GetTenant(ctx context.Context, id string) (*Tenant, error) {
 	t := &Tenant{}
-	query := `SELECT id, org_id, code, name, status, created_at, updated_at FROM tenants WHERE id = $1`
+	query := `SELECT id, org_id, code, name, status, queue_weight, max_concurrent_runs, created_at, updated_at FROM tenants WHERE id = $1`
 	args := []interface{}{id}
 	if scope := scopeFromContext(ctx); scope != nil {
 		query += ` AND org_id = $2 AND id = $3`
 		args = append(args, scope.OrgID, scope.TenantID)
 	}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&t.ID, &t.OrgID, &t.Code, &t.Name, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&t.ID, &t.OrgID, &t.Code, &t.Name, &t.Status, &t.QueueWeight, &t.MaxConcurrentRuns, &t.CreatedAt, &t.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1084,7 +2713,7 @@ func (r *Repository) GetTenant(ctx context.Context, id string) (*Tenant, error)
 }
 
 func (r *Repository) ListTenants(ctx context.Context, orgID string) ([]*Tenant, error) {
-	query := `SELECT id, org_id, code, name, status, created_at, updated_at FROM tenants WHERE 1=1`
+	query := `SELECT id, org_id, code, name, status, queue_weight, max_concurrent_runs, created_at, updated_at FROM tenants WHERE 1=1`
 	var args []interface{}
 	argIdx := 1
 	if scope := scopeFromContext(ctx); scope != nil {
@@ -1106,7 +2735,7 @@ func (r *Repository) ListTenants(ctx context.Context, orgID string) ([]*Tenant,
 	var tenants []*Tenant
 	for rows.Next() {
 		t := &Tenant{}
-		if err := rows.Scan(&t.ID, &t.OrgID, &t.Code, &t.Name, &t.Status, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.OrgID, &t.Code, &t.Name, &t.Status, &t.QueueWeight, &t.MaxConcurrentRuns, &t.CreatedAt, &t.UpdatedAt); err != nil {
 			return nil, err
 		}
 		tenants = append(tenants, t)
@@ -1115,10 +2744,13 @@ func (r *Repository) ListTenants(ctx context.Context, orgID string) ([]*Tenant,
 }
 
 func (r *Repository) UpdateTenant(ctx context.Context, tenant *Tenant) error {
-	query := `UPDATE tenants SET code=$1, name=$2, status=$3, updated_at=NOW() WHERE id=$4`
-	args := []interface{}{tenant.Code, tenant.Name, tenant.Status, tenant.ID}
+	if tenant.QueueWeight <= 0 {
+		tenant.QueueWeight = 1
+	}
+	query := `UPDATE tenants SET code=$1, name=$2, status=$3, queue_weight=$4, max_concurrent_runs=$5, updated_at=NOW() WHERE id=$6`
+	args := []interface{}{tenant.Code, tenant.Name, tenant.Status, tenant.QueueWeight, tenant.MaxConcurrentRuns, tenant.ID}
 	if scope := scopeFromContext(ctx); scope != nil {
-		query += ` AND org_id=$5 AND id=$6`
+		query += ` AND org_id=$7 AND id=$8`
 		args = append(args, scope.OrgID, scope.TenantID)
 	}
 	_, err := r.db.ExecContext(ctx, query, args...)
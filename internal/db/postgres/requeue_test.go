@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRequeueRun_DeadLetterResetsRetryCount(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	wf := &Workflow{Name: "requeue", DSL: json.RawMessage(`{"nodes":[],"edges":[]}`)}
+	if err := repo.CreateWorkflow(ctx, wf); err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	dead := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	failed := &WorkflowRun{WorkflowID: wf.ID, Status: RunStatusQueued}
+	for _, run := range []*WorkflowRun{dead, failed} {
+		if err := repo.CreateRun(ctx, run); err != nil {
+			t.Fatalf("create run: %v", err)
+		}
+	}
+	// 死信运行已耗尽重试次数，并留有历次尝试记录
+	if _, err := repo.db.ExecContext(ctx,
+		`UPDATE workflow_runs SET status = $1, retry_count = 3, attempts = '[{"attempt":1},{"attempt":2},{"attempt":3},{"attempt":4}]'::jsonb WHERE id = $2`,
+		RunStatusDeadLetter, dead.ID); err != nil {
+		t.Fatalf("mark dead letter: %v", err)
+	}
+	if _, err := repo.db.ExecContext(ctx, `UPDATE workflow_runs SET status = $1, retry_count = 1 WHERE id = $2`, RunStatusFailed, failed.ID); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+
+	for _, id := range []string{dead.ID, failed.ID} {
+		ok, err := repo.RequeueRun(ctx, id, []RunStatus{RunStatusDeadLetter, RunStatusFailed})
+		if err != nil || !ok {
+			t.Fatalf("requeue %s: ok=%v err=%v", id, ok, err)
+		}
+	}
+
+	got, err := repo.GetRun(ctx, dead.ID)
+	if err != nil {
+		t.Fatalf("get dead-letter run: %v", err)
+	}
+	if got.Status != RunStatusQueued || got.RetryCount != 0 || len(got.Attempts) != 4 {
+		t.Fatalf("expected dead-letter run requeued with a full retry budget and its attempts kept, got status=%s retry_count=%d attempts=%d",
+			got.Status, got.RetryCount, len(got.Attempts))
+	}
+	if got, err := repo.GetRun(ctx, failed.ID); err != nil || got.RetryCount != 2 {
+		t.Fatalf("expected failed run requeue to count as a retry, got %+v err=%v", got, err)
+	}
+}
@@ -0,0 +1,66 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"flowweave/internal/domain/workflow/port"
+	applog "flowweave/internal/platform/log"
+)
+
+// runCommandChannelPrefix 运行控制命令频道前缀，完整频道为 prefix + run_id
+const runCommandChannelPrefix = "flowweave:run:cmd:"
+
+// RunCommandBus 基于 Redis pub/sub 的运行控制命令总线
+type RunCommandBus struct {
+	client *goredis.Client
+}
+
+// NewRunCommandBus 创建运行控制命令总线
+func NewRunCommandBus(client *goredis.Client) *RunCommandBus {
+	return &RunCommandBus{client: client}
+}
+
+// Publish 发布命令到 run_id 对应的频道
+func (b *RunCommandBus) Publish(ctx context.Context, cmd *port.RunCommand) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("marshal run command: %w", err)
+	}
+	return b.client.Publish(ctx, runCommandChannelPrefix+cmd.RunID, data).Err()
+}
+
+// Subscribe 按模式订阅所有运行的命令频道，阻塞直到 ctx 取消或订阅断开
+func (b *RunCommandBus) Subscribe(ctx context.Context, handler func(cmd *port.RunCommand)) error {
+	pubsub := b.client.PSubscribe(ctx, runCommandChannelPrefix+"*")
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe run commands: %w", err)
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("run command subscription closed")
+			}
+			var cmd port.RunCommand
+			if err := json.Unmarshal([]byte(msg.Payload), &cmd); err != nil {
+				applog.Warn("[RunCommandBus] Invalid command payload", "channel", msg.Channel, "error", err)
+				continue
+			}
+			if cmd.RunID == "" {
+				cmd.RunID = strings.TrimPrefix(msg.Channel, runCommandChannelPrefix)
+			}
+			handler(&cmd)
+		}
+	}
+}
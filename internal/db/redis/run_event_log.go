@@ -0,0 +1,96 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"flowweave/internal/domain/workflow/port"
+)
+
+// runEventKeyPrefix 运行事件流 key 前缀，完整 key 为 prefix + run_id（序号计数器再加 ":seq"）
+const runEventKeyPrefix = "flowweave:run:events:"
+
+const (
+	defaultRunEventTTL    = 24 * time.Hour
+	defaultRunEventMaxLen = 10000
+)
+
+// appendRunEventScript 分配序号并以 "0-<seq>" 为条目 ID 写入 Stream，同时刷新过期时间。
+// 序号分配与写入在同一脚本中完成，并发追加也不会因 ID 乱序失败
+var appendRunEventScript = goredis.NewScript(`
+local seq = redis.call('INCR', KEYS[2])
+redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[4], '0-' .. seq, 'event', ARGV[1], 'data', ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[3])
+return seq
+`)
+
+// RunEventLog 基于 Redis Streams 的运行事件日志
+type RunEventLog struct {
+	client *goredis.Client
+	ttl    time.Duration
+	maxLen int64
+}
+
+// NewRunEventLog 创建运行事件日志：ttl 为最后一次写入后的保留时长，maxLen 为单个运行保留的事件数上限（近似裁剪）
+func NewRunEventLog(client *goredis.Client, ttl time.Duration, maxLen int) *RunEventLog {
+	if ttl <= 0 {
+		ttl = defaultRunEventTTL
+	}
+	if maxLen <= 0 {
+		maxLen = defaultRunEventMaxLen
+	}
+	return &RunEventLog{client: client, ttl: ttl, maxLen: int64(maxLen)}
+}
+
+// Append 追加事件并返回序号
+func (l *RunEventLog) Append(ctx context.Context, runID, event string, data json.RawMessage) (int64, error) {
+	key := runEventKeyPrefix + runID
+	seq, err := appendRunEventScript.Run(ctx, l.client,
+		[]string{key, key + ":seq"},
+		event, []byte(data), int64(l.ttl/time.Second), l.maxLen,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("append run event: %w", err)
+	}
+	return seq, nil
+}
+
+// Read 读取序号大于 afterSeq 的事件；block > 0 时暂无新事件会阻塞等待
+func (l *RunEventLog) Read(ctx context.Context, runID string, afterSeq int64, limit int, block time.Duration) ([]port.RunEvent, error) {
+	if block <= 0 {
+		block = -1 // 不阻塞
+	}
+	streams, err := l.client.XRead(ctx, &goredis.XReadArgs{
+		Streams: []string{runEventKeyPrefix + runID, "0-" + strconv.FormatInt(afterSeq, 10)},
+		Count:   int64(limit),
+		Block:   block,
+	}).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read run events: %w", err)
+	}
+
+	var events []port.RunEvent
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			seq, err := strconv.ParseInt(strings.TrimPrefix(msg.ID, "0-"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid run event id %q", msg.ID)
+			}
+			event, _ := msg.Values["event"].(string)
+			data, _ := msg.Values["data"].(string)
+			events = append(events, port.RunEvent{Seq: seq, Event: event, Data: json.RawMessage(data)})
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,256 @@
+package engine_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/node/code"
+)
+
+// aggregatorSlowFunction 慢分支：延迟后返回结果
+type aggregatorSlowFunction struct{}
+
+func (f *aggregatorSlowFunction) Name() string {
+	return "test.engine.aggregator.slow.v1"
+}
+
+func (f *aggregatorSlowFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	time.Sleep(150 * time.Millisecond)
+	return map[string]interface{}{"result": fmt.Sprintf("%v-slow", input["query"])}, nil
+}
+
+func init() {
+	code.MustRegisterFunction(&aggregatorSlowFunction{})
+}
+
+// aggregatorBranchDSL if-else 分叉后由变量聚合节点合并实际执行的分支
+func aggregatorBranchDSL(aggregatorData string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "age", "label": "Age", "type": "number", "required": true}
+					]
+				}
+			},
+			{
+				"id": "ifelse_1",
+				"data": {
+					"type": "if-else",
+					"title": "Check Age",
+					"conditions": [
+						{
+							"id": "adult",
+							"logical_operator": "and",
+							"conditions": [
+								{"variable_selector": ["start_1", "age"], "comparison_operator": "gte", "value": "18"}
+							]
+						}
+					]
+				}
+			},
+			{
+				"id": "tpl_adult",
+				"data": {"type": "template-transform", "title": "Adult", "template": "adult", "variables": []}
+			},
+			{
+				"id": "tpl_minor",
+				"data": {"type": "template-transform", "title": "Minor", "template": "minor", "variables": []}
+			},
+			{
+				"id": "agg_1",
+				"data": %s
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [
+						{"variable": "result", "value_selector": ["agg_1", "output"]},
+						{"variable": "label", "value_selector": ["agg_1", "label"]}
+					]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "ifelse_1"},
+			{"source": "ifelse_1", "target": "tpl_adult", "sourceHandle": "adult"},
+			{"source": "ifelse_1", "target": "tpl_minor", "sourceHandle": "false"},
+			{"source": "tpl_adult", "target": "agg_1"},
+			{"source": "tpl_minor", "target": "agg_1"},
+			{"source": "agg_1", "target": "end_1"}
+		]
+	}`, aggregatorData))
+}
+
+// --- 变量聚合节点 ---
+
+func TestVariableAggregator_MergesTakenBranch(t *testing.T) {
+	dsl := aggregatorBranchDSL(`{
+		"type": "variable-aggregator",
+		"title": "Merge",
+		"variables": [["tpl_adult", "output"], ["tpl_minor", "output"]]
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	for age, expected := range map[float64]string{25: "adult", 15: "minor"} {
+		result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"age": age}, nil)
+		if err != nil {
+			t.Fatalf("age=%v: run failed: %v", age, err)
+		}
+		if result.Outputs["result"] != expected {
+			t.Fatalf("age=%v: expected %q, got %v", age, expected, result.Outputs)
+		}
+		exec := findNodeExec(result.NodeExecutions, "agg_1")
+		if exec == nil {
+			t.Fatalf("age=%v: expected aggregator execution", age)
+		}
+		selected, _ := exec.Metadata["selected"].(map[string]interface{})
+		if selected["output"] != "tpl_"+expected+".output" {
+			t.Fatalf("age=%v: unexpected selected metadata %v", age, exec.Metadata)
+		}
+	}
+
+	t.Logf("✅ Variable aggregator branch merge test passed")
+}
+
+func TestVariableAggregator_GroupedOutputs(t *testing.T) {
+	dsl := aggregatorBranchDSL(`{
+		"type": "variable-aggregator",
+		"title": "Merge",
+		"advanced_settings": {
+			"group_enabled": true,
+			"groups": [
+				{"group_name": "output", "variables": [["tpl_adult", "output"], ["tpl_minor", "output"]]},
+				{"group_name": "label", "variables": [["tpl_minor", "output"], ["start_1", "age"]]}
+			]
+		}
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"age": float64(30)}, nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Outputs["result"] != "adult" {
+		t.Fatalf("expected output group to pick adult branch, got %v", result.Outputs)
+	}
+	if result.Outputs["label"] != float64(30) {
+		t.Fatalf("expected label group to fall back to start age, got %v", result.Outputs)
+	}
+
+	t.Logf("✅ Variable aggregator grouped outputs test passed")
+}
+
+func TestVariableAggregator_InvalidConfig(t *testing.T) {
+	cases := []string{
+		`{"type": "variable-aggregator", "title": "Merge", "variables": []}`,
+		`{"type": "variable-aggregator", "title": "Merge", "advanced_settings": {"group_enabled": true, "groups": []}}`,
+		`{"type": "variable-aggregator", "title": "Merge", "variables": [["tpl_adult", "output"]], "join": "first"}`,
+	}
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	for i, data := range cases {
+		if _, err := runner.RunSync(context.Background(), aggregatorBranchDSL(data), map[string]interface{}{"age": float64(1)}, nil); err == nil {
+			t.Fatalf("case %d: expected build error", i)
+		}
+	}
+
+	t.Logf("✅ Variable aggregator validation test passed")
+}
+
+// --- join: any ---
+
+func TestJoinAny_FiresOnFirstCompletedEdge(t *testing.T) {
+	dsl := []byte(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "slow_1",
+				"data": {
+					"type": "func",
+					"title": "Slow",
+					"function_ref": "test.engine.aggregator.slow.v1",
+					"inputs": [
+						{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "fast_1",
+				"data": {
+					"type": "template-transform",
+					"title": "Fast",
+					"template": "{{ query }}-fast",
+					"variables": [{"variable": "query", "value_selector": ["start_1", "query"]}]
+				}
+			},
+			{
+				"id": "agg_1",
+				"data": {
+					"type": "variable-aggregator",
+					"title": "First",
+					"join": "any",
+					"variables": [["slow_1", "result"], ["fast_1", "output"]]
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [{"variable": "result", "value_selector": ["agg_1", "output"]}]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "slow_1"},
+			{"source": "start_1", "target": "fast_1"},
+			{"source": "slow_1", "target": "agg_1"},
+			{"source": "fast_1", "target": "agg_1"},
+			{"source": "agg_1", "target": "end_1"}
+		]
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "q"}, nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Outputs["result"] != "q-fast" {
+		t.Fatalf("expected join any to fire on the fast branch, got %v", result.Outputs)
+	}
+
+	counts := make(map[string]int)
+	for _, exec := range result.NodeExecutions {
+		counts[exec.NodeID]++
+	}
+	if counts["agg_1"] != 1 || counts["end_1"] != 1 {
+		t.Fatalf("expected join node and downstream to run once, got %v", counts)
+	}
+	if counts["slow_1"] != 1 {
+		t.Fatalf("expected slow branch to still complete, got %v", counts)
+	}
+
+	t.Logf("✅ Join any test passed")
+}
@@ -0,0 +1,235 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node/code"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// asyncLeaseSlowFunction 执行耗时超过多个租约周期，验证心跳续约
+type asyncLeaseSlowFunction struct{}
+
+func (f *asyncLeaseSlowFunction) Name() string {
+	return "test.engine.async_lease.slow.v1"
+}
+
+func (f *asyncLeaseSlowFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	select {
+	case <-time.After(300 * time.Millisecond):
+		return map[string]interface{}{"result": "ok"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// asyncLeaseBlockFunction 阻塞直到 context 取消（模拟停机时仍在执行的运行）
+type asyncLeaseBlockFunction struct{}
+
+func (f *asyncLeaseBlockFunction) Name() string {
+	return "test.engine.async_lease.block.v1"
+}
+
+func (f *asyncLeaseBlockFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func init() {
+	code.MustRegisterFunction(&asyncLeaseSlowFunction{})
+	code.MustRegisterFunction(&asyncLeaseBlockFunction{})
+}
+
+// asyncLeaseDSL 不带 async_retry 的单节点工作流
+func asyncLeaseDSL(t *testing.T, functionRef string) json.RawMessage {
+	t.Helper()
+	var dsl map[string]interface{}
+	if err := json.Unmarshal(asyncRetryDSL(t, functionRef, 0), &dsl); err != nil {
+		t.Fatalf("decode dsl: %v", err)
+	}
+	delete(dsl, "async_retry")
+	raw, _ := json.Marshal(dsl)
+	return raw
+}
+
+// newLostRunRepo 运行已被一个失联 worker 认领，租约已过期
+func newLostRunRepo(t *testing.T, retryCount int) *asyncRetryRepo {
+	t.Helper()
+	repo := newAsyncRetryRepo(asyncLeaseDSL(t, "test.engine.async_lease.slow.v1"))
+	expired := time.Now().Add(-time.Second)
+	repo.run.Status = port.RunStatusRunning
+	repo.run.WorkerID = "dead-host-1/async-worker-1"
+	repo.run.LeaseExpiresAt = &expired
+	repo.run.RetryCount = retryCount
+	return repo
+}
+
+// waitRunStatus 等待运行进入 want 状态
+func (r *asyncRetryRepo) waitRunStatus(t *testing.T, want port.RunStatus) port.WorkflowRun {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		run := r.run
+		r.mu.Unlock()
+		if run.Status == want {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not reach %s, status=%s", want, run.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// controlAsyncRun 模拟运行控制接口：先写入数据库状态，再向执行中的引擎发送命令
+func controlAsyncRun(t *testing.T, ctx context.Context, repo *asyncRetryRepo, runner *workflow.WorkflowRunner, cmdType types.CommandType, to port.RunStatus) {
+	t.Helper()
+	repo.mu.Lock()
+	repo.run.Status = to
+	runID := repo.run.ID
+	repo.mu.Unlock()
+
+	// 运行刚被认领时引擎可能尚未注册
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := runner.SendCommand(ctx, runID, types.Command{Type: cmdType})
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("send %s: %v", cmdType, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// --- worker 租约与失联回收 ---
+
+func TestAsyncLease_HeartbeatKeepsLongRun(t *testing.T) {
+	repo := newAsyncRetryRepo(asyncLeaseDSL(t, "test.engine.async_lease.slow.v1"))
+	cfg := asyncManagerConfig()
+	cfg.LeaseDuration = 60 * time.Millisecond
+
+	run := repo.waitFinished(t, cfg)
+	if run.Status != port.RunStatusSucceeded || run.RetryCount != 0 || len(run.Attempts) != 0 {
+		t.Fatalf("expected run to succeed without being reaped, got status=%s retry_count=%d attempts=%+v", run.Status, run.RetryCount, run.Attempts)
+	}
+	t.Logf("✅ Heartbeat renewed lease across %s of execution", 300*time.Millisecond)
+}
+
+func TestAsyncLease_ExpiredRunRequeued(t *testing.T) {
+	repo := newLostRunRepo(t, 0)
+	cfg := asyncManagerConfig()
+	cfg.WorkerLostRetries = 1
+
+	run := repo.waitFinished(t, cfg)
+	if run.Status != port.RunStatusSucceeded || run.RetryCount != 1 {
+		t.Fatalf("expected lost run to be requeued and succeed, got status=%s retry_count=%d", run.Status, run.RetryCount)
+	}
+	if len(run.Attempts) != 1 || run.Attempts[0].ErrorClass != "worker_lost" || run.Attempts[0].WorkerID != "dead-host-1/async-worker-1" {
+		t.Fatalf("expected one worker_lost attempt, got %+v", run.Attempts)
+	}
+	t.Logf("✅ Run with expired lease requeued and finished on another worker")
+}
+
+func TestAsyncLease_RetriesExhaustedFailsWorkerLost(t *testing.T) {
+	repo := newLostRunRepo(t, 1)
+	cfg := asyncManagerConfig()
+	cfg.WorkerLostRetries = 1
+
+	run := repo.waitFinished(t, cfg)
+	if run.Status != port.RunStatusFailed || !strings.HasPrefix(run.Error, "worker_lost") {
+		t.Fatalf("expected failed with worker_lost, got status=%s error=%q", run.Status, run.Error)
+	}
+	t.Logf("✅ Lost run failed after retries exhausted: %s", run.Error)
+}
+
+func TestAsyncLease_RetriesExhaustedWithPolicyGoesToDeadLetter(t *testing.T) {
+	repo := newLostRunRepo(t, 2)
+	repo.dsl = asyncRetryDSL(t, "test.engine.async_lease.slow.v1", 2)
+	cfg := asyncManagerConfig()
+	cfg.WorkerLostRetries = 5 // 配置了 async_retry 时以 max_retries 为准
+
+	run := repo.waitFinished(t, cfg)
+	if run.Status != port.RunStatusDeadLetter || !strings.HasPrefix(run.Error, "worker_lost") {
+		t.Fatalf("expected dead-letter with worker_lost, got status=%s error=%q", run.Status, run.Error)
+	}
+	if len(run.Attempts) != 1 || run.Attempts[0].ErrorClass != "worker_lost" {
+		t.Fatalf("expected one worker_lost attempt, got %+v", run.Attempts)
+	}
+	t.Logf("✅ Lost run moved to dead letter after async_retry exhausted: %s", run.Error)
+}
+
+func TestAsyncLease_ReleasedOnShutdown(t *testing.T) {
+	repo := newAsyncRetryRepo(asyncLeaseDSL(t, "test.engine.async_lease.block.v1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mgr := workflow.NewAsyncRunManager(repo, workflow.NewWorkflowRunner(engine.DefaultConfig(), nil), asyncManagerConfig())
+	mgr.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		repo.mu.Lock()
+		status := repo.run.Status
+		repo.mu.Unlock()
+		if status == port.RunStatusRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run was not claimed, status=%s", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	if err := mgr.Wait(waitCtx); err != nil {
+		t.Fatalf("workers did not stop: %v", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.released != 1 || repo.run.Status != port.RunStatusQueued || repo.run.WorkerID != "" || repo.run.RetryCount != 0 {
+		t.Fatalf("expected lease released back to queue, got released=%d status=%s worker=%q retry_count=%d",
+			repo.released, repo.run.Status, repo.run.WorkerID, repo.run.RetryCount)
+	}
+	t.Logf("✅ Lease released on shutdown, run back in queue")
+}
+
+func TestAsyncLease_PausedRunKeepsLease(t *testing.T) {
+	repo := newAsyncRetryRepo(asyncLeaseDSL(t, "test.engine.async_lease.slow.v1"))
+	cfg := asyncManagerConfig()
+	cfg.LeaseDuration = 60 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	workflow.NewAsyncRunManager(repo, runner, cfg).Start(ctx)
+
+	repo.waitRunStatus(t, port.RunStatusRunning)
+	controlAsyncRun(t, ctx, repo, runner, types.CommandPause, port.RunStatusPaused)
+
+	// 暂停跨越多个租约周期：心跳继续续约，运行既不被回收也不被丢弃
+	time.Sleep(10 * cfg.LeaseDuration)
+	repo.mu.Lock()
+	paused := repo.run
+	repo.mu.Unlock()
+	if paused.Status != port.RunStatusPaused || paused.RetryCount != 0 || paused.LeaseExpiresAt == nil || paused.LeaseExpiresAt.Before(time.Now()) {
+		t.Fatalf("expected paused run to keep its lease, got status=%s retry_count=%d lease=%v", paused.Status, paused.RetryCount, paused.LeaseExpiresAt)
+	}
+
+	controlAsyncRun(t, ctx, repo, runner, types.CommandResume, port.RunStatusRunning)
+	run := repo.waitRunStatus(t, port.RunStatusSucceeded)
+	if run.RetryCount != 0 || len(run.Attempts) != 0 || run.LeaseExpiresAt != nil {
+		t.Fatalf("expected run to finish on the same worker, got retry_count=%d attempts=%+v lease=%v", run.RetryCount, run.Attempts, run.LeaseExpiresAt)
+	}
+	t.Logf("✅ Paused run kept its lease across %s and finished after resume", 10*cfg.LeaseDuration)
+}
@@ -0,0 +1,364 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	provider "flowweave/internal/adapter/provider/llm"
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/node/code"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// asyncFlakyFailures 剩余失败次数：大于 0 时返回 503 并减一
+var asyncFlakyFailures atomic.Int32
+
+// asyncFlakyFunction 在 asyncFlakyFailures 耗尽前返回 503
+type asyncFlakyFunction struct{}
+
+func (f *asyncFlakyFunction) Name() string {
+	return "test.engine.async_retry.flaky.v1"
+}
+
+func (f *asyncFlakyFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	if asyncFlakyFailures.Add(-1) >= 0 {
+		return nil, &provider.APIError{StatusCode: 503, Body: "upstream unavailable"}
+	}
+	return map[string]interface{}{"result": "ok"}, nil
+}
+
+func init() {
+	code.MustRegisterFunction(&asyncFlakyFunction{})
+}
+
+// asyncRetryDSL 在 retryDSL 的基础上去掉节点级重试，改为工作流级 async_retry
+func asyncRetryDSL(t *testing.T, functionRef string, maxRetries int) json.RawMessage {
+	t.Helper()
+	var dsl map[string]interface{}
+	if err := json.Unmarshal(retryDSL(functionRef), &dsl); err != nil {
+		t.Fatalf("decode dsl: %v", err)
+	}
+	for _, n := range dsl["nodes"].([]interface{}) {
+		data := n.(map[string]interface{})["data"].(map[string]interface{})
+		delete(data, "error_strategy")
+		delete(data, "retry")
+	}
+	dsl["async_retry"] = map[string]interface{}{
+		"max_retries":        maxRetries,
+		"retry_interval":     20,
+		"backoff_multiplier": 2,
+	}
+	raw, _ := json.Marshal(dsl)
+	return raw
+}
+
+// asyncRetryRepo 单个运行的内存队列
+type asyncRetryRepo struct {
+	port.Repository
+	mu       sync.Mutex
+	dsl      json.RawMessage
+	run      port.WorkflowRun
+	released int // 主动释放租约的次数
+}
+
+func newAsyncRetryRepo(dsl json.RawMessage) *asyncRetryRepo {
+	now := time.Now()
+	return &asyncRetryRepo{dsl: dsl, run: port.WorkflowRun{
+		ID:              "run_async_retry",
+		WorkflowID:      "wf_1",
+		WorkflowVersion: 1,
+		Status:          port.RunStatusQueued,
+		Inputs:          json.RawMessage(`{"x": "hello"}`),
+		QueuedAt:        &now,
+	}}
+}
+
+func (r *asyncRetryRepo) ClaimNextQueuedRun(_ context.Context, workerID string, opts port.ClaimOptions) (*port.WorkflowRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.run.Status != port.RunStatusQueued || (r.run.NextAttemptAt != nil && r.run.NextAttemptAt.After(time.Now())) {
+		return nil, nil
+	}
+	lease := time.Now().Add(opts.LeaseDuration)
+	r.run.Status = port.RunStatusRunning
+	r.run.WorkerID = workerID
+	r.run.LeaseExpiresAt = &lease
+	cp := r.run
+	cp.Attempts = append([]port.RunAttempt(nil), r.run.Attempts...)
+	return &cp, nil
+}
+
+func (r *asyncRetryRepo) GetWorkflowVersion(_ context.Context, workflowID string, version int) (*port.WorkflowVersion, error) {
+	return &port.WorkflowVersion{WorkflowID: workflowID, Version: version, DSL: r.dsl}, nil
+}
+
+func (r *asyncRetryRepo) GetRunCheckpoint(context.Context, string) (*port.RunCheckpoint, error) {
+	return nil, nil
+}
+
+func (r *asyncRetryRepo) SaveRunCheckpoint(context.Context, *port.RunCheckpoint) error { return nil }
+func (r *asyncRetryRepo) DeleteRunCheckpoint(context.Context, string) error            { return nil }
+func (r *asyncRetryRepo) BatchCreateNodeExecs(context.Context, []*port.NodeExecutionRecord) error {
+	return nil
+}
+
+func (r *asyncRetryRepo) RenewRunLease(_ context.Context, _ string, workerID string, lease time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.run.Status {
+	case port.RunStatusRunning, port.RunStatusPaused, port.RunStatusAborted:
+	default:
+		return false, nil
+	}
+	if r.run.WorkerID != workerID {
+		return false, nil
+	}
+	until := time.Now().Add(lease)
+	r.run.LeaseExpiresAt = &until
+	return true, nil
+}
+
+func (r *asyncRetryRepo) ReleaseRunLease(_ context.Context, _ string, workerID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if (r.run.Status != port.RunStatusRunning && r.run.Status != port.RunStatusPaused) || r.run.WorkerID != workerID {
+		return false, nil
+	}
+	r.run.Status = port.RunStatusQueued
+	r.run.WorkerID = ""
+	r.run.LeaseExpiresAt = nil
+	r.released++
+	return true, nil
+}
+
+// leaseExpired 调用方需持有锁
+func (r *asyncRetryRepo) leaseExpired(workerID string) bool {
+	if r.run.WorkerID == "" || r.run.WorkerID != workerID {
+		return false
+	}
+	switch r.run.Status {
+	case port.RunStatusRunning:
+		return r.run.LeaseExpiresAt == nil || r.run.LeaseExpiresAt.Before(time.Now())
+	case port.RunStatusPaused:
+		return r.run.LeaseExpiresAt != nil && r.run.LeaseExpiresAt.Before(time.Now())
+	}
+	return false
+}
+
+func (r *asyncRetryRepo) ListExpiredRunLeases(context.Context, int) ([]*port.WorkflowRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.leaseExpired(r.run.WorkerID) {
+		return nil, nil
+	}
+	cp := r.run
+	cp.Attempts = append([]port.RunAttempt(nil), r.run.Attempts...)
+	return []*port.WorkflowRun{&cp}, nil
+}
+
+func (r *asyncRetryRepo) RequeueExpiredRun(_ context.Context, _ string, workerID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.leaseExpired(workerID) {
+		return false, nil
+	}
+	r.run.Status = port.RunStatusQueued
+	r.run.WorkerID = ""
+	r.run.LeaseExpiresAt = nil
+	r.run.RetryCount++
+	return true, nil
+}
+
+func (r *asyncRetryRepo) FailExpiredRun(_ context.Context, _ string, workerID string, status port.RunStatus, errMsg string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.leaseExpired(workerID) {
+		return false, nil
+	}
+	r.run.Status = status
+	r.run.Error = errMsg
+	r.run.LeaseExpiresAt = nil
+	return true, nil
+}
+
+func (r *asyncRetryRepo) TimeoutExpiredPendingInputs(context.Context, time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (r *asyncRetryRepo) PurgeExpiredIdempotencyKeys(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *asyncRetryRepo) AppendRunAttempt(_ context.Context, _ string, attempt port.RunAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Attempts = append(r.run.Attempts, attempt)
+	return nil
+}
+
+func (r *asyncRetryRepo) ScheduleRunRetry(_ context.Context, _ string, notBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.run.Status != port.RunStatusRunning {
+		return false, nil
+	}
+	r.run.Status = port.RunStatusQueued
+	r.run.NextAttemptAt = &notBefore
+	r.run.LeaseExpiresAt = nil
+	r.run.RetryCount++
+	return true, nil
+}
+
+// RequeueRun 与 SQL 实现一致：死信运行 retry_count 归零，其余加一
+func (r *asyncRetryRepo) RequeueRun(_ context.Context, _ string, from []port.RunStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	allowed := false
+	for _, s := range from {
+		if r.run.Status == s {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return false, nil
+	}
+	if r.run.Status == port.RunStatusDeadLetter {
+		r.run.RetryCount = 0
+	} else {
+		r.run.RetryCount++
+	}
+	r.run.Status = port.RunStatusQueued
+	r.run.WorkerID = ""
+	r.run.LeaseExpiresAt = nil
+	r.run.NextAttemptAt = nil
+	r.run.Error = ""
+	return true, nil
+}
+
+func (r *asyncRetryRepo) UpdateRun(_ context.Context, run *port.WorkflowRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Status = run.Status
+	r.run.Error = run.Error
+	r.run.Outputs = run.Outputs
+	if run.Status != port.RunStatusRunning {
+		r.run.LeaseExpiresAt = nil
+	}
+	return nil
+}
+
+// asyncManagerConfig 测试用的快速轮询配置
+func asyncManagerConfig() workflow.AsyncRunManagerConfig {
+	return workflow.AsyncRunManagerConfig{
+		Workers:       1,
+		PollInterval:  5 * time.Millisecond,
+		RunTimeout:    5 * time.Second,
+		LeaseDuration: time.Second,
+	}
+}
+
+// waitFinished 启动 manager，等待运行离开 queued / running 状态
+func (r *asyncRetryRepo) waitFinished(t *testing.T, cfg workflow.AsyncRunManagerConfig) port.WorkflowRun {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	workflow.NewAsyncRunManager(r, runner, cfg).Start(ctx)
+
+	var run port.WorkflowRun
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		run = r.run
+		r.mu.Unlock()
+		if run.Status != port.RunStatusQueued && run.Status != port.RunStatusRunning {
+			return run
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("run did not finish, status=%s", run.Status)
+	return run
+}
+
+// --- 异步运行自动重试与死信 ---
+
+func TestAsyncRetry_SucceedsAfterBackoff(t *testing.T) {
+	asyncFlakyFailures.Store(2)
+	repo := newAsyncRetryRepo(asyncRetryDSL(t, "test.engine.async_retry.flaky.v1", 3))
+
+	run := repo.waitFinished(t, asyncManagerConfig())
+	if run.Status != port.RunStatusSucceeded {
+		t.Fatalf("expected succeeded after retries, got %s (error=%s)", run.Status, run.Error)
+	}
+	if run.RetryCount != 2 || len(run.Attempts) != 2 {
+		t.Fatalf("expected 2 retries with 2 failed attempts, got retry_count=%d attempts=%d", run.RetryCount, len(run.Attempts))
+	}
+	for i, a := range run.Attempts {
+		if a.Attempt != i+1 || a.ErrorClass != "http_5xx" || a.Error == "" || a.BackoffMs <= 0 {
+			t.Fatalf("unexpected attempt %d: %+v", i+1, a)
+		}
+	}
+	// 退避按倍数增长：20ms → 40ms
+	if run.Attempts[1].BackoffMs <= run.Attempts[0].BackoffMs {
+		t.Fatalf("expected growing backoff, got %d then %d", run.Attempts[0].BackoffMs, run.Attempts[1].BackoffMs)
+	}
+	t.Logf("✅ Async run succeeded after %d retries", run.RetryCount)
+}
+
+func TestAsyncRetry_ExhaustedGoesToDeadLetter(t *testing.T) {
+	asyncFlakyFailures.Store(100)
+	repo := newAsyncRetryRepo(asyncRetryDSL(t, "test.engine.async_retry.flaky.v1", 1))
+
+	run := repo.waitFinished(t, asyncManagerConfig())
+	if run.Status != port.RunStatusDeadLetter {
+		t.Fatalf("expected dead-letter, got %s", run.Status)
+	}
+	if len(run.Attempts) != 2 || run.Attempts[1].BackoffMs != 0 {
+		t.Fatalf("expected 2 attempts and no backoff after the last one, got %+v", run.Attempts)
+	}
+	t.Logf("✅ Exhausted run moved to dead letter after %d attempts", len(run.Attempts))
+}
+
+func TestAsyncRetry_RequeuedDeadLetterGetsFullRetryBudget(t *testing.T) {
+	asyncFlakyFailures.Store(100)
+	repo := newAsyncRetryRepo(asyncRetryDSL(t, "test.engine.async_retry.flaky.v1", 2))
+
+	run := repo.waitFinished(t, asyncManagerConfig())
+	if run.Status != port.RunStatusDeadLetter || run.RetryCount != 2 {
+		t.Fatalf("expected dead-letter with retry_count 2, got %s retry_count=%d", run.Status, run.RetryCount)
+	}
+
+	ok, err := repo.RequeueRun(context.Background(), run.ID, []port.RunStatus{port.RunStatusDeadLetter})
+	if err != nil || !ok {
+		t.Fatalf("requeue dead-letter run: ok=%v err=%v", ok, err)
+	}
+
+	// 重新入队后再失败两次仍在 max_retries 之内，第三次成功
+	asyncFlakyFailures.Store(2)
+	run = repo.waitFinished(t, asyncManagerConfig())
+	if run.Status != port.RunStatusSucceeded {
+		t.Fatalf("expected requeued run to succeed within a full retry budget, got %s (error=%s)", run.Status, run.Error)
+	}
+	if run.RetryCount != 2 || len(run.Attempts) != 5 {
+		t.Fatalf("expected retry_count 2 and 5 attempts kept, got retry_count=%d attempts=%d", run.RetryCount, len(run.Attempts))
+	}
+	t.Logf("✅ Requeued dead-letter run succeeded after %d more retries", run.RetryCount)
+}
+
+func TestAsyncRetry_NonRetryableFailsImmediately(t *testing.T) {
+	repo := newAsyncRetryRepo(asyncRetryDSL(t, "test.engine.retry.validation.v1", 3))
+
+	run := repo.waitFinished(t, asyncManagerConfig())
+	if run.Status != port.RunStatusFailed || run.RetryCount != 0 {
+		t.Fatalf("expected failed without retry, got status=%s retry_count=%d", run.Status, run.RetryCount)
+	}
+	if len(run.Attempts) != 1 || run.Attempts[0].ErrorClass != "validation" {
+		t.Fatalf("expected one validation attempt, got %+v", run.Attempts)
+	}
+	t.Logf("✅ Validation failure not retried")
+}
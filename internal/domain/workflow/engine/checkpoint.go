@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// eventTypeCheckpoint 引擎内部的检查点标记事件（不对外转发）
+const eventTypeCheckpoint event.EventType = "internal_checkpoint"
+
+// CheckpointFunc 检查点持久化回调，每个节点处理完成后调用
+type CheckpointFunc func(ctx context.Context, cp *port.RunCheckpoint) error
+
+// SetCheckpointer 设置检查点回调（须在 Run 之前调用）
+func (e *GraphEngine) SetCheckpointer(runID string, fn CheckpointFunc) {
+	e.runID = runID
+	e.checkpointer = fn
+}
+
+// Restore 从检查点恢复引擎状态（须在 Run 之前调用）
+// 恢复后 Run 将跳过已完成节点，从检查点前沿继续执行
+func (e *GraphEngine) Restore(cp *port.RunCheckpoint) error {
+	if cp == nil {
+		return nil
+	}
+
+	if len(cp.State) > 0 {
+		if err := e.runtimeState.Restore(cp.State); err != nil {
+			return err
+		}
+	}
+	if len(cp.VariablePool) > 0 {
+		var snapshot map[string]interface{}
+		if err := json.Unmarshal(cp.VariablePool, &snapshot); err != nil {
+			return fmt.Errorf("unmarshal variable pool: %w", err)
+		}
+		e.runtimeState.VariablePool.Load(snapshot)
+	}
+
+	for _, nodeID := range cp.CompletedNodes {
+		if _, ok := e.graph.Nodes[nodeID]; !ok {
+			return fmt.Errorf("checkpoint node %s not found in graph", nodeID)
+		}
+		e.finishedNodes[nodeID] = true
+		e.completedNodes.Store(nodeID, true)
+	}
+	for nodeID, state := range cp.NodeStates {
+		if n, ok := e.graph.Nodes[nodeID]; ok {
+			n.SetState(types.NodeState(state))
+		}
+	}
+	for edgeID, state := range cp.EdgeStates {
+		if edge, ok := e.graph.Edges[edgeID]; ok {
+			edge.SetState(types.NodeState(state))
+		}
+	}
+
+	e.nodeExecMu.Lock()
+	e.nodeExecutions = append(e.nodeExecutions[:0], cp.NodeExecutions...)
+	e.nodeExecMu.Unlock()
+
+	if cp.RunID != "" {
+		e.runID = cp.RunID
+	}
+	e.checkpointSeq = cp.Sequence
+	e.restored = true
+	return nil
+}
+
+// resumeFrontier 计算恢复执行的起始节点：
+// 入边已被选中（taken）但自身尚未完成的节点（含崩溃时正在执行的节点）
+func (e *GraphEngine) resumeFrontier() []string {
+	rootID := e.graph.RootNode.ID()
+	if !e.finishedNodes[rootID] {
+		return []string{rootID}
+	}
+
+	seen := make(map[string]bool)
+	frontier := make([]string, 0)
+	for _, edge := range e.graph.Edges {
+		if edge.GetState() != types.NodeStateTaken {
+			continue
+		}
+		if e.finishedNodes[edge.Head] || seen[edge.Head] {
+			continue
+		}
+		n, ok := e.graph.Nodes[edge.Head]
+		if !ok || n.State() == types.NodeStateSkipped {
+			continue
+		}
+		seen[edge.Head] = true
+		frontier = append(frontier, edge.Head)
+	}
+	sort.Strings(frontier)
+	return frontier
+}
+
+// snapshot 生成当前执行状态的检查点
+func (e *GraphEngine) snapshot() (*port.RunCheckpoint, error) {
+	e.stateMu.Lock()
+	completed := make([]string, 0, len(e.finishedNodes))
+	for nodeID := range e.finishedNodes {
+		completed = append(completed, nodeID)
+	}
+	nodeStates := make(map[string]string)
+	for nodeID, n := range e.graph.Nodes {
+		if st := n.State(); st != types.NodeStateUnknown && st != "" {
+			nodeStates[nodeID] = string(st)
+		}
+	}
+	edgeStates := make(map[string]string)
+	for edgeID, edge := range e.graph.Edges {
+		if st := edge.GetState(); st != types.NodeStateUnknown && st != "" {
+			edgeStates[edgeID] = string(st)
+		}
+	}
+	e.stateMu.Unlock()
+	sort.Strings(completed)
+
+	state, err := e.runtimeState.Dump()
+	if err != nil {
+		return nil, fmt.Errorf("dump runtime state: %w", err)
+	}
+	pool, err := json.Marshal(e.runtimeState.VariablePool.Dump())
+	if err != nil {
+		return nil, fmt.Errorf("dump variable pool: %w", err)
+	}
+
+	e.checkpointSeq++
+	return &port.RunCheckpoint{
+		RunID:          e.runID,
+		Sequence:       e.checkpointSeq,
+		State:          state,
+		VariablePool:   pool,
+		CompletedNodes: completed,
+		NodeStates:     nodeStates,
+		EdgeStates:     edgeStates,
+		NodeExecutions: e.GetNodeExecutions(),
+	}, nil
+}
+
+// saveCheckpoint 生成并持久化检查点（由 dispatcher 串行调用，失败仅记录日志）
+func (e *GraphEngine) saveCheckpoint(ctx context.Context) {
+	if e.checkpointer == nil {
+		return
+	}
+	cp, err := e.snapshot()
+	if err != nil {
+		e.logger.Warn("failed to build checkpoint", "run_id", e.runID, "error", err)
+		return
+	}
+	if err := e.checkpointer(ctx, cp); err != nil {
+		e.logger.Warn("failed to save checkpoint", "run_id", e.runID, "sequence", cp.Sequence, "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// childSink 外层引擎接收子图节点事件的入口（随上下文传给容器节点）
+type childSink func(evt event.NodeEvent)
+
+type childSinkKeyType struct{}
+
+type childLinkKeyType struct{}
+
+// childLink 子图引擎与外层引擎的关联：本引擎的节点事件附带 parent 后交给 forward
+type childLink struct {
+	parent  port.NodeParent
+	forward childSink
+}
+
+// IterationItemParent 迭代第 index 项的层级信息，每项生成独立的执行 ID
+func IterationItemParent(nodeID string, index int) port.NodeParent {
+	return port.NodeParent{
+		ParentNodeID:      nodeID,
+		ParentExecutionID: node.GenerateExecutionID(),
+		IterationIndex:    &index,
+	}
+}
+
+// LoopRoundParent 循环第 round 轮的层级信息，每轮生成独立的执行 ID
+func LoopRoundParent(nodeID string, round int) port.NodeParent {
+	return port.NodeParent{
+		ParentNodeID:      nodeID,
+		ParentExecutionID: node.GenerateExecutionID(),
+		LoopRound:         &round,
+	}
+}
+
+// WithChildParent 标记以 ctx 运行的子图引擎属于容器节点的某一项：子图内的节点事件附带层级信息
+// 逐级转发到最外层引擎，由其输出并记入节点执行明细。ctx 不来自引擎节点（如单节点调试）时原样返回
+func WithChildParent(ctx context.Context, parent port.NodeParent) context.Context {
+	forward, ok := ctx.Value(childSinkKeyType{}).(childSink)
+	if !ok || forward == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, childLinkKeyType{}, &childLink{parent: parent, forward: forward})
+}
+
+// bindChildEvents 读取本引擎与外层引擎的关联，并为本引擎的节点注入接收子图事件的入口。
+// 关联不向下继承：节点内另起的引擎（如子工作流）不会把事件转发到本引擎
+func (e *GraphEngine) bindChildEvents(ctx context.Context) context.Context {
+	if link, ok := ctx.Value(childLinkKeyType{}).(*childLink); ok && link != nil {
+		e.parent = link
+	}
+	ctx = context.WithValue(ctx, childLinkKeyType{}, (*childLink)(nil))
+	return context.WithValue(ctx, childSinkKeyType{}, childSink(func(evt event.NodeEvent) {
+		e.acceptChildEvent(ctx, evt)
+	}))
+}
+
+// acceptChildEvent 子图节点事件进入事件队列，与本引擎节点事件保持先后顺序
+func (e *GraphEngine) acceptChildEvent(ctx context.Context, evt event.NodeEvent) {
+	e.queueMu.RLock()
+	defer e.queueMu.RUnlock()
+	if e.queueClosed {
+		return
+	}
+	select {
+	case e.eventQueue <- evt:
+	case <-ctx.Done():
+	}
+}
+
+// forwardToParent 子图引擎将本引擎的节点事件附带层级信息转发给外层引擎
+func (e *GraphEngine) forwardToParent(evt event.NodeEvent) {
+	switch evt.Type {
+	case event.EventTypeNodeRunStarted, event.EventTypeNodeRunSucceeded, event.EventTypeNodeRunFailed,
+		event.EventTypeNodeRunPaused, event.EventTypeNodeStreamChunk:
+		evt.NodeParent = e.parent.parent
+		e.parent.forward(evt)
+	}
+}
+
+// dispatchChildEvent 子图转发来的事件：嵌套子图继续向外层转发；最外层引擎记录执行明细并输出。
+// 子图节点的失败由容器节点按自身策略处理，不计入本次运行的异常数
+func (e *GraphEngine) dispatchChildEvent(evt event.NodeEvent, outputCh chan<- event.GraphEvent) {
+	if e.parent != nil {
+		e.parent.forward(evt)
+		return
+	}
+
+	graphEvt := event.GraphEvent{
+		Type:       evt.Type,
+		NodeID:     evt.NodeID,
+		NodeParent: evt.NodeParent,
+	}
+	switch evt.Type {
+	case event.EventTypeNodeRunStarted:
+		e.nodeExecMu.Lock()
+		e.nodeStartTimes[nodeStartKey(evt)] = evt.StartAt
+		e.nodeExecMu.Unlock()
+	case event.EventTypeNodeRunSucceeded:
+		e.addNodeExec(evt, "succeeded")
+	case event.EventTypeNodeRunFailed:
+		graphEvt.Error = evt.Error
+		e.addNodeExec(evt, "failed")
+	case event.EventTypeNodeRunPaused:
+		e.addNodeExec(evt, "paused")
+	case event.EventTypeNodeStreamChunk:
+		graphEvt.Chunk = evt.Chunk
+	}
+	outputCh <- graphEvt
+}
+
+// nodeStartKey 节点开始时间的索引：子图节点在每个迭代项 / 循环轮次中各执行一次
+func nodeStartKey(evt event.NodeEvent) string {
+	if evt.ParentExecutionID == "" {
+		return evt.NodeID
+	}
+	return evt.ParentExecutionID + "/" + evt.NodeID
+}
+
+// nodeStartTime 取节点开始时间，没有记录时以当前时间代替
+func (e *GraphEngine) nodeStartTime(evt event.NodeEvent) time.Time {
+	if startTime, ok := e.nodeStartTimes[nodeStartKey(evt)]; ok {
+		return startTime
+	}
+	return time.Now()
+}
@@ -2,8 +2,11 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,9 +22,12 @@ import (
 
 // Config 引擎配置
 type Config struct {
-	MaxWorkers   int           // 最大并行工作线程数
-	NodeTimeout  time.Duration // 单个节点执行超时
-	MaxNodeSteps int           // 最大节点执行步数
+	MaxWorkers     int           // 最大并行工作线程数
+	NodeTimeout    time.Duration // 单个节点执行超时
+	MaxNodeSteps   int           // 最大节点执行步数
+	MaxOutputBytes int           // 单个节点输出大小上限（字节，0 不限制）
+
+	Limiter *ConcurrencyLimiter // 进程级节点并发限制器（跨运行共享，可为空）
 }
 
 // DefaultConfig 默认配置
@@ -33,6 +39,21 @@ func DefaultConfig() *Config {
 	}
 }
 
+// WithNodeDefaults 返回应用了工作流级节点默认限制的配置副本
+func (c *Config) WithNodeDefaults(defaults *types.NodeLimits) *Config {
+	cfg := *c
+	if defaults == nil {
+		return &cfg
+	}
+	if defaults.TimeoutMs > 0 {
+		cfg.NodeTimeout = time.Duration(defaults.TimeoutMs) * time.Millisecond
+	}
+	if defaults.MaxOutputBytes > 0 {
+		cfg.MaxOutputBytes = defaults.MaxOutputBytes
+	}
+	return &cfg
+}
+
 // GraphEngine 队列驱动的图执行引擎
 type GraphEngine struct {
 	graph        *graph.Graph
@@ -44,6 +65,9 @@ type GraphEngine struct {
 	readyQueue chan string
 	// 事件队列（节点执行产出的事件）
 	eventQueue chan event.NodeEvent
+	// 保护命令处理器向已关闭的事件队列写入
+	queueMu     sync.RWMutex
+	queueClosed bool
 	// 完成信号
 	completedNodes sync.Map // node_id -> bool
 	// 活跃节点计数（原子操作，避免竞态）
@@ -62,6 +86,21 @@ type GraphEngine struct {
 	nodeExecMu     sync.Mutex
 	nodeExecutions []port.NodeExecution
 	nodeStartTimes map[string]time.Time // node_id -> start time
+
+	// 检查点（崩溃恢复）
+	stateMu       sync.Mutex      // 保护 finishedNodes 与边/节点状态迁移的一致性
+	finishedNodes map[string]bool // 已处理完出边的节点
+	runID         string
+	checkpointer  CheckpointFunc
+	checkpointSeq int64
+	restored      bool
+
+	// 挂起等待人工输入的节点
+	suspendMu sync.Mutex
+	suspended []*port.PendingInput
+
+	// 作为迭代 / 循环子图运行时与外层引擎的关联（顶层引擎为空）
+	parent *childLink
 }
 
 // New 创建新的 GraphEngine
@@ -83,6 +122,7 @@ func New(
 		eventQueue:     make(chan event.NodeEvent, 256),
 		commandCh:      make(chan types.Command, 16),
 		nodeStartTimes: make(map[string]time.Time),
+		finishedNodes:  make(map[string]bool),
 	}
 	eng.pauseCond = sync.NewCond(&eng.pauseMu)
 	return eng
@@ -107,6 +147,14 @@ func (e *GraphEngine) Run(ctx context.Context) <-chan event.GraphEvent {
 		// 将变量池注入上下文（供节点使用）
 		ctx = context.WithValue(ctx, node.ContextKeyVariablePool, e.runtimeState.VariablePool)
 
+		// 并发限制器随上下文传递给子图引擎（迭代 / 循环）
+		if e.config.Limiter != nil {
+			ctx = withLimiter(ctx, e.config.Limiter)
+		}
+
+		// 子图引擎的节点事件经上下文转发给外层引擎
+		ctx = e.bindChildEvents(ctx)
+
 		// 检查根节点
 		rootNode := e.graph.RootNode
 		if rootNode.State() == types.NodeStateSkipped {
@@ -114,12 +162,31 @@ func (e *GraphEngine) Run(ctx context.Context) <-chan event.GraphEvent {
 			return
 		}
 
-		// 入队根节点（先增加计数再入队）
-		e.pendingCount.Add(1)
-		e.readyQueue <- rootNode.ID()
+		// 入队起始节点（先增加计数再入队）：全新执行从根节点开始，恢复执行从检查点前沿继续
+		startNodes := []string{rootNode.ID()}
+		if e.restored {
+			startNodes = e.resumeFrontier()
+			e.logger.Info("resuming from checkpoint", "run_id", e.runID, "frontier", startNodes)
+		}
+		for _, nodeID := range startNodes {
+			e.enqueueNode(nodeID)
+		}
+		if len(startNodes) == 0 {
+			e.closeOnce.Do(func() {
+				close(e.readyQueue)
+			})
+		}
 
 		// 启动命令处理器
-		go e.commandHandler(cancel)
+		go e.commandHandler(ctx, cancel)
+
+		// 取消时唤醒暂停中的 worker
+		go func() {
+			<-ctx.Done()
+			e.pauseMu.Lock()
+			e.pauseCond.Broadcast()
+			e.pauseMu.Unlock()
+		}()
 
 		// 启动工作协程池
 		var workerWg sync.WaitGroup
@@ -138,7 +205,10 @@ func (e *GraphEngine) Run(ctx context.Context) <-chan event.GraphEvent {
 
 		// 等待所有工作完成
 		workerWg.Wait()
+		e.queueMu.Lock()
+		e.queueClosed = true
 		close(e.eventQueue)
+		e.queueMu.Unlock()
 		dispatcherDone.Wait()
 
 		// 生成最终事件（附带节点执行明细）
@@ -156,6 +226,18 @@ func (e *GraphEngine) Run(ctx context.Context) <-chan event.GraphEvent {
 			failEvt := event.NewGraphRunFailedEvent(errMsg, execution.ExceptionsCount)
 			failEvt.NodeExecutions = nodeExecs
 			outputCh <- failEvt
+		} else if pending := e.PendingInputs(); len(pending) > 0 {
+			outputCh <- e.pausedEvent(ctx, pending, nodeExecs)
+		} else if err := ctx.Err(); err != nil {
+			// 外部取消或超时（如暂停期间到达运行超时）：未执行完的节点不能视为成功
+			failEvt := event.NewGraphRunFailedEvent(fmt.Sprintf("workflow execution interrupted: %v", err), execution.ExceptionsCount)
+			failEvt.NodeExecutions = nodeExecs
+			outputCh <- failEvt
+		} else if execution.ExceptionsCount > 0 {
+			// 有节点失败但被 fail-branch / default-value 吸收
+			partialEvt := event.NewGraphRunPartialSucceededEvent(e.runtimeState.GetOutputs(), execution.ExceptionsCount)
+			partialEvt.NodeExecutions = nodeExecs
+			outputCh <- partialEvt
 		} else {
 			outputs := e.runtimeState.GetOutputs()
 			successEvt := event.NewGraphRunSucceededEvent(outputs)
@@ -179,8 +261,15 @@ func (e *GraphEngine) SendCommand(cmd types.Command) {
 }
 
 // commandHandler 命令处理器
-func (e *GraphEngine) commandHandler(cancel context.CancelFunc) {
-	for cmd := range e.commandCh {
+func (e *GraphEngine) commandHandler(ctx context.Context, cancel context.CancelFunc) {
+	for {
+		var cmd types.Command
+		select {
+		case <-ctx.Done():
+			return
+		case cmd = <-e.commandCh:
+		}
+
 		switch cmd.Type {
 		case types.CommandAbort:
 			e.logger.Info("received abort command", "reason", cmd.Reason)
@@ -189,18 +278,37 @@ func (e *GraphEngine) commandHandler(cancel context.CancelFunc) {
 
 		case types.CommandPause:
 			e.logger.Info("received pause command", "reason", cmd.Reason)
-			e.paused.Store(true)
-			e.runtimeState.Execution().Pause()
+			if !e.paused.Swap(true) {
+				e.runtimeState.Execution().Pause()
+				e.emitGraphEvent(ctx, event.EventTypeGraphRunPaused)
+			}
 
 		case types.CommandResume:
 			e.logger.Info("received resume command")
-			e.paused.Store(false)
-			e.runtimeState.Execution().Resume()
+			if e.paused.Swap(false) {
+				e.runtimeState.Execution().Resume()
+				e.emitGraphEvent(ctx, event.EventTypeGraphRunResumed)
+			}
+			e.pauseMu.Lock()
 			e.pauseCond.Broadcast()
+			e.pauseMu.Unlock()
 		}
 	}
 }
 
+// emitGraphEvent 经事件队列发出图级状态事件（保证与节点事件的先后顺序）
+func (e *GraphEngine) emitGraphEvent(ctx context.Context, eventType event.EventType) {
+	e.queueMu.RLock()
+	defer e.queueMu.RUnlock()
+	if e.queueClosed {
+		return
+	}
+	select {
+	case e.eventQueue <- event.NodeEvent{Type: eventType}:
+	case <-ctx.Done():
+	}
+}
+
 // checkPaused 阻塞等待直到恢复（如果已暂停）
 func (e *GraphEngine) checkPaused(ctx context.Context) bool {
 	if !e.paused.Load() {
@@ -248,6 +356,9 @@ func (e *GraphEngine) worker(ctx context.Context, wg *sync.WaitGroup) {
 			}
 
 			e.executeNode(ctx, nodeID)
+			if e.checkpointer != nil && !e.runtimeState.Execution().HasError() {
+				e.eventQueue <- event.NodeEvent{Type: eventTypeCheckpoint, NodeID: nodeID}
+			}
 			e.nodeFinished()
 		}
 	}
@@ -292,133 +403,309 @@ func (e *GraphEngine) executeNode(ctx context.Context, nodeID string) {
 	}
 
 	e.runtimeState.IncrementNodeRunSteps()
+	e.stateMu.Lock()
 	n.SetState(types.NodeStateTaken)
+	e.stateMu.Unlock()
 
-	// 确定重试次数
+	// 确定重试策略
+	var retry *types.RetryConfig
+	var attempts *attemptLog
 	maxAttempts := 1
-	retryInterval := time.Duration(0)
 	if n.ErrorStrategy() == types.ErrorStrategyRetry {
 		if rc := n.RetryConfig(); rc != nil && rc.MaxRetries > 0 {
+			retry = rc
+			attempts = &attemptLog{}
 			maxAttempts = rc.MaxRetries + 1
-			retryInterval = time.Duration(rc.RetryInterval) * time.Millisecond
 		}
 	}
 
-	var lastOutputs map[string]interface{}
-	var nodeErr string
-	var succeeded bool
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		if attempt > 0 {
-			e.logger.Info("retrying node", "node_id", nodeID, "attempt", attempt+1, "max", maxAttempts)
-			if retryInterval > 0 {
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(retryInterval):
-				}
-			}
+	var last nodeOutcome
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		last = e.runNodeOnce(ctx, nodeID, n, attempts)
+		if last.status != types.NodeExecutionStatusFailed {
+			break
 		}
 
-		outputs, errMsg, ok := e.runNodeOnce(ctx, nodeID, n)
-		if ok {
-			lastOutputs = outputs
-			succeeded = true
+		if attempt == maxAttempts {
+			break
+		}
+		if !retry.ShouldRetry(last.errClass) {
+			e.logger.Info("node error is not retryable", "node_id", nodeID, "error_class", last.errClass, "attempt", attempt)
 			break
 		}
-		nodeErr = errMsg
+
+		delay := retry.Backoff(attempt, rand.Float64())
+		attempts.setBackoff(delay)
+		e.logger.Info("retrying node",
+			"node_id", nodeID,
+			"attempt", attempt+1,
+			"max", maxAttempts,
+			"error_class", last.errClass,
+			"backoff", delay,
+		)
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
 	}
 
-	if !succeeded {
+	// 挂起：不处理出边，下游等待恢复执行后继续
+	if last.status == types.NodeExecutionStatusPaused {
+		e.logger.Info("node paused, waiting for input", "node_id", nodeID)
+		return
+	}
+
+	if last.status == types.NodeExecutionStatusFailed {
 		// 根据错误策略处理
 		switch n.ErrorStrategy() {
 		case types.ErrorStrategyFailBranch:
 			// 以失败状态继续执行下游 (通过 fail-branch edge)
-			e.logger.Info("node failed, following fail-branch", "node_id", nodeID)
-			e.eventQueue <- event.NewNodeRunFailedEvent("", nodeID, n.Type(), nodeErr)
-			e.runtimeState.VariablePool.SetNodeOutputs(nodeID, map[string]interface{}{
-				"__error__": nodeErr,
-			})
+			e.logger.Info("node failed, following fail-branch", "node_id", nodeID, "error_type", last.errType)
+			e.eventQueue <- attempts.failedEvent(nodeID, n, last)
+			errOutputs := map[string]interface{}{
+				"__error__":       last.err,
+				"__error_class__": string(last.errClass),
+			}
+			if last.errType != "" {
+				errOutputs["__error_type__"] = last.errType
+			}
+			e.runtimeState.VariablePool.SetNodeOutputs(nodeID, errOutputs)
 			e.processEdges(ctx, nodeID, nil, true) // failed=true
 			return
 
 		case types.ErrorStrategyDefaultValue:
 			// 使用默认值作为输出
-			e.logger.Info("node failed, using default value", "node_id", nodeID)
+			e.logger.Info("node failed, using default value", "node_id", nodeID, "error_type", last.errType)
+			e.runtimeState.Execution().IncrementExceptions()
 			defaultOutputs := n.DefaultValue()
 			if defaultOutputs == nil {
 				defaultOutputs = make(map[string]interface{})
 			}
 			e.runtimeState.VariablePool.SetNodeOutputs(nodeID, defaultOutputs)
 
-			// 发送成功事件（带默认值）
+			// 发送成功事件（带默认值，元数据中保留原始错误）
 			execID := node.GenerateExecutionID()
 			successEvt := event.NewNodeRunSucceededEvent(execID, nodeID, n.Type(), defaultOutputs)
-			successEvt.Metadata = map[string]interface{}{"used_default_value": true}
+			successEvt.Metadata = map[string]interface{}{
+				"used_default_value": true,
+				"error":              last.err,
+				"error_class":        string(last.errClass),
+			}
+			if last.errType != "" {
+				successEvt.Metadata["error_type"] = last.errType
+			}
+			attempts.annotate(&successEvt)
 			e.eventQueue <- successEvt
 			e.processEdges(ctx, nodeID, defaultOutputs, false)
 			return
 
 		default:
 			// 无策略或 retry 已耗尽：报错
-			e.logger.Error("node failed", "node_id", nodeID, "error", nodeErr)
-			e.runtimeState.Execution().Fail(fmt.Errorf("node %s failed: %s", nodeID, nodeErr))
-			e.eventQueue <- event.NewNodeRunFailedEvent("", nodeID, n.Type(), nodeErr)
+			e.logger.Error("node failed", "node_id", nodeID, "error", last.err)
+			e.runtimeState.Execution().Fail(fmt.Errorf("node %s failed: %s", nodeID, last.err))
+			e.eventQueue <- attempts.failedEvent(nodeID, n, last)
 			return
 		}
 	}
 
 	// 成功：将输出写入变量池
-	if lastOutputs != nil {
-		e.runtimeState.VariablePool.SetNodeOutputs(nodeID, lastOutputs)
+	if last.outputs != nil {
+		e.runtimeState.VariablePool.SetNodeOutputs(nodeID, last.outputs)
+	}
+
+	e.processEdges(ctx, nodeID, last.outputs, false)
+}
+
+// nodeOutcome 节点单次执行结果
+type nodeOutcome struct {
+	status   types.NodeExecutionStatus // succeeded / failed / paused
+	outputs  map[string]interface{}
+	err      string
+	errClass types.ErrorClass
+	errType  string // 引擎判定的错误类型（node_timeout / node_output_too_large）
+
+	queueWait *time.Duration // 受并发限制时的排队等待时长
+}
+
+// acquireSlot 从并发限制器获取节点槽位；回放模式下以录制响应代替的节点不占用槽位
+func (e *GraphEngine) acquireSlot(ctx context.Context, n node.Node) (func(), time.Duration, bool, error) {
+	limiter := e.config.Limiter
+	if limiter == nil {
+		limiter = limiterFromContext(ctx)
+	}
+	if _, replaying := node.GetReplaySourceFromContext(ctx); replaying && node.IsReplayable(n.Type()) {
+		limiter = nil
 	}
+	return limiter.Acquire(ctx, n)
+}
 
-	e.processEdges(ctx, nodeID, lastOutputs, false)
+// nodeTimeout 节点执行超时：节点配置优先，其次引擎配置
+func (e *GraphEngine) nodeTimeout(n node.Node) time.Duration {
+	if ms := n.Limits().TimeoutMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return e.config.NodeTimeout
+}
+
+// maxOutputBytes 节点输出大小上限：节点配置优先，其次引擎配置
+func (e *GraphEngine) maxOutputBytes(n node.Node) int {
+	if limit := n.Limits().MaxOutputBytes; limit > 0 {
+		return limit
+	}
+	return e.config.MaxOutputBytes
 }
 
-// runNodeOnce 执行一次节点，返回 (outputs, errMsg, success)
+// runNodeOnce 执行一次节点；attempts 非空时记录本次尝试
+// 超时由引擎强制执行：超时后不再等待节点返回，直接判定为 node_timeout
 // 注意：NodeRunFailed 事件不会在此转发，由上层策略处理器决定如何处理
-func (e *GraphEngine) runNodeOnce(ctx context.Context, nodeID string, n node.Node) (map[string]interface{}, string, bool) {
+func (e *GraphEngine) runNodeOnce(ctx context.Context, nodeID string, n node.Node, attempts *attemptLog) nodeOutcome {
+	startedAt := time.Now()
+
+	// 获取全局并发槽位（排队时间不计入节点超时）
+	release, queueWait, limited, err := e.acquireSlot(ctx, n)
+	fail := func(out nodeOutcome) nodeOutcome {
+		out.status = types.NodeExecutionStatusFailed
+		if out.errClass == "" {
+			out.errClass = types.ErrorClassUnknown
+		}
+		if limited {
+			out.queueWait = &queueWait
+		}
+		attempts.addFailure(startedAt, out.err, out.errClass)
+		return out
+	}
+	if err != nil {
+		return fail(nodeOutcome{
+			err:      fmt.Sprintf("node %s canceled while waiting for a concurrency slot: %v", nodeID, err),
+			errClass: node.ClassifyError(err),
+		})
+	}
+	// 超时放弃的节点仍在运行，槽位在其事件通道关闭后才归还
+	releaseOnReturn := true
+	defer func() {
+		if releaseOnReturn {
+			release()
+		}
+	}()
+	startedAt = time.Now()
+
 	// 创建带超时的上下文
-	nodeCtx, nodeCancel := context.WithTimeout(ctx, e.config.NodeTimeout)
+	timeout := e.nodeTimeout(n)
+	nodeCtx, nodeCancel := context.WithTimeout(ctx, timeout)
 	defer nodeCancel()
+	timedOut := func() nodeOutcome {
+		return fail(nodeOutcome{
+			err:      fmt.Sprintf("%s: node %s exceeded timeout of %s", types.NodeErrorTypeTimeout, nodeID, timeout),
+			errClass: types.ErrorClassTimeout,
+			errType:  types.NodeErrorTypeTimeout,
+		})
+	}
 
-	// 执行节点
-	eventCh, err := n.Run(nodeCtx)
+	// 执行节点（回放模式下可回放节点返回录制响应）
+	eventCh, err := node.Execute(nodeCtx, n)
 	if err != nil {
-		return nil, err.Error(), false
+		return fail(nodeOutcome{err: err.Error(), errClass: node.ClassifyError(err)})
 	}
 
 	// 收集节点事件
 	var lastOutputs map[string]interface{}
-	var failed bool
-	var failErr string
+	var failed *nodeOutcome
+	var paused bool
 
-	for evt := range eventCh {
-		switch evt.Type {
-		case event.EventTypeNodeRunFailed:
-			// 不转发失败事件，由上层策略处理器负责
-			failed = true
-			failErr = evt.Error
-		default:
-			// 转发其他事件（started, succeeded, stream chunk）
-			e.eventQueue <- evt
-			if evt.Type == event.EventTypeNodeRunSucceeded && evt.Outputs != nil {
-				lastOutputs = evt.Outputs
+	deadline := nodeCtx.Done()
+collect:
+	for {
+		select {
+		case evt, ok := <-eventCh:
+			if !ok {
+				break collect
 			}
+			switch evt.Type {
+			case event.EventTypeNodeRunFailed:
+				// 不转发失败事件，由上层策略处理器负责
+				failed = &nodeOutcome{err: evt.Error, errClass: evt.ErrorClass}
+			case event.EventTypeNodeRunPaused:
+				paused = true
+				e.suspend(nodeID, evt)
+				e.eventQueue <- evt
+			case event.EventTypeNodeRunSucceeded:
+				if limit := e.maxOutputBytes(n); limit > 0 {
+					if size := outputSize(evt.Outputs); size > limit {
+						failed = &nodeOutcome{
+							err:      fmt.Sprintf("%s: node %s output is %d bytes, exceeds limit of %d bytes", types.NodeErrorTypeOutputTooLarge, nodeID, size, limit),
+							errClass: types.ErrorClassSchemaViolation,
+							errType:  types.NodeErrorTypeOutputTooLarge,
+						}
+						continue
+					}
+				}
+				attempts.addSuccess(startedAt)
+				attempts.annotate(&evt)
+				if limited {
+					annotateQueueWait(&evt, queueWait)
+				}
+				e.eventQueue <- evt
+				if evt.Outputs != nil {
+					lastOutputs = evt.Outputs
+				}
+			default:
+				// 转发其他事件（started, stream chunk）
+				e.eventQueue <- evt
+			}
+		case <-deadline:
+			if ctx.Err() != nil {
+				// 整体取消：等待节点自行退出
+				deadline = nil
+				continue
+			}
+			// 节点超时：丢弃其后续事件，不再等待；节点退出后释放槽位
+			releaseOnReturn = false
+			go func() {
+				for range eventCh {
+				}
+				release()
+			}()
+			return timedOut()
 		}
 	}
 
-	if failed {
-		return nil, failErr, false
+	if failed != nil {
+		if errors.Is(nodeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return timedOut()
+		}
+		return fail(*failed)
+	}
+	if paused {
+		return nodeOutcome{status: types.NodeExecutionStatusPaused}
 	}
 
-	return lastOutputs, "", true
+	return nodeOutcome{status: types.NodeExecutionStatusSucceeded, outputs: lastOutputs}
+}
+
+// outputSize 节点输出 JSON 序列化后的字节数
+func outputSize(outputs map[string]interface{}) int {
+	if len(outputs) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return 0
+	}
+	return len(data)
 }
 
 // processEdges 处理节点的出边，确定并入队后续节点
 // nodeFailed 标记该节点是否以失败状态到达（用于 fail-branch）
 func (e *GraphEngine) processEdges(ctx context.Context, nodeID string, outputs map[string]interface{}, nodeFailed bool) {
+	// 边状态迁移与完成标记在同一把锁内进行，保证检查点快照的一致性
+	e.stateMu.Lock()
+	e.finishedNodes[nodeID] = true
+
+	var readyNodes []string
 	outEdges := e.graph.GetOutgoingEdges(nodeID)
 
 	for _, edge := range outEdges {
@@ -439,6 +726,16 @@ func (e *GraphEngine) processEdges(ctx context.Context, nodeID string, outputs m
 			continue
 		}
 
+		// join: any —— 首条完成的入边触发，之后完成的入边仅标记为 taken
+		if targetNode.JoinMode() == types.JoinModeAny {
+			triggered := e.anyInEdgeTaken(targetNodeID)
+			edge.SetState(types.NodeStateTaken)
+			if !triggered && targetNode.State() != types.NodeStateSkipped {
+				readyNodes = append(readyNodes, targetNodeID)
+			}
+			continue
+		}
+
 		// 检查目标节点的所有入边是否满足（合流）
 		if !e.allRequiredInEdgesReady(targetNodeID) {
 			continue
@@ -448,9 +745,14 @@ func (e *GraphEngine) processEdges(ctx context.Context, nodeID string, outputs m
 
 		// 入队目标节点
 		if targetNode.State() != types.NodeStateSkipped {
-			e.enqueueNode(targetNodeID)
+			readyNodes = append(readyNodes, targetNodeID)
 		}
 	}
+	e.stateMu.Unlock()
+
+	for _, targetNodeID := range readyNodes {
+		e.enqueueNode(targetNodeID)
+	}
 }
 
 // shouldFollowEdge 判断是否应该走这条边（条件分支判断）
@@ -496,6 +798,16 @@ func (e *GraphEngine) allRequiredInEdgesReady(nodeID string) bool {
 	return true
 }
 
+// anyInEdgeTaken 检查目标节点是否已有入边被选中（join: any 节点已被触发）
+func (e *GraphEngine) anyInEdgeTaken(nodeID string) bool {
+	for _, edge := range e.graph.GetIncomingEdges(nodeID) {
+		if edge.GetState() == types.NodeStateTaken {
+			return true
+		}
+	}
+	return false
+}
+
 // propagateSkip 向下游传播 SKIPPED 状态
 func (e *GraphEngine) propagateSkip(nodeID string) {
 	targetNode, ok := e.graph.Nodes[nodeID]
@@ -533,6 +845,18 @@ func (e *GraphEngine) dispatcher(ctx context.Context, outputCh chan<- event.Grap
 		default:
 		}
 
+		// 检查点标记：此前该节点的事件均已记录，落盘后不对外转发
+		if evt.Type == eventTypeCheckpoint {
+			e.saveCheckpoint(ctx)
+			continue
+		}
+
+		// 迭代 / 循环子图转发来的节点事件
+		if evt.ParentNodeID != "" {
+			e.dispatchChildEvent(evt, outputCh)
+			continue
+		}
+
 		graphEvt := event.GraphEvent{
 			Type:   evt.Type,
 			NodeID: evt.NodeID,
@@ -579,10 +903,28 @@ func (e *GraphEngine) dispatcher(ctx context.Context, outputCh chan<- event.Grap
 			// 记录节点执行失败
 			e.addNodeExec(evt, "failed")
 
+		case event.EventTypeNodeRunPaused:
+			e.logger.Info("node paused",
+				"node_id", evt.NodeID,
+				"node_type", evt.NodeType,
+			)
+			if pi, ok := evt.Metadata["pending_input"].(*port.PendingInput); ok {
+				graphEvt.PendingInputs = []*port.PendingInput{pi}
+			}
+
+			// 记录节点挂起
+			e.addNodeExec(evt, "paused")
+
 		case event.EventTypeNodeStreamChunk:
 			graphEvt.Chunk = evt.Chunk
+
+		case event.EventTypeGraphRunPaused, event.EventTypeGraphRunResumed:
+			e.logger.Info("graph run state changed", "run_id", e.runID, "type", evt.Type)
 		}
 
+		if e.parent != nil {
+			e.forwardToParent(evt)
+		}
 		outputCh <- graphEvt
 	}
 }
@@ -592,22 +934,29 @@ func (e *GraphEngine) addNodeExec(evt event.NodeEvent, status string) {
 	e.nodeExecMu.Lock()
 	defer e.nodeExecMu.Unlock()
 
-	startTime, ok := e.nodeStartTimes[evt.NodeID]
-	if !ok {
-		startTime = time.Now()
-	}
+	startTime := e.nodeStartTime(evt)
 	elapsedMs := time.Since(startTime).Milliseconds()
 
+	metadata := evt.Metadata
+	if evt.ErrorClass != "" {
+		metadata = make(map[string]interface{}, len(evt.Metadata)+1)
+		for k, v := range evt.Metadata {
+			metadata[k] = v
+		}
+		metadata["error_class"] = string(evt.ErrorClass)
+	}
+
 	exec := port.NodeExecution{
-		NodeID:    evt.NodeID,
-		NodeType:  string(evt.NodeType),
-		Title:     evt.NodeTitle,
-		Status:    status,
-		Outputs:   evt.Outputs,
-		Error:     evt.Error,
-		StartedAt: startTime,
-		ElapsedMs: elapsedMs,
-		Metadata:  evt.Metadata,
+		NodeID:     evt.NodeID,
+		NodeType:   string(evt.NodeType),
+		Title:      evt.NodeTitle,
+		Status:     status,
+		Outputs:    evt.Outputs,
+		Error:      evt.Error,
+		StartedAt:  startTime,
+		ElapsedMs:  elapsedMs,
+		Metadata:   metadata,
+		NodeParent: evt.NodeParent,
 	}
 
 	e.nodeExecutions = append(e.nodeExecutions, exec)
@@ -0,0 +1,211 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+const humanInputDSL = `{
+	"nodes": [
+		{
+			"id": "start_1",
+			"data": {
+				"type": "start",
+				"title": "Start",
+				"variables": [
+					{"variable": "text", "label": "Text", "type": "string", "required": true}
+				]
+			}
+		},
+		{
+			"id": "review_1",
+			"data": {
+				"type": "human-input",
+				"title": "Review",
+				"prompt": "Please review: {{#start_1.text#}}",
+				"fields": [
+					{"name": "comment", "type": "text", "required": true}
+				],
+				"actions": [
+					{"id": "approve", "title": "Approve"},
+					{"id": "reject", "title": "Reject"}
+				],
+				"assignee": "alice",
+				"timeout_seconds": 60
+			}
+		},
+		{
+			"id": "end_approved",
+			"data": {
+				"type": "end",
+				"title": "Approved",
+				"outputs": [
+					{"variable": "comment", "value_selector": ["review_1", "comment"]}
+				]
+			}
+		},
+		{
+			"id": "end_rejected",
+			"data": {
+				"type": "end",
+				"title": "Rejected",
+				"outputs": [
+					{"variable": "comment", "value_selector": ["review_1", "comment"]}
+				]
+			}
+		},
+		{
+			"id": "end_timeout",
+			"data": {
+				"type": "end",
+				"title": "Timed Out",
+				"outputs": [
+					{"variable": "timed_out", "value_selector": ["review_1", "timed_out"]}
+				]
+			}
+		}
+	],
+	"edges": [
+		{"source": "start_1", "target": "review_1"},
+		{"source": "review_1", "target": "end_approved", "sourceHandle": "approve"},
+		{"source": "review_1", "target": "end_rejected", "sourceHandle": "reject"},
+		{"source": "review_1", "target": "end_timeout", "sourceHandle": "timeout"}
+	]
+}`
+
+// roundTripCheckpoint 模拟检查点落库后再读取
+func roundTripCheckpoint(t *testing.T, cp *port.RunCheckpoint) *port.RunCheckpoint {
+	t.Helper()
+	raw, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("marshal checkpoint: %v", err)
+	}
+	var stored port.RunCheckpoint
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		t.Fatalf("unmarshal checkpoint: %v", err)
+	}
+	return &stored
+}
+
+// --- 人工输入：挂起与恢复 ---
+
+func TestHumanInput_PauseAndResumeWithSubmission(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(nil, nil)
+	inputs := map[string]interface{}{"text": "draft v1"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 第一次执行：在 review_1 挂起
+	paused, err := runner.RunSync(ctx, []byte(humanInputDSL), inputs, &workflow.RunOptions{RunID: "run-human-1"})
+	if err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if !paused.Paused {
+		t.Fatalf("expected run to pause, got outputs=%v", paused.Outputs)
+	}
+	if len(paused.PendingInputs) != 1 {
+		t.Fatalf("expected 1 pending input, got %d", len(paused.PendingInputs))
+	}
+	pi := paused.PendingInputs[0]
+	if pi.NodeID != "review_1" || pi.RunID != "run-human-1" || pi.Status != port.PendingInputStatusWaiting {
+		t.Fatalf("unexpected pending input: %+v", pi)
+	}
+	if pi.Prompt != "Please review: draft v1" {
+		t.Fatalf("expected rendered prompt, got %q", pi.Prompt)
+	}
+	if pi.Assignee != "alice" || len(pi.Actions) != 2 || len(pi.Fields) != 1 {
+		t.Fatalf("expected form definition to be carried over, got %+v", pi)
+	}
+	if pi.ExpiresAt == nil || pi.ExpiresAt.Before(time.Now()) {
+		t.Fatalf("expected expires_at in the future, got %v", pi.ExpiresAt)
+	}
+	if paused.Checkpoint == nil {
+		t.Fatal("expected checkpoint attached to paused result")
+	}
+	if len(paused.Outputs) != 0 {
+		t.Fatalf("expected no outputs while paused, got %v", paused.Outputs)
+	}
+
+	// 第二次执行：携带提交的响应从检查点恢复
+	resumed, err := runner.RunSync(ctx, []byte(humanInputDSL), inputs, &workflow.RunOptions{
+		RunID:      "run-human-1",
+		ResumeFrom: roundTripCheckpoint(t, paused.Checkpoint),
+		HumanInputs: map[string]*port.PendingInput{
+			"review_1": {
+				NodeID:      "review_1",
+				Status:      port.PendingInputStatusSubmitted,
+				Action:      "approve",
+				Response:    map[string]interface{}{"comment": "looks good"},
+				RespondedBy: "alice",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if resumed.Paused {
+		t.Fatal("expected resumed run to complete")
+	}
+	if resumed.Outputs["comment"] != "looks good" {
+		t.Fatalf("expected comment from approved branch, got %v", resumed.Outputs)
+	}
+
+	statuses := make(map[string][]string)
+	for _, exec := range resumed.NodeExecutions {
+		statuses[exec.NodeID] = append(statuses[exec.NodeID], exec.Status)
+	}
+	if len(statuses["start_1"]) != 1 {
+		t.Fatalf("expected start_1 to execute once, got %v", statuses["start_1"])
+	}
+	if got := statuses["review_1"]; len(got) != 2 || got[0] != "paused" || got[1] != "succeeded" {
+		t.Fatalf("expected review_1 paused then succeeded, got %v", got)
+	}
+	if _, ok := statuses["end_rejected"]; ok {
+		t.Fatal("expected reject branch to be skipped")
+	}
+
+	t.Logf("✅ Human input pause/resume test passed")
+}
+
+func TestHumanInput_TimeoutBranch(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(nil, nil)
+	inputs := map[string]interface{}{"text": "draft v2"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	paused, err := runner.RunSync(ctx, []byte(humanInputDSL), inputs, &workflow.RunOptions{RunID: "run-human-2"})
+	if err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if !paused.Paused {
+		t.Fatal("expected run to pause")
+	}
+
+	// 超时：action 为节点的 timeout_branch
+	resumed, err := runner.RunSync(ctx, []byte(humanInputDSL), inputs, &workflow.RunOptions{
+		RunID:      "run-human-2",
+		ResumeFrom: roundTripCheckpoint(t, paused.Checkpoint),
+		HumanInputs: map[string]*port.PendingInput{
+			"review_1": {
+				NodeID: "review_1",
+				Status: port.PendingInputStatusTimeout,
+				Action: "timeout",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if resumed.Outputs["timed_out"] != true {
+		t.Fatalf("expected timeout branch output, got %v", resumed.Outputs)
+	}
+
+	t.Logf("✅ Human input timeout branch test passed")
+}
@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+)
+
+// ConcurrencyLimit 节点并发限制规则
+// 仅配置 NodeType 时限制该类型的全部节点；配置 Provider / Model 时仅限制匹配的节点
+type ConcurrencyLimit struct {
+	NodeType      types.NodeType
+	Provider      string
+	Model         string
+	MaxConcurrent int
+}
+
+func (l ConcurrencyLimit) key() string {
+	return limiterKey(l.NodeType, l.Provider, l.Model)
+}
+
+func limiterKey(nodeType types.NodeType, provider, model string) string {
+	key := string(nodeType)
+	if provider != "" {
+		key += ":" + provider
+		if model != "" {
+			key += ":" + model
+		}
+	}
+	return key
+}
+
+// ConcurrencyLimiter 进程级节点并发限制器，在所有运行之间共享
+// 节点依次获取 类型 → 类型:provider → 类型:provider:model 三级中已配置的槽位，
+// 每级槽位按先到先得（FIFO）排队
+type ConcurrencyLimiter struct {
+	slots map[string]*fairSemaphore
+}
+
+// NewConcurrencyLimiter 创建并发限制器
+func NewConcurrencyLimiter(limits []ConcurrencyLimit) (*ConcurrencyLimiter, error) {
+	l := &ConcurrencyLimiter{slots: make(map[string]*fairSemaphore, len(limits))}
+	for _, limit := range limits {
+		if limit.NodeType == "" {
+			return nil, fmt.Errorf("concurrency limit requires node_type")
+		}
+		if limit.Model != "" && limit.Provider == "" {
+			return nil, fmt.Errorf("concurrency limit for %s: model requires provider", limit.NodeType)
+		}
+		if limit.MaxConcurrent <= 0 {
+			return nil, fmt.Errorf("concurrency limit for %s: max_concurrent must be > 0", limit.key())
+		}
+		if _, ok := l.slots[limit.key()]; ok {
+			return nil, fmt.Errorf("duplicate concurrency limit for %s", limit.key())
+		}
+		l.slots[limit.key()] = newFairSemaphore(limit.MaxConcurrent)
+	}
+	return l, nil
+}
+
+// Acquire 为节点获取所有匹配的槽位，返回释放函数与排队等待时长
+// limited 为 false 表示没有规则匹配该节点
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, n node.Node) (release func(), wait time.Duration, limited bool, err error) {
+	release = func() {}
+	if l == nil || len(l.slots) == 0 {
+		return release, 0, false, nil
+	}
+
+	keys := []string{limiterKey(n.Type(), "", "")}
+	if pm, ok := n.(node.ProviderModel); ok {
+		provider, model := pm.ProviderModel()
+		if provider != "" {
+			keys = append(keys, limiterKey(n.Type(), provider, ""))
+			if model != "" {
+				keys = append(keys, limiterKey(n.Type(), provider, model))
+			}
+		}
+	}
+
+	start := time.Now()
+	var held []*fairSemaphore
+	release = func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].release()
+		}
+	}
+	for _, key := range keys {
+		sem, ok := l.slots[key]
+		if !ok {
+			continue
+		}
+		limited = true
+		if err := sem.acquire(ctx); err != nil {
+			release()
+			return func() {}, time.Since(start), true, err
+		}
+		held = append(held, sem)
+	}
+	if !limited {
+		return release, 0, false, nil
+	}
+	return release, time.Since(start), true, nil
+}
+
+type limiterKeyType struct{}
+
+func withLimiter(ctx context.Context, l *ConcurrencyLimiter) context.Context {
+	return context.WithValue(ctx, limiterKeyType{}, l)
+}
+
+func limiterFromContext(ctx context.Context) *ConcurrencyLimiter {
+	l, _ := ctx.Value(limiterKeyType{}).(*ConcurrencyLimiter)
+	return l
+}
+
+// annotateQueueWait 将并发排队等待时长写入事件元数据（复制 map）
+func annotateQueueWait(evt *event.NodeEvent, wait time.Duration) {
+	metadata := make(map[string]interface{}, len(evt.Metadata)+1)
+	for k, v := range evt.Metadata {
+		metadata[k] = v
+	}
+	metadata["queue_wait_ms"] = wait.Milliseconds()
+	evt.Metadata = metadata
+}
+
+// fairSemaphore 先到先得的计数信号量
+type fairSemaphore struct {
+	mu      sync.Mutex
+	max     int
+	inUse   int
+	waiters list.List // chan struct{}
+}
+
+func newFairSemaphore(max int) *fairSemaphore {
+	return &fairSemaphore{max: max}
+}
+
+func (s *fairSemaphore) acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inUse < s.max && s.waiters.Len() == 0 {
+		s.inUse++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// 取消的同时已被分配槽位：归还给下一个等待者
+			s.mu.Unlock()
+			s.release()
+		default:
+			s.waiters.Remove(elem)
+			s.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release 归还槽位：有等待者时直接移交给队首，保证 FIFO
+func (s *fairSemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if front := s.waiters.Front(); front != nil {
+		s.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	s.inUse--
+}
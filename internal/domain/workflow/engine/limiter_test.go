@@ -0,0 +1,234 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node/code"
+)
+
+var (
+	limiterActive atomic.Int32
+	limiterPeak   atomic.Int32
+)
+
+// limiterTrackFunction 记录同时执行的节点数
+type limiterTrackFunction struct{}
+
+func (f *limiterTrackFunction) Name() string {
+	return "test.engine.limiter.track.v1"
+}
+
+func (f *limiterTrackFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	return f.track(30 * time.Millisecond), nil
+}
+
+func (f *limiterTrackFunction) track(d time.Duration) map[string]interface{} {
+	active := limiterActive.Add(1)
+	defer limiterActive.Add(-1)
+	for {
+		peak := limiterPeak.Load()
+		if active <= peak || limiterPeak.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	time.Sleep(d)
+	return map[string]interface{}{"result": "ok"}
+}
+
+// limiterStuckFunction 忽略 context，超时后仍继续运行一段时间
+type limiterStuckFunction struct{}
+
+func (f *limiterStuckFunction) Name() string {
+	return "test.engine.limiter.stuck.v1"
+}
+
+func (f *limiterStuckFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	return (&limiterTrackFunction{}).track(200 * time.Millisecond), nil
+}
+
+func init() {
+	code.MustRegisterFunction(&limiterTrackFunction{})
+	code.MustRegisterFunction(&limiterStuckFunction{})
+}
+
+// --- 进程级节点并发限制 ---
+
+func TestConcurrencyLimiter_SharedAcrossRuns(t *testing.T) {
+	dsl := json.RawMessage(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "code_1",
+				"data": {
+					"type": "func",
+					"title": "Tracked Code",
+					"function_ref": "test.engine.limiter.track.v1",
+					"inputs": [
+						{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [{"variable": "result", "value_selector": ["code_1", "result"]}]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "code_1"},
+			{"source": "code_1", "target": "end_1"}
+		]
+	}`)
+
+	limiter, err := engine.NewConcurrencyLimiter([]engine.ConcurrencyLimit{
+		{NodeType: types.NodeTypeFunc, MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatalf("create limiter: %v", err)
+	}
+	cfg := engine.DefaultConfig()
+	cfg.Limiter = limiter
+	runner := workflow.NewWorkflowRunner(cfg, nil)
+
+	limiterPeak.Store(0)
+	const runs = 4
+	var wg sync.WaitGroup
+	var maxWait atomic.Int64
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "q"}, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			exec := findNodeExec(result.NodeExecutions, "code_1")
+			if exec == nil {
+				return
+			}
+			wait, ok := exec.Metadata["queue_wait_ms"].(int64)
+			if !ok {
+				t.Errorf("expected queue_wait_ms in metadata, got %v", exec.Metadata)
+				return
+			}
+			for {
+				cur := maxWait.Load()
+				if wait <= cur || maxWait.CompareAndSwap(cur, wait) {
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if peak := limiterPeak.Load(); peak != 1 {
+		t.Fatalf("expected at most 1 concurrent func node across runs, got %d", peak)
+	}
+	if maxWait.Load() < 30 {
+		t.Fatalf("expected queued nodes to report wait time, max queue_wait_ms=%d", maxWait.Load())
+	}
+
+	t.Logf("✅ Concurrency limiter test passed, max queue_wait_ms=%d", maxWait.Load())
+}
+
+func TestConcurrencyLimiter_InvalidRules(t *testing.T) {
+	cases := [][]engine.ConcurrencyLimit{
+		{{NodeType: types.NodeTypeLLM, MaxConcurrent: 0}},
+		{{NodeType: types.NodeTypeLLM, Model: "gpt-4o", MaxConcurrent: 1}},
+		{{NodeType: types.NodeTypeLLM, MaxConcurrent: 1}, {NodeType: types.NodeTypeLLM, MaxConcurrent: 2}},
+	}
+	for i, limits := range cases {
+		if _, err := engine.NewConcurrencyLimiter(limits); err == nil {
+			t.Fatalf("case %d: expected error for %+v", i, limits)
+		}
+	}
+
+	t.Logf("✅ Concurrency limiter validation test passed")
+}
+
+func TestConcurrencyLimiter_TimedOutNodeHoldsSlot(t *testing.T) {
+	dsl := json.RawMessage(`{
+		"nodes": [
+			{"id": "start_1", "data": {"type": "start", "title": "Start", "variables": [{"variable": "query", "type": "string", "required": true}]}},
+			{
+				"id": "code_1",
+				"data": {
+					"type": "func",
+					"title": "Stuck Code",
+					"function_ref": "test.engine.limiter.stuck.v1",
+					"inputs": [{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}],
+					"outputs": [{"name": "result", "type": "string", "required": false}],
+					"timeout_ms": 30,
+					"error_strategy": "default-value",
+					"default_value": {"result": "timeout"}
+				}
+			},
+			{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [{"variable": "result", "value_selector": ["code_1", "result"]}]}}
+		],
+		"edges": [
+			{"source": "start_1", "target": "code_1"},
+			{"source": "code_1", "target": "end_1"}
+		]
+	}`)
+
+	limiter, err := engine.NewConcurrencyLimiter([]engine.ConcurrencyLimit{
+		{NodeType: types.NodeTypeFunc, MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatalf("create limiter: %v", err)
+	}
+	cfg := engine.DefaultConfig()
+	cfg.Limiter = limiter
+	runner := workflow.NewWorkflowRunner(cfg, nil)
+
+	limiterPeak.Store(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "q"}, nil); err != nil {
+				t.Errorf("run failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// 等最后一个被放弃的节点退出
+	for limiterActive.Load() != 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 超时后节点仍在运行，槽位不能提前交给下一个节点
+	if peak := limiterPeak.Load(); peak != 1 {
+		t.Fatalf("expected timed out nodes to keep their slot until they exit, peak=%d", peak)
+	}
+}
@@ -0,0 +1,202 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node/code"
+)
+
+// limitsStuckFunction 忽略 context 长时间阻塞，验证引擎强制超时
+type limitsStuckFunction struct{}
+
+func (f *limitsStuckFunction) Name() string {
+	return "test.engine.limits.stuck.v1"
+}
+
+func (f *limitsStuckFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	time.Sleep(2 * time.Second)
+	return map[string]interface{}{"result": "late"}, nil
+}
+
+// limitsLargeFunction 返回较大的输出
+type limitsLargeFunction struct{}
+
+func (f *limitsLargeFunction) Name() string {
+	return "test.engine.limits.large.v1"
+}
+
+func (f *limitsLargeFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"result": strings.Repeat("x", 4096)}, nil
+}
+
+func init() {
+	code.MustRegisterFunction(&limitsStuckFunction{})
+	code.MustRegisterFunction(&limitsLargeFunction{})
+}
+
+// --- 节点级超时与输出大小限制 ---
+
+func TestNodeLimits_TimeoutFollowsFailBranch(t *testing.T) {
+	dsl := json.RawMessage(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "code_1",
+				"data": {
+					"type": "func",
+					"title": "Stuck Code",
+					"function_ref": "test.engine.limits.stuck.v1",
+					"inputs": [
+						{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": false}
+					],
+					"timeout_ms": 50,
+					"error_strategy": "fail-branch"
+				}
+			},
+			{
+				"id": "end_success",
+				"data": {
+					"type": "end",
+					"title": "Success End",
+					"outputs": [
+						{"variable": "result", "value_selector": ["code_1", "result"]}
+					]
+				}
+			},
+			{
+				"id": "end_failed",
+				"data": {
+					"type": "end",
+					"title": "Failed End",
+					"outputs": [
+						{"variable": "error_type", "value_selector": ["code_1", "__error_type__"]}
+					]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "code_1"},
+			{"source": "code_1", "target": "end_success", "sourceHandle": "success-branch"},
+			{"source": "code_1", "target": "end_failed", "sourceHandle": "fail-branch"}
+		]
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	start := time.Now()
+	result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "test"}, nil)
+	if err != nil {
+		t.Fatalf("expected fail-branch to handle timeout, got error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected engine to enforce node timeout, run took %v", elapsed)
+	}
+	if result.Outputs["error_type"] != types.NodeErrorTypeTimeout {
+		t.Fatalf("expected error_type=%s, got %v", types.NodeErrorTypeTimeout, result.Outputs)
+	}
+
+	t.Logf("✅ Node timeout fail-branch test passed")
+}
+
+func TestNodeLimits_WorkflowDefaultOutputLimit(t *testing.T) {
+	dsl := json.RawMessage(`{
+		"node_defaults": {"max_output_bytes": 1024},
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "code_1",
+				"data": {
+					"type": "func",
+					"title": "Large Code",
+					"function_ref": "test.engine.limits.large.v1",
+					"inputs": [
+						{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": false}
+					],
+					"error_strategy": "default-value",
+					"default_value": {"result": "truncated"}
+				}
+			},
+			{
+				"id": "code_2",
+				"data": {
+					"type": "func",
+					"title": "Large Code Allowed",
+					"function_ref": "test.engine.limits.large.v1",
+					"inputs": [
+						{"name": "query", "type": "string", "required": true, "value_selector": ["start_1", "query"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": false}
+					],
+					"max_output_bytes": 8192
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [
+						{"variable": "limited", "value_selector": ["code_1", "result"]},
+						{"variable": "allowed", "value_selector": ["code_2", "result"]}
+					],
+					"max_output_bytes": 8192
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "code_1"},
+			{"source": "code_1", "target": "code_2"},
+			{"source": "code_2", "target": "end_1"}
+		]
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "test"}, nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Outputs["limited"] != "truncated" {
+		t.Fatalf("expected default value for oversized output, got %v", result.Outputs["limited"])
+	}
+	if allowed, _ := result.Outputs["allowed"].(string); len(allowed) != 4096 {
+		t.Fatalf("expected node-level override to allow large output, got %d bytes", len(allowed))
+	}
+
+	exec := findNodeExec(result.NodeExecutions, "code_1")
+	if exec == nil || exec.Metadata["error_type"] != types.NodeErrorTypeOutputTooLarge {
+		t.Fatalf("expected error_type=%s in metadata, got %+v", types.NodeErrorTypeOutputTooLarge, exec)
+	}
+
+	t.Logf("✅ Node output limit test passed")
+}
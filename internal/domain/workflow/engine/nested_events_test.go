@@ -0,0 +1,254 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/node/code"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// nestedCheckFunction 输入为 "bad" 时失败，其余原样返回
+type nestedCheckFunction struct{}
+
+func (f *nestedCheckFunction) Name() string {
+	return "test.engine.nested.check.v1"
+}
+
+func (f *nestedCheckFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	if input["item"] == "bad" {
+		return nil, fmt.Errorf("bad item")
+	}
+	return map[string]interface{}{"result": input["item"]}, nil
+}
+
+func init() {
+	code.MustRegisterFunction(&nestedCheckFunction{})
+}
+
+const nestedIterationDSL = `{
+	"nodes": [
+		{"id": "start_1", "data": {"type": "start", "title": "Start", "variables": [{"variable": "items", "type": "array[string]", "required": true}]}},
+		{
+			"id": "iter_1",
+			"data": {
+				"type": "iteration",
+				"title": "Check Items",
+				"mode": "map",
+				"input": {"value_selector": ["start_1", "items"]},
+				"subgraph": {
+					"start": "iter_start",
+					"nodes": [
+						{"id": "iter_start", "data": {"type": "iteration-start", "title": "Iter Start"}},
+						{
+							"id": "check_1",
+							"data": {
+								"type": "func",
+								"title": "Check",
+								"function_ref": "test.engine.nested.check.v1",
+								"inputs": [{"name": "item", "type": "string", "required": true, "value_selector": ["iter_1", "item"]}],
+								"outputs": [{"name": "result", "type": "string", "required": true}]
+							}
+						}
+					],
+					"edges": [{"source": "iter_start", "target": "check_1"}],
+					"result_selector": ["check_1", "result"]
+				},
+				"concurrency": {"max_concurrency": 2, "order": "input-order"},
+				"aggregate": {"strategy": "collect"},
+				"on_item_error": "continue",
+				"outputs": [
+					{"name": "results", "from": "aggregate.result"},
+					{"name": "errors", "from": "aggregate.errors"}
+				]
+			}
+		},
+		{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [
+			{"variable": "results", "value_selector": ["iter_1", "results"]},
+			{"variable": "errors", "value_selector": ["iter_1", "errors"]}
+		]}}
+	],
+	"edges": [
+		{"source": "start_1", "target": "iter_1"},
+		{"source": "iter_1", "target": "end_1"}
+	]
+}`
+
+func TestNestedEvents_Iteration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	eventCh, err := runner.RunFromDSL(ctx, []byte(nestedIterationDSL), map[string]interface{}{
+		"items": []interface{}{"a", "bad", "c"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var last event.GraphEvent
+	started := map[int]string{}
+	for evt := range eventCh {
+		last = evt
+		inSubgraph := evt.NodeID == "iter_start" || evt.NodeID == "check_1"
+		if !inSubgraph {
+			if evt.ParentNodeID != "" {
+				t.Fatalf("unexpected parent on %s event: %+v", evt.NodeID, evt.NodeParent)
+			}
+			continue
+		}
+		if evt.ParentNodeID != "iter_1" || evt.IterationIndex == nil || evt.ParentExecutionID == "" || evt.LoopRound != nil {
+			t.Fatalf("child event without hierarchy: %s %+v", evt.Type, evt.NodeParent)
+		}
+		if evt.NodeID == "iter_start" {
+			continue
+		}
+		if evt.Type == event.EventTypeNodeRunStarted {
+			started[*evt.IterationIndex] = evt.ParentExecutionID
+		} else if id := started[*evt.IterationIndex]; id != evt.ParentExecutionID {
+			t.Fatalf("item %d: execution id changed from %q to %q", *evt.IterationIndex, id, evt.ParentExecutionID)
+		}
+
+		payload := workflow.StreamEventPayload(evt)
+		if payload["parent_node_id"] != "iter_1" || payload["iteration_index"] != *evt.IterationIndex {
+			t.Fatalf("stream payload without hierarchy: %v", payload)
+		}
+	}
+
+	if last.Type != event.EventTypeGraphRunSucceeded {
+		t.Fatalf("expected run succeeded, got %s (%s)", last.Type, last.Error)
+	}
+	if last.ExceptionsCount != 0 {
+		t.Fatalf("failed items are handled by the iteration node, got exceptions=%d", last.ExceptionsCount)
+	}
+	if len(started) != 3 || started[0] == started[1] || started[1] == started[2] || started[0] == started[2] {
+		t.Fatalf("expected a distinct execution id per item, got %v", started)
+	}
+
+	children := map[int]port.NodeExecution{}
+	for _, exec := range last.NodeExecutions {
+		if exec.ParentNodeID == "" || exec.NodeID == "iter_start" {
+			continue
+		}
+		if exec.NodeID != "check_1" || exec.IterationIndex == nil {
+			t.Fatalf("unexpected child execution: %+v", exec)
+		}
+		children[*exec.IterationIndex] = exec
+	}
+	if len(children) != 3 {
+		t.Fatalf("expected 3 child executions, got %+v", children)
+	}
+	for idx, exec := range children {
+		wantStatus := "succeeded"
+		if idx == 1 {
+			wantStatus = "failed"
+		}
+		if exec.Status != wantStatus || exec.ParentExecutionID != started[idx] {
+			t.Fatalf("item %d: unexpected execution %+v", idx, exec)
+		}
+	}
+
+	// 迭代错误明细可关联到失败项的子节点执行记录
+	errs, ok := last.Outputs["errors"].([]map[string]interface{})
+	if !ok || len(errs) != 1 || errs[0]["execution_id"] != started[1] {
+		t.Fatalf("expected failed item linked to its execution, got %v", last.Outputs["errors"])
+	}
+	data, _ := json.Marshal(children[1])
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(data, &decoded)
+	if decoded["parent_node_id"] != "iter_1" || decoded["iteration_index"] != float64(1) {
+		t.Fatalf("unexpected execution json: %s", data)
+	}
+}
+
+func TestNestedEvents_Loop(t *testing.T) {
+	dsl := `{
+		"nodes": [
+			{"id": "start_1", "data": {"type": "start", "title": "Start", "variables": [{"variable": "input_text", "type": "string", "required": true}]}},
+			{
+				"id": "loop_1",
+				"data": {
+					"type": "loop",
+					"title": "Loop",
+					"mode": "while",
+					"max_rounds": 3,
+					"state_init": [
+						{"name": "current_text", "value_selector": ["start_1", "input_text"], "required": true},
+						{"name": "round", "default": 0, "required": true}
+					],
+					"subgraph": {
+						"start": "loop_start",
+						"nodes": [
+							{"id": "loop_start", "data": {"type": "loop-start", "title": "Loop Start"}},
+							{
+								"id": "step_1",
+								"data": {
+									"type": "func",
+									"title": "Loop Step",
+									"function_ref": "test.engine.loop.step.v1",
+									"inputs": [
+										{"name": "text", "type": "string", "required": true, "value_selector": ["loop_1", "current_text"]},
+										{"name": "round", "type": "number", "required": true, "value_selector": ["loop_1", "round"]}
+									],
+									"outputs": [
+										{"name": "next_text", "type": "string", "required": true},
+										{"name": "over_limit", "type": "boolean", "required": true}
+									]
+								}
+							}
+						],
+						"edges": [{"source": "loop_start", "target": "step_1"}],
+						"continue_selector": ["step_1", "over_limit"]
+					},
+					"state_update": [
+						{"name": "current_text", "op": "assign", "value_selector": ["step_1", "next_text"], "required": true},
+						{"name": "round", "op": "inc", "value": 1}
+					],
+					"outputs": [{"name": "final_text", "from": "loop.state.current_text", "required": true}]
+				}
+			},
+			{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [{"variable": "summary", "value_selector": ["loop_1", "final_text"]}]}}
+		],
+		"edges": [
+			{"source": "start_1", "target": "loop_1"},
+			{"source": "loop_1", "target": "end_1"}
+		]
+	}`
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	result, err := runner.RunSync(context.Background(), []byte(dsl), map[string]interface{}{"input_text": "seed"}, nil)
+	if err != nil {
+		t.Fatalf("loop workflow failed: %v", err)
+	}
+
+	rounds := map[int]string{}
+	for _, exec := range result.NodeExecutions {
+		if exec.ParentNodeID == "" || exec.NodeID == "loop_start" {
+			continue
+		}
+		if exec.ParentNodeID != "loop_1" || exec.NodeID != "step_1" || exec.LoopRound == nil || exec.IterationIndex != nil {
+			t.Fatalf("unexpected child execution: %+v", exec)
+		}
+		rounds[*exec.LoopRound] = exec.ParentExecutionID
+	}
+	if len(rounds) != 2 || rounds[0] == "" || rounds[0] == rounds[1] {
+		t.Fatalf("expected one execution per round, got %v", rounds)
+	}
+
+	var loopExec port.NodeExecution
+	for _, exec := range result.NodeExecutions {
+		if exec.NodeID == "loop_1" {
+			loopExec = exec
+		}
+	}
+	stats, _ := loopExec.Metadata["round_stats"].([]map[string]interface{})
+	if len(stats) != 2 || stats[1]["execution_id"] != rounds[1] {
+		t.Fatalf("expected round stats linked to executions, got %v", loopExec.Metadata["round_stats"])
+	}
+}
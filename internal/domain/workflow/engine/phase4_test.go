@@ -152,6 +152,9 @@ func TestErrorStrategy_DefaultValue(t *testing.T) {
 	if !ok || result != "fallback_value" {
 		t.Fatalf("expected fallback_value, got %v", runResult.Outputs)
 	}
+	if !runResult.PartialSucceeded || runResult.ExceptionsCount != 1 {
+		t.Fatalf("expected partial-succeeded with 1 exception, got partial=%v exceptions=%d", runResult.PartialSucceeded, runResult.ExceptionsCount)
+	}
 
 	t.Logf("✅ Default value strategy test passed: result=%v", result)
 }
@@ -227,6 +230,9 @@ func TestErrorStrategy_FailBranch(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected error_msg in runResult.Outputs, got %v", runResult.Outputs)
 	}
+	if !runResult.PartialSucceeded || runResult.ExceptionsCount != 1 {
+		t.Fatalf("expected partial-succeeded with 1 exception, got partial=%v exceptions=%d", runResult.PartialSucceeded, runResult.ExceptionsCount)
+	}
 
 	t.Logf("✅ Fail-branch strategy test passed: error_msg=%v", errorMsg)
 }
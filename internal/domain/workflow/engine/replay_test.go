@@ -0,0 +1,176 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/port"
+)
+
+func replayDSL(url string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "http_1",
+				"data": {
+					"type": "http-request",
+					"title": "Fetch",
+					"method": "GET",
+					"url": %q
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [{"variable": "body", "value_selector": ["http_1", "body"]}]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "http_1"},
+			{"source": "http_1", "target": "end_1"}
+		]
+	}`, url))
+}
+
+// toRecords 模拟持久化：节点执行明细经 JSON 序列化后作为录制
+func toRecords(t *testing.T, execs []port.NodeExecution) []*port.NodeExecutionRecord {
+	t.Helper()
+	data, err := json.Marshal(execs)
+	if err != nil {
+		t.Fatalf("marshal node executions: %v", err)
+	}
+	var records []*port.NodeExecutionRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("unmarshal node executions: %v", err)
+	}
+	return records
+}
+
+// recordReplayRun 调用真实 HTTP 服务执行一次，返回 DSL（服务已关闭）与录制
+func recordReplayRun(t *testing.T, runner *workflow.WorkflowRunner) ([]byte, *port.WorkflowRun, []*port.NodeExecutionRecord, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, "recorded")
+	}))
+	dsl := replayDSL(srv.URL)
+
+	result, err := runner.RunSync(context.Background(), dsl, map[string]interface{}{"query": "q"}, nil)
+	srv.Close()
+	if err != nil {
+		t.Fatalf("original run failed: %v", err)
+	}
+	if result.Outputs["body"] != "recorded" {
+		t.Fatalf("unexpected original outputs: %v", result.Outputs)
+	}
+	return dsl, &port.WorkflowRun{Status: port.RunStatusSucceeded}, toRecords(t, result.NodeExecutions), &hits
+}
+
+// --- 确定性回放 ---
+
+func TestReplay_ServesRecordedResponses(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	dsl, original, records, hits := recordReplayRun(t, runner)
+
+	result, err := runner.Replay(context.Background(), dsl, map[string]interface{}{"query": "q"}, original,
+		workflow.NewReplayRecording(records, nil), nil)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("expected replay not to call the provider, hits=%d", hits.Load())
+	}
+	if result.Status != port.RunStatusSucceeded || result.Outputs["body"] != "recorded" {
+		t.Fatalf("unexpected replay result: status=%s outputs=%v error=%s", result.Status, result.Outputs, result.Error)
+	}
+	if result.ServedCalls != 1 {
+		t.Fatalf("expected 1 served call, got %d", result.ServedCalls)
+	}
+	if result.Diverged {
+		t.Fatalf("expected no divergence, got %+v", result.Divergences)
+	}
+
+	t.Logf("✅ Replay recorded responses test passed")
+}
+
+func TestReplay_ReportsOutputMismatch(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	dsl, original, records, _ := recordReplayRun(t, runner)
+
+	// 篡改原始 End 输出：回放路径一致但输出不同
+	for _, rec := range records {
+		if rec.NodeID == "end_1" {
+			rec.Outputs = map[string]interface{}{"body": "tampered"}
+		}
+	}
+
+	result, err := runner.Replay(context.Background(), dsl, map[string]interface{}{"query": "q"}, original,
+		workflow.NewReplayRecording(records, nil), nil)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if !result.Diverged || len(result.Divergences) != 1 {
+		t.Fatalf("expected exactly one divergence, got %+v", result.Divergences)
+	}
+	if d := result.Divergences[0]; d.NodeID != "end_1" || d.Kind != workflow.ReplayDivergenceOutputMismatch {
+		t.Fatalf("expected end_1 output mismatch, got %+v", d)
+	}
+
+	t.Logf("✅ Replay output mismatch test passed")
+}
+
+func TestReplay_ReportsMissingRecording(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	dsl, original, records, _ := recordReplayRun(t, runner)
+
+	// 移除 HTTP 录制：回放时 HTTP 节点失败，下游 End 未执行
+	var trimmed []*port.NodeExecutionRecord
+	for _, rec := range records {
+		if rec.NodeID != "http_1" {
+			trimmed = append(trimmed, rec)
+		}
+	}
+
+	result, err := runner.Replay(context.Background(), dsl, map[string]interface{}{"query": "q"}, original,
+		workflow.NewReplayRecording(trimmed, nil), nil)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if !result.Diverged || result.Status != port.RunStatusFailed {
+		t.Fatalf("expected diverged failed replay, got status=%s diverged=%v", result.Status, result.Diverged)
+	}
+
+	kinds := make(map[string]workflow.ReplayDivergenceKind)
+	for _, d := range result.Divergences {
+		kinds[d.NodeID] = d.Kind
+	}
+	if kinds["end_1"] != workflow.ReplayDivergenceMissingNode {
+		t.Fatalf("expected end_1 missing in replay, got %+v", result.Divergences)
+	}
+	if kinds["http_1"] != workflow.ReplayDivergenceMissingRecording {
+		t.Fatalf("expected http_1 missing recording, got %+v", result.Divergences)
+	}
+
+	t.Logf("✅ Replay missing recording test passed")
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// attemptLog retry 策略下节点的逐次尝试记录，最终写入节点事件的 metadata["attempts"]
+// nil 表示节点未启用重试，所有方法均为空操作
+type attemptLog struct {
+	attempts []port.NodeAttempt
+}
+
+func (l *attemptLog) addSuccess(startedAt time.Time) {
+	if l == nil {
+		return
+	}
+	l.attempts = append(l.attempts, port.NodeAttempt{
+		Attempt:   len(l.attempts) + 1,
+		Status:    string(types.NodeExecutionStatusSucceeded),
+		StartedAt: startedAt,
+		ElapsedMs: time.Since(startedAt).Milliseconds(),
+	})
+}
+
+func (l *attemptLog) addFailure(startedAt time.Time, errMsg string, errClass types.ErrorClass) {
+	if l == nil {
+		return
+	}
+	l.attempts = append(l.attempts, port.NodeAttempt{
+		Attempt:    len(l.attempts) + 1,
+		Status:     string(types.NodeExecutionStatusFailed),
+		Error:      errMsg,
+		ErrorClass: string(errClass),
+		StartedAt:  startedAt,
+		ElapsedMs:  time.Since(startedAt).Milliseconds(),
+	})
+}
+
+// setBackoff 记录最近一次失败后的退避时长
+func (l *attemptLog) setBackoff(delay time.Duration) {
+	if l == nil || len(l.attempts) == 0 {
+		return
+	}
+	l.attempts[len(l.attempts)-1].BackoffMs = delay.Milliseconds()
+}
+
+// annotate 将尝试历史写入事件元数据（复制 map，避免修改节点返回的元数据）
+func (l *attemptLog) annotate(evt *event.NodeEvent) {
+	if l == nil || len(l.attempts) == 0 {
+		return
+	}
+	metadata := make(map[string]interface{}, len(evt.Metadata)+1)
+	for k, v := range evt.Metadata {
+		metadata[k] = v
+	}
+	metadata["attempts"] = append([]port.NodeAttempt(nil), l.attempts...)
+	evt.Metadata = metadata
+}
+
+// failedEvent 创建节点最终失败事件（携带错误分类、错误类型与尝试历史）
+func (l *attemptLog) failedEvent(nodeID string, n node.Node, out nodeOutcome) event.NodeEvent {
+	evt := event.NewNodeRunFailedEvent("", nodeID, n.Type(), out.err)
+	evt.ErrorClass = out.errClass
+	if out.errType != "" {
+		evt.Metadata = map[string]interface{}{"error_type": out.errType}
+	}
+	l.annotate(&evt)
+	if out.queueWait != nil {
+		annotateQueueWait(&evt, *out.queueWait)
+	}
+	return evt
+}
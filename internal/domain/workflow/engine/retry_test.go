@@ -0,0 +1,217 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	provider "flowweave/internal/adapter/provider/llm"
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node/code"
+	"flowweave/internal/domain/workflow/port"
+)
+
+var (
+	retryRateLimitCalls  atomic.Int32
+	retryValidationCalls atomic.Int32
+)
+
+// retryRateLimitFunction 前两次返回 429，第三次成功
+type retryRateLimitFunction struct{}
+
+func (f *retryRateLimitFunction) Name() string {
+	return "test.engine.retry.rate_limit.v1"
+}
+
+func (f *retryRateLimitFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	if retryRateLimitCalls.Add(1) <= 2 {
+		return nil, &provider.APIError{StatusCode: 429, Body: "slow down"}
+	}
+	return map[string]interface{}{"result": "ok"}, nil
+}
+
+type invalidInputError struct{}
+
+func (invalidInputError) Error() string                { return "input rejected" }
+func (invalidInputError) ErrorClass() types.ErrorClass { return types.ErrorClassValidation }
+
+// retryValidationFunction 始终返回校验错误
+type retryValidationFunction struct{}
+
+func (f *retryValidationFunction) Name() string {
+	return "test.engine.retry.validation.v1"
+}
+
+func (f *retryValidationFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	retryValidationCalls.Add(1)
+	return nil, invalidInputError{}
+}
+
+func init() {
+	code.MustRegisterFunction(&retryRateLimitFunction{})
+	code.MustRegisterFunction(&retryValidationFunction{})
+}
+
+func retryDSL(functionRef string) json.RawMessage {
+	return json.RawMessage(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "x", "label": "X", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "code_1",
+				"data": {
+					"type": "func",
+					"title": "Retry Code",
+					"function_ref": "` + functionRef + `",
+					"inputs": [
+						{"name": "x", "type": "string", "required": true, "value_selector": ["start_1", "x"]}
+					],
+					"outputs": [
+						{"name": "result", "type": "string", "required": false}
+					],
+					"error_strategy": "retry",
+					"retry": {
+						"max_retries": 3,
+						"retry_interval": 5,
+						"backoff_multiplier": 2,
+						"max_interval": 50,
+						"jitter": 0.2,
+						"retry_on": ["rate_limit", "http_5xx", "timeout"]
+					}
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [
+						{"variable": "result", "value_selector": ["code_1", "result"]}
+					]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "code_1"},
+			{"source": "code_1", "target": "end_1"}
+		]
+	}`)
+}
+
+func runRetryWorkflow(t *testing.T, functionRef string) event.GraphEvent {
+	t.Helper()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	eventCh, err := runner.RunFromDSL(ctx, retryDSL(functionRef), map[string]interface{}{"x": "hello"}, nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	var last event.GraphEvent
+	for evt := range eventCh {
+		last = evt
+	}
+	return last
+}
+
+func findNodeExec(execs []port.NodeExecution, nodeID string) *port.NodeExecution {
+	for i := range execs {
+		if execs[i].NodeID == nodeID {
+			return &execs[i]
+		}
+	}
+	return nil
+}
+
+// --- 重试策略：错误分类 + 退避 + 尝试记录 ---
+
+func TestRetryPolicy_RetriesRateLimitAndRecordsAttempts(t *testing.T) {
+	retryRateLimitCalls.Store(0)
+
+	last := runRetryWorkflow(t, "test.engine.retry.rate_limit.v1")
+	if last.Type != event.EventTypeGraphRunSucceeded {
+		t.Fatalf("expected graph succeeded, got %s (%s)", last.Type, last.Error)
+	}
+	if got := retryRateLimitCalls.Load(); got != 3 {
+		t.Fatalf("expected 3 calls, got %d", got)
+	}
+
+	exec := findNodeExec(last.NodeExecutions, "code_1")
+	if exec == nil {
+		t.Fatal("expected node execution for code_1")
+	}
+	attempts, ok := exec.Metadata["attempts"].([]port.NodeAttempt)
+	if !ok || len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts in metadata, got %#v", exec.Metadata["attempts"])
+	}
+	for i, a := range attempts[:2] {
+		if a.Status != "failed" || a.ErrorClass != string(types.ErrorClassRateLimit) {
+			t.Fatalf("attempt %d: expected failed rate_limit, got %+v", i+1, a)
+		}
+		if a.BackoffMs <= 0 {
+			t.Fatalf("attempt %d: expected backoff to be recorded, got %+v", i+1, a)
+		}
+	}
+	if attempts[2].Status != "succeeded" || attempts[2].Attempt != 3 {
+		t.Fatalf("expected third attempt to succeed, got %+v", attempts[2])
+	}
+
+	t.Logf("✅ Retry policy rate-limit test passed, attempts=%d", len(attempts))
+}
+
+func TestRetryPolicy_SkipsNonRetryableErrorClass(t *testing.T) {
+	retryValidationCalls.Store(0)
+
+	last := runRetryWorkflow(t, "test.engine.retry.validation.v1")
+	if last.Type != event.EventTypeGraphRunFailed {
+		t.Fatalf("expected graph failed, got %s", last.Type)
+	}
+	if got := retryValidationCalls.Load(); got != 1 {
+		t.Fatalf("expected validation error not to be retried, got %d calls", got)
+	}
+
+	exec := findNodeExec(last.NodeExecutions, "code_1")
+	if exec == nil {
+		t.Fatal("expected node execution for code_1")
+	}
+	if exec.Metadata["error_class"] != string(types.ErrorClassValidation) {
+		t.Fatalf("expected error_class=validation, got %v", exec.Metadata["error_class"])
+	}
+
+	t.Logf("✅ Retry policy non-retryable test passed")
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	rc := &types.RetryConfig{RetryInterval: 100, BackoffMultiplier: 2, MaxInterval: 500}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond}
+	for i, w := range want {
+		if got := rc.Backoff(i+1, 0.5); got != w {
+			t.Fatalf("retry %d: expected %v, got %v", i+1, w, got)
+		}
+	}
+
+	rc.Jitter = 0.5
+	if got := rc.Backoff(1, 0); got != 50*time.Millisecond {
+		t.Fatalf("expected lower jitter bound 50ms, got %v", got)
+	}
+	if got := rc.Backoff(1, 0.999); got < 140*time.Millisecond || got > 150*time.Millisecond {
+		t.Fatalf("expected upper jitter bound ~150ms, got %v", got)
+	}
+
+	t.Logf("✅ Retry backoff test passed")
+}
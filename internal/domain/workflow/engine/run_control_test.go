@@ -0,0 +1,349 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node/code"
+	"flowweave/internal/domain/workflow/port"
+)
+
+var (
+	controlGateStarted = make(chan struct{}, 1)
+	controlGateRelease = make(chan struct{}, 1)
+	controlAfterCount  atomic.Int32
+)
+
+// controlGateFunction 通知已开始执行，并阻塞直到放行
+type controlGateFunction struct{}
+
+func (f *controlGateFunction) Name() string {
+	return "test.engine.control.gate.v1"
+}
+
+func (f *controlGateFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	controlGateStarted <- struct{}{}
+	select {
+	case <-controlGateRelease:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return map[string]interface{}{"result": "gated"}, nil
+}
+
+type controlAfterFunction struct{}
+
+func (f *controlAfterFunction) Name() string {
+	return "test.engine.control.after.v1"
+}
+
+func (f *controlAfterFunction) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	controlAfterCount.Add(1)
+	return map[string]interface{}{"result": "done"}, nil
+}
+
+func init() {
+	code.MustRegisterFunction(&controlGateFunction{})
+	code.MustRegisterFunction(&controlAfterFunction{})
+}
+
+const runControlDSL = `{
+	"nodes": [
+		{
+			"id": "start_1",
+			"data": {
+				"type": "start",
+				"title": "Start",
+				"variables": [
+					{"variable": "text", "label": "Text", "type": "string", "required": true}
+				]
+			}
+		},
+		{
+			"id": "code_gate",
+			"data": {
+				"type": "func",
+				"title": "Gate",
+				"function_ref": "test.engine.control.gate.v1",
+				"inputs": [
+					{"name": "text", "type": "string", "required": true, "value_selector": ["start_1", "text"]}
+				],
+				"outputs": [{"name": "result", "type": "string", "required": true}]
+			}
+		},
+		{
+			"id": "code_after",
+			"data": {
+				"type": "func",
+				"title": "After",
+				"function_ref": "test.engine.control.after.v1",
+				"inputs": [
+					{"name": "text", "type": "string", "required": true, "value_selector": ["code_gate", "result"]}
+				],
+				"outputs": [{"name": "result", "type": "string", "required": true}]
+			}
+		},
+		{
+			"id": "end_1",
+			"data": {
+				"type": "end",
+				"title": "End",
+				"outputs": [{"variable": "result", "value_selector": ["code_after", "result"]}]
+			}
+		}
+	],
+	"edges": [
+		{"source": "start_1", "target": "code_gate"},
+		{"source": "code_gate", "target": "code_after"},
+		{"source": "code_after", "target": "end_1"}
+	]
+}`
+
+// memoryCommandBus 进程内命令总线，模拟多个实例共享的 Redis pub/sub
+type memoryCommandBus struct {
+	mu       sync.Mutex
+	handlers []func(cmd *port.RunCommand)
+}
+
+func (b *memoryCommandBus) Publish(ctx context.Context, cmd *port.RunCommand) error {
+	b.mu.Lock()
+	handlers := append([]func(cmd *port.RunCommand){}, b.handlers...)
+	b.mu.Unlock()
+	for _, h := range handlers {
+		h(cmd)
+	}
+	return nil
+}
+
+func (b *memoryCommandBus) Subscribe(ctx context.Context, handler func(cmd *port.RunCommand)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *memoryCommandBus) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers)
+}
+
+// waitForEvent 等待指定类型的事件（期间的其他事件写入 seen）
+func waitForEvent(t *testing.T, eventCh <-chan event.GraphEvent, want event.EventType, seen *[]event.EventType) event.GraphEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt, ok := <-eventCh:
+			if !ok {
+				t.Fatalf("event stream closed before %s, seen=%v", want, *seen)
+			}
+			*seen = append(*seen, evt.Type)
+			if evt.Type == want {
+				return evt
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s, seen=%v", want, *seen)
+		}
+	}
+}
+
+// startControlledRun 在 owner 实例上启动运行，另一个实例通过命令总线控制
+func startControlledRun(t *testing.T, ctx context.Context, runID string) (<-chan event.GraphEvent, *workflow.WorkflowRunner) {
+	t.Helper()
+	bus := &memoryCommandBus{}
+	owner := workflow.NewWorkflowRunner(nil, nil)
+	remote := workflow.NewWorkflowRunner(nil, nil)
+	owner.StartCommandRelay(ctx, bus)
+	remote.StartCommandRelay(ctx, bus)
+	for bus.subscribers() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	controlAfterCount.Store(0)
+	eventCh, err := owner.RunFromDSL(ctx, []byte(runControlDSL), map[string]interface{}{"text": "go"}, &workflow.RunOptions{RunID: runID})
+	if err != nil {
+		t.Fatalf("run failed to start: %v", err)
+	}
+	select {
+	case <-controlGateStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("gate node did not start")
+	}
+	return eventCh, remote
+}
+
+// --- 运行控制：跨实例 pause / resume / abort ---
+
+func TestRunControl_PauseResumeAcrossInstances(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	eventCh, remote := startControlledRun(t, ctx, "run-control-1")
+	var seen []event.EventType
+
+	if err := remote.SendCommand(ctx, "run-control-1", types.Command{Type: types.CommandPause}); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	waitForEvent(t, eventCh, event.EventTypeGraphRunPaused, &seen)
+
+	// 放行当前节点：暂停期间不应调度下游节点
+	controlGateRelease <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	if got := controlAfterCount.Load(); got != 0 {
+		t.Fatalf("expected downstream node not to run while paused, got %d", got)
+	}
+
+	if err := remote.SendCommand(ctx, "run-control-1", types.Command{Type: types.CommandResume}); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	waitForEvent(t, eventCh, event.EventTypeGraphRunResumed, &seen)
+	done := waitForEvent(t, eventCh, event.EventTypeGraphRunSucceeded, &seen)
+	if done.Outputs["result"] != "done" {
+		t.Fatalf("expected result=done, got %v", done.Outputs)
+	}
+	if got := controlAfterCount.Load(); got != 1 {
+		t.Fatalf("expected downstream node to run once after resume, got %d", got)
+	}
+
+	t.Logf("✅ Run control pause/resume test passed, events=%v", seen)
+}
+
+func TestRunControl_AbortAcrossInstances(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	eventCh, remote := startControlledRun(t, ctx, "run-control-2")
+	var seen []event.EventType
+
+	if err := remote.SendCommand(ctx, "run-control-2", types.Command{Type: types.CommandAbort, Reason: "test"}); err != nil {
+		t.Fatalf("abort failed: %v", err)
+	}
+	waitForEvent(t, eventCh, event.EventTypeGraphRunAborted, &seen)
+	if got := controlAfterCount.Load(); got != 0 {
+		t.Fatalf("expected downstream node not to run after abort, got %d", got)
+	}
+
+	t.Logf("✅ Run control abort test passed, events=%v", seen)
+}
+
+// waitRecordedEvent 等待运行事件日志中出现指定类型的事件
+func waitRecordedEvent(t *testing.T, log *memoryRunEventLog, runID string, want event.EventType) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		events, _ := log.Read(context.Background(), runID, 0, 1000, 0)
+		for _, e := range events {
+			var data map[string]interface{}
+			if json.Unmarshal(e.Data, &data) == nil && data["type"] == string(want) {
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for recorded %s", want)
+}
+
+func TestRunControl_PauseResumeAsyncRun(t *testing.T) {
+	repo := newAsyncRetryRepo(json.RawMessage(runControlDSL))
+	repo.run.Inputs = json.RawMessage(`{"text": "go"}`)
+	cfg := asyncManagerConfig()
+	cfg.LeaseDuration = 60 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	eventLog := newMemoryRunEventLog()
+	runner.SetRunEventLog(eventLog)
+	controlAfterCount.Store(0)
+	workflow.NewAsyncRunManager(repo, runner, cfg).Start(ctx)
+
+	select {
+	case <-controlGateStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("gate node did not start")
+	}
+	controlAsyncRun(t, ctx, repo, runner, types.CommandPause, port.RunStatusPaused)
+	waitRecordedEvent(t, eventLog, repo.run.ID, event.EventTypeGraphRunPaused)
+
+	// 放行当前节点后暂停持续多个租约周期：不调度下游节点，运行保持暂停
+	controlGateRelease <- struct{}{}
+	time.Sleep(10 * cfg.LeaseDuration)
+	if got := controlAfterCount.Load(); got != 0 {
+		t.Fatalf("expected downstream node not to run while paused, got %d", got)
+	}
+	repo.mu.Lock()
+	status := repo.run.Status
+	repo.mu.Unlock()
+	if status != port.RunStatusPaused {
+		t.Fatalf("expected run to stay paused, got %s", status)
+	}
+
+	controlAsyncRun(t, ctx, repo, runner, types.CommandResume, port.RunStatusRunning)
+	run := repo.waitRunStatus(t, port.RunStatusSucceeded)
+	var outputs map[string]interface{}
+	_ = json.Unmarshal(run.Outputs, &outputs)
+	if outputs["result"] != "done" || controlAfterCount.Load() != 1 {
+		t.Fatalf("expected run to finish after resume, got outputs=%s after_count=%d", run.Outputs, controlAfterCount.Load())
+	}
+
+	t.Logf("✅ Async run paused and resumed on its worker")
+}
+
+func TestRunControl_PauseOutlastsAsyncRunTimeout(t *testing.T) {
+	repo := newAsyncRetryRepo(json.RawMessage(runControlDSL))
+	repo.run.Inputs = json.RawMessage(`{"text": "go"}`)
+	cfg := asyncManagerConfig()
+	cfg.RunTimeout = 300 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	eventLog := newMemoryRunEventLog()
+	runner.SetRunEventLog(eventLog)
+	controlAfterCount.Store(0)
+	workflow.NewAsyncRunManager(repo, runner, cfg).Start(ctx)
+
+	select {
+	case <-controlGateStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("gate node did not start")
+	}
+	controlAsyncRun(t, ctx, repo, runner, types.CommandPause, port.RunStatusPaused)
+	waitRecordedEvent(t, eventLog, repo.run.ID, event.EventTypeGraphRunPaused)
+	controlGateRelease <- struct{}{}
+
+	// 暂停时长超过 RunTimeout：暂停期间不计时，运行不会因超时失败
+	time.Sleep(4 * cfg.RunTimeout)
+	repo.mu.Lock()
+	status := repo.run.Status
+	repo.mu.Unlock()
+	if status != port.RunStatusPaused {
+		t.Fatalf("expected run to stay paused past the run timeout, got %s", status)
+	}
+
+	controlAsyncRun(t, ctx, repo, runner, types.CommandResume, port.RunStatusRunning)
+	run := repo.waitRunStatus(t, port.RunStatusSucceeded)
+	if controlAfterCount.Load() != 1 || len(run.Attempts) != 0 {
+		t.Fatalf("expected run to finish after resume, got after_count=%d attempts=%+v", controlAfterCount.Load(), run.Attempts)
+	}
+}
+
+func TestRunControl_AsyncRunTimeoutWithoutPause(t *testing.T) {
+	repo := newAsyncRetryRepo(asyncLeaseDSL(t, "test.engine.async_lease.block.v1"))
+	cfg := asyncManagerConfig()
+	cfg.RunTimeout = 100 * time.Millisecond
+
+	run := repo.waitFinished(t, cfg)
+	if run.Status != port.RunStatusFailed || !strings.Contains(run.Error, "deadline exceeded") {
+		t.Fatalf("expected run to fail on its timeout, got status=%s error=%q", run.Status, run.Error)
+	}
+}
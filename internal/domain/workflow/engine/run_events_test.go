@@ -0,0 +1,169 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// memoryRunEventLog 运行事件日志的内存实现
+type memoryRunEventLog struct {
+	mu     sync.Mutex
+	events map[string][]port.RunEvent
+}
+
+func newMemoryRunEventLog() *memoryRunEventLog {
+	return &memoryRunEventLog{events: make(map[string][]port.RunEvent)}
+}
+
+func (l *memoryRunEventLog) Append(_ context.Context, runID, name string, data json.RawMessage) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seq := int64(len(l.events[runID]) + 1)
+	l.events[runID] = append(l.events[runID], port.RunEvent{Seq: seq, Event: name, Data: data})
+	return seq, nil
+}
+
+func (l *memoryRunEventLog) Read(_ context.Context, runID string, afterSeq int64, limit int, _ time.Duration) ([]port.RunEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []port.RunEvent
+	for _, e := range l.events[runID] {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+const runEventsDSL = `{
+	"nodes": [
+		{"id": "start_1", "data": {"type": "start", "title": "Start", "variables": [{"variable": "name", "type": "string"}]}},
+		{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [{"variable": "result", "value_selector": ["start_1", "name"]}]}}
+	],
+	"edges": [{"source": "start_1", "target": "end_1"}]
+}`
+
+func TestRunEvents_RecordedWithSequence(t *testing.T) {
+	log := newMemoryRunEventLog()
+	runner := workflow.NewWorkflowRunner(nil, nil)
+	runner.SetRunEventLog(log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	eventCh, err := runner.RunFromDSL(ctx, []byte(runEventsDSL), map[string]interface{}{"name": "hi"}, &workflow.RunOptions{RunID: "run_events"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var received []event.GraphEvent
+	for evt := range eventCh {
+		received = append(received, evt)
+	}
+
+	recorded, _ := log.Read(ctx, "run_events", 0, 1000, 0)
+	if len(recorded) != len(received) || len(received) == 0 {
+		t.Fatalf("expected every event recorded, got %d recorded for %d received", len(recorded), len(received))
+	}
+	for i, evt := range received {
+		if evt.Seq != int64(i+1) {
+			t.Fatalf("event %d: expected seq %d, got %d", i, i+1, evt.Seq)
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(recorded[i].Data, &data); err != nil {
+			t.Fatalf("decode recorded event: %v", err)
+		}
+		if recorded[i].Event != workflow.RunEventMessage || data["type"] != string(evt.Type) {
+			t.Fatalf("event %d: recorded %s %v for %s", i, recorded[i].Event, data, evt.Type)
+		}
+		if _, ok := data["node_executions"]; ok {
+			t.Fatalf("node executions must not be recorded")
+		}
+	}
+	if last := received[len(received)-1]; last.Type != event.EventTypeGraphRunSucceeded {
+		t.Fatalf("expected last event succeeded, got %s", last.Type)
+	}
+
+	// 结果落库后追加 done；非终态不追加
+	runner.NotifyRunFinished(ctx, &port.WorkflowRun{ID: "run_events", Status: port.RunStatusPaused})
+	runner.NotifyRunFinished(ctx, &port.WorkflowRun{
+		ID:      "run_events",
+		Status:  port.RunStatusSucceeded,
+		Outputs: json.RawMessage(`{"result":"hi"}`),
+	})
+	recorded, _ = log.Read(ctx, "run_events", int64(len(received)), 10, 0)
+	if len(recorded) != 1 || recorded[0].Event != workflow.RunEventDone {
+		t.Fatalf("expected a single done event, got %+v", recorded)
+	}
+	var done map[string]interface{}
+	_ = json.Unmarshal(recorded[0].Data, &done)
+	if done["status"] != string(port.RunStatusSucceeded) || done["outputs"].(map[string]interface{})["result"] != "hi" {
+		t.Fatalf("unexpected done payload: %v", done)
+	}
+}
+
+func TestRunEvents_NotRecordedWithoutRunID(t *testing.T) {
+	log := newMemoryRunEventLog()
+	runner := workflow.NewWorkflowRunner(nil, nil)
+	runner.SetRunEventLog(log)
+
+	if _, err := runner.RunSync(context.Background(), []byte(runEventsDSL), map[string]interface{}{"name": "hi"}, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(log.events) != 0 {
+		t.Fatalf("expected no recorded events, got %v", log.events)
+	}
+}
+
+// blockingRunEventLog 模拟无响应的 Redis：写入阻塞到超时
+type blockingRunEventLog struct {
+	appends atomic.Int32
+}
+
+func (l *blockingRunEventLog) Append(ctx context.Context, _, _ string, _ json.RawMessage) (int64, error) {
+	l.appends.Add(1)
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func (l *blockingRunEventLog) Read(context.Context, string, int64, int, time.Duration) ([]port.RunEvent, error) {
+	return nil, nil
+}
+
+func TestRunEvents_StopsAppendingAfterFailure(t *testing.T) {
+	log := &blockingRunEventLog{}
+	runner := workflow.NewWorkflowRunner(nil, nil)
+	runner.SetRunEventLog(log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	eventCh, err := runner.RunFromDSL(ctx, []byte(runEventsDSL), map[string]interface{}{"name": "hi"}, &workflow.RunOptions{RunID: "run_events_blocked"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var received []event.GraphEvent
+	for evt := range eventCh {
+		if evt.Seq != 0 {
+			t.Fatalf("expected no seq without a working event log, got %d", evt.Seq)
+		}
+		received = append(received, evt)
+	}
+
+	if len(received) < 3 || received[len(received)-1].Type != event.EventTypeGraphRunSucceeded {
+		t.Fatalf("expected the run to complete, got %d events", len(received))
+	}
+	// 只有首个事件等待写入超时，其余事件直接转发
+	if got := log.appends.Load(); got != 1 {
+		t.Fatalf("expected a single append attempt, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("expected events forwarded without waiting on every append, took %s", elapsed)
+	}
+}
@@ -0,0 +1,323 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// notifyRepo 通知订阅与投递记录的内存实现
+type notifyRepo struct {
+	port.Repository
+	mu         sync.Mutex
+	runs       map[string]*port.WorkflowRun
+	subs       []*port.RunNotificationSubscription
+	deliveries []*port.RunNotificationDelivery
+}
+
+func newNotifyRepo() *notifyRepo {
+	return &notifyRepo{runs: make(map[string]*port.WorkflowRun)}
+}
+
+func (r *notifyRepo) GetRun(_ context.Context, id string) (*port.WorkflowRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run, ok := r.runs[id]; ok {
+		cp := *run
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (r *notifyRepo) ListActiveNotificationSubscriptions(_ context.Context, orgID, tenantID string) ([]*port.RunNotificationSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*port.RunNotificationSubscription
+	for _, sub := range r.subs {
+		if sub.Enabled && sub.OrgID == orgID && sub.TenantID == tenantID {
+			out = append(out, sub)
+		}
+	}
+	return out, nil
+}
+
+func (r *notifyRepo) CreateNotificationDelivery(_ context.Context, d *port.RunNotificationDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = fmt.Sprintf("delivery_%d", len(r.deliveries)+1)
+	cp := *d
+	r.deliveries = append(r.deliveries, &cp)
+	return nil
+}
+
+func (r *notifyRepo) ClaimDueNotificationDeliveries(_ context.Context, limit int, lock time.Duration) ([]*port.RunNotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var due []*port.RunNotificationDelivery
+	for _, d := range r.deliveries {
+		if len(due) >= limit {
+			break
+		}
+		if d.Status != port.NotificationDeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		until := now.Add(lock)
+		d.NextAttemptAt = &until
+		cp := *d
+		cp.Attempts = append([]port.NotificationAttempt(nil), d.Attempts...)
+		due = append(due, &cp)
+	}
+	return due, nil
+}
+
+func (r *notifyRepo) RecordNotificationAttempt(_ context.Context, id string, attempt port.NotificationAttempt, status port.NotificationDeliveryStatus, next *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			d.Attempts = append(d.Attempts, attempt)
+			d.Status = status
+			d.NextAttemptAt = next
+		}
+	}
+	return nil
+}
+
+func (r *notifyRepo) delivery(t *testing.T, url string) port.RunNotificationDelivery {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deliveries {
+		if d.URL == url {
+			return *d
+		}
+	}
+	t.Fatalf("no delivery to %s", url)
+	return port.RunNotificationDelivery{}
+}
+
+// notifyReceiver 记录收到的通知；前 failures 次返回 500
+type notifyReceiver struct {
+	*httptest.Server
+	failures atomic.Int32
+	mu       sync.Mutex
+	received []*http.Request
+	bodies   [][]byte
+}
+
+func newNotifyReceiver(t *testing.T, failures int32) *notifyReceiver {
+	rcv := &notifyReceiver{}
+	rcv.failures.Store(failures)
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		rcv.received = append(rcv.received, r)
+		rcv.bodies = append(rcv.bodies, body)
+		rcv.mu.Unlock()
+		if rcv.failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *notifyReceiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.received)
+}
+
+// notifyConfig 测试接收端监听在回环地址上，需放开内网限制
+func notifyConfig() workflow.RunNotifierConfig {
+	return workflow.RunNotifierConfig{
+		Timeout:              time.Second,
+		MaxAttempts:          3,
+		BaseBackoff:          10 * time.Millisecond,
+		MaxBackoff:           20 * time.Millisecond,
+		AllowPrivateNetworks: true,
+	}
+}
+
+// tickUntil 反复投递到期记录，直到 done 返回 true
+func tickUntil(t *testing.T, n *workflow.RunNotifier, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for notification deliveries")
+		}
+		n.Tick(context.Background())
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func finishedRun(id string) *port.WorkflowRun {
+	now := time.Now()
+	return &port.WorkflowRun{
+		ID:         id,
+		WorkflowID: "wf_1",
+		OrgID:      "org_1",
+		TenantID:   "tenant_1",
+		Status:     port.RunStatusSucceeded,
+		Outputs:    json.RawMessage(`{"answer":"42"}`),
+		ElapsedMs:  120,
+		FinishedAt: &now,
+	}
+}
+
+func TestRunNotifier_RetriesUntilDeliveredAndSigns(t *testing.T) {
+	callback := newNotifyReceiver(t, 1)
+	tenantHook := newNotifyReceiver(t, 0)
+	failedOnly := newNotifyReceiver(t, 0)
+
+	repo := newNotifyRepo()
+	run := finishedRun("run_notify")
+	run.CallbackURL = callback.URL
+	run.CallbackSecret = "run-secret"
+	repo.runs[run.ID] = run
+	repo.subs = []*port.RunNotificationSubscription{
+		{ID: "sub_all", OrgID: "org_1", TenantID: "tenant_1", URL: tenantHook.URL, Secret: "sub-secret", Enabled: true},
+		{ID: "sub_failed", OrgID: "org_1", TenantID: "tenant_1", URL: failedOnly.URL, Secret: "sub-secret", Enabled: true,
+			Statuses: []port.RunStatus{port.RunStatusFailed}},
+		{ID: "sub_other_tenant", OrgID: "org_1", TenantID: "tenant_2", URL: failedOnly.URL, Secret: "sub-secret", Enabled: true},
+	}
+
+	n := workflow.NewRunNotifier(repo, notifyConfig())
+	// 运行对象不带回调时从存储中读取（异步 worker 的路径）
+	stripped := *run
+	stripped.CallbackURL, stripped.CallbackSecret = "", ""
+	n.RunFinished(context.Background(), &stripped)
+
+	tickUntil(t, n, func() bool {
+		return repo.delivery(t, callback.URL).Status == port.NotificationDeliverySucceeded &&
+			repo.delivery(t, tenantHook.URL).Status == port.NotificationDeliverySucceeded
+	})
+
+	if got := failedOnly.count(); got != 0 {
+		t.Fatalf("expected no delivery to status-filtered or other-tenant subscriptions, got %d", got)
+	}
+	d := repo.delivery(t, callback.URL)
+	if len(d.Attempts) != 2 || d.Attempts[0].StatusCode != http.StatusInternalServerError || d.Attempts[1].StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected callback attempts: %+v", d.Attempts)
+	}
+	if sub := repo.delivery(t, tenantHook.URL); sub.SubscriptionID != "sub_all" || len(sub.Attempts) != 1 {
+		t.Fatalf("unexpected subscription delivery: %+v", sub)
+	}
+
+	callback.mu.Lock()
+	req, body := callback.received[1], callback.bodies[1]
+	callback.mu.Unlock()
+	if err := workflow.VerifyWebhookSignature("run-secret",
+		req.Header.Get(workflow.WebhookTimestampHeader), req.Header.Get(workflow.WebhookSignatureHeader),
+		body, time.Now(), 0); err != nil {
+		t.Fatalf("signature did not verify: %v", err)
+	}
+	if req.Header.Get(workflow.NotificationEventHeader) != workflow.RunFinishedEvent || req.Header.Get(workflow.NotificationDeliveryHeader) != d.ID {
+		t.Fatalf("unexpected headers: %v", req.Header)
+	}
+	var payload workflow.RunNotificationPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.RunID != run.ID || payload.Status != port.RunStatusSucceeded || string(payload.Outputs) != `{"answer":"42"}` || payload.ElapsedMs != 120 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestRunNotifier_ExhaustedThenRedeliver(t *testing.T) {
+	callback := newNotifyReceiver(t, 3)
+
+	repo := newNotifyRepo()
+	run := finishedRun("run_notify_exhausted")
+	run.Status = port.RunStatusFailed
+	run.Error = "boom"
+	run.CallbackURL = callback.URL
+	repo.runs[run.ID] = run
+
+	cfg := notifyConfig()
+	cfg.DefaultSecret = "default-secret"
+	n := workflow.NewRunNotifier(repo, cfg)
+	n.RunFinished(context.Background(), run)
+
+	tickUntil(t, n, func() bool {
+		return repo.delivery(t, callback.URL).Status == port.NotificationDeliveryFailed
+	})
+	d := repo.delivery(t, callback.URL)
+	if len(d.Attempts) != 3 || d.NextAttemptAt != nil {
+		t.Fatalf("expected 3 attempts and no further retry, got %+v", d)
+	}
+	if d.Secret != "default-secret" {
+		t.Fatalf("expected default secret for callback without callback_secret, got %q", d.Secret)
+	}
+
+	updated := n.Redeliver(context.Background(), &d)
+	if updated.Status != port.NotificationDeliverySucceeded || len(updated.Attempts) != 4 || updated.DeliveredAt == nil {
+		t.Fatalf("unexpected redelivery result: %+v", updated)
+	}
+	if got := repo.delivery(t, callback.URL); got.Status != port.NotificationDeliverySucceeded {
+		t.Fatalf("expected stored delivery succeeded, got %s", got.Status)
+	}
+}
+
+func TestRunNotifier_SkipsChildAndNonTerminalRuns(t *testing.T) {
+	callback := newNotifyReceiver(t, 0)
+	repo := newNotifyRepo()
+	n := workflow.NewRunNotifier(repo, notifyConfig())
+
+	child := finishedRun("run_child")
+	child.ParentRunID = "run_parent"
+	child.CallbackURL = callback.URL
+	paused := finishedRun("run_paused")
+	paused.Status = port.RunStatusPaused
+	paused.CallbackURL = callback.URL
+
+	n.RunFinished(context.Background(), child)
+	n.RunFinished(context.Background(), paused)
+	if len(repo.deliveries) != 0 {
+		t.Fatalf("expected no deliveries, got %d", len(repo.deliveries))
+	}
+}
+
+func TestRunNotifier_BlocksPrivateTargetsAtDial(t *testing.T) {
+	callback := newNotifyReceiver(t, 0)
+	repo := newNotifyRepo()
+	run := finishedRun("run_notify_private")
+	run.CallbackURL = callback.URL
+	run.CallbackSecret = "run-secret"
+	repo.runs[run.ID] = run
+
+	cfg := notifyConfig()
+	cfg.AllowPrivateNetworks = false
+	n := workflow.NewRunNotifier(repo, cfg)
+	// 绕过提交时的校验（如 DNS 重绑定），投递时拨号仍被拒绝
+	n.RunFinished(context.Background(), run)
+	tickUntil(t, n, func() bool {
+		return repo.delivery(t, callback.URL).Status == port.NotificationDeliveryFailed
+	})
+
+	d := repo.delivery(t, callback.URL)
+	if got := callback.count(); got != 0 {
+		t.Fatalf("expected no request to reach the loopback receiver, got %d", got)
+	}
+	if len(d.Attempts) != 3 || !strings.Contains(d.Attempts[0].Error, "private/local IP not allowed") {
+		t.Fatalf("expected attempts rejected at dial, got %+v", d.Attempts)
+	}
+	if err := n.ValidateTarget(context.Background(), "127.0.0.1"); err == nil {
+		t.Fatal("expected loopback target rejected")
+	}
+}
@@ -0,0 +1,279 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// --- cron 表达式 ---
+
+func mustTime(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+	ts, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatalf("parse time %q: %v", value, err)
+	}
+	return ts
+}
+
+func TestCron_Next(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"*/15 * * * *", "2024-03-01 10:07", "2024-03-01 10:15"},
+		{"0 2 * * *", "2024-03-01 02:00", "2024-03-02 02:00"},
+		{"30 9 * * mon-fri", "2024-03-01 10:00", "2024-03-04 09:30"}, // 周五之后是周一
+		{"0 0 1 jan,jul *", "2024-03-01 00:00", "2024-07-01 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 13 * 5", "2024-03-01 00:00", "2024-03-08 00:00"}, // 日与周同时限定时按 OR 匹配
+		{"0 12 * * 7", "2024-03-01 00:00", "2024-03-03 12:00"}, // 7 等同周日
+		{"@hourly", "2024-03-01 10:59", "2024-03-01 11:00"},
+	}
+	for _, tc := range cases {
+		c, err := workflow.ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+		}
+		got := c.Next(mustTime(t, time.UTC, tc.from))
+		if want := mustTime(t, time.UTC, tc.want); !got.Equal(want) {
+			t.Fatalf("%q after %s: expected %s, got %s", tc.expr, tc.from, want, got)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 * foo *"} {
+		if _, err := workflow.ParseCron(expr); err == nil {
+			t.Fatalf("expected ParseCron(%q) to fail", expr)
+		}
+	}
+	t.Logf("✅ Cron expressions parsed and evaluated")
+}
+
+func TestCron_Timezone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 上海 02:00 = UTC 前一天 18:00
+	next, err := workflow.NextFireTime("0 2 * * *", "Asia/Shanghai", mustTime(t, time.UTC, "2024-03-01 12:00"))
+	if err != nil {
+		t.Fatalf("NextFireTime: %v", err)
+	}
+	if want := mustTime(t, shanghai, "2024-03-02 02:00"); !next.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, next.UTC())
+	}
+
+	// 夏令时跳过的本地时间不会触发，之后的计划照常
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	times, err := workflow.UpcomingFireTimes("30 2 * * *", "America/New_York", mustTime(t, ny, "2024-03-09 12:00"), 2)
+	if err != nil {
+		t.Fatalf("UpcomingFireTimes: %v", err)
+	}
+	if times[0].Day() != 11 || times[1].Day() != 12 {
+		t.Fatalf("expected the skipped 2024-03-10 02:30 to be passed over, got %v", times)
+	}
+
+	if _, err := workflow.NextFireTime("0 2 * * *", "Mars/Olympus", time.Now()); err == nil {
+		t.Fatalf("expected invalid timezone to fail")
+	}
+	if _, err := workflow.NextFireTime("0 0 30 2 *", "UTC", time.Now()); err == nil {
+		t.Fatalf("expected never-firing expression to fail")
+	}
+	t.Logf("✅ Cron timezone evaluation verified")
+}
+
+// --- 调度器 ---
+
+// scheduleRepo 内存仓储：仅实现调度器所需的方法，AdvanceSchedule 为 CAS 语义
+type scheduleRepo struct {
+	port.Repository
+
+	mu        sync.Mutex
+	workflow  *port.Workflow
+	schedules map[string]*port.WorkflowSchedule
+	runs      []*port.WorkflowRun
+	scopes    [][2]string
+}
+
+func newScheduleRepo(s *port.WorkflowSchedule) *scheduleRepo {
+	return &scheduleRepo{
+		workflow:  &port.Workflow{ID: "wf_1", Version: 3, PublishedVersion: 2},
+		schedules: map[string]*port.WorkflowSchedule{s.ID: s},
+	}
+}
+
+func (r *scheduleRepo) ListDueSchedules(_ context.Context, now time.Time, limit int) ([]*port.WorkflowSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*port.WorkflowSchedule
+	for _, s := range r.schedules {
+		if s.Enabled && s.NextFireAt != nil && !s.NextFireAt.After(now) {
+			cp := *s
+			due = append(due, &cp)
+		}
+	}
+	return due, nil
+}
+
+func (r *scheduleRepo) AdvanceSchedule(_ context.Context, id string, expected, next time.Time, firedAt *time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.schedules[id]
+	if s == nil || !s.Enabled || s.NextFireAt == nil || !s.NextFireAt.Equal(expected) {
+		return false, nil
+	}
+	s.NextFireAt = nil
+	if !next.IsZero() {
+		s.NextFireAt = &next
+	}
+	if firedAt != nil {
+		s.LastFireAt = firedAt
+	}
+	return true, nil
+}
+
+func (r *scheduleRepo) RecordScheduleRun(_ context.Context, id, runID, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if runID != "" {
+		r.schedules[id].LastRunID = runID
+	}
+	r.schedules[id].LastError = lastError
+	return nil
+}
+
+func (r *scheduleRepo) GetWorkflow(_ context.Context, id string) (*port.Workflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id != r.workflow.ID {
+		return nil, nil
+	}
+	cp := *r.workflow
+	return &cp, nil
+}
+
+func (r *scheduleRepo) CreateRun(ctx context.Context, run *port.WorkflowRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = fmt.Sprintf("run_%d", len(r.runs)+1)
+	orgID, tenantID, _ := port.RepoScopeFrom(ctx)
+	r.scopes = append(r.scopes, [2]string{orgID, tenantID})
+	cp := *run
+	r.runs = append(r.runs, &cp)
+	return nil
+}
+
+func newTestSchedule(nextFireAt time.Time, policy port.MisfirePolicy) *port.WorkflowSchedule {
+	return &port.WorkflowSchedule{
+		ID:            "sch_1",
+		WorkflowID:    "wf_1",
+		OrgID:         "org_1",
+		TenantID:      "tenant_1",
+		CronExpr:      "0 * * * *",
+		Timezone:      "UTC",
+		Inputs:        json.RawMessage(`{"topic":"digest"}`),
+		Enabled:       true,
+		MisfirePolicy: policy,
+		NextFireAt:    &nextFireAt,
+	}
+}
+
+func TestScheduler_FiresOncePerTickAcrossReplicas(t *testing.T) {
+	tick := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	repo := newScheduleRepo(newTestSchedule(tick, port.MisfirePolicyFireOnce))
+
+	// 多个副本同时处理同一触发时间，只有 CAS 成功的副本创建运行
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		s := workflow.NewScheduler(repo, workflow.SchedulerConfig{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Tick(context.Background(), tick.Add(2*time.Second))
+		}()
+	}
+	wg.Wait()
+
+	if len(repo.runs) != 1 {
+		t.Fatalf("expected exactly one run, got %d", len(repo.runs))
+	}
+	run := repo.runs[0]
+	if run.Status != port.RunStatusQueued || run.WorkflowVersion != 2 || string(run.Inputs) != `{"topic":"digest"}` {
+		t.Fatalf("unexpected run: status=%s version=%d inputs=%s", run.Status, run.WorkflowVersion, run.Inputs)
+	}
+	if repo.scopes[0] != [2]string{"org_1", "tenant_1"} {
+		t.Fatalf("expected run created in schedule scope, got %v", repo.scopes[0])
+	}
+	sch := repo.schedules["sch_1"]
+	if want := tick.Add(time.Hour); sch.NextFireAt == nil || !sch.NextFireAt.Equal(want) {
+		t.Fatalf("expected next fire at %s, got %v", want, sch.NextFireAt)
+	}
+	if sch.LastRunID != "run_1" || sch.LastFireAt == nil {
+		t.Fatalf("expected last run recorded, got run=%q fired=%v", sch.LastRunID, sch.LastFireAt)
+	}
+	t.Logf("✅ Tick fired once across replicas")
+}
+
+func TestScheduler_Misfire(t *testing.T) {
+	missed := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC) // 停机数小时后恢复
+
+	// fire_once：补触发一次，而不是每个错过的整点各一次
+	repo := newScheduleRepo(newTestSchedule(missed, port.MisfirePolicyFireOnce))
+	s := workflow.NewScheduler(repo, workflow.SchedulerConfig{MisfireThreshold: time.Minute})
+	if n := s.Tick(context.Background(), now); n != 1 {
+		t.Fatalf("expected one catch-up run, got %d", n)
+	}
+	if n := s.Tick(context.Background(), now.Add(time.Second)); n != 0 || len(repo.runs) != 1 {
+		t.Fatalf("expected no further runs before the next tick, got %d", len(repo.runs))
+	}
+	if want := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC); !repo.schedules["sch_1"].NextFireAt.Equal(want) {
+		t.Fatalf("expected next fire at %s, got %s", want, repo.schedules["sch_1"].NextFireAt)
+	}
+
+	// skip：跳过错过的触发，只推进 next_fire_at
+	repo = newScheduleRepo(newTestSchedule(missed, port.MisfirePolicySkip))
+	s = workflow.NewScheduler(repo, workflow.SchedulerConfig{MisfireThreshold: time.Minute})
+	if n := s.Tick(context.Background(), now); n != 0 || len(repo.runs) != 0 {
+		t.Fatalf("expected misfired tick to be skipped, got %d runs", len(repo.runs))
+	}
+	sch := repo.schedules["sch_1"]
+	if sch.LastFireAt != nil || sch.LastError == "" || !sch.NextFireAt.Equal(time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected schedule after skip: last_fire=%v error=%q next=%v", sch.LastFireAt, sch.LastError, sch.NextFireAt)
+	}
+
+	// 阈值内的延迟不算 misfire
+	repo = newScheduleRepo(newTestSchedule(missed, port.MisfirePolicySkip))
+	s = workflow.NewScheduler(repo, workflow.SchedulerConfig{MisfireThreshold: time.Minute})
+	if n := s.Tick(context.Background(), missed.Add(30*time.Second)); n != 1 {
+		t.Fatalf("expected slightly late tick to fire, got %d", n)
+	}
+	t.Logf("✅ Misfire policies verified")
+}
+
+func TestScheduler_UnpublishedWorkflow(t *testing.T) {
+	tick := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	repo := newScheduleRepo(newTestSchedule(tick, port.MisfirePolicyFireOnce))
+	repo.workflow.PublishedVersion = 0
+
+	s := workflow.NewScheduler(repo, workflow.SchedulerConfig{})
+	if n := s.Tick(context.Background(), tick); n != 0 || len(repo.runs) != 0 {
+		t.Fatalf("expected no run for an unpublished workflow")
+	}
+	sch := repo.schedules["sch_1"]
+	if sch.LastError == "" || sch.NextFireAt == nil || !sch.NextFireAt.After(tick) {
+		t.Fatalf("expected error recorded and schedule advanced, got error=%q next=%v", sch.LastError, sch.NextFireAt)
+	}
+	t.Logf("✅ Unpublished workflow reported: %s", sch.LastError)
+}
@@ -0,0 +1,295 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// subWorkflowRepo 内存仓储：仅实现子工作流调用所需的方法
+type subWorkflowRepo struct {
+	port.Repository
+
+	mu        sync.Mutex
+	workflows map[string]*port.Workflow
+	runs      map[string]*port.WorkflowRun
+	scopes    map[string][2]string // run_id → {org_id, tenant_id}
+	execs     map[string]int
+}
+
+func newSubWorkflowRepo() *subWorkflowRepo {
+	return &subWorkflowRepo{
+		workflows: make(map[string]*port.Workflow),
+		runs:      make(map[string]*port.WorkflowRun),
+		scopes:    make(map[string][2]string),
+		execs:     make(map[string]int),
+	}
+}
+
+func (r *subWorkflowRepo) GetWorkflow(_ context.Context, id string) (*port.Workflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.workflows[id], nil
+}
+
+func (r *subWorkflowRepo) CreateRun(ctx context.Context, run *port.WorkflowRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = fmt.Sprintf("child-%d", len(r.runs)+1)
+	orgID, tenantID, _ := port.RepoScopeFrom(ctx)
+	r.scopes[run.ID] = [2]string{orgID, tenantID}
+	cp := *run
+	r.runs[run.ID] = &cp
+	return nil
+}
+
+func (r *subWorkflowRepo) UpdateRun(_ context.Context, run *port.WorkflowRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *run
+	r.runs[run.ID] = &cp
+	return nil
+}
+
+func (r *subWorkflowRepo) BatchCreateNodeExecs(_ context.Context, records []*port.NodeExecutionRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range records {
+		r.execs[rec.RunID]++
+	}
+	return nil
+}
+
+const subWorkflowChildDSL = `{
+	"nodes": [
+		{
+			"id": "start_1",
+			"data": {
+				"type": "start",
+				"title": "Start",
+				"variables": [
+					{"variable": "name", "label": "Name", "type": "string", "required": true}
+				]
+			}
+		},
+		{
+			"id": "tpl_1",
+			"data": {
+				"type": "template-transform",
+				"title": "Greet",
+				"template": "hello {{ name }}",
+				"variables": [{"variable": "name", "value_selector": ["start_1", "name"]}]
+			}
+		},
+		{
+			"id": "end_1",
+			"data": {
+				"type": "end",
+				"title": "End",
+				"outputs": [{"variable": "greeting", "value_selector": ["tpl_1", "output"]}]
+			}
+		}
+	],
+	"edges": [
+		{"source": "start_1", "target": "tpl_1"},
+		{"source": "tpl_1", "target": "end_1"}
+	]
+}`
+
+func subWorkflowParentDSL(workflowID string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {
+					"type": "start",
+					"title": "Start",
+					"variables": [
+						{"variable": "query", "label": "Query", "type": "string", "required": true}
+					]
+				}
+			},
+			{
+				"id": "wf_1",
+				"data": {
+					"type": "workflow",
+					"title": "Child",
+					"workflow_id": %q,
+					"inputs": [{"variable": "name", "value_selector": ["start_1", "query"]}]
+				}
+			},
+			{
+				"id": "end_1",
+				"data": {
+					"type": "end",
+					"title": "End",
+					"outputs": [{"variable": "result", "value_selector": ["wf_1", "greeting"]}]
+				}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "wf_1"},
+			{"source": "wf_1", "target": "end_1"}
+		]
+	}`, workflowID))
+}
+
+// --- 子工作流节点 ---
+
+func TestSubWorkflow_RunsChildAndLinksParent(t *testing.T) {
+	repo := newSubWorkflowRepo()
+	repo.workflows["wf-child"] = &port.Workflow{ID: "wf-child", Version: 1, PublishedVersion: 1, DSL: json.RawMessage(subWorkflowChildDSL)}
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	runner.SetRepository(repo)
+
+	result, err := runner.RunSync(context.Background(), subWorkflowParentDSL("wf-child"),
+		map[string]interface{}{"query": "world"},
+		&workflow.RunOptions{RunID: "parent-1", OrgID: "org-1", TenantID: "tenant-1"})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Outputs["result"] != "hello world" {
+		t.Fatalf("expected child output to flow back, got %v", result.Outputs)
+	}
+
+	if len(repo.runs) != 1 {
+		t.Fatalf("expected 1 child run, got %d", len(repo.runs))
+	}
+	child := repo.runs["child-1"]
+	if child.ParentRunID != "parent-1" || child.WorkflowID != "wf-child" {
+		t.Fatalf("expected child linked to parent-1, got %+v", child)
+	}
+	if child.Status != port.RunStatusSucceeded || child.FinishedAt == nil {
+		t.Fatalf("expected finalized succeeded child run, got status=%s", child.Status)
+	}
+	if child.OrgID != "org-1" || child.TenantID != "tenant-1" {
+		t.Fatalf("expected child run in parent tenant, got org=%s tenant=%s", child.OrgID, child.TenantID)
+	}
+	if scope := repo.scopes["child-1"]; scope != [2]string{"org-1", "tenant-1"} {
+		t.Fatalf("expected child run created under parent scope, got %+v", scope)
+	}
+	if repo.execs["child-1"] == 0 {
+		t.Fatalf("expected child node executions to be persisted")
+	}
+
+	exec := findNodeExec(result.NodeExecutions, "wf_1")
+	if exec == nil || exec.Metadata["child_run_id"] != "child-1" {
+		t.Fatalf("expected child_run_id in workflow node metadata, got %+v", exec)
+	}
+	if exec.Metadata["workflow_version"] != 1 {
+		t.Fatalf("expected resolved published version in metadata, got %v", exec.Metadata["workflow_version"])
+	}
+
+	t.Logf("✅ Sub-workflow child run test passed")
+}
+
+func TestSubWorkflow_DepthLimit(t *testing.T) {
+	repo := newSubWorkflowRepo()
+	repo.workflows["wf-self"] = &port.Workflow{ID: "wf-self", Version: 1, PublishedVersion: 1, DSL: subWorkflowParentDSL("wf-self")}
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	runner.SetRepository(repo)
+	runner.SetMaxSubWorkflowDepth(3)
+
+	_, err := runner.RunSync(context.Background(), subWorkflowParentDSL("wf-self"),
+		map[string]interface{}{"query": "loop"}, &workflow.RunOptions{RunID: "parent-1"})
+	if err == nil || !strings.Contains(err.Error(), "depth limit exceeded") {
+		t.Fatalf("expected depth limit error, got %v", err)
+	}
+	if len(repo.runs) != 3 {
+		t.Fatalf("expected 3 nested child runs before the limit, got %d", len(repo.runs))
+	}
+	for id, run := range repo.runs {
+		if run.Status != port.RunStatusFailed {
+			t.Fatalf("expected child run %s to fail, got %s", id, run.Status)
+		}
+	}
+	if repo.runs["child-2"].ParentRunID != "child-1" {
+		t.Fatalf("expected nested child to link to its parent child run, got %q", repo.runs["child-2"].ParentRunID)
+	}
+
+	t.Logf("✅ Sub-workflow depth limit test passed")
+}
+
+func TestSubWorkflow_MissingWorkflow(t *testing.T) {
+	repo := newSubWorkflowRepo()
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	runner.SetRepository(repo)
+
+	_, err := runner.RunSync(context.Background(), subWorkflowParentDSL("wf-missing"),
+		map[string]interface{}{"query": "q"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected workflow not found error, got %v", err)
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no child run for a missing workflow, got %d", len(repo.runs))
+	}
+
+	t.Logf("✅ Sub-workflow missing workflow test passed")
+}
+
+func TestSubWorkflow_UnpublishedWorkflow(t *testing.T) {
+	repo := newSubWorkflowRepo()
+	repo.workflows["wf-draft"] = &port.Workflow{ID: "wf-draft", Version: 1, Status: port.WorkflowStatusDraft, DSL: json.RawMessage(subWorkflowChildDSL)}
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	runner.SetRepository(repo)
+
+	_, err := runner.RunSync(context.Background(), subWorkflowParentDSL("wf-draft"),
+		map[string]interface{}{"query": "q"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "no published version") {
+		t.Fatalf("expected unpublished workflow error, got %v", err)
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no child run for an unpublished workflow, got %d", len(repo.runs))
+	}
+
+	t.Logf("✅ Sub-workflow unpublished workflow test passed")
+}
+
+func TestSubWorkflow_DoesNotInheritHumanInputs(t *testing.T) {
+	repo := newSubWorkflowRepo()
+	repo.workflows["wf-review"] = &port.Workflow{ID: "wf-review", Version: 1, PublishedVersion: 1, DSL: json.RawMessage(humanInputDSL)}
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	runner.SetRepository(repo)
+
+	parent := `{
+		"nodes": [
+			{"id": "start_1", "data": {"type": "start", "title": "Start", "variables": [{"variable": "text", "type": "string", "required": true}]}},
+			{"id": "wf_1", "data": {"type": "workflow", "title": "Child", "workflow_id": "wf-review",
+				"inputs": [{"variable": "text", "value_selector": ["start_1", "text"]}]}},
+			{"id": "end_1", "data": {"type": "end", "title": "End", "outputs": [{"variable": "comment", "value_selector": ["wf_1", "comment"]}]}}
+		],
+		"edges": [
+			{"source": "start_1", "target": "wf_1"},
+			{"source": "wf_1", "target": "end_1"}
+		]
+	}`
+	// 父运行携带的响应与子工作流中的人工输入节点同 ID，子运行不得消费
+	_, err := runner.RunSync(context.Background(), []byte(parent), map[string]interface{}{"text": "draft"}, &workflow.RunOptions{
+		RunID: "parent-1",
+		HumanInputs: map[string]*port.PendingInput{
+			"review_1": {
+				NodeID:   "review_1",
+				Status:   port.PendingInputStatusSubmitted,
+				Action:   "approve",
+				Response: map[string]interface{}{"comment": "parent answer"},
+			},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "paused for human input") {
+		t.Fatalf("expected child run to pause instead of using the parent's input, got %v", err)
+	}
+	if child := repo.runs["child-1"]; child == nil || child.Status != port.RunStatusFailed {
+		t.Fatalf("expected failed child run, got %+v", child)
+	}
+}
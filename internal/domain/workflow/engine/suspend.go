@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+
+	"flowweave/internal/domain/workflow/event"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// suspend 记录挂起节点的人工输入请求
+func (e *GraphEngine) suspend(nodeID string, evt event.NodeEvent) {
+	pi, ok := evt.Metadata["pending_input"].(*port.PendingInput)
+	if !ok || pi == nil {
+		pi = &port.PendingInput{}
+	}
+	pi.NodeID = nodeID
+	if pi.RunID == "" {
+		pi.RunID = e.runID
+	}
+	if pi.Status == "" {
+		pi.Status = port.PendingInputStatusWaiting
+	}
+
+	e.suspendMu.Lock()
+	e.suspended = append(e.suspended, pi)
+	e.suspendMu.Unlock()
+}
+
+// PendingInputs 返回本次执行中挂起等待输入的请求
+func (e *GraphEngine) PendingInputs() []*port.PendingInput {
+	e.suspendMu.Lock()
+	defer e.suspendMu.Unlock()
+	result := make([]*port.PendingInput, len(e.suspended))
+	copy(result, e.suspended)
+	return result
+}
+
+// pausedEvent 生成图挂起事件：附带检查点快照，恢复时从挂起节点继续执行
+func (e *GraphEngine) pausedEvent(ctx context.Context, pending []*port.PendingInput, nodeExecs []port.NodeExecution) event.GraphEvent {
+	evt := event.NewGraphRunPausedEvent(pending)
+	evt.NodeExecutions = nodeExecs
+
+	cp, err := e.snapshot()
+	if err != nil {
+		e.logger.Warn("failed to build checkpoint on pause", "run_id", e.runID, "error", err)
+		return evt
+	}
+	if e.checkpointer != nil {
+		if err := e.checkpointer(ctx, cp); err != nil {
+			e.logger.Warn("failed to save checkpoint on pause", "run_id", e.runID, "sequence", cp.Sequence, "error", err)
+		}
+	}
+	evt.Checkpoint = cp
+	return evt
+}
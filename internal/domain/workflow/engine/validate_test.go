@@ -0,0 +1,208 @@
+package engine_test
+
+import (
+	"testing"
+
+	"flowweave/internal/app/workflow"
+	"flowweave/internal/domain/workflow/engine"
+)
+
+// issueCodes 按节点收集校验问题代码
+func issueCodes(report *workflow.ValidationReport) map[string][]string {
+	codes := make(map[string][]string)
+	for _, issue := range report.Issues {
+		codes[issue.NodeID] = append(codes[issue.NodeID], issue.Code)
+	}
+	return codes
+}
+
+func hasCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// --- 静态校验 ---
+
+func TestValidate_ValidWorkflow(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+
+	dsls := map[string][]byte{
+		"linear":    []byte(subWorkflowChildDSL),
+		"branches":  aggregatorBranchDSL(`{"type": "variable-aggregator", "title": "Merge", "variables": [["tpl_adult", "output"], ["tpl_minor", "output"]]}`),
+		"iteration": []byte(validateIterationDSL),
+	}
+	for name, dsl := range dsls {
+		report := runner.Validate(dsl)
+		if !report.Valid || len(report.Issues) != 0 {
+			t.Fatalf("%s: expected clean report, got %+v", name, report.Issues)
+		}
+	}
+
+	t.Logf("✅ Validate valid workflow test passed")
+}
+
+func TestValidate_ReportsIssuesWithNodeIDs(t *testing.T) {
+	dsl := []byte(`{
+		"nodes": [
+			{
+				"id": "start_1",
+				"data": {"type": "start", "title": "Start", "variables": [{"variable": "query", "label": "Query", "type": "string"}]}
+			},
+			{
+				"id": "ifelse_1",
+				"data": {
+					"type": "if-else",
+					"title": "Check",
+					"conditions": [
+						{"id": "yes", "logical_operator": "and", "conditions": [
+							{"variable_selector": ["tpl_1", "output"], "comparison_operator": "is", "value": "x"}
+						]}
+					]
+				}
+			},
+			{
+				"id": "llm_1",
+				"data": {
+					"type": "llm",
+					"title": "LLM",
+					"model": {"provider": "no-such-provider", "name": "m"},
+					"prompts": [{"role": "user", "text": "{{#start_1.query#}} {{#ghost_1.text#}}"}],
+					"tools": [{"name": "no_such_tool"}]
+				}
+			},
+			{
+				"id": "func_1",
+				"data": {"type": "func", "title": "Func", "function_ref": "no.such.function", "inputs": [], "outputs": []}
+			},
+			{
+				"id": "tpl_1",
+				"data": {"type": "template-transform", "title": "Tpl", "template": "x", "variables": []}
+			},
+			{
+				"id": "loop_a",
+				"data": {"type": "template-transform", "title": "A", "template": "a", "variables": []}
+			},
+			{
+				"id": "loop_b",
+				"data": {"type": "template-transform", "title": "B", "template": "b", "variables": []}
+			},
+			{
+				"id": "mystery_1",
+				"data": {"type": "no-such-type", "title": "Mystery"}
+			},
+			{
+				"id": "end_1",
+				"data": {"type": "end", "title": "End", "outputs": [{"variable": "r", "value_selector": ["llm_1", "text"]}]}
+			}
+		],
+		"edges": [
+			{"source": "start_1", "target": "ifelse_1"},
+			{"source": "ifelse_1", "target": "llm_1", "sourceHandle": "yes"},
+			{"source": "llm_1", "target": "func_1"},
+			{"source": "func_1", "target": "tpl_1"},
+			{"source": "tpl_1", "target": "end_1"},
+			{"source": "func_1", "target": "ghost_2"},
+			{"source": "loop_a", "target": "loop_b"},
+			{"source": "loop_b", "target": "loop_a"}
+		]
+	}`)
+
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	report := runner.Validate(dsl)
+	if report.Valid {
+		t.Fatalf("expected invalid report")
+	}
+	codes := issueCodes(report)
+
+	expected := map[string][]string{
+		"ifelse_1":  {workflow.ValidationReferenceNotUpstream, workflow.ValidationMissingBranchEdge},
+		"llm_1":     {workflow.ValidationUnknownReference, workflow.ValidationUnknownProvider, workflow.ValidationUnknownTool},
+		"func_1":    {workflow.ValidationUnknownFunction, workflow.ValidationUnknownEdgeNode},
+		"loop_a":    {workflow.ValidationCycle, workflow.ValidationUnreachableNode},
+		"loop_b":    {workflow.ValidationCycle},
+		"mystery_1": {workflow.ValidationUnknownNodeType},
+	}
+	for nodeID, want := range expected {
+		for _, code := range want {
+			if !hasCode(codes[nodeID], code) {
+				t.Fatalf("expected %s on %s, got %v", code, nodeID, report.Issues)
+			}
+		}
+	}
+	if len(codes["start_1"]) != 0 || len(codes["end_1"]) != 0 || len(codes["tpl_1"]) != 0 {
+		t.Fatalf("expected no issues on valid nodes, got %v", codes)
+	}
+
+	t.Logf("✅ Validate issues test passed, %d issues", len(report.Issues))
+}
+
+func TestValidate_InvalidJSON(t *testing.T) {
+	runner := workflow.NewWorkflowRunner(engine.DefaultConfig(), nil)
+	report := runner.Validate([]byte(`{"nodes": [`))
+	if report.Valid || len(report.Issues) != 1 || report.Issues[0].Code != workflow.ValidationInvalidDSL {
+		t.Fatalf("expected single invalid_dsl issue, got %+v", report.Issues)
+	}
+
+	t.Logf("✅ Validate invalid JSON test passed")
+}
+
+// validateIterationDSL 子图节点引用容器变量与容器上游
+const validateIterationDSL = `{
+	"nodes": [
+		{
+			"id": "start_1",
+			"data": {
+				"type": "start",
+				"title": "Start",
+				"variables": [{"variable": "names", "label": "Names", "type": "array", "required": true}]
+			}
+		},
+		{
+			"id": "iter_1",
+			"data": {
+				"type": "iteration",
+				"title": "Iterate",
+				"mode": "map",
+				"input": {"value_selector": ["start_1", "names"]},
+				"subgraph": {
+					"start": "iter_start",
+					"nodes": [
+						{"id": "iter_start", "data": {"type": "iteration-start", "title": "Iter Start"}},
+						{
+							"id": "tpl_in",
+							"data": {
+								"type": "template-transform",
+								"title": "Wrap",
+								"template": "{{ name }}",
+								"variables": [
+									{"variable": "name", "value_selector": ["iter_1", "item"]},
+									{"variable": "all", "value_selector": ["start_1", "names"]}
+								]
+							}
+						}
+					],
+					"edges": [{"source": "iter_start", "target": "tpl_in"}],
+					"result_selector": ["tpl_in", "output"]
+				},
+				"aggregate": {"strategy": "collect"},
+				"outputs": [{"name": "results", "from": "aggregate.result"}]
+			}
+		},
+		{
+			"id": "end_1",
+			"data": {
+				"type": "end",
+				"title": "End",
+				"outputs": [{"variable": "items", "value_selector": ["iter_1", "results"]}]
+			}
+		}
+	],
+	"edges": [
+		{"source": "start_1", "target": "iter_1"},
+		{"source": "iter_1", "target": "end_1"}
+	]
+}`
@@ -17,12 +17,14 @@ const (
 	EventTypeGraphRunFailed           EventType = "graph_run_failed"
 	EventTypeGraphRunAborted          EventType = "graph_run_aborted"
 	EventTypeGraphRunPaused           EventType = "graph_run_paused"
+	EventTypeGraphRunResumed          EventType = "graph_run_resumed"
 	EventTypeGraphRunPartialSucceeded EventType = "graph_run_partial_succeeded"
 
 	// 节点级事件
 	EventTypeNodeRunStarted   EventType = "node_run_started"
 	EventTypeNodeRunSucceeded EventType = "node_run_succeeded"
 	EventTypeNodeRunFailed    EventType = "node_run_failed"
+	EventTypeNodeRunPaused    EventType = "node_run_paused"
 	EventTypeNodeStreamChunk  EventType = "node_stream_chunk"
 )
 
@@ -35,6 +37,16 @@ type GraphEvent struct {
 	Error           string                 `json:"error,omitempty"`
 	ExceptionsCount int                    `json:"exceptions_count,omitempty"`
 	NodeExecutions  []port.NodeExecution   `json:"node_executions,omitempty"`
+	PendingInputs   []*port.PendingInput   `json:"pending_inputs,omitempty"`
+
+	// Checkpoint 挂起时的执行快照（仅供运行方持久化，不对外输出）
+	Checkpoint *port.RunCheckpoint `json:"-"`
+
+	// Seq 事件在运行事件日志中的序号（未写入事件日志时为 0），作为 SSE 事件 ID 供断线续传
+	Seq int64 `json:"-"`
+
+	// 迭代 / 循环子图内节点的层级信息（顶层节点事件为空）
+	port.NodeParent
 }
 
 // NewGraphRunStartedEvent 创建图开始执行事件
@@ -47,6 +59,11 @@ func NewGraphRunSucceededEvent(outputs map[string]interface{}) GraphEvent {
 	return GraphEvent{Type: EventTypeGraphRunSucceeded, Outputs: outputs}
 }
 
+// NewGraphRunPartialSucceededEvent 创建图执行部分成功事件（存在被错误策略吸收的节点异常）
+func NewGraphRunPartialSucceededEvent(outputs map[string]interface{}, exceptionsCount int) GraphEvent {
+	return GraphEvent{Type: EventTypeGraphRunPartialSucceeded, Outputs: outputs, ExceptionsCount: exceptionsCount}
+}
+
 // NewGraphRunFailedEvent 创建图执行失败事件
 func NewGraphRunFailedEvent(err string, exceptionsCount int) GraphEvent {
 	return GraphEvent{Type: EventTypeGraphRunFailed, Error: err, ExceptionsCount: exceptionsCount}
@@ -57,6 +74,16 @@ func NewGraphRunAbortedEvent(reason string) GraphEvent {
 	return GraphEvent{Type: EventTypeGraphRunAborted, Error: reason}
 }
 
+// NewGraphRunPausedEvent 创建图执行挂起事件（等待人工输入）
+func NewGraphRunPausedEvent(pending []*port.PendingInput) GraphEvent {
+	return GraphEvent{Type: EventTypeGraphRunPaused, PendingInputs: pending}
+}
+
+// NewGraphRunResumedEvent 创建图执行恢复事件
+func NewGraphRunResumedEvent() GraphEvent {
+	return GraphEvent{Type: EventTypeGraphRunResumed}
+}
+
 // NodeEvent 节点级事件（内部节点执行产生的事件）
 type NodeEvent struct {
 	Type      EventType                 `json:"type"`
@@ -69,6 +96,9 @@ type NodeEvent struct {
 	Error     string                    `json:"error,omitempty"`
 	Status    types.NodeExecutionStatus `json:"status,omitempty"`
 
+	// ErrorClass 失败事件的错误分类（retry 策略据此判断是否重试）
+	ErrorClass types.ErrorClass `json:"error_class,omitempty"`
+
 	// 流式输出
 	Chunk string `json:"chunk,omitempty"`
 
@@ -78,6 +108,9 @@ type NodeEvent struct {
 	// LLM 相关
 	TotalTokens int   `json:"total_tokens,omitempty"`
 	ElapsedMs   int64 `json:"elapsed_ms,omitempty"`
+
+	// 由迭代 / 循环子图转发的事件标明所属容器节点与迭代项
+	port.NodeParent
 }
 
 // NewNodeRunStartedEvent 创建节点开始事件
@@ -116,6 +149,17 @@ func NewNodeRunFailedEvent(executionID, nodeID string, nodeType types.NodeType,
 	}
 }
 
+// NewNodeRunPausedEvent 创建节点挂起事件
+func NewNodeRunPausedEvent(executionID, nodeID string, nodeType types.NodeType) NodeEvent {
+	return NodeEvent{
+		Type:     EventTypeNodeRunPaused,
+		ID:       executionID,
+		NodeID:   nodeID,
+		NodeType: nodeType,
+		Status:   types.NodeExecutionStatusPaused,
+	}
+}
+
 // NewNodeStreamChunkEvent 创建流式输出事件
 func NewNodeStreamChunkEvent(executionID, nodeID string, nodeType types.NodeType, chunk string) NodeEvent {
 	return NodeEvent{
@@ -1,11 +1,18 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
 
 // GraphConfig 图的完整配置，来自 DSL 或数据库
 type GraphConfig struct {
-	Nodes []NodeConfig `json:"nodes"`
-	Edges []EdgeConfig `json:"edges"`
+	Nodes        []NodeConfig `json:"nodes"`
+	Edges        []EdgeConfig `json:"edges"`
+	NodeDefaults *NodeLimits  `json:"node_defaults,omitempty"` // 工作流级节点限制默认值（覆盖引擎配置）
+	AsyncRetry   *RetryConfig `json:"async_retry,omitempty"`   // 异步运行失败后整体重试的策略（耗尽后进入死信）
 }
 
 // NodeConfig 节点配置
@@ -24,8 +31,91 @@ type EdgeConfig struct {
 
 // RetryConfig 重试配置
 type RetryConfig struct {
-	MaxRetries    int `json:"max_retries"`    // 最大重试次数
-	RetryInterval int `json:"retry_interval"` // 重试间隔（毫秒）
+	MaxRetries        int          `json:"max_retries"`                  // 最大重试次数
+	RetryInterval     int          `json:"retry_interval"`               // 首次重试间隔（毫秒）
+	BackoffMultiplier float64      `json:"backoff_multiplier,omitempty"` // 退避倍数（<=1 时为固定间隔）
+	MaxInterval       int          `json:"max_interval,omitempty"`       // 最大重试间隔（毫秒，0 不限制）
+	Jitter            float64      `json:"jitter,omitempty"`             // 抖动比例 [0,1]，间隔在 ±jitter 范围内随机浮动
+	RetryOn           []ErrorClass `json:"retry_on,omitempty"`           // 仅重试这些错误分类（为空时除 validation 外均重试）
+	NoRetryOn         []ErrorClass `json:"no_retry_on,omitempty"`        // 不重试的错误分类（优先于 retry_on）
+}
+
+// Validate 校验重试配置
+func (rc *RetryConfig) Validate() error {
+	if rc.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must be >= 0")
+	}
+	if rc.RetryInterval < 0 || rc.MaxInterval < 0 {
+		return fmt.Errorf("retry_interval and max_interval must be >= 0")
+	}
+	if rc.BackoffMultiplier < 0 {
+		return fmt.Errorf("backoff_multiplier must be >= 0")
+	}
+	if rc.Jitter < 0 || rc.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	for _, c := range append(append([]ErrorClass{}, rc.RetryOn...), rc.NoRetryOn...) {
+		if !c.IsValid() {
+			return fmt.Errorf("unknown error class: %s", c)
+		}
+	}
+	return nil
+}
+
+// ShouldRetry 判断该错误分类是否允许重试
+func (rc *RetryConfig) ShouldRetry(class ErrorClass) bool {
+	if class == "" {
+		class = ErrorClassUnknown
+	}
+	for _, c := range rc.NoRetryOn {
+		if c == class {
+			return false
+		}
+	}
+	if len(rc.RetryOn) == 0 {
+		return class != ErrorClassValidation
+	}
+	for _, c := range rc.RetryOn {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff 计算第 retry 次重试（从 1 开始）前的等待时长，random 为 [0,1) 随机数
+func (rc *RetryConfig) Backoff(retry int, random float64) time.Duration {
+	interval := float64(rc.RetryInterval)
+	if rc.BackoffMultiplier > 1 && retry > 1 {
+		interval *= math.Pow(rc.BackoffMultiplier, float64(retry-1))
+	}
+	if rc.MaxInterval > 0 && interval > float64(rc.MaxInterval) {
+		interval = float64(rc.MaxInterval)
+	}
+	if rc.Jitter > 0 {
+		interval *= 1 - rc.Jitter + 2*rc.Jitter*random
+		if rc.MaxInterval > 0 && interval > float64(rc.MaxInterval) {
+			interval = float64(rc.MaxInterval)
+		}
+	}
+	return time.Duration(interval * float64(time.Millisecond))
+}
+
+// NodeLimits 节点执行限制（0 表示沿用上一级配置）
+type NodeLimits struct {
+	TimeoutMs      int `json:"timeout_ms,omitempty"`       // 执行超时（毫秒）
+	MaxOutputBytes int `json:"max_output_bytes,omitempty"` // 输出 JSON 序列化后的大小上限（字节）
+}
+
+// Validate 校验节点限制
+func (l NodeLimits) Validate() error {
+	if l.TimeoutMs < 0 {
+		return fmt.Errorf("timeout_ms must be >= 0")
+	}
+	if l.MaxOutputBytes < 0 {
+		return fmt.Errorf("max_output_bytes must be >= 0")
+	}
+	return nil
 }
 
 // NodeData 节点数据的通用结构
@@ -36,6 +126,8 @@ type NodeData struct {
 	ErrorStrategy ErrorStrategy          `json:"error_strategy,omitempty"`
 	DefaultValue  map[string]interface{} `json:"default_value,omitempty"` // default-value 策略的默认输出
 	Retry         *RetryConfig           `json:"retry,omitempty"`         // retry 策略配置
+	Join          JoinMode               `json:"join,omitempty"`          // 多入边合流方式（all / any）
+	NodeLimits                           // 节点级超时与输出大小限制（覆盖工作流默认值）
 }
 
 // VariableSelector 变量选择器 [node_id, variable_name]
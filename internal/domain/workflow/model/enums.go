@@ -27,6 +27,7 @@ const (
 	NodeTypeListOperator       NodeType = "list-operator"
 	NodeTypeAgent              NodeType = "agent"
 	NodeTypeHumanInput         NodeType = "human-input"
+	NodeTypeWorkflow           NodeType = "workflow"
 )
 
 // IsStartNode 判断节点类型是否可以作为工作流的入口点
@@ -69,6 +70,45 @@ const (
 	ErrorStrategyRetry        ErrorStrategy = "retry"
 )
 
+// JoinMode 多入边节点的合流方式
+type JoinMode string
+
+const (
+	JoinModeAll JoinMode = "all" // 等待所有未跳过的入边完成（默认）
+	JoinModeAny JoinMode = "any" // 任一入边完成即触发，之后完成的入边不再触发
+)
+
+// ErrorClass 节点错误分类（用于 retry 策略判断是否值得重试）
+type ErrorClass string
+
+const (
+	ErrorClassUnknown         ErrorClass = "unknown"
+	ErrorClassTimeout         ErrorClass = "timeout"          // 执行超时
+	ErrorClassNetwork         ErrorClass = "network"          // 网络错误（连接失败、重置等）
+	ErrorClassRateLimit       ErrorClass = "rate_limit"       // HTTP 429 或供应商限流
+	ErrorClassHTTP5xx         ErrorClass = "http_5xx"         // 上游服务端错误
+	ErrorClassHTTP4xx         ErrorClass = "http_4xx"         // 上游客户端错误（429 除外）
+	ErrorClassValidation      ErrorClass = "validation"       // 配置或输入校验失败，重试无意义
+	ErrorClassSchemaViolation ErrorClass = "schema_violation" // 输出不符合 schema
+)
+
+// IsValid 判断错误分类是否为已知取值
+func (c ErrorClass) IsValid() bool {
+	switch c {
+	case ErrorClassUnknown, ErrorClassTimeout, ErrorClassNetwork, ErrorClassRateLimit,
+		ErrorClassHTTP5xx, ErrorClassHTTP4xx, ErrorClassValidation, ErrorClassSchemaViolation:
+		return true
+	default:
+		return false
+	}
+}
+
+// 引擎判定的节点错误类型（写入 fail-branch 的 __error_type__ 及节点执行元数据）
+const (
+	NodeErrorTypeTimeout        = "node_timeout"
+	NodeErrorTypeOutputTooLarge = "node_output_too_large"
+)
+
 // CommandType 引擎命令类型
 type CommandType string
 
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -21,6 +20,7 @@ import (
 	"flowweave/internal/domain/workflow/event"
 	types "flowweave/internal/domain/workflow/model"
 	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/platform/netguard"
 )
 
 // ASRNode executes speech-to-text transcription.
@@ -47,6 +47,11 @@ func NewASRNode(id string, rawData json.RawMessage) (node.Node, error) {
 	}, nil
 }
 
+// ProviderModel 返回节点使用的 ASR provider（用于并发限制）
+func (n *ASRNode) ProviderModel() (string, string) {
+	return n.data.Provider, ""
+}
+
 func (n *ASRNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 	return node.RunWithEvents(ctx, n, func(ctx context.Context) (*node.NodeRunResult, error) {
 		vp, ok := node.GetVariablePoolFromContext(ctx)
@@ -522,7 +527,7 @@ func fetchAudioByURL(ctx context.Context, rawURL string, timeoutMS int, maxBytes
 	if u.Scheme != "http" && u.Scheme != "https" {
 		return nil, "", "", newError(ASRURLBlocked, "audio_url must use http or https", nil)
 	}
-	if err := validatePublicHost(u.Hostname()); err != nil {
+	if err := netguard.ValidatePublicHost(u.Hostname()); err != nil {
 		return nil, "", "", newError(ASRURLBlocked, "audio_url host is blocked", err)
 	}
 
@@ -561,29 +566,6 @@ func fetchAudioByURL(ctx context.Context, rawURL string, timeoutMS int, maxBytes
 	return data, filename, resp.Header.Get("Content-Type"), nil
 }
 
-func validatePublicHost(host string) error {
-	ips, err := net.LookupIP(host)
-	if err != nil {
-		return err
-	}
-	for _, ip := range ips {
-		if isPrivateOrLocalIP(ip) {
-			return fmt.Errorf("private/local IP not allowed: %s", ip.String())
-		}
-	}
-	return nil
-}
-
-func isPrivateOrLocalIP(ip net.IP) bool {
-	if ip == nil {
-		return true
-	}
-	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
-		return true
-	}
-	return false
-}
-
 func validateFilePath(path string, baseDir string) error {
 	if strings.TrimSpace(path) == "" {
 		return newError(ASRFilePathInvalid, "audio_file.temp_path is empty", nil)
@@ -626,8 +608,5 @@ func readMapString(m map[string]interface{}, key string) string {
 }
 
 func failResult(err error) *node.NodeRunResult {
-	return &node.NodeRunResult{
-		Status: types.NodeExecutionStatusFailed,
-		Error:  err.Error(),
-	}
+	return node.ErrorResult(err)
 }
@@ -1,6 +1,11 @@
 package asr
 
-import "fmt"
+import (
+	"fmt"
+
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+)
 
 type ErrorCode string
 
@@ -34,6 +39,26 @@ func (e *ASRNodeError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// ErrorClass 按错误码归类，供 retry 策略判断是否重试
+func (e *ASRNodeError) ErrorClass() types.ErrorClass {
+	if e == nil {
+		return types.ErrorClassUnknown
+	}
+	switch e.Code {
+	case ASRInvalidConfig, ASRConfigMissing, ASRInvalidInput, ASRInputTooLarge, ASRBase64DecodeFailed,
+		ASRFilePathInvalid, ASRFileNotFound, ASRURLBlocked:
+		return types.ErrorClassValidation
+	case ASRTimeout:
+		return types.ErrorClassTimeout
+	case ASRURLFetchFailed, ASRProviderCallFailed:
+		// 执行失败按底层原因归类（如上游限流、网络错误）
+		if e.Cause != nil {
+			return node.ClassifyError(e.Cause)
+		}
+	}
+	return types.ErrorClassUnknown
+}
+
 func newError(code ErrorCode, message string, cause error) error {
 	return &ASRNodeError{Code: code, Message: message, Cause: cause}
 }
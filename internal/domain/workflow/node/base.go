@@ -5,6 +5,7 @@ import (
 
 	"flowweave/internal/domain/workflow/event"
 	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/port"
 )
 
 // Node 是所有工作流节点必须实现的接口
@@ -36,17 +37,31 @@ type Node interface {
 	// RetryConfig 返回 retry 策略配置
 	RetryConfig() *types.RetryConfig
 
+	// Limits 返回节点级超时与输出大小限制
+	Limits() types.NodeLimits
+
+	// JoinMode 返回多入边时的合流方式
+	JoinMode() types.JoinMode
+
 	// Run 执行节点逻辑，通过 channel 返回事件流
 	// ctx 用于传递取消信号和运行时状态
 	Run(ctx context.Context) (<-chan event.NodeEvent, error)
 }
 
+// ProviderModel 调用外部 provider 的节点可实现该接口，声明使用的 provider 与模型（用于并发限制）
+type ProviderModel interface {
+	ProviderModel() (provider, model string)
+}
+
 // NodeRunResult 节点执行结果
 type NodeRunResult struct {
 	Status   types.NodeExecutionStatus `json:"status"`
 	Outputs  map[string]interface{}    `json:"outputs,omitempty"`
 	Error    string                    `json:"error,omitempty"`
 	Metadata map[string]interface{}    `json:"metadata,omitempty"`
+
+	// ErrorClass 失败时的错误分类（为空时按 unknown 处理）
+	ErrorClass types.ErrorClass `json:"error_class,omitempty"`
 }
 
 // FailedResult 创建失败的执行结果
@@ -57,6 +72,15 @@ func FailedResult(errMsg string) *NodeRunResult {
 	}
 }
 
+// ErrorResult 根据错误创建失败的执行结果（附带错误分类）
+func ErrorResult(err error) *NodeRunResult {
+	return &NodeRunResult{
+		Status:     types.NodeExecutionStatusFailed,
+		Error:      err.Error(),
+		ErrorClass: ClassifyError(err),
+	}
+}
+
 // VariablePoolAccessor 节点访问变量池的接口
 type VariablePoolAccessor interface {
 	// GetVariable 通过选择器获取变量值
@@ -78,3 +102,21 @@ func GetVariablePoolFromContext(ctx context.Context) (VariablePoolAccessor, bool
 	vp, ok := ctx.Value(ContextKeyVariablePool).(VariablePoolAccessor)
 	return vp, ok
 }
+
+// ContextKeyHumanInputs 人工输入响应上下文键（恢复执行时注入）
+const ContextKeyHumanInputs contextKey = "human_inputs"
+
+// WithHumanInputs 将已响应的人工输入（node_id → PendingInput）注入 context
+func WithHumanInputs(ctx context.Context, inputs map[string]*port.PendingInput) context.Context {
+	return context.WithValue(ctx, ContextKeyHumanInputs, inputs)
+}
+
+// GetHumanInputFromContext 获取指定节点的人工输入响应
+func GetHumanInputFromContext(ctx context.Context, nodeID string) (*port.PendingInput, bool) {
+	inputs, ok := ctx.Value(ContextKeyHumanInputs).(map[string]*port.PendingInput)
+	if !ok {
+		return nil, false
+	}
+	input, ok := inputs[nodeID]
+	return input, ok && input != nil
+}
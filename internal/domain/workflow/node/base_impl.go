@@ -20,6 +20,8 @@ type BaseNode struct {
 	errorStrategy types.ErrorStrategy
 	defaultValue  map[string]interface{}
 	retryConfig   *types.RetryConfig
+	limits        types.NodeLimits
+	joinMode      types.JoinMode
 	rawData       map[string]interface{}
 }
 
@@ -32,6 +34,7 @@ func NewBaseNode(id string, nodeType types.NodeType, title string, executionType
 		executionType: executionType,
 		state:         types.NodeStateUnknown,
 		errorStrategy: types.ErrorStrategyNone,
+		joinMode:      types.JoinModeAll,
 	}
 }
 
@@ -44,6 +47,8 @@ func (n *BaseNode) SetState(state types.NodeState)         { n.state = state }
 func (n *BaseNode) ErrorStrategy() types.ErrorStrategy     { return n.errorStrategy }
 func (n *BaseNode) DefaultValue() map[string]interface{}   { return n.defaultValue }
 func (n *BaseNode) RetryConfig() *types.RetryConfig        { return n.retryConfig }
+func (n *BaseNode) Limits() types.NodeLimits               { return n.limits }
+func (n *BaseNode) JoinMode() types.JoinMode               { return n.joinMode }
 
 // SetErrorStrategy 设置错误策略
 func (n *BaseNode) SetErrorStrategy(strategy types.ErrorStrategy) {
@@ -60,6 +65,16 @@ func (n *BaseNode) SetRetryConfig(rc *types.RetryConfig) {
 	n.retryConfig = rc
 }
 
+// SetLimits 设置节点级执行限制
+func (n *BaseNode) SetLimits(limits types.NodeLimits) {
+	n.limits = limits
+}
+
+// SetJoinMode 设置合流方式
+func (n *BaseNode) SetJoinMode(mode types.JoinMode) {
+	n.joinMode = mode
+}
+
 // SetRawData 设置原始配置数据
 func (n *BaseNode) SetRawData(data map[string]interface{}) {
 	n.rawData = data
@@ -95,7 +110,7 @@ func RunWithEvents(
 		// 执行节点逻辑
 		result, err := executor(ctx)
 		if err != nil {
-			ch <- event.NewNodeRunFailedEvent(executionID, n.ID(), n.Type(), err.Error())
+			ch <- failedEvent(executionID, n, err.Error(), ClassifyError(err))
 			return
 		}
 
@@ -105,7 +120,15 @@ func RunWithEvents(
 		}
 
 		if result.Status == types.NodeExecutionStatusFailed {
-			ch <- event.NewNodeRunFailedEvent(executionID, n.ID(), n.Type(), result.Error)
+			ch <- failedEvent(executionID, n, result.Error, result.ErrorClass)
+			return
+		}
+
+		// 挂起：等待外部输入后恢复
+		if result.Status == types.NodeExecutionStatusPaused {
+			pausedEvent := event.NewNodeRunPausedEvent(executionID, n.ID(), n.Type())
+			pausedEvent.Metadata = result.Metadata
+			ch <- pausedEvent
 			return
 		}
 
@@ -157,7 +180,7 @@ func RunStreamWithEvents(
 		<-doneCh
 
 		if execErr != nil {
-			ch <- event.NewNodeRunFailedEvent(executionID, n.ID(), n.Type(), execErr.Error())
+			ch <- failedEvent(executionID, n, execErr.Error(), ClassifyError(execErr))
 			return
 		}
 
@@ -167,8 +190,14 @@ func RunStreamWithEvents(
 		}
 
 		if result.Status == types.NodeExecutionStatusFailed {
-			ch <- event.NewNodeRunFailedEvent(executionID, n.ID(), n.Type(),
-				fmt.Sprintf("node execution failed: %s", result.Error))
+			ch <- failedEvent(executionID, n, fmt.Sprintf("node execution failed: %s", result.Error), result.ErrorClass)
+			return
+		}
+
+		if result.Status == types.NodeExecutionStatusPaused {
+			pausedEvent := event.NewNodeRunPausedEvent(executionID, n.ID(), n.Type())
+			pausedEvent.Metadata = result.Metadata
+			ch <- pausedEvent
 			return
 		}
 
@@ -179,3 +208,13 @@ func RunStreamWithEvents(
 
 	return ch, nil
 }
+
+// failedEvent 创建带错误分类的节点失败事件
+func failedEvent(executionID string, n Node, errMsg string, class types.ErrorClass) event.NodeEvent {
+	evt := event.NewNodeRunFailedEvent(executionID, n.ID(), n.Type(), errMsg)
+	if class == "" {
+		class = types.ErrorClassUnknown
+	}
+	evt.ErrorClass = class
+	return evt
+}
@@ -92,8 +92,5 @@ func (n *CodeNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 }
 
 func failResult(err error) *node.NodeRunResult {
-	return &node.NodeRunResult{
-		Status: types.NodeExecutionStatusFailed,
-		Error:  err.Error(),
-	}
+	return node.ErrorResult(err)
 }
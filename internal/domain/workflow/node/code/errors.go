@@ -1,6 +1,11 @@
 package code
 
-import "fmt"
+import (
+	"fmt"
+
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+)
 
 type ErrorCode string
 
@@ -32,6 +37,27 @@ func (e *CodeNodeError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// ErrorClass 按错误码归类，供 retry 策略判断是否重试
+func (e *CodeNodeError) ErrorClass() types.ErrorClass {
+	if e == nil {
+		return types.ErrorClassUnknown
+	}
+	switch e.Code {
+	case CodeNodeInvalidConfig, CodeNodeFunctionNotFound, CodeNodeInputMissing, CodeNodeInputTypeMismatch:
+		return types.ErrorClassValidation
+	case CodeNodeExecTimeout:
+		return types.ErrorClassTimeout
+	case CodeNodeOutputMissing, CodeNodeOutputTypeMismatch, CodeNodeOutputSchemaViolation:
+		return types.ErrorClassSchemaViolation
+	case CodeNodeExecFailed:
+		// 执行失败按底层原因归类（如上游限流、网络错误）
+		if e.Cause != nil {
+			return node.ClassifyError(e.Cause)
+		}
+	}
+	return types.ErrorClassUnknown
+}
+
 func NewError(code ErrorCode, message string, cause error) error {
 	return &CodeNodeError{
 		Code:    code,
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	types "flowweave/internal/domain/workflow/model"
+)
+
+// ErrorClassifier 节点错误可实现该接口，声明自身的错误分类
+type ErrorClassifier interface {
+	ErrorClass() types.ErrorClass
+}
+
+// httpStatusError 携带 HTTP 状态码的上游错误（如 LLM 供应商 API 错误）
+type httpStatusError interface {
+	HTTPStatusCode() int
+}
+
+// ClassifyError 对节点执行错误进行分类，供 retry 策略判断是否重试
+func ClassifyError(err error) types.ErrorClass {
+	if err == nil {
+		return types.ErrorClassUnknown
+	}
+
+	var classifier ErrorClassifier
+	if errors.As(err, &classifier) {
+		if class := classifier.ErrorClass(); class != "" {
+			return class
+		}
+	}
+
+	var statusErr httpStatusError
+	if errors.As(err, &statusErr) {
+		return ClassifyHTTPStatus(statusErr.HTTPStatusCode())
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return types.ErrorClassTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return types.ErrorClassTimeout
+		}
+		return types.ErrorClassNetwork
+	}
+
+	return types.ErrorClassUnknown
+}
+
+// ClassifyHTTPStatus 按 HTTP 状态码分类
+func ClassifyHTTPStatus(statusCode int) types.ErrorClass {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return types.ErrorClassRateLimit
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return types.ErrorClassTimeout
+	case statusCode >= 500:
+		return types.ErrorClassHTTP5xx
+	case statusCode >= 400:
+		return types.ErrorClassHTTP4xx
+	default:
+		return types.ErrorClassUnknown
+	}
+}
@@ -189,9 +189,10 @@ func (n *HTTPNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 		status := types.NodeExecutionStatusSucceeded
 		if resp.StatusCode >= 400 {
 			return &node.NodeRunResult{
-				Status:  types.NodeExecutionStatusFailed,
-				Error:   fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)),
-				Outputs: outputs,
+				Status:     types.NodeExecutionStatusFailed,
+				Error:      fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)),
+				Outputs:    outputs,
+				ErrorClass: node.ClassifyHTTPStatus(resp.StatusCode),
 			}, nil
 		}
 
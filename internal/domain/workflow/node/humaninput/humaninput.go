@@ -0,0 +1,152 @@
+package humaninput
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+	"flowweave/internal/domain/workflow/node/template"
+	"flowweave/internal/domain/workflow/port"
+)
+
+// DefaultTimeoutBranch 超时未响应时走的默认分支
+const DefaultTimeoutBranch = "timeout"
+
+// HumanInputNodeData 人工输入节点配置
+type HumanInputNodeData struct {
+	Type           string                    `json:"type"`
+	Title          string                    `json:"title"`
+	Prompt         string                    `json:"prompt"` // 支持 {{#node_id.var#}} 变量池引用
+	Fields         []port.PendingInputField  `json:"fields"`
+	Actions        []port.PendingInputAction `json:"actions"` // 可选动作（如 approve/reject），作为分支 handle
+	Assignee       string                    `json:"assignee"`
+	TimeoutSeconds int                       `json:"timeout_seconds"` // 0 表示不超时
+	TimeoutBranch  string                    `json:"timeout_branch"`
+}
+
+// HumanInputNode 人工输入节点：挂起执行，等待外部提交表单/审批后恢复
+type HumanInputNode struct {
+	*node.BaseNode
+	data HumanInputNodeData
+}
+
+func init() {
+	node.Register(types.NodeTypeHumanInput, NewHumanInputNode)
+}
+
+// NewHumanInputNode 创建人工输入节点
+func NewHumanInputNode(id string, rawData json.RawMessage) (node.Node, error) {
+	var data HumanInputNodeData
+	if err := json.Unmarshal(rawData, &data); err != nil {
+		return nil, err
+	}
+	if data.TimeoutBranch == "" {
+		data.TimeoutBranch = DefaultTimeoutBranch
+	}
+
+	n := &HumanInputNode{
+		BaseNode: node.NewBaseNode(id, types.NodeTypeHumanInput, data.Title, types.NodeExecutionTypeBranch),
+		data:     data,
+	}
+	return n, nil
+}
+
+// Run 执行人工输入节点
+// 已有响应（恢复执行）时输出响应内容并选择分支，否则挂起并返回待输入请求
+func (n *HumanInputNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
+	return node.RunWithEvents(ctx, n, func(ctx context.Context) (*node.NodeRunResult, error) {
+		if input, ok := node.GetHumanInputFromContext(ctx, n.ID()); ok {
+			switch input.Status {
+			case port.PendingInputStatusSubmitted:
+				return n.submittedResult(input), nil
+			case port.PendingInputStatusTimeout:
+				return n.timeoutResult(input), nil
+			}
+		}
+
+		return &node.NodeRunResult{
+			Status: types.NodeExecutionStatusPaused,
+			Metadata: map[string]interface{}{
+				"pending_input": n.pendingInput(ctx),
+			},
+		}, nil
+	})
+}
+
+// pendingInput 构建待输入请求
+func (n *HumanInputNode) pendingInput(ctx context.Context) *port.PendingInput {
+	prompt := n.data.Prompt
+	if vp, ok := node.GetVariablePoolFromContext(ctx); ok {
+		prompt = template.RenderWithVariablePool(prompt, nil, vp)
+	}
+
+	pi := &port.PendingInput{
+		NodeID:        n.ID(),
+		Title:         n.Title(),
+		Prompt:        prompt,
+		Fields:        n.data.Fields,
+		Actions:       n.data.Actions,
+		Assignee:      n.data.Assignee,
+		TimeoutBranch: n.data.TimeoutBranch,
+		Status:        port.PendingInputStatusWaiting,
+	}
+	if n.data.TimeoutSeconds > 0 {
+		expiresAt := time.Now().Add(time.Duration(n.data.TimeoutSeconds) * time.Second)
+		pi.ExpiresAt = &expiresAt
+	}
+	return pi
+}
+
+// submittedResult 已提交：表单字段作为输出，动作作为分支
+func (n *HumanInputNode) submittedResult(input *port.PendingInput) *node.NodeRunResult {
+	outputs := make(map[string]interface{}, len(input.Response)+4)
+	for _, f := range n.data.Fields {
+		if f.Default != nil {
+			outputs[f.Name] = f.Default
+		}
+	}
+	for k, v := range input.Response {
+		outputs[k] = v
+	}
+
+	branch := input.Action
+	if branch == "" {
+		branch = "submitted"
+	}
+	outputs["action"] = input.Action
+	outputs["responded_by"] = input.RespondedBy
+	outputs["timed_out"] = false
+	outputs["__branch__"] = branch
+
+	return &node.NodeRunResult{
+		Status:  types.NodeExecutionStatusSucceeded,
+		Outputs: outputs,
+	}
+}
+
+// timeoutResult 超时：使用字段默认值并走超时分支
+func (n *HumanInputNode) timeoutResult(input *port.PendingInput) *node.NodeRunResult {
+	outputs := make(map[string]interface{}, len(n.data.Fields)+4)
+	for _, f := range n.data.Fields {
+		if f.Default != nil {
+			outputs[f.Name] = f.Default
+		}
+	}
+
+	branch := input.Action
+	if branch == "" {
+		branch = n.data.TimeoutBranch
+	}
+	outputs["action"] = branch
+	outputs["responded_by"] = ""
+	outputs["timed_out"] = true
+	outputs["__branch__"] = branch
+
+	return &node.NodeRunResult{
+		Status:  types.NodeExecutionStatusSucceeded,
+		Outputs: outputs,
+	}
+}
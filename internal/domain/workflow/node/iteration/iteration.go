@@ -127,6 +127,8 @@ type itemRunResult struct {
 	Index int
 	Value interface{}
 	Err   error
+	// ExecutionID 该项子图的执行 ID，对应子节点执行记录的 parent_execution_id
+	ExecutionID string
 }
 
 func (n *IterationNode) executeItems(ctx context.Context, parentVP node.VariablePoolAccessor, items []interface{}) []itemRunResult {
@@ -148,15 +150,16 @@ func (n *IterationNode) executeItems(ctx context.Context, parentVP node.Variable
 			}
 			defer func() { <-sem }()
 
-			val, err := n.runSubgraphForItem(itemCtx, parentVP, it, idx, len(items))
+			parent := engine.IterationItemParent(n.ID(), idx)
+			val, err := n.runSubgraphForItem(engine.WithChildParent(itemCtx, parent), parentVP, it, idx, len(items))
 			if err != nil {
 				if n.data.OnItemError == itemErrorFailFast {
 					cancel()
 				}
-				resultCh <- itemRunResult{Index: idx, Err: err}
+				resultCh <- itemRunResult{Index: idx, Err: err, ExecutionID: parent.ParentExecutionID}
 				return
 			}
-			resultCh <- itemRunResult{Index: idx, Value: val}
+			resultCh <- itemRunResult{Index: idx, Value: val, ExecutionID: parent.ParentExecutionID}
 		}(i, item)
 	}
 
@@ -209,7 +212,7 @@ func (n *IterationNode) aggregate(runs []itemRunResult) (map[string]interface{},
 	errors := make([]map[string]interface{}, 0)
 	for _, r := range runs {
 		if r.Err != nil {
-			errors = append(errors, map[string]interface{}{"index": r.Index, "error": r.Err.Error()})
+			errors = append(errors, map[string]interface{}{"index": r.Index, "error": r.Err.Error(), "execution_id": r.ExecutionID})
 			if n.data.OnItemError == itemErrorFailFast {
 				return nil, newIterationError(IterationItemExecFailed, fmt.Sprintf("item execution failed at index=%d", r.Index), r.Err)
 			}
@@ -427,7 +430,7 @@ func buildMeta(runs []itemRunResult, maxConcurrency int, order string, duration
 	for _, r := range runs {
 		if r.Err != nil {
 			failed++
-			errors = append(errors, map[string]interface{}{"index": r.Index, "error": r.Err.Error()})
+			errors = append(errors, map[string]interface{}{"index": r.Index, "error": r.Err.Error(), "execution_id": r.ExecutionID})
 			continue
 		}
 		success++
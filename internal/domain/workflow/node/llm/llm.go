@@ -99,6 +99,11 @@ func NewLLMNode(id string, rawData json.RawMessage) (node.Node, error) {
 	return n, nil
 }
 
+// ProviderModel 返回节点使用的 provider 与模型（用于并发限制）
+func (n *LLMNode) ProviderModel() (string, string) {
+	return n.data.Model.Provider, n.data.Model.Name
+}
+
 // Run 执行 LLM 节点（支持 Agent Tool Calling 循环）
 func (n *LLMNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 	return node.RunStreamWithEvents(ctx, n, func(ctx context.Context, stream chan<- string) (*node.NodeRunResult, error) {
@@ -72,19 +72,22 @@ func (n *LoopNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 			executedRounds++
 			roundStart := time.Now()
 
-			continueRaw, nextState, roundErr := n.runRound(ctx, vp, state, round)
+			parent := engine.LoopRoundParent(n.ID(), round)
+			continueRaw, nextState, roundErr := n.runRound(engine.WithChildParent(ctx, parent), vp, state, round)
 			roundDuration := time.Since(roundStart).Milliseconds()
 			if roundErr != nil {
 				failedRounds++
 				errors = append(errors, map[string]interface{}{
-					"round": round,
-					"error": roundErr.Error(),
+					"round":        round,
+					"error":        roundErr.Error(),
+					"execution_id": parent.ParentExecutionID,
 				})
 				roundStats = append(roundStats, map[string]interface{}{
-					"round":       round,
-					"status":      "failed",
-					"duration_ms": roundDuration,
-					"error":       roundErr.Error(),
+					"round":        round,
+					"status":       "failed",
+					"duration_ms":  roundDuration,
+					"error":        roundErr.Error(),
+					"execution_id": parent.ParentExecutionID,
 				})
 
 				if n.data.OnRoundError == loopRoundErrorFailFast {
@@ -113,6 +116,7 @@ func (n *LoopNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
 				"continue_raw":    continueRaw,
 				"continue_final":  continueDecision,
 				"state_key_count": len(state),
+				"execution_id":    parent.ParentExecutionID,
 			})
 
 			if n.data.MaxDurationMS > 0 && time.Since(startAt) >= time.Duration(n.data.MaxDurationMS)*time.Millisecond {
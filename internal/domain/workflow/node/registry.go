@@ -102,10 +102,34 @@ func (f *Factory) CreateNode(config types.NodeConfig) (Node, error) {
 				setter.SetDefaultValue(nodeData.DefaultValue)
 			}
 			if nodeData.Retry != nil {
+				if err := nodeData.Retry.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid retry config for node %s: %w", config.ID, err)
+				}
 				setter.SetRetryConfig(nodeData.Retry)
 			}
 		}
 	}
 
+	// 应用节点级执行限制
+	if nodeData.NodeLimits != (types.NodeLimits{}) {
+		if err := nodeData.NodeLimits.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid limits for node %s: %w", config.ID, err)
+		}
+		if setter, ok := n.(interface{ SetLimits(types.NodeLimits) }); ok {
+			setter.SetLimits(nodeData.NodeLimits)
+		}
+	}
+
+	// 应用合流方式
+	switch nodeData.Join {
+	case "", types.JoinModeAll:
+	case types.JoinModeAny:
+		if setter, ok := n.(interface{ SetJoinMode(types.JoinMode) }); ok {
+			setter.SetJoinMode(types.JoinModeAny)
+		}
+	default:
+		return nil, fmt.Errorf("invalid join mode for node %s: %q (expected all or any)", config.ID, nodeData.Join)
+	}
+
 	return n, nil
 }
@@ -0,0 +1,72 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+)
+
+// RecordedResponse 录制的外部调用响应（来自历史运行的节点执行记录 / LLM 调用溯源）
+type RecordedResponse struct {
+	Status     types.NodeExecutionStatus
+	Outputs    map[string]interface{}
+	Error      string
+	ErrorClass types.ErrorClass
+	Metadata   map[string]interface{}
+}
+
+// ReplaySource 确定性回放的录制响应来源
+type ReplaySource interface {
+	// Next 按调用顺序返回节点的下一条录制响应；没有可用录制时返回 false
+	Next(nodeID string) (*RecordedResponse, bool)
+}
+
+// ContextKeyReplaySource 回放来源上下文键（回放模式下注入）
+const ContextKeyReplaySource contextKey = "replay_source"
+
+// WithReplaySource 注入回放来源：可回放节点不再调用外部 provider，而是返回录制响应
+func WithReplaySource(ctx context.Context, src ReplaySource) context.Context {
+	return context.WithValue(ctx, ContextKeyReplaySource, src)
+}
+
+// GetReplaySourceFromContext 获取回放来源
+func GetReplaySourceFromContext(ctx context.Context) (ReplaySource, bool) {
+	src, ok := ctx.Value(ContextKeyReplaySource).(ReplaySource)
+	return src, ok && src != nil
+}
+
+// IsReplayable 判断节点类型是否调用外部 provider 或产生子运行（回放时以录制响应代替）
+func IsReplayable(nodeType types.NodeType) bool {
+	switch nodeType {
+	case types.NodeTypeLLM, types.NodeTypeHTTPRequest, types.NodeTypeASR, types.NodeTypeWorkflow:
+		return true
+	}
+	return false
+}
+
+// Execute 执行节点：回放模式下可回放节点返回录制响应，其余节点正常执行
+func Execute(ctx context.Context, n Node) (<-chan event.NodeEvent, error) {
+	src, ok := GetReplaySourceFromContext(ctx)
+	if !ok || !IsReplayable(n.Type()) {
+		return n.Run(ctx)
+	}
+	return RunWithEvents(ctx, n, func(ctx context.Context) (*NodeRunResult, error) {
+		rec, ok := src.Next(n.ID())
+		if !ok {
+			return &NodeRunResult{
+				Status:     types.NodeExecutionStatusFailed,
+				Error:      fmt.Sprintf("no recorded response for node %s", n.ID()),
+				ErrorClass: types.ErrorClassValidation,
+			}, nil
+		}
+		return &NodeRunResult{
+			Status:     rec.Status,
+			Outputs:    rec.Outputs,
+			Error:      rec.Error,
+			ErrorClass: rec.ErrorClass,
+			Metadata:   rec.Metadata,
+		}, nil
+	})
+}
@@ -0,0 +1,39 @@
+package node
+
+import "context"
+
+// SubWorkflowRequest 子工作流调用请求
+type SubWorkflowRequest struct {
+	NodeID     string                 // 发起调用的 workflow 节点
+	WorkflowID string                 // 被调用的工作流
+	Version    int                    // 工作流版本（0 表示已发布版本）
+	Inputs     map[string]interface{} // 子工作流输入
+}
+
+// SubWorkflowResult 子工作流执行结果
+type SubWorkflowResult struct {
+	RunID   string                 // 子运行 ID
+	Status  string                 // 子运行最终状态
+	Outputs map[string]interface{} // 子工作流输出
+	Version int                    // 实际执行的工作流版本
+	Depth   int                    // 子运行的嵌套深度（从 1 开始）
+}
+
+// SubWorkflowInvoker 子工作流调用器（由应用层注入，负责加载工作流并创建子运行）
+type SubWorkflowInvoker interface {
+	InvokeWorkflow(ctx context.Context, req *SubWorkflowRequest) (*SubWorkflowResult, error)
+}
+
+// ContextKeySubWorkflowInvoker 子工作流调用器上下文键
+const ContextKeySubWorkflowInvoker contextKey = "subworkflow_invoker"
+
+// WithSubWorkflowInvoker 注入子工作流调用器
+func WithSubWorkflowInvoker(ctx context.Context, invoker SubWorkflowInvoker) context.Context {
+	return context.WithValue(ctx, ContextKeySubWorkflowInvoker, invoker)
+}
+
+// GetSubWorkflowInvokerFromContext 获取子工作流调用器
+func GetSubWorkflowInvokerFromContext(ctx context.Context) (SubWorkflowInvoker, bool) {
+	invoker, ok := ctx.Value(ContextKeySubWorkflowInvoker).(SubWorkflowInvoker)
+	return invoker, ok && invoker != nil
+}
@@ -0,0 +1,113 @@
+package subworkflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+)
+
+// SubWorkflowNodeData 子工作流节点配置
+type SubWorkflowNodeData struct {
+	Type       string          `json:"type"`
+	Title      string          `json:"title"`
+	WorkflowID string          `json:"workflow_id"`       // 被调用的工作流 ID
+	Version    int             `json:"version,omitempty"` // 工作流版本（0 表示已发布版本）
+	Inputs     []InputVariable `json:"inputs"`            // 子工作流输入映射
+}
+
+// InputVariable 子工作流输入绑定
+type InputVariable struct {
+	Variable      string                 `json:"variable"`
+	ValueSelector types.VariableSelector `json:"value_selector"`
+}
+
+// SubWorkflowNode 子工作流节点：以子运行的方式执行另一个已保存的工作流，子工作流输出作为节点输出
+type SubWorkflowNode struct {
+	*node.BaseNode
+	data SubWorkflowNodeData
+}
+
+func init() {
+	node.Register(types.NodeTypeWorkflow, NewSubWorkflowNode)
+}
+
+// NewSubWorkflowNode 创建子工作流节点
+func NewSubWorkflowNode(id string, rawData json.RawMessage) (node.Node, error) {
+	var data SubWorkflowNodeData
+	if err := json.Unmarshal(rawData, &data); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(data.WorkflowID) == "" {
+		return nil, fmt.Errorf("workflow node %s: workflow_id is required", id)
+	}
+	if data.Version < 0 {
+		return nil, fmt.Errorf("workflow node %s: version must be >= 0", id)
+	}
+	for _, in := range data.Inputs {
+		if in.Variable == "" || len(in.ValueSelector) < 2 {
+			return nil, fmt.Errorf("workflow node %s: each input requires variable and value_selector", id)
+		}
+	}
+
+	n := &SubWorkflowNode{
+		BaseNode: node.NewBaseNode(id, types.NodeTypeWorkflow, data.Title, types.NodeExecutionTypeExecutable),
+		data:     data,
+	}
+	return n, nil
+}
+
+// Run 执行子工作流节点
+func (n *SubWorkflowNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
+	return node.RunWithEvents(ctx, n, func(ctx context.Context) (*node.NodeRunResult, error) {
+		invoker, ok := node.GetSubWorkflowInvokerFromContext(ctx)
+		if !ok {
+			return &node.NodeRunResult{
+				Status:     types.NodeExecutionStatusFailed,
+				Error:      "sub-workflow invocation is not available in this runtime",
+				ErrorClass: types.ErrorClassValidation,
+			}, nil
+		}
+
+		// 1. 从变量池收集子工作流输入
+		inputs := make(map[string]interface{}, len(n.data.Inputs))
+		if vp, ok := node.GetVariablePoolFromContext(ctx); ok {
+			for _, in := range n.data.Inputs {
+				if val, exists := vp.GetVariable(in.ValueSelector); exists {
+					inputs[in.Variable] = val
+				}
+			}
+		}
+
+		// 2. 以子运行执行
+		result, err := invoker.InvokeWorkflow(ctx, &node.SubWorkflowRequest{
+			NodeID:     n.ID(),
+			WorkflowID: n.data.WorkflowID,
+			Version:    n.data.Version,
+			Inputs:     inputs,
+		})
+		if err != nil {
+			res := node.ErrorResult(err)
+			if result != nil && result.RunID != "" {
+				res.Metadata = map[string]interface{}{"child_run_id": result.RunID}
+			}
+			return res, nil
+		}
+
+		return &node.NodeRunResult{
+			Status:  types.NodeExecutionStatusSucceeded,
+			Outputs: result.Outputs,
+			Metadata: map[string]interface{}{
+				"child_run_id":     result.RunID,
+				"child_status":     result.Status,
+				"workflow_id":      n.data.WorkflowID,
+				"workflow_depth":   result.Depth,
+				"workflow_version": result.Version,
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,119 @@
+package variableaggregator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"flowweave/internal/domain/workflow/event"
+	types "flowweave/internal/domain/workflow/model"
+	"flowweave/internal/domain/workflow/node"
+)
+
+// defaultOutputName 未分组时的输出变量名
+const defaultOutputName = "output"
+
+// VariableAggregatorNodeData 变量聚合节点配置
+type VariableAggregatorNodeData struct {
+	Type             string                   `json:"type"`
+	Title            string                   `json:"title"`
+	Variables        []types.VariableSelector `json:"variables"` // 按顺序尝试的候选变量，取第一个有值的
+	AdvancedSettings *AdvancedSettings        `json:"advanced_settings,omitempty"`
+}
+
+// AdvancedSettings 分组聚合配置
+type AdvancedSettings struct {
+	GroupEnabled bool    `json:"group_enabled"`
+	Groups       []Group `json:"groups"`
+}
+
+// Group 一组候选变量，聚合结果以 group_name 为输出变量名
+type Group struct {
+	GroupName string                   `json:"group_name"`
+	Variables []types.VariableSelector `json:"variables"`
+}
+
+// VariableAggregatorNode 变量聚合节点：将多个分支中实际产生的值合并为一个变量
+type VariableAggregatorNode struct {
+	*node.BaseNode
+	data   VariableAggregatorNodeData
+	groups []Group
+}
+
+func init() {
+	node.Register(types.NodeTypeVariableAggregator, NewVariableAggregatorNode)
+}
+
+// NewVariableAggregatorNode 创建变量聚合节点
+func NewVariableAggregatorNode(id string, rawData json.RawMessage) (node.Node, error) {
+	var data VariableAggregatorNodeData
+	if err := json.Unmarshal(rawData, &data); err != nil {
+		return nil, err
+	}
+
+	// 未启用分组时视为一个名为 output 的分组
+	groups := []Group{{GroupName: defaultOutputName, Variables: data.Variables}}
+	if data.AdvancedSettings != nil && data.AdvancedSettings.GroupEnabled {
+		groups = data.AdvancedSettings.Groups
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("variable-aggregator node %s: group_enabled requires at least one group", id)
+		}
+	}
+
+	seen := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		name := strings.TrimSpace(g.GroupName)
+		if name == "" {
+			return nil, fmt.Errorf("variable-aggregator node %s: group_name is required", id)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("variable-aggregator node %s: duplicate group %s", id, name)
+		}
+		seen[name] = true
+		if len(g.Variables) == 0 {
+			return nil, fmt.Errorf("variable-aggregator node %s: group %s has no variables", id, name)
+		}
+		for _, sel := range g.Variables {
+			if len(sel) < 2 {
+				return nil, fmt.Errorf("variable-aggregator node %s: invalid selector %v in group %s", id, sel, name)
+			}
+		}
+	}
+
+	n := &VariableAggregatorNode{
+		BaseNode: node.NewBaseNode(id, types.NodeTypeVariableAggregator, data.Title, types.NodeExecutionTypeExecutable),
+		data:     data,
+		groups:   groups,
+	}
+	return n, nil
+}
+
+// Run 执行变量聚合：每个分组取第一个存在且非空的候选变量
+func (n *VariableAggregatorNode) Run(ctx context.Context) (<-chan event.NodeEvent, error) {
+	return node.RunWithEvents(ctx, n, func(ctx context.Context) (*node.NodeRunResult, error) {
+		outputs := make(map[string]interface{}, len(n.groups))
+		selected := make(map[string]interface{}, len(n.groups))
+
+		vp, ok := node.GetVariablePoolFromContext(ctx)
+		if ok {
+			for _, g := range n.groups {
+				for _, sel := range g.Variables {
+					val, exists := vp.GetVariable(sel)
+					if !exists || val == nil {
+						continue
+					}
+					outputs[g.GroupName] = val
+					selected[g.GroupName] = strings.Join(sel, ".")
+					break
+				}
+			}
+		}
+
+		return &node.NodeRunResult{
+			Status:   types.NodeExecutionStatusSucceeded,
+			Outputs:  outputs,
+			Metadata: map[string]interface{}{"selected": selected},
+		}, nil
+	})
+}
@@ -0,0 +1,18 @@
+package port
+
+import "context"
+
+// RunCommand 运行控制命令（abort / pause / resume），按 run_id 路由到持有该运行的实例
+type RunCommand struct {
+	RunID  string `json:"run_id"`
+	Type   string `json:"type"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RunCommandBus 跨实例的运行控制命令总线
+type RunCommandBus interface {
+	// Publish 发布命令
+	Publish(ctx context.Context, cmd *RunCommand) error
+	// Subscribe 订阅所有运行的命令，阻塞直到 ctx 取消或订阅出错
+	Subscribe(ctx context.Context, handler func(cmd *RunCommand)) error
+}
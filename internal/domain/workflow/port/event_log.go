@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// RunEvent 运行事件日志中的一条事件
+type RunEvent struct {
+	Seq   int64           `json:"seq"`   // 同一运行内从 1 开始递增，作为 SSE 的事件 ID
+	Event string          `json:"event"` // SSE 事件名：message / done
+	Data  json.RawMessage `json:"data"`
+}
+
+// RunEventLog 跨实例的运行事件日志：执行方按运行追加事件，观察方从任意序号之后回放并等待新事件
+type RunEventLog interface {
+	// Append 追加事件，返回其序号
+	Append(ctx context.Context, runID, event string, data json.RawMessage) (int64, error)
+	// Read 返回序号大于 afterSeq 的事件（最多 limit 条）；暂无新事件时最长等待 block，超时返回空
+	Read(ctx context.Context, runID string, afterSeq int64, limit int, block time.Duration) ([]RunEvent, error)
+}
@@ -9,8 +9,9 @@ import (
 type WorkflowStatus string
 
 const (
-	WorkflowStatusActive   WorkflowStatus = "active"
-	WorkflowStatusArchived WorkflowStatus = "archived"
+	WorkflowStatusDraft     WorkflowStatus = "draft"     // 尚未发布，仅可显式以草稿运行
+	WorkflowStatusPublished WorkflowStatus = "published" // 已发布，运行默认执行 PublishedVersion
+	WorkflowStatusArchived  WorkflowStatus = "archived"
 )
 
 // RunStatus 执行状态
@@ -22,42 +23,105 @@ const (
 	RunStatusSucceeded RunStatus = "succeeded"
 	RunStatusFailed    RunStatus = "failed"
 	RunStatusAborted   RunStatus = "aborted"
+	RunStatusCanceled  RunStatus = "canceled" // 排队中被取消，未执行
+	RunStatusPaused    RunStatus = "paused"   // 手动暂停，或等待人工输入（响应后重新入队恢复）
+
+	// RunStatusDeadLetter 异步运行耗尽 async_retry 重试次数，等待人工处理（可批量重新入队）
+	RunStatusDeadLetter RunStatus = "dead-letter"
+
+	// RunStatusPartialSucceeded 执行完成，但有节点失败并被 fail-branch / default-value 策略吸收
+	RunStatusPartialSucceeded RunStatus = "partial-succeeded"
 )
 
 // Workflow 工作流定义模型
+// DSL / Version 为草稿（最近一次保存的版本），线上运行执行 PublishedVersion
 type Workflow struct {
-	ID          string          `json:"id"`
-	OrgID       string          `json:"org_id,omitempty"`
-	TenantID    string          `json:"tenant_id,omitempty"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	DSL         json.RawMessage `json:"dsl"`
-	Version     int             `json:"version"`
-	Status      WorkflowStatus  `json:"status"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID               string          `json:"id"`
+	OrgID            string          `json:"org_id,omitempty"`
+	TenantID         string          `json:"tenant_id,omitempty"`
+	Name             string          `json:"name"`
+	Description      string          `json:"description"`
+	DSL              json.RawMessage `json:"dsl"`
+	Version          int             `json:"version"`
+	PublishedVersion int             `json:"published_version"` // 0 表示尚未发布
+	PublishedAt      *time.Time      `json:"published_at,omitempty"`
+	Status           WorkflowStatus  `json:"status"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// WorkflowVersion 工作流 DSL 的不可变历史版本（每次保存 DSL 产生一个新版本）
+type WorkflowVersion struct {
+	WorkflowID string          `json:"workflow_id"`
+	Version    int             `json:"version"`
+	DSL        json.RawMessage `json:"dsl,omitempty"` // 列表查询不返回 DSL
+	CreatedAt  time.Time       `json:"created_at"`
 }
 
 // WorkflowRun 执行记录模型
 type WorkflowRun struct {
-	ID             string          `json:"id"`
-	WorkflowID     string          `json:"workflow_id"`
-	OrgID          string          `json:"org_id,omitempty"`
-	TenantID       string          `json:"tenant_id,omitempty"`
-	ConversationID string          `json:"conversation_id,omitempty"`
-	Status         RunStatus       `json:"status"`
-	WorkerID       string          `json:"worker_id,omitempty"`
-	RetryCount     int             `json:"retry_count,omitempty"`
-	Inputs         json.RawMessage `json:"inputs,omitempty"`
-	Outputs        json.RawMessage `json:"outputs,omitempty"`
-	Error          string          `json:"error,omitempty"`
-	TotalTokens    int             `json:"total_tokens"`
-	TotalSteps     int             `json:"total_steps"`
-	ElapsedMs      int64           `json:"elapsed_ms"`
-	QueuedAt       *time.Time      `json:"queued_at,omitempty"`
-	PickedAt       *time.Time      `json:"picked_at,omitempty"`
-	StartedAt      time.Time       `json:"started_at"`
-	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+	ID              string          `json:"id"`
+	WorkflowID      string          `json:"workflow_id"`
+	OrgID           string          `json:"org_id,omitempty"`
+	TenantID        string          `json:"tenant_id,omitempty"`
+	WorkflowVersion int             `json:"workflow_version,omitempty"` // 本次运行执行的工作流版本
+	ParentRunID     string          `json:"parent_run_id,omitempty"`    // 子工作流运行所属的父运行
+	ConversationID  string          `json:"conversation_id,omitempty"`
+	Status          RunStatus       `json:"status"`
+	WorkerID        string          `json:"worker_id,omitempty"`
+	RetryCount      int             `json:"retry_count,omitempty"`
+	Priority        int             `json:"priority"` // 异步队列优先级 0-9，越大越先执行（同租户内）
+	Inputs          json.RawMessage `json:"inputs,omitempty"`
+	Outputs         json.RawMessage `json:"outputs,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	ExceptionsCount int             `json:"exceptions_count"` // 被错误策略吸收的节点异常数
+	TotalTokens     int             `json:"total_tokens"`
+	TotalSteps      int             `json:"total_steps"`
+	ElapsedMs       int64           `json:"elapsed_ms"`
+	QueuedAt        *time.Time      `json:"queued_at,omitempty"`
+	PickedAt        *time.Time      `json:"picked_at,omitempty"`
+	NextAttemptAt   *time.Time      `json:"next_attempt_at,omitempty"`  // 退避重试：早于该时间不会被 worker 认领
+	LeaseExpiresAt  *time.Time      `json:"lease_expires_at,omitempty"` // worker 租约到期时间，执行中由心跳续约
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+	Attempts        []RunAttempt    `json:"attempts,omitempty"`     // 异步执行失败的历次尝试
+	CallbackURL     string          `json:"callback_url,omitempty"` // 运行到达终态时推送通知的地址
+	CallbackSecret  string          `json:"-"`                      // callback_url 的签名密钥（为空时使用服务默认密钥）
+}
+
+// 异步运行优先级范围（默认 0）
+const (
+	RunPriorityMin = 0
+	RunPriorityMax = 9
+)
+
+// ClaimOptions 认领排队运行时的公平调度与租约参数
+type ClaimOptions struct {
+	TenantMaxConcurrency int           // 每个租户同时执行的异步运行上限（租户未单独配置时使用，0 不限制）
+	LeaseDuration        time.Duration // 认领后的 worker 租约时长
+}
+
+// TenantQueueStats 单个租户的异步队列深度
+type TenantQueueStats struct {
+	OrgID             string     `json:"org_id,omitempty"`
+	TenantID          string     `json:"tenant_id,omitempty"`
+	Queued            int        `json:"queued"`  // 可立即认领的排队运行
+	Delayed           int        `json:"delayed"` // 处于重试退避中的排队运行
+	Running           int        `json:"running"` // 占用并发配额的运行（worker 执行中或手动暂停）
+	OldestQueuedAt    *time.Time `json:"oldest_queued_at,omitempty"`
+	QueueWeight       int        `json:"queue_weight"`        // 公平调度权重
+	MaxConcurrentRuns int        `json:"max_concurrent_runs"` // 租户级并发上限（0 表示沿用服务默认值）
+}
+
+// RunAttempt 异步运行单次失败尝试记录（自动重试与死信排查使用）
+type RunAttempt struct {
+	Attempt    int       `json:"attempt"` // 从 1 开始
+	WorkerID   string    `json:"worker_id,omitempty"`
+	Error      string    `json:"error"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	ElapsedMs  int64     `json:"elapsed_ms"`
+	BackoffMs  int64     `json:"backoff_ms,omitempty"` // 本次失败后等待下一次重试的时长
 }
 
 // NodeExecution 单个节点的执行记录（引擎内部使用）
@@ -71,6 +135,87 @@ type NodeExecution struct {
 	StartedAt time.Time              `json:"started_at"`
 	ElapsedMs int64                  `json:"elapsed_ms"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"` // tokens 等额外信息
+	NodeParent
+}
+
+// NodeParent 迭代 / 循环子图内节点的层级信息（顶层节点为空）
+type NodeParent struct {
+	ParentNodeID      string `json:"parent_node_id,omitempty"`      // 所属容器节点（iteration / loop）
+	ParentExecutionID string `json:"parent_execution_id,omitempty"` // 所属迭代项 / 循环轮次的执行 ID
+	IterationIndex    *int   `json:"iteration_index,omitempty"`     // 迭代项序号（从 0 开始）
+	LoopRound         *int   `json:"loop_round,omitempty"`          // 循环轮次（从 0 开始）
+}
+
+// NodeAttempt 节点单次尝试记录（retry 策略下写入 NodeExecution.Metadata["attempts"]）
+type NodeAttempt struct {
+	Attempt    int       `json:"attempt"` // 从 1 开始
+	Status     string    `json:"status"`  // succeeded / failed
+	Error      string    `json:"error,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	ElapsedMs  int64     `json:"elapsed_ms"`
+	BackoffMs  int64     `json:"backoff_ms,omitempty"` // 本次失败后等待下一次重试的时长
+}
+
+// RunCheckpoint 工作流执行检查点（每个节点完成后落盘，用于崩溃恢复）
+type RunCheckpoint struct {
+	RunID          string            `json:"run_id"`
+	Sequence       int64             `json:"sequence"`
+	State          json.RawMessage   `json:"state"`         // GraphRuntimeState.Dump()
+	VariablePool   json.RawMessage   `json:"variable_pool"` // VariablePool.Dump()
+	CompletedNodes []string          `json:"completed_nodes"`
+	NodeStates     map[string]string `json:"node_states,omitempty"` // node_id -> unknown/taken/skipped
+	EdgeStates     map[string]string `json:"edge_states,omitempty"` // edge_id -> unknown/taken/skipped
+	NodeExecutions []NodeExecution   `json:"node_executions,omitempty"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// PendingInputStatus 人工输入状态
+type PendingInputStatus string
+
+const (
+	PendingInputStatusWaiting   PendingInputStatus = "waiting"
+	PendingInputStatusSubmitted PendingInputStatus = "submitted"
+	PendingInputStatusTimeout   PendingInputStatus = "timeout"
+)
+
+// PendingInputField 人工输入表单字段
+type PendingInputField struct {
+	Name     string      `json:"name"`
+	Label    string      `json:"label,omitempty"`
+	Type     string      `json:"type"` // text / number / boolean / select
+	Required bool        `json:"required,omitempty"`
+	Options  []string    `json:"options,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+}
+
+// PendingInputAction 人工输入可选操作（对应节点出边的 source handle）
+type PendingInputAction struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// PendingInput human-input 节点挂起等待的人工输入
+type PendingInput struct {
+	ID            string                 `json:"id"`
+	RunID         string                 `json:"run_id"`
+	WorkflowID    string                 `json:"workflow_id,omitempty"`
+	NodeID        string                 `json:"node_id"`
+	OrgID         string                 `json:"org_id,omitempty"`
+	TenantID      string                 `json:"tenant_id,omitempty"`
+	Title         string                 `json:"title,omitempty"`
+	Prompt        string                 `json:"prompt,omitempty"`
+	Fields        []PendingInputField    `json:"fields,omitempty"`
+	Actions       []PendingInputAction   `json:"actions,omitempty"`
+	Assignee      string                 `json:"assignee,omitempty"`
+	TimeoutBranch string                 `json:"timeout_branch,omitempty"`
+	Status        PendingInputStatus     `json:"status"`
+	Action        string                 `json:"action,omitempty"`
+	Response      map[string]interface{} `json:"response,omitempty"`
+	RespondedBy   string                 `json:"responded_by,omitempty"`
+	ExpiresAt     *time.Time             `json:"expires_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	RespondedAt   *time.Time             `json:"responded_at,omitempty"`
 }
 
 // NodeExecutionRecord 独立表的节点执行记录
@@ -86,6 +231,7 @@ type NodeExecutionRecord struct {
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 	ElapsedMs int64                  `json:"elapsed_ms"`
 	StartedAt time.Time              `json:"started_at"`
+	NodeParent
 }
 
 // ConversationTrace AI Provider 调用溯源记录
@@ -166,6 +312,22 @@ type ListWorkflowsParams struct {
 	Search   string // 按名称模糊搜索
 }
 
+// ListRunsParams 执行记录查询参数
+type ListRunsParams struct {
+	WorkflowID string
+	Status     RunStatus // 为空时不过滤
+	Page       int
+	PageSize   int
+}
+
+// ListRunsResult 执行记录分页结果
+type ListRunsResult struct {
+	Runs     []*WorkflowRun `json:"runs"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
 // ListWorkflowsResult 分页结果
 type ListWorkflowsResult struct {
 	Workflows []*Workflow `json:"workflows"`
@@ -186,13 +348,15 @@ type Organization struct {
 
 // Tenant 租户数据模型
 type Tenant struct {
-	ID        string    `json:"id"`
-	OrgID     string    `json:"org_id"`
-	Code      string    `json:"code"`
-	Name      string    `json:"name"`
-	Status    string    `json:"status"` // active / inactive
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                string    `json:"id"`
+	OrgID             string    `json:"org_id"`
+	Code              string    `json:"code"`
+	Name              string    `json:"name"`
+	Status            string    `json:"status"`                        // active / inactive
+	QueueWeight       int       `json:"queue_weight,omitempty"`        // 异步队列公平调度权重（0 视为 1）
+	MaxConcurrentRuns int       `json:"max_concurrent_runs,omitempty"` // 同时执行的异步运行上限（0 沿用服务默认值）
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // Dataset 知识库数据模型
@@ -268,3 +432,125 @@ type ExternalAsyncTask struct {
 	CreatedAt          time.Time              `json:"created_at"`
 	UpdatedAt          time.Time              `json:"updated_at"`
 }
+
+// MisfirePolicy 调度错过触发时间（停机、积压）后的处理策略
+type MisfirePolicy string
+
+const (
+	MisfirePolicyFireOnce MisfirePolicy = "fire_once" // 补触发一次，之后按计划继续
+	MisfirePolicySkip     MisfirePolicy = "skip"      // 跳过错过的触发，等待下一个计划时间
+)
+
+// WorkflowSchedule 工作流定时触发（cron）
+type WorkflowSchedule struct {
+	ID            string          `json:"id"`
+	WorkflowID    string          `json:"workflow_id"`
+	OrgID         string          `json:"org_id,omitempty"`
+	TenantID      string          `json:"tenant_id,omitempty"`
+	Name          string          `json:"name"`
+	CronExpr      string          `json:"cron"`
+	Timezone      string          `json:"timezone"`
+	Inputs        json.RawMessage `json:"inputs,omitempty"`
+	Enabled       bool            `json:"enabled"`
+	MisfirePolicy MisfirePolicy   `json:"misfire_policy"`
+	NextFireAt    *time.Time      `json:"next_fire_at,omitempty"` // 停用时为空
+	LastFireAt    *time.Time      `json:"last_fire_at,omitempty"`
+	LastRunID     string          `json:"last_run_id,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// WebhookMode 入站 Webhook 的执行方式
+type WebhookMode string
+
+const (
+	WebhookModeAsync WebhookMode = "async" // 入队异步执行，立即返回 run_id
+	WebhookModeSync  WebhookMode = "sync"  // 同步执行并返回输出
+)
+
+// WorkflowWebhook 工作流入站 Webhook 触发器（HMAC-SHA256 签名校验）
+type WorkflowWebhook struct {
+	ID           string            `json:"id"`
+	WorkflowID   string            `json:"workflow_id"`
+	OrgID        string            `json:"org_id,omitempty"`
+	TenantID     string            `json:"tenant_id,omitempty"`
+	Name         string            `json:"name"`
+	Secret       string            `json:"secret,omitempty"`        // 仅在创建与轮换时返回
+	InputMapping map[string]string `json:"input_mapping,omitempty"` // 开始节点变量名 → JSONPath；为空时使用请求体顶层字段
+	Mode         WebhookMode       `json:"mode"`                    // 默认执行方式，调用方可用 ?mode= 覆盖
+	Enabled      bool              `json:"enabled"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// RunIdempotencyKey 运行创建接口的 Idempotency-Key 记录（按租户隔离，过期后可复用）
+type RunIdempotencyKey struct {
+	Key         string    `json:"key"`
+	OrgID       string    `json:"org_id,omitempty"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	Endpoint    string    `json:"endpoint"` // sync / stream / async
+	WorkflowID  string    `json:"workflow_id"`
+	RequestHash string    `json:"request_hash"` // 请求指纹，同一 key 不同请求视为冲突
+	RunID       string    `json:"run_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// RunNotificationSubscription 租户级运行完成通知订阅：租户内任一运行到达终态时推送签名通知
+type RunNotificationSubscription struct {
+	ID        string      `json:"id"`
+	OrgID     string      `json:"org_id,omitempty"`
+	TenantID  string      `json:"tenant_id,omitempty"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"`   // 仅创建与轮换时返回
+	Statuses  []RunStatus `json:"statuses,omitempty"` // 只推送这些终态，为空表示全部终态
+	Enabled   bool        `json:"enabled"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// NotificationDeliveryStatus 通知投递状态
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryPending   NotificationDeliveryStatus = "pending"   // 等待投递或退避重试中
+	NotificationDeliverySucceeded NotificationDeliveryStatus = "succeeded" // 接收方返回 2xx
+	NotificationDeliveryFailed    NotificationDeliveryStatus = "failed"    // 重试次数耗尽，可手动重新投递
+)
+
+// RunNotificationDelivery 运行完成通知的投递记录（一次终态 × 一个接收地址）
+type RunNotificationDelivery struct {
+	ID             string                     `json:"id"`
+	RunID          string                     `json:"run_id"`
+	OrgID          string                     `json:"org_id,omitempty"`
+	TenantID       string                     `json:"tenant_id,omitempty"`
+	SubscriptionID string                     `json:"subscription_id,omitempty"` // 为空表示来自运行的 callback_url
+	URL            string                     `json:"url"`
+	Secret         string                     `json:"-"`
+	RunStatus      RunStatus                  `json:"run_status"`
+	Payload        json.RawMessage            `json:"payload"`
+	Status         NotificationDeliveryStatus `json:"status"`
+	Attempts       []NotificationAttempt      `json:"attempts,omitempty"`
+	MaxAttempts    int                        `json:"max_attempts"`
+	NextAttemptAt  *time.Time                 `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time                 `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+}
+
+// NotificationAttempt 单次投递尝试
+type NotificationAttempt struct {
+	Attempt    int       `json:"attempt"` // 从 1 开始
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ElapsedMs  int64     `json:"elapsed_ms"`
+	At         time.Time `json:"at"`
+}
+
+// ListNotificationDeliveriesParams 投递记录查询参数
+type ListNotificationDeliveriesParams struct {
+	RunID  string
+	Status NotificationDeliveryStatus
+	Limit  int
+}
@@ -1,6 +1,9 @@
 package port
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository 工作流存储接口
 type Repository interface {
@@ -11,6 +14,28 @@ type Repository interface {
 	DeleteWorkflow(ctx context.Context, id string) error
 	ListWorkflows(ctx context.Context, params ListWorkflowsParams) (*ListWorkflowsResult, error)
 
+	// WorkflowVersion 不可变版本历史
+	GetWorkflowVersion(ctx context.Context, workflowID string, version int) (*WorkflowVersion, error)
+	ListWorkflowVersions(ctx context.Context, workflowID string) ([]*WorkflowVersion, error)
+	PublishWorkflowVersion(ctx context.Context, workflowID string, version int) (bool, error)
+
+	// WorkflowSchedule 定时触发
+	CreateSchedule(ctx context.Context, s *WorkflowSchedule) error
+	GetSchedule(ctx context.Context, id string) (*WorkflowSchedule, error)
+	ListSchedules(ctx context.Context, workflowID string) ([]*WorkflowSchedule, error)
+	UpdateSchedule(ctx context.Context, s *WorkflowSchedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+	ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]*WorkflowSchedule, error)
+	AdvanceSchedule(ctx context.Context, id string, expected, next time.Time, firedAt *time.Time) (bool, error)
+	RecordScheduleRun(ctx context.Context, id, runID, lastError string) error
+
+	// WorkflowWebhook 入站 Webhook 触发
+	CreateWebhook(ctx context.Context, hook *WorkflowWebhook) error
+	GetWebhook(ctx context.Context, id string) (*WorkflowWebhook, error)
+	ListWebhooks(ctx context.Context, workflowID string) ([]*WorkflowWebhook, error)
+	UpdateWebhook(ctx context.Context, hook *WorkflowWebhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+
 	// Organization CRUD
 	CreateOrganization(ctx context.Context, org *Organization) error
 	GetOrganization(ctx context.Context, id string) (*Organization, error)
@@ -43,8 +68,48 @@ type Repository interface {
 	CreateRun(ctx context.Context, run *WorkflowRun) error
 	GetRun(ctx context.Context, id string) (*WorkflowRun, error)
 	UpdateRun(ctx context.Context, run *WorkflowRun) error
-	ListRuns(ctx context.Context, workflowID string, page, pageSize int) ([]*WorkflowRun, error)
-	ClaimNextQueuedRun(ctx context.Context, workerID string) (*WorkflowRun, error)
+	ListRuns(ctx context.Context, params ListRunsParams) (*ListRunsResult, error)
+	ClaimNextQueuedRun(ctx context.Context, workerID string, opts ClaimOptions) (*WorkflowRun, error)
+	ListQueueStats(ctx context.Context) ([]*TenantQueueStats, error)
+	RenewRunLease(ctx context.Context, id, workerID string, lease time.Duration) (bool, error)
+	ReleaseRunLease(ctx context.Context, id, workerID string) (bool, error)
+	ListExpiredRunLeases(ctx context.Context, limit int) ([]*WorkflowRun, error)
+	RequeueExpiredRun(ctx context.Context, id, workerID string) (bool, error)
+	FailExpiredRun(ctx context.Context, id, workerID string, status RunStatus, errMsg string) (bool, error)
+	TransitionRunStatus(ctx context.Context, id string, from []RunStatus, to RunStatus) (bool, error)
+	RequeueRun(ctx context.Context, id string, from []RunStatus) (bool, error)
+	AppendRunAttempt(ctx context.Context, id string, attempt RunAttempt) error
+	ScheduleRunRetry(ctx context.Context, id string, notBefore time.Time) (bool, error)
+
+	// RunIdempotencyKey 运行创建接口幂等键
+	ReserveIdempotencyKey(ctx context.Context, key *RunIdempotencyKey) (*RunIdempotencyKey, error)
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+
+	// RunNotification 运行完成通知（租户订阅 + 投递记录）
+	CreateNotificationSubscription(ctx context.Context, sub *RunNotificationSubscription) error
+	GetNotificationSubscription(ctx context.Context, id string) (*RunNotificationSubscription, error)
+	ListNotificationSubscriptions(ctx context.Context) ([]*RunNotificationSubscription, error)
+	ListActiveNotificationSubscriptions(ctx context.Context, orgID, tenantID string) ([]*RunNotificationSubscription, error)
+	UpdateNotificationSubscription(ctx context.Context, sub *RunNotificationSubscription) error
+	DeleteNotificationSubscription(ctx context.Context, id string) error
+	CreateNotificationDelivery(ctx context.Context, d *RunNotificationDelivery) error
+	GetNotificationDelivery(ctx context.Context, id string) (*RunNotificationDelivery, error)
+	ListNotificationDeliveries(ctx context.Context, params ListNotificationDeliveriesParams) ([]*RunNotificationDelivery, error)
+	ClaimDueNotificationDeliveries(ctx context.Context, limit int, lock time.Duration) ([]*RunNotificationDelivery, error)
+	RecordNotificationAttempt(ctx context.Context, id string, attempt NotificationAttempt, status NotificationDeliveryStatus, nextAttemptAt *time.Time) error
+
+	// RunCheckpoint 执行检查点（崩溃恢复）
+	SaveRunCheckpoint(ctx context.Context, cp *RunCheckpoint) error
+	GetRunCheckpoint(ctx context.Context, runID string) (*RunCheckpoint, error)
+	DeleteRunCheckpoint(ctx context.Context, runID string) error
+
+	// PendingInput 人工输入（human-input 节点挂起/恢复）
+	UpsertPendingInput(ctx context.Context, input *PendingInput) error
+	ListPendingInputs(ctx context.Context, runID string) ([]*PendingInput, error)
+	SubmitPendingInput(ctx context.Context, runID, nodeID, action string, response map[string]interface{}, respondedBy string) (bool, error)
+	TimeoutExpiredPendingInputs(ctx context.Context, now time.Time) ([]string, error)
+	ResumePausedRun(ctx context.Context, runID string) (bool, error)
 
 	// NodeExecution 独立表
 	BatchCreateNodeExecs(ctx context.Context, records []*NodeExecutionRecord) error
@@ -73,6 +138,14 @@ type Repository interface {
 	EnsureTenantColumns(ctx context.Context) error
 	EnsureRAGTables(ctx context.Context) error
 	EnsureExternalAsyncTaskTable(ctx context.Context) error
+	EnsureRunCheckpointTable(ctx context.Context) error
+	EnsurePendingInputTable(ctx context.Context) error
+	EnsureWorkflowVersionTable(ctx context.Context) error
+	EnsureWorkflowPublishColumns(ctx context.Context) error
+	EnsureScheduleTable(ctx context.Context) error
+	EnsureWebhookTable(ctx context.Context) error
+	EnsureIdempotencyKeyTable(ctx context.Context) error
+	EnsureNotificationTables(ctx context.Context) error
 }
 
 // scopeInfo 用于从 context 中读取 scope（repository 层的轻量读取）
@@ -111,6 +111,39 @@ func (s *GraphRuntimeState) Dump() ([]byte, error) {
 	return json.Marshal(snapshot)
 }
 
+// Restore 从 Dump 生成的快照恢复运行时状态（用于检查点恢复）
+// 执行标记（started/completed 等）不恢复，仅恢复计数类字段
+func (s *GraphRuntimeState) Restore(data []byte) error {
+	var snapshot struct {
+		StartAt      int64                  `json:"start_at"`
+		TotalTokens  int64                  `json:"total_tokens"`
+		NodeRunSteps int32                  `json:"node_run_steps"`
+		Outputs      map[string]interface{} `json:"outputs"`
+		VariablePool map[string]interface{} `json:"variable_pool"`
+		Execution    struct {
+			ExceptionsCount int `json:"exceptions_count"`
+		} `json:"execution"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("unmarshal runtime state: %w", err)
+	}
+
+	if snapshot.StartAt > 0 {
+		s.StartAt = time.UnixMilli(snapshot.StartAt)
+	}
+	s.TotalTokens.Store(snapshot.TotalTokens)
+	s.NodeRunSteps.Store(snapshot.NodeRunSteps)
+	s.UpdateOutputs(snapshot.Outputs)
+	if s.VariablePool != nil && snapshot.VariablePool != nil {
+		s.VariablePool.Load(snapshot.VariablePool)
+	}
+
+	s.execution.mu.Lock()
+	s.execution.ExceptionsCount = snapshot.Execution.ExceptionsCount
+	s.execution.mu.Unlock()
+	return nil
+}
+
 // GraphExecution 图执行聚合体，跟踪整体执行状态
 type GraphExecution struct {
 	mu sync.RWMutex
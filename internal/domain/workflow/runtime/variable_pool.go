@@ -134,6 +134,30 @@ func (vp *VariablePool) Dump() map[string]interface{} {
 	return result
 }
 
+// Load 从 Dump 快照恢复变量（覆盖同名节点变量，用于检查点恢复）
+func (vp *VariablePool) Load(snapshot map[string]interface{}) {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	for nodeID, raw := range snapshot {
+		vars, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nodeID == "__system__" {
+			for k, v := range vars {
+				vp.system[k] = v
+			}
+			continue
+		}
+		nodeVars := make(map[string]interface{}, len(vars))
+		for k, v := range vars {
+			nodeVars[k] = v
+		}
+		vp.variables[nodeID] = nodeVars
+	}
+}
+
 // ResolveTemplate 解析模板字符串中的变量引用
 // 格式: {{#node_id.variable_name#}}
 func (vp *VariablePool) ResolveTemplate(template string) string {
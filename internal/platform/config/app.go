@@ -30,22 +30,35 @@ type AppConfig struct {
 }
 
 type ServerConfig struct {
-	Host                string `json:"host"`
-	Port                int    `json:"port"`
-	ReadTimeoutSeconds  int    `json:"read_timeout_seconds"`
-	WriteTimeoutSeconds int    `json:"write_timeout_seconds"`
-	RunTimeoutSeconds   int    `json:"run_timeout_seconds"`
+	Host                   string `json:"host"`
+	Port                   int    `json:"port"`
+	ReadTimeoutSeconds     int    `json:"read_timeout_seconds"`
+	WriteTimeoutSeconds    int    `json:"write_timeout_seconds"`
+	RunTimeoutSeconds      int    `json:"run_timeout_seconds"`
+	IdempotencyKeyTTLHours int    `json:"idempotency_key_ttl_hours"`
 }
 
 type RuntimeConfig struct {
-	MigrationTimeoutSeconds      int `json:"migration_timeout_seconds"`
-	RedisPingTimeoutSeconds      int `json:"redis_ping_timeout_seconds"`
-	MTMEnsureTimeoutSeconds      int `json:"mtm_ensure_timeout_seconds"`
-	ShutdownTimeoutSeconds       int `json:"shutdown_timeout_seconds"`
-	OpenSearchPingTimeoutSeconds int `json:"opensearch_ping_timeout_seconds"`
-	AsyncRunWorkers              int `json:"async_run_workers"`
-	AsyncRunPollIntervalMs       int `json:"async_run_poll_interval_ms"`
-	AsyncRunTimeoutSeconds       int `json:"async_run_timeout_seconds"`
+	MigrationTimeoutSeconds      int    `json:"migration_timeout_seconds"`
+	RedisPingTimeoutSeconds      int    `json:"redis_ping_timeout_seconds"`
+	MTMEnsureTimeoutSeconds      int    `json:"mtm_ensure_timeout_seconds"`
+	ShutdownTimeoutSeconds       int    `json:"shutdown_timeout_seconds"`
+	OpenSearchPingTimeoutSeconds int    `json:"opensearch_ping_timeout_seconds"`
+	AsyncRunWorkers              int    `json:"async_run_workers"`
+	AsyncRunPollIntervalMs       int    `json:"async_run_poll_interval_ms"`
+	AsyncRunTimeoutSeconds       int    `json:"async_run_timeout_seconds"`
+	AsyncRunTenantMaxConcurrency int    `json:"async_run_tenant_max_concurrency"`
+	AsyncRunLeaseSeconds         int    `json:"async_run_lease_seconds"`
+	AsyncRunWorkerLostRetries    int    `json:"async_run_worker_lost_retries"`
+	SchedulerPollIntervalMs      int    `json:"scheduler_poll_interval_ms"`
+	SchedulerMisfireSeconds      int    `json:"scheduler_misfire_seconds"`
+	RunNotifyPollIntervalMs      int    `json:"run_notify_poll_interval_ms"`
+	RunNotifyTimeoutSeconds      int    `json:"run_notify_timeout_seconds"`
+	RunNotifyMaxAttempts         int    `json:"run_notify_max_attempts"`
+	RunNotifyAllowPrivateNet     bool   `json:"run_notify_allow_private_net"`
+	RunEventTTLHours             int    `json:"run_event_ttl_hours"`
+	RunEventMaxLen               int    `json:"run_event_max_len"`
+	RunCallbackSecret            string `json:"run_callback_secret"`
 }
 
 type DatabaseConfig struct {
@@ -63,6 +76,20 @@ type EngineConfig struct {
 	MaxWorkers         int `json:"max_workers"`
 	NodeTimeoutSeconds int `json:"node_timeout_seconds"`
 	MaxNodeSteps       int `json:"max_node_steps"`
+	NodeMaxOutputBytes int `json:"node_max_output_bytes"` // 单个节点输出上限（字节），0 表示不限制
+
+	MaxSubWorkflowDepth int `json:"max_subworkflow_depth"` // workflow 子工作流节点最大嵌套深度
+
+	// 进程级节点并发限制（跨运行共享），按节点类型，可细化到 provider / model
+	NodeConcurrency []NodeConcurrencyConfig `json:"node_concurrency,omitempty"`
+}
+
+// NodeConcurrencyConfig 节点并发限制规则
+type NodeConcurrencyConfig struct {
+	NodeType      string `json:"node_type"`
+	Provider      string `json:"provider,omitempty"`
+	Model         string `json:"model,omitempty"`
+	MaxConcurrent int    `json:"max_concurrent"`
 }
 
 type AuthConfig struct {
@@ -146,11 +173,12 @@ func Default() *AppConfig {
 		LogLevel:  "info",
 		LogFormat: "text",
 		Server: ServerConfig{
-			Host:                "0.0.0.0",
-			Port:                8080,
-			ReadTimeoutSeconds:  30,
-			WriteTimeoutSeconds: 600,
-			RunTimeoutSeconds:   300,
+			Host:                   "0.0.0.0",
+			Port:                   8080,
+			ReadTimeoutSeconds:     30,
+			WriteTimeoutSeconds:    600,
+			RunTimeoutSeconds:      300,
+			IdempotencyKeyTTLHours: 24,
 		},
 		Runtime: RuntimeConfig{
 			MigrationTimeoutSeconds:      10,
@@ -161,6 +189,15 @@ func Default() *AppConfig {
 			AsyncRunWorkers:              2,
 			AsyncRunPollIntervalMs:       500,
 			AsyncRunTimeoutSeconds:       300,
+			AsyncRunLeaseSeconds:         30,
+			AsyncRunWorkerLostRetries:    3,
+			SchedulerPollIntervalMs:      1000,
+			SchedulerMisfireSeconds:      60,
+			RunNotifyPollIntervalMs:      1000,
+			RunNotifyTimeoutSeconds:      10,
+			RunNotifyMaxAttempts:         6,
+			RunEventTTLHours:             24,
+			RunEventMaxLen:               10000,
 		},
 		Database: DatabaseConfig{
 			MaxOpenConns:           25,
@@ -168,9 +205,10 @@ func Default() *AppConfig {
 			ConnMaxLifetimeSeconds: 300,
 		},
 		Engine: EngineConfig{
-			MaxWorkers:         4,
-			NodeTimeoutSeconds: 300,
-			MaxNodeSteps:       100,
+			MaxWorkers:          4,
+			NodeTimeoutSeconds:  300,
+			MaxNodeSteps:        100,
+			MaxSubWorkflowDepth: 5,
 		},
 		OpenAI: OpenAIConfig{
 			BaseURL:                    "https://api.openai.com/v1",
@@ -263,6 +301,7 @@ func (c *AppConfig) applyEnv() {
 	applyInt("SERVER_READ_TIMEOUT", &c.Server.ReadTimeoutSeconds)
 	applyInt("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeoutSeconds)
 	applyInt("SERVER_RUN_TIMEOUT", &c.Server.RunTimeoutSeconds)
+	applyInt("SERVER_IDEMPOTENCY_KEY_TTL_HOURS", &c.Server.IdempotencyKeyTTLHours)
 
 	applyInt("RUNTIME_MIGRATION_TIMEOUT", &c.Runtime.MigrationTimeoutSeconds)
 	applyInt("RUNTIME_REDIS_PING_TIMEOUT", &c.Runtime.RedisPingTimeoutSeconds)
@@ -272,6 +311,18 @@ func (c *AppConfig) applyEnv() {
 	applyInt("RUNTIME_ASYNC_RUN_WORKERS", &c.Runtime.AsyncRunWorkers)
 	applyInt("RUNTIME_ASYNC_RUN_POLL_INTERVAL_MS", &c.Runtime.AsyncRunPollIntervalMs)
 	applyInt("RUNTIME_ASYNC_RUN_TIMEOUT", &c.Runtime.AsyncRunTimeoutSeconds)
+	applyInt("RUNTIME_ASYNC_RUN_TENANT_MAX_CONCURRENCY", &c.Runtime.AsyncRunTenantMaxConcurrency)
+	applyInt("RUNTIME_ASYNC_RUN_LEASE_SECONDS", &c.Runtime.AsyncRunLeaseSeconds)
+	applyInt("RUNTIME_ASYNC_RUN_WORKER_LOST_RETRIES", &c.Runtime.AsyncRunWorkerLostRetries)
+	applyInt("RUNTIME_SCHEDULER_POLL_INTERVAL_MS", &c.Runtime.SchedulerPollIntervalMs)
+	applyInt("RUNTIME_SCHEDULER_MISFIRE_THRESHOLD", &c.Runtime.SchedulerMisfireSeconds)
+	applyInt("RUNTIME_RUN_NOTIFY_POLL_INTERVAL_MS", &c.Runtime.RunNotifyPollIntervalMs)
+	applyInt("RUNTIME_RUN_NOTIFY_TIMEOUT", &c.Runtime.RunNotifyTimeoutSeconds)
+	applyInt("RUNTIME_RUN_NOTIFY_MAX_ATTEMPTS", &c.Runtime.RunNotifyMaxAttempts)
+	applyBool("RUNTIME_RUN_NOTIFY_ALLOW_PRIVATE_NET", &c.Runtime.RunNotifyAllowPrivateNet)
+	applyInt("RUNTIME_RUN_EVENT_TTL_HOURS", &c.Runtime.RunEventTTLHours)
+	applyInt("RUNTIME_RUN_EVENT_MAX_LEN", &c.Runtime.RunEventMaxLen)
+	applyString("RUNTIME_RUN_CALLBACK_SECRET", &c.Runtime.RunCallbackSecret)
 
 	applyString("DATABASE_URL", &c.Database.URL)
 	applyInt("DATABASE_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
@@ -283,6 +334,9 @@ func (c *AppConfig) applyEnv() {
 	applyInt("ENGINE_MAX_WORKERS", &c.Engine.MaxWorkers)
 	applyInt("ENGINE_NODE_TIMEOUT", &c.Engine.NodeTimeoutSeconds)
 	applyInt("ENGINE_MAX_NODE_STEPS", &c.Engine.MaxNodeSteps)
+	applyInt("ENGINE_NODE_MAX_OUTPUT_BYTES", &c.Engine.NodeMaxOutputBytes)
+	applyInt("ENGINE_MAX_SUBWORKFLOW_DEPTH", &c.Engine.MaxSubWorkflowDepth)
+	applyNodeConcurrency("ENGINE_NODE_CONCURRENCY", &c.Engine.NodeConcurrency)
 
 	applyString("JWT_SECRET", &c.Auth.JWTSecret)
 	applyString("JWT_ISSUER", &c.Auth.JWTIssuer)
@@ -463,3 +517,33 @@ func applyBool(key string, target *bool) {
 		}
 	}
 }
+
+// applyNodeConcurrency 解析节点并发限制，格式：node_type[:provider[:model]]=max,...
+// 例如 llm=8,llm:openai:gpt-4o=2,http-request=16
+func applyNodeConcurrency(key string, target *[]NodeConcurrencyConfig) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return
+	}
+	var limits []NodeConcurrencyConfig
+	for _, item := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(name), ":", 3)
+		limit := NodeConcurrencyConfig{NodeType: parts[0], MaxConcurrent: n}
+		if len(parts) > 1 {
+			limit.Provider = parts[1]
+		}
+		if len(parts) > 2 {
+			limit.Model = parts[2]
+		}
+		limits = append(limits, limit)
+	}
+	*target = limits
+}
@@ -0,0 +1,56 @@
+// Package netguard 限制服务端主动发起的出站请求只能访问公网地址，防止 SSRF。
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrPrivateAddress 目标解析到内网或本地地址。
+var ErrPrivateAddress = errors.New("private/local IP not allowed")
+
+// IsPrivateOrLocalIP 判断是否为回环、内网、链路本地、组播或未指定地址；nil 视为不可访问。
+func IsPrivateOrLocalIP(ip net.IP) bool {
+	if ip == nil {
+		return true
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	return false
+}
+
+// ValidatePublicHost 解析主机名，任一解析结果为内网或本地地址时返回错误。
+func ValidatePublicHost(host string) error {
+	return ValidatePublicHostContext(context.Background(), host)
+}
+
+// ValidatePublicHostContext 同 ValidatePublicHost，DNS 查询受 ctx 控制。
+func ValidatePublicHostContext(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if IsPrivateOrLocalIP(ip) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, ip.String())
+		}
+	}
+	return nil
+}
+
+// DialControl 用作 net.Dialer.Control，在建立连接前校验实际拨号的 IP，
+// 防止校验通过后通过 DNS 重绑定指向内网地址。
+func DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); IsPrivateOrLocalIP(ip) {
+		return fmt.Errorf("dial %s %s: %w", network, address, ErrPrivateAddress)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+-- run_checkpoints 执行检查点表（每个节点完成后覆盖写入，用于崩溃恢复）
+CREATE TABLE IF NOT EXISTS run_checkpoints (
+    run_id          UUID PRIMARY KEY REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    sequence        BIGINT NOT NULL DEFAULT 0,
+    state           JSONB,
+    variable_pool   JSONB,
+    completed_nodes JSONB NOT NULL DEFAULT '[]',
+    node_states     JSONB,
+    edge_states     JSONB,
+    node_executions JSONB,
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_checkpoints_updated ON run_checkpoints(updated_at);
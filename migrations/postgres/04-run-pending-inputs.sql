@@ -0,0 +1,26 @@
+-- run_pending_inputs 人工输入表（human-input 节点挂起等待的表单/审批）
+CREATE TABLE IF NOT EXISTS run_pending_inputs (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id         UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    workflow_id    UUID,
+    node_id        VARCHAR(255) NOT NULL,
+    org_id         UUID,
+    tenant_id      UUID,
+    title          VARCHAR(255) DEFAULT '',
+    prompt         TEXT DEFAULT '',
+    fields         JSONB,
+    actions        JSONB,
+    assignee       VARCHAR(255) DEFAULT '',
+    timeout_branch VARCHAR(128) DEFAULT '',
+    status         VARCHAR(32) NOT NULL DEFAULT 'waiting', -- waiting / submitted / timeout
+    action         VARCHAR(128) DEFAULT '',
+    response       JSONB,
+    responded_by   VARCHAR(255) DEFAULT '',
+    expires_at     TIMESTAMP WITH TIME ZONE,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    responded_at   TIMESTAMP WITH TIME ZONE,
+    UNIQUE (run_id, node_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_inputs_status_expires ON run_pending_inputs(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_pending_inputs_scope_assignee ON run_pending_inputs(org_id, tenant_id, assignee);
@@ -0,0 +1,4 @@
+-- 被 fail-branch / default-value 策略吸收的节点异常数（>0 时运行状态为 partial-succeeded）
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS exceptions_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_status ON workflow_runs(workflow_id, status, started_at DESC);
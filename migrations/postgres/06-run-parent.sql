@@ -0,0 +1,4 @@
+-- 子工作流运行关联父运行（workflow 节点发起的子运行）
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS parent_run_id UUID REFERENCES workflow_runs(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_parent ON workflow_runs(parent_run_id) WHERE parent_run_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- 工作流 DSL 不可变版本历史；运行记录关联执行时的版本
+CREATE TABLE IF NOT EXISTS workflow_versions (
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    dsl_json    JSONB NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workflow_id, version)
+);
+
+-- 已有工作流补齐当前版本
+INSERT INTO workflow_versions (workflow_id, version, dsl_json, created_at)
+SELECT id, version, dsl_json, updated_at FROM workflows
+ON CONFLICT (workflow_id, version) DO NOTHING;
+
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- 草稿 / 发布生命周期：dsl_json / version 为草稿，运行默认执行 published_version
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS published_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workflows ALTER COLUMN status SET DEFAULT 'draft';
+
+-- 旧版 active 状态的工作流视为已发布当前版本
+UPDATE workflows SET status = 'published', published_version = version, published_at = updated_at WHERE status = 'active';
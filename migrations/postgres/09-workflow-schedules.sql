@@ -0,0 +1,23 @@
+-- 工作流定时触发（cron），由服务内调度器入队到 workflow_runs
+CREATE TABLE IF NOT EXISTS workflow_schedules (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id    UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    org_id         UUID,
+    tenant_id      UUID,
+    name           VARCHAR(255) DEFAULT '',
+    cron_expr      VARCHAR(128) NOT NULL,
+    timezone       VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    inputs         JSONB,
+    enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+    misfire_policy VARCHAR(32) NOT NULL DEFAULT 'fire_once',
+    next_fire_at   TIMESTAMP WITH TIME ZONE,
+    last_fire_at   TIMESTAMP WITH TIME ZONE,
+    last_run_id    UUID REFERENCES workflow_runs(id) ON DELETE SET NULL,
+    last_error     TEXT DEFAULT '',
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_due ON workflow_schedules(next_fire_at) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_workflow ON workflow_schedules(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_scope ON workflow_schedules(org_id, tenant_id);
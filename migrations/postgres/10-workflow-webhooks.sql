@@ -0,0 +1,17 @@
+-- 工作流入站 Webhook 触发器（HMAC-SHA256 签名 + 时间戳防重放）
+CREATE TABLE IF NOT EXISTS workflow_webhooks (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id   UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    org_id        UUID,
+    tenant_id     UUID,
+    name          VARCHAR(255) DEFAULT '',
+    secret        VARCHAR(128) NOT NULL,
+    input_mapping JSONB,
+    mode          VARCHAR(16) NOT NULL DEFAULT 'async',
+    enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_workflow ON workflow_webhooks(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_scope ON workflow_webhooks(org_id, tenant_id);
@@ -0,0 +1,5 @@
+-- 异步运行自动重试与死信：退避期间的最早认领时间、历次失败尝试记录
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS attempts JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_dead_letter ON workflow_runs(started_at DESC) WHERE status = 'dead-letter';
@@ -0,0 +1,7 @@
+-- 异步队列优先级与租户公平调度：运行优先级（0-9）、租户调度权重与并发上限
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS queue_weight INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS max_concurrent_runs INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_fair_pick ON workflow_runs(org_id, tenant_id, priority DESC, queued_at ASC) WHERE status = 'queued';
@@ -0,0 +1,4 @@
+-- worker 租约：执行中的异步运行由心跳续约，过期后由任一实例回收（重新入队或标记 worker_lost 失败）
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_lease ON workflow_runs(lease_expires_at) WHERE status = 'running';
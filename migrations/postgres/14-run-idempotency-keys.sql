@@ -0,0 +1,15 @@
+-- 运行创建接口幂等键（Idempotency-Key，按租户隔离，过期后可复用）
+CREATE TABLE IF NOT EXISTS run_idempotency_keys (
+    idem_key     VARCHAR(255) NOT NULL,
+    org_id       UUID,
+    tenant_id    UUID,
+    endpoint     VARCHAR(16) NOT NULL,
+    workflow_id  UUID NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    run_id       UUID NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_run_idempotency_keys_scope ON run_idempotency_keys((COALESCE(org_id::text, '')), (COALESCE(tenant_id::text, '')), idem_key);
+CREATE INDEX IF NOT EXISTS idx_run_idempotency_keys_expires ON run_idempotency_keys(expires_at);
@@ -0,0 +1,40 @@
+-- 运行完成通知：运行级 callback_url（可选独立签名密钥）、租户级订阅与投递记录（失败按退避重试）
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS callback_url TEXT DEFAULT '';
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS callback_secret VARCHAR(128) DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS run_notification_subscriptions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id     UUID,
+    tenant_id  UUID,
+    url        TEXT NOT NULL,
+    secret     VARCHAR(128) NOT NULL,
+    statuses   JSONB,
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_notification_subscriptions_scope ON run_notification_subscriptions(org_id, tenant_id);
+
+CREATE TABLE IF NOT EXISTS run_notification_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id          UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    org_id          UUID,
+    tenant_id       UUID,
+    subscription_id UUID REFERENCES run_notification_subscriptions(id) ON DELETE SET NULL,
+    url             TEXT NOT NULL,
+    secret          VARCHAR(128) NOT NULL,
+    run_status      VARCHAR(32) NOT NULL,
+    payload         JSONB NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        JSONB,
+    max_attempts    INTEGER NOT NULL DEFAULT 1,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_at    TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_run ON run_notification_deliveries(run_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_due ON run_notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_scope ON run_notification_deliveries(org_id, tenant_id, status);
@@ -0,0 +1,10 @@
+-- 迭代 / 循环子图节点执行记录：所属容器节点、每项 / 每轮的执行 ID 与序号
+ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS parent_node_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS parent_execution_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS iteration_index INTEGER;
+ALTER TABLE node_executions ADD COLUMN IF NOT EXISTS loop_round INTEGER;
+
+-- 子图节点在每项中各执行一次，唯一键加入 parent_execution_id（顶层节点为空串）
+ALTER TABLE node_executions DROP CONSTRAINT IF EXISTS node_executions_run_id_node_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_node_exec_run_node_parent ON node_executions(run_id, node_id, parent_execution_id);
+CREATE INDEX IF NOT EXISTS idx_node_exec_parent ON node_executions(run_id, parent_node_id) WHERE parent_node_id <> '';
@@ -0,0 +1,8 @@
+-- worker 租约覆盖手动暂停的运行：暂停期间心跳继续续约，worker 失联后同样由回收流程接管
+DROP INDEX IF EXISTS idx_workflow_runs_lease;
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_lease_active ON workflow_runs(lease_expires_at) WHERE status IN ('running', 'paused');
+
+-- 等待人工输入的运行已没有 worker 执行，清除挂起前遗留的租约
+UPDATE workflow_runs wr SET lease_expires_at = NULL
+ WHERE wr.status = 'paused' AND wr.lease_expires_at IS NOT NULL
+   AND EXISTS (SELECT 1 FROM run_pending_inputs pi WHERE pi.run_id = wr.id AND pi.status = 'waiting');
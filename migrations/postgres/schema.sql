@@ -19,6 +19,8 @@ CREATE TABLE IF NOT EXISTS tenants (
     code       VARCHAR(64) NOT NULL,
     name       VARCHAR(255) NOT NULL,
     status     VARCHAR(32) NOT NULL DEFAULT 'active',
+    queue_weight        INTEGER NOT NULL DEFAULT 1, -- 异步队列公平调度权重
+    max_concurrent_runs INTEGER NOT NULL DEFAULT 0, -- 异步运行并发上限，0 表示使用全局默认
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     UNIQUE (org_id, code),
@@ -38,7 +40,7 @@ CREATE TABLE IF NOT EXISTS conversations (
 
 CREATE INDEX IF NOT EXISTS idx_conversations_scope ON conversations(org_id, tenant_id);
 
--- 4) workflows 工作流定义表
+-- 4) workflows 工作流定义表（dsl_json / version 为草稿，运行默认执行 published_version）
 CREATE TABLE IF NOT EXISTS workflows (
     id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     org_id      UUID,
@@ -47,7 +49,9 @@ CREATE TABLE IF NOT EXISTS workflows (
     description TEXT DEFAULT '',
     dsl_json    JSONB NOT NULL,
     version     INTEGER NOT NULL DEFAULT 1,
-    status      VARCHAR(32) NOT NULL DEFAULT 'active',
+    published_version INTEGER NOT NULL DEFAULT 0,
+    published_at TIMESTAMP WITH TIME ZONE,
+    status      VARCHAR(32) NOT NULL DEFAULT 'draft',
     created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
@@ -57,33 +61,56 @@ CREATE INDEX IF NOT EXISTS idx_workflows_name ON workflows(name);
 CREATE INDEX IF NOT EXISTS idx_workflows_updated_at ON workflows(updated_at DESC);
 CREATE INDEX IF NOT EXISTS idx_workflows_scope_updated ON workflows(org_id, tenant_id, updated_at DESC);
 
+-- 4a) workflow_versions 工作流 DSL 不可变版本历史
+CREATE TABLE IF NOT EXISTS workflow_versions (
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    dsl_json    JSONB NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workflow_id, version)
+);
+
 -- 5) workflow_runs 执行记录表
 CREATE TABLE IF NOT EXISTS workflow_runs (
     id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     workflow_id     UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    workflow_version INTEGER NOT NULL DEFAULT 0,
     org_id          UUID,
     tenant_id       UUID,
+    parent_run_id   UUID REFERENCES workflow_runs(id) ON DELETE SET NULL,
     conversation_id VARCHAR(255),
     status          VARCHAR(32) NOT NULL DEFAULT 'running',
     worker_id       VARCHAR(128) NOT NULL DEFAULT '',
     retry_count     INTEGER NOT NULL DEFAULT 0,
+    priority        SMALLINT NOT NULL DEFAULT 0,
+    callback_url    TEXT DEFAULT '',
+    callback_secret VARCHAR(128) DEFAULT '',
     inputs          JSONB,
     outputs         JSONB,
     error           TEXT DEFAULT '',
+    exceptions_count INTEGER NOT NULL DEFAULT 0,
     total_tokens    INTEGER DEFAULT 0,
     total_steps     INTEGER DEFAULT 0,
     elapsed_ms      BIGINT DEFAULT 0,
     queued_at       TIMESTAMP WITH TIME ZONE,
     picked_at       TIMESTAMP WITH TIME ZONE,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    lease_expires_at TIMESTAMP WITH TIME ZONE,
     started_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    finished_at     TIMESTAMP WITH TIME ZONE
+    finished_at     TIMESTAMP WITH TIME ZONE,
+    attempts        JSONB
 );
 
 CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_id ON workflow_runs(workflow_id);
 CREATE INDEX IF NOT EXISTS idx_workflow_runs_status ON workflow_runs(status);
 CREATE INDEX IF NOT EXISTS idx_workflow_runs_queued_pick ON workflow_runs(status, queued_at ASC, started_at ASC);
 CREATE INDEX IF NOT EXISTS idx_workflow_runs_started_at ON workflow_runs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_status ON workflow_runs(workflow_id, status, started_at DESC);
 CREATE INDEX IF NOT EXISTS idx_workflow_runs_conversation_id ON workflow_runs(conversation_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_parent ON workflow_runs(parent_run_id) WHERE parent_run_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_fair_pick ON workflow_runs(org_id, tenant_id, priority DESC, queued_at ASC) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_lease_active ON workflow_runs(lease_expires_at) WHERE status IN ('running', 'paused');
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_dead_letter ON workflow_runs(started_at DESC) WHERE status = 'dead-letter';
 CREATE INDEX IF NOT EXISTS idx_runs_scope_started ON workflow_runs(org_id, tenant_id, started_at DESC);
 CREATE INDEX IF NOT EXISTS idx_runs_scope_conv ON workflow_runs(org_id, tenant_id, conversation_id);
 
@@ -100,12 +127,156 @@ CREATE TABLE IF NOT EXISTS node_executions (
     metadata    JSONB,
     elapsed_ms  BIGINT DEFAULT 0,
     started_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    UNIQUE (run_id, node_id)
+    -- 迭代 / 循环子图内的节点：所属容器节点、每项 / 每轮的执行 ID 与序号（顶层节点为空）
+    parent_node_id      VARCHAR(255) NOT NULL DEFAULT '',
+    parent_execution_id VARCHAR(64) NOT NULL DEFAULT '',
+    iteration_index     INTEGER,
+    loop_round          INTEGER
 );
 
+CREATE UNIQUE INDEX IF NOT EXISTS uq_node_exec_run_node_parent ON node_executions(run_id, node_id, parent_execution_id);
 CREATE INDEX IF NOT EXISTS idx_node_exec_run ON node_executions(run_id);
 CREATE INDEX IF NOT EXISTS idx_node_exec_type ON node_executions(node_type);
 CREATE INDEX IF NOT EXISTS idx_node_exec_status ON node_executions(status);
+CREATE INDEX IF NOT EXISTS idx_node_exec_parent ON node_executions(run_id, parent_node_id) WHERE parent_node_id <> '';
+
+-- 5b) run_checkpoints 执行检查点表（每个节点完成后覆盖写入，用于崩溃恢复）
+CREATE TABLE IF NOT EXISTS run_checkpoints (
+    run_id          UUID PRIMARY KEY REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    sequence        BIGINT NOT NULL DEFAULT 0,
+    state           JSONB,
+    variable_pool   JSONB,
+    completed_nodes JSONB NOT NULL DEFAULT '[]',
+    node_states     JSONB,
+    edge_states     JSONB,
+    node_executions JSONB,
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_checkpoints_updated ON run_checkpoints(updated_at);
+
+-- 5c) run_pending_inputs 人工输入表（human-input 节点挂起等待的表单/审批）
+CREATE TABLE IF NOT EXISTS run_pending_inputs (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id         UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    workflow_id    UUID,
+    node_id        VARCHAR(255) NOT NULL,
+    org_id         UUID,
+    tenant_id      UUID,
+    title          VARCHAR(255) DEFAULT '',
+    prompt         TEXT DEFAULT '',
+    fields         JSONB,
+    actions        JSONB,
+    assignee       VARCHAR(255) DEFAULT '',
+    timeout_branch VARCHAR(128) DEFAULT '',
+    status         VARCHAR(32) NOT NULL DEFAULT 'waiting', -- waiting / submitted / timeout
+    action         VARCHAR(128) DEFAULT '',
+    response       JSONB,
+    responded_by   VARCHAR(255) DEFAULT '',
+    expires_at     TIMESTAMP WITH TIME ZONE,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    responded_at   TIMESTAMP WITH TIME ZONE,
+    UNIQUE (run_id, node_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_inputs_status_expires ON run_pending_inputs(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_pending_inputs_scope_assignee ON run_pending_inputs(org_id, tenant_id, assignee);
+
+-- 5d) workflow_schedules 工作流定时触发（cron）
+CREATE TABLE IF NOT EXISTS workflow_schedules (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id    UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    org_id         UUID,
+    tenant_id      UUID,
+    name           VARCHAR(255) DEFAULT '',
+    cron_expr      VARCHAR(128) NOT NULL,
+    timezone       VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    inputs         JSONB,
+    enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+    misfire_policy VARCHAR(32) NOT NULL DEFAULT 'fire_once',
+    next_fire_at   TIMESTAMP WITH TIME ZONE,
+    last_fire_at   TIMESTAMP WITH TIME ZONE,
+    last_run_id    UUID REFERENCES workflow_runs(id) ON DELETE SET NULL,
+    last_error     TEXT DEFAULT '',
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_due ON workflow_schedules(next_fire_at) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_workflow ON workflow_schedules(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_schedules_scope ON workflow_schedules(org_id, tenant_id);
+
+-- 5e) workflow_webhooks 工作流入站 Webhook 触发器
+CREATE TABLE IF NOT EXISTS workflow_webhooks (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id   UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    org_id        UUID,
+    tenant_id     UUID,
+    name          VARCHAR(255) DEFAULT '',
+    secret        VARCHAR(128) NOT NULL,
+    input_mapping JSONB,
+    mode          VARCHAR(16) NOT NULL DEFAULT 'async',
+    enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_workflow ON workflow_webhooks(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_webhooks_scope ON workflow_webhooks(org_id, tenant_id);
+
+-- 5f) run_idempotency_keys 运行创建接口幂等键（Idempotency-Key）
+CREATE TABLE IF NOT EXISTS run_idempotency_keys (
+    idem_key     VARCHAR(255) NOT NULL,
+    org_id       UUID,
+    tenant_id    UUID,
+    endpoint     VARCHAR(16) NOT NULL,
+    workflow_id  UUID NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    run_id       UUID NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_run_idempotency_keys_scope ON run_idempotency_keys((COALESCE(org_id::text, '')), (COALESCE(tenant_id::text, '')), idem_key);
+CREATE INDEX IF NOT EXISTS idx_run_idempotency_keys_expires ON run_idempotency_keys(expires_at);
+
+-- 5g) run_notification_subscriptions / run_notification_deliveries 运行完成通知（出站 Webhook）
+CREATE TABLE IF NOT EXISTS run_notification_subscriptions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id     UUID,
+    tenant_id  UUID,
+    url        TEXT NOT NULL,
+    secret     VARCHAR(128) NOT NULL,
+    statuses   JSONB,
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_notification_subscriptions_scope ON run_notification_subscriptions(org_id, tenant_id);
+
+CREATE TABLE IF NOT EXISTS run_notification_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id          UUID NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    org_id          UUID,
+    tenant_id       UUID,
+    subscription_id UUID REFERENCES run_notification_subscriptions(id) ON DELETE SET NULL,
+    url             TEXT NOT NULL,
+    secret          VARCHAR(128) NOT NULL,
+    run_status      VARCHAR(32) NOT NULL,
+    payload         JSONB NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        JSONB,
+    max_attempts    INTEGER NOT NULL DEFAULT 1,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_at    TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_run ON run_notification_deliveries(run_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_due ON run_notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_run_notification_deliveries_scope ON run_notification_deliveries(org_id, tenant_id, status);
 
 -- 6) conversation_summaries 中期记忆摘要表
 CREATE TABLE IF NOT EXISTS conversation_summaries (